@@ -24,6 +24,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"math"
 
 	"github.com/ontio/ontology-crypto/keypair"
 	"github.com/polynetwork/poly/common"
@@ -99,12 +100,33 @@ var CHAIN_ID = map[uint32]uint64{
 	NETWORK_ID_TEST_NET: TESTNET_CHAIN_ID,
 }
 
-var EXTRA_INFO_HEIGHT = map[uint32]uint32{
-	NETWORK_ID_MAIN_NET: constants.EXTRA_INFO_HEIGHT_MAINNET,
-	NETWORK_ID_TEST_NET: constants.EXTRA_INFO_HEIGHT_TESTNET,
-}
+// names of hard forks
+const (
+	FORK_SIDE_CHAIN_EXTRA_INFO = "sideChainExtraInfo" // ExtraInfo is encoded in side chain states
+	FORK_GOVERNANCE_PROPOSAL   = "governanceProposal" // consensus signs are kept in expiring proposals of consensus peers
+	FORK_FINALITY              = "finality"           // BlocksToWait of 0 accepts proofs in the synced tip
+)
 
-var EXTRA_INFO_HEIGHT_FORK_CHECK bool
+// FORK_UNSCHEDULED is the activation height of forks not scheduled yet on a network
+const FORK_UNSCHEDULED uint32 = math.MaxUint32
+
+// FORK_HEIGHTS is the first block height each fork activates at on known networks, forks missing
+// for a network are active from genesis
+var FORK_HEIGHTS = map[string]map[uint32]uint32{
+	// the fork was checked against the last committed block, so it applies from the next one
+	FORK_SIDE_CHAIN_EXTRA_INFO: {
+		NETWORK_ID_MAIN_NET: constants.EXTRA_INFO_HEIGHT_MAINNET + 1,
+		NETWORK_ID_TEST_NET: constants.EXTRA_INFO_HEIGHT_TESTNET + 1,
+	},
+	FORK_GOVERNANCE_PROPOSAL: {
+		NETWORK_ID_MAIN_NET: FORK_UNSCHEDULED,
+		NETWORK_ID_TEST_NET: FORK_UNSCHEDULED,
+	},
+	FORK_FINALITY: {
+		NETWORK_ID_MAIN_NET: FORK_UNSCHEDULED,
+		NETWORK_ID_TEST_NET: FORK_UNSCHEDULED,
+	},
+}
 
 func GetNetworkMagic(id uint32) uint32 {
 	nid, ok := NETWORK_MAGIC[id]
@@ -114,8 +136,20 @@ func GetNetworkMagic(id uint32) uint32 {
 	return id
 }
 
-func GetExtraInfoHeight(id uint32) uint32 {
-	return EXTRA_INFO_HEIGHT[id]
+// GetForkHeight returns the activation height of fork name on network id, heights set in Forks of
+// genesis config take precedence so private networks can schedule forks
+func GetForkHeight(id uint32, name string) uint32 {
+	if DefConfig.Genesis != nil {
+		if height, ok := DefConfig.Genesis.Forks[name]; ok {
+			return height
+		}
+	}
+	return FORK_HEIGHTS[name][id]
+}
+
+// IsForkActive tells if fork name is active at block height on network id
+func IsForkActive(id uint32, name string, height uint32) bool {
+	return height >= GetForkHeight(id, name)
 }
 
 func GetNetworkName(id uint32) string {
@@ -242,6 +276,7 @@ type GenesisConfig struct {
 	VBFT          *VBFTConfig
 	DBFT          *DBFTConfig
 	SOLO          *SOLOConfig
+	Forks         map[string]uint32 `json:",omitempty"` // activation heights overriding FORK_HEIGHTS
 }
 
 func NewGenesisConfig() *GenesisConfig {
@@ -165,6 +165,17 @@ func (self *Ledger) GetStorageItem(codeHash common.Address, key []byte) ([]byte,
 	return storageItem.Value, nil
 }
 
+func (self *Ledger) GetRawStorageItem(codeHash common.Address, key []byte) ([]byte, error) {
+	return self.ldgStore.GetRawStorageItem(&states.StorageKey{
+		ContractAddress: codeHash,
+		Key:             key,
+	})
+}
+
+func (self *Ledger) FindStorageItems(codeHash common.Address, prefix []byte) ([][]byte, [][]byte, error) {
+	return self.ldgStore.FindStorageItems(codeHash, prefix)
+}
+
 func (self *Ledger) GetMerkleProof(proofHeight, rootHeight uint32) ([]byte, error) {
 	blockHash := self.ldgStore.GetBlockHash(proofHeight)
 	if bytes.Equal(blockHash.ToArray(), common.UINT256_EMPTY.ToArray()) {
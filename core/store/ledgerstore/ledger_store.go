@@ -961,6 +961,16 @@ func (this *LedgerStoreImp) GetStorageItem(key *states.StorageKey) (*states.Stor
 	return this.stateStore.GetStorageState(key)
 }
 
+//GetRawStorageItem return the storage value of the key in smart contract without unwrapping. Wrap function of StateStore.GetRawStorageItem
+func (this *LedgerStoreImp) GetRawStorageItem(key *states.StorageKey) ([]byte, error) {
+	return this.stateStore.GetRawStorageItem(key)
+}
+
+//FindStorageItems return the storage keys and values with the key prefix in smart contract. Wrap function of StateStore.FindStorageItems
+func (this *LedgerStoreImp) FindStorageItems(contract common.Address, prefix []byte) ([][]byte, [][]byte, error) {
+	return this.stateStore.FindStorageItems(contract, prefix)
+}
+
 //GetEventNotifyByTx return the events notify gen by executing of smart contract.  Wrap function of EventStore.GetEventNotifyByTx
 func (this *LedgerStoreImp) GetEventNotifyByTx(tx common.Uint256) (*event.ExecuteNotify, error) {
 	return this.eventStore.GetEventNotifyByTx(tx)
@@ -354,6 +354,42 @@ func (self *StateStore) GetStorageState(key *states.StorageKey) (*states.Storage
 	return storageState, nil
 }
 
+//GetRawStorageItem return the value of the key in smart contract as it is stored, for the one put without storage item wrapping
+func (self *StateStore) GetRawStorageItem(key *states.StorageKey) ([]byte, error) {
+	storeKey, err := self.getStorageKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return self.store.Get(storeKey)
+}
+
+//FindStorageItems return the keys and values in smart contract whose key starts with prefix,
+//the keys are without contract address
+func (self *StateStore) FindStorageItems(contract common.Address, prefix []byte) ([][]byte, [][]byte, error) {
+	storeKey, err := self.getStorageKey(&states.StorageKey{ContractAddress: contract, Key: prefix})
+	if err != nil {
+		return nil, nil, err
+	}
+	keys := make([][]byte, 0)
+	values := make([][]byte, 0)
+	iter := self.store.NewIterator(storeKey)
+	defer iter.Release()
+	for iter.Next() {
+		storageState := new(states.StorageItem)
+		if err := storageState.Deserialize(bytes.NewReader(iter.Value())); err != nil {
+			return nil, nil, err
+		}
+		key := make([]byte, len(iter.Key())-1-common.ADDR_LEN)
+		copy(key, iter.Key()[1+common.ADDR_LEN:])
+		keys = append(keys, key)
+		values = append(values, storageState.Value)
+	}
+	if err := iter.Error(); err != nil {
+		return nil, nil, err
+	}
+	return keys, values, nil
+}
+
 func (self *StateStore) GetStorageValue(key []byte) ([]byte, error) {
 	data, err := self.store.Get(append([]byte{byte(byte(scom.ST_STORAGE))}, key...))
 	if err != nil {
@@ -19,10 +19,12 @@
 package ledgerstore
 
 import (
+	"bytes"
 	"math/rand"
 	"testing"
 
 	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/core/states"
 	"github.com/polynetwork/poly/merkle"
 	"github.com/stretchr/testify/assert"
 )
@@ -71,3 +73,33 @@ func TestStateMerkleRoot(t *testing.T) {
 	}
 
 }
+
+func TestFindStorageItems(t *testing.T) {
+	db := NewMemStateStore(0)
+	contract := common.Address{1}
+	items := map[common.Address][]string{
+		contract:          {"a1", "a2", "ab", "b1"},
+		common.Address{2}: {"a3"},
+	}
+	for addr, keys := range items {
+		for _, key := range keys {
+			storeKey, err := db.getStorageKey(&states.StorageKey{ContractAddress: addr, Key: []byte(key)})
+			assert.Nil(t, err)
+			buf := bytes.NewBuffer(nil)
+			err = (&states.StorageItem{Value: []byte("v" + key)}).Serialize(buf)
+			assert.Nil(t, err)
+			err = db.store.Put(storeKey, buf.Bytes())
+			assert.Nil(t, err)
+		}
+	}
+
+	keys, values, err := db.FindStorageItems(contract, []byte("a"))
+	assert.Nil(t, err)
+	assert.Equal(t, [][]byte{[]byte("a1"), []byte("a2"), []byte("ab")}, keys)
+	assert.Equal(t, [][]byte{[]byte("va1"), []byte("va2"), []byte("vab")}, values)
+
+	keys, values, err = db.FindStorageItems(contract, []byte("c"))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(keys))
+	assert.Equal(t, 0, len(values))
+}
@@ -64,6 +64,8 @@ type LedgerStore interface {
 	GetCrossStatesProof(height uint32, key []byte) ([]byte, error)
 	GetBookkeeperState() (*states.BookkeeperState, error)
 	GetStorageItem(key *states.StorageKey) (*states.StorageItem, error)
+	GetRawStorageItem(key *states.StorageKey) ([]byte, error)
+	FindStorageItems(contract common.Address, prefix []byte) ([][]byte, [][]byte, error)
 	PreExecuteContract(tx *types.Transaction) (*cstates.PreExecResult, error)
 	GetEventNotifyByTx(tx common.Uint256) (*event.ExecuteNotify, error)
 	GetEventNotifyByBlock(height uint32) ([]*event.ExecuteNotify, error)
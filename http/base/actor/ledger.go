@@ -76,6 +76,16 @@ func GetStorageItem(address common.Address, key []byte) ([]byte, error) {
 	return ledger.DefLedger.GetStorageItem(address, key)
 }
 
+//GetRawStorageItem from ledger
+func GetRawStorageItem(address common.Address, key []byte) ([]byte, error) {
+	return ledger.DefLedger.GetRawStorageItem(address, key)
+}
+
+//FindStorageItems from ledger
+func FindStorageItems(address common.Address, prefix []byte) ([][]byte, [][]byte, error) {
+	return ledger.DefLedger.FindStorageItems(address, prefix)
+}
+
 //GetTxnWithHeightByTxHash from ledger
 func GetTxnWithHeightByTxHash(hash common.Uint256) (uint32, *types.Transaction, error) {
 	tx, height, err := ledger.DefLedger.GetTransactionWithHeight(hash)
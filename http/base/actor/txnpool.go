@@ -71,9 +71,15 @@ func AppendTxToPool(txn *types.Transaction) (polyErrors.ErrCode, string) {
 		}
 		// Check if address is registreed relayer
 		if value != nil {
-			// Here means address is registered relayer
-			flag = false
-			break
+			relayer := new(relayer_manager.Relayer)
+			if err := relayer.Deserialization(common.NewZeroCopySource(value)); err != nil {
+				return polyErrors.ErrUnknown, err.Error()
+			}
+			// Here means address is registered relayer, expired ones are treated as not registered
+			if !relayer.IsExpired(GetCurrentBlockHeight() + 1) {
+				flag = false
+				break
+			}
 		}
 		// Check if permittedAddrMap is empty
 		if len(permittedAddrMap) == 0 {
@@ -20,17 +20,39 @@
 package common
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
 	"github.com/ontio/ontology-crypto/keypair"
 	"github.com/polynetwork/poly/common"
 	"github.com/polynetwork/poly/common/log"
+	storecom "github.com/polynetwork/poly/core/store/common"
 	"github.com/polynetwork/poly/core/types"
 	ontErrors "github.com/polynetwork/poly/errors"
 	bactor "github.com/polynetwork/poly/http/base/actor"
 	"github.com/polynetwork/poly/native/event"
+	"github.com/polynetwork/poly/native/service/cross_chain_manager/btc"
+	ccmcom "github.com/polynetwork/poly/native/service/cross_chain_manager/common"
+	"github.com/polynetwork/poly/native/service/governance/node_manager"
+	"github.com/polynetwork/poly/native/service/governance/relayer_manager"
+	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
+	hsbtc "github.com/polynetwork/poly/native/service/header_sync/btc"
+	hscom "github.com/polynetwork/poly/native/service/header_sync/common"
+	"github.com/polynetwork/poly/native/service/utils"
 	cstate "github.com/polynetwork/poly/native/states"
 )
 
 const MAX_SEARCH_HEIGHT uint32 = 100
+const MAX_REQUEST_LIMIT uint64 = 100
+
+// upper bounds in btc blocks of utxo age buckets, about an hour, a day, a week and a month
+var BTC_UTXO_AGE_BOUNDS = []uint32{6, 144, 1008, 4320}
 
 type BalanceOfRsp struct {
 	Ont string `json:"ont"`
@@ -141,6 +163,151 @@ type ConsensusInfo struct {
 	// TODO
 }
 
+type RouterInfo struct {
+	Router     uint64
+	Name       string
+	CrossChain bool // router has a cross chain handler
+	HeaderSync bool // router has a header sync handler
+}
+
+type CrossChainStatusInfo struct {
+	FromChainID     uint64
+	ToChainID       uint64
+	CrossChainID    string
+	SourceTxHash    string
+	PolyTxHash      string
+	Status          string
+	ReceivedHeight  uint32
+	VerifiedHeight  uint32
+	ForwardedHeight uint32
+}
+
+type CrossChainRequest struct {
+	Sequence uint64
+	TxHash   string // poly tx hash of the request
+	Height   uint32
+	Key      string // storage key used to get proof by getcrossstatesproof
+	Value    string // serialized ToMerkleValue
+}
+
+type CrossChainRequests struct {
+	NextSequence uint64
+	Requests     []*CrossChainRequest
+}
+
+type CrossChainProofBundle struct {
+	TxHash        string // poly tx hash of the request
+	Height        uint32 // height of the poly tx
+	Key           string // storage key of the request
+	ToMerkleValue string
+	AuditPath     string // proof of ToMerkleValue to the CrossStateRoot of Header
+	Header        string // header at Height+1, its CrossStateRoot commits the states of Height
+	AnchorHeader  string `json:",omitempty"`
+	HeaderProof   string `json:",omitempty"` // proof of Header hash to the BlockRoot of AnchorHeader
+}
+
+type ChainReorgInfo struct {
+	OldTip     string
+	OldHeight  uint64
+	NewTip     string
+	NewHeight  uint64
+	ForkPoint  string
+	ForkHeight uint64
+	Depth      uint64
+	PolyHeight uint32
+}
+
+type ChainForkInfo struct {
+	ChainID   uint64
+	Router    uint64
+	TipHeight uint64
+	TipHash   string
+	Reorgs    []*ChainReorgInfo
+}
+
+type BtcPsbtInfo struct {
+	TxHash   string
+	Psbt     string
+	Signed   int
+	Required int
+}
+
+type BtcTxoInfo struct {
+	OutPoint string
+	Value    uint64
+	AtHeight uint32
+}
+
+// BtcUtxoAgeInfo counts utxos confirmed for [MinAge, MaxAge) blocks, MaxAge is 0 for the oldest bucket
+type BtcUtxoAgeInfo struct {
+	MinAge uint32
+	MaxAge uint32
+	Count  int
+	Value  uint64
+}
+
+type BtcUtxoInfo struct {
+	ChainID    uint64
+	RedeemKey  string
+	TipHeight  uint32
+	Count      int
+	Total      uint64
+	Ages       []*BtcUtxoAgeInfo
+	UnknownAge int
+	StxoCount  int
+	StxoTotal  uint64
+	Stxos      []*BtcTxoInfo
+}
+
+type ProposalInfo struct {
+	ID           uint64
+	Hash         string // sha256 of method and input
+	Method       string
+	Input        string
+	Proposer     string
+	Height       uint32
+	ExpiryHeight uint32
+	Voters       []string
+	Votes        int // voters in current consensus peers
+	Required     int // votes required to pass
+}
+
+type Proposals struct {
+	NextID    uint64 // id to list from next, the proposal sequence once all are listed
+	Proposals []*ProposalInfo
+}
+
+type SideChainInfo struct {
+	ChainID      uint64
+	Router       uint64
+	RouterName   string
+	Name         string
+	Address      string
+	BlocksToWait uint64
+	CCMCAddress  string
+	ExtraInfo    json.RawMessage // as is if json, otherwise the hex string
+}
+
+type SideChains struct {
+	Chains       []*SideChainInfo
+	Applications []*SideChainInfo // pending registrations
+	Updates      []*SideChainInfo // pending updates
+	Quits        []uint64         // chain ids of pending quits
+}
+
+type RelayerInfo struct {
+	Address      string
+	ChainIDs     []uint64 // empty for all chains
+	ExpiryHeight uint32   // 0 for no expiry
+}
+
+type Relayers struct {
+	ChainID   uint64
+	Policy    uint8    // relayer policy mode of the chain
+	AllowList []string // allowed relayers of the allow list policy
+	Relayers  []*RelayerInfo
+}
+
 type TXNAttrInfo struct {
 	Height  uint32
 	Type    int
@@ -276,3 +443,711 @@ func GetAddress(str string) (common.Address, error) {
 	}
 	return address, err
 }
+
+// GetSupportedRouters lists the routers registered by chain packages, ordered by router id
+func GetSupportedRouters() []RouterInfo {
+	infos := make(map[uint64]*RouterInfo)
+	for router, name := range ccmcom.GetRegisteredRouters() {
+		infos[router] = &RouterInfo{Router: router, Name: name, CrossChain: true}
+	}
+	for router, name := range hscom.GetRegisteredRouters() {
+		info, ok := infos[router]
+		if !ok {
+			info = &RouterInfo{Router: router, Name: name}
+			infos[router] = info
+		}
+		info.HeaderSync = true
+	}
+	res := make([]RouterInfo, 0, len(infos))
+	for _, info := range infos {
+		res = append(res, *info)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Router < res[j].Router })
+	return res
+}
+
+// GetCrossChainStatus looks up a cross chain tx by poly tx hash. It returns nil if the tx is not found.
+func GetCrossChainStatus(hash string) (*CrossChainStatusInfo, error) {
+	polyHash, err := common.Uint256FromHexString(ccmcom.Replace0x(hash))
+	if err != nil {
+		return nil, err
+	}
+	index, err := getStorage(utils.CrossChainManagerContractAddress, []byte(ccmcom.STATUS_BY_POLY_TX), polyHash[:])
+	if err != nil {
+		return nil, err
+	}
+	return getCrossChainStatusByIndex(index)
+}
+
+// GetCrossChainStatusBySourceTx looks up a cross chain tx by the tx hash of source chain fromChainID
+// as shown in the makeProof event. It returns nil if the tx is not found.
+func GetCrossChainStatusBySourceTx(fromChainID uint64, hash string) (*CrossChainStatusInfo, error) {
+	raw, err := hex.DecodeString(ccmcom.Replace0x(hash))
+	if err != nil {
+		return nil, err
+	}
+	index, err := getStorage(utils.CrossChainManagerContractAddress, []byte(ccmcom.STATUS_BY_SOURCE_TX),
+		utils.GetUint64Bytes(fromChainID), raw)
+	if err != nil {
+		return nil, err
+	}
+	return getCrossChainStatusByIndex(index)
+}
+
+func getCrossChainStatusByIndex(index []byte) (*CrossChainStatusInfo, error) {
+	if index == nil {
+		return nil, nil
+	}
+	value, err := getStorage(utils.CrossChainManagerContractAddress, []byte(ccmcom.CROSS_CHAIN_STATUS), index)
+	if err != nil || value == nil {
+		return nil, err
+	}
+	status := new(ccmcom.CrossChainStatus)
+	if err := status.Deserialization(common.NewZeroCopySource(value)); err != nil {
+		return nil, err
+	}
+	info := &CrossChainStatusInfo{
+		FromChainID:     status.FromChainID,
+		ToChainID:       status.ToChainID,
+		CrossChainID:    hex.EncodeToString(status.CrossChainID),
+		SourceTxHash:    hex.EncodeToString(status.SourceTxHash),
+		PolyTxHash:      status.PolyTxHash.ToHexString(),
+		ReceivedHeight:  status.ReceivedHeight,
+		VerifiedHeight:  status.VerifiedHeight,
+		ForwardedHeight: status.ForwardedHeight,
+	}
+	switch status.Status {
+	case ccmcom.STATUS_RECEIVED:
+		info.Status = "received"
+	case ccmcom.STATUS_VERIFIED:
+		info.Status = "verified"
+	case ccmcom.STATUS_FORWARDED:
+		info.Status = "forwarded"
+	}
+	return info, nil
+}
+
+// getStorage reads the value of a native contract, it returns nil if the key is not found
+func getStorage(contract common.Address, keys ...[]byte) ([]byte, error) {
+	key := make([]byte, 0)
+	for _, v := range keys {
+		key = append(key, v...)
+	}
+	value, err := bactor.GetStorageItem(contract, key)
+	if err != nil {
+		if err == storecom.ErrNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return value, nil
+}
+
+// GetCrossChainRequests lists at most limit requests to toChainID starting from sequence fromSeq
+func GetCrossChainRequests(toChainID, fromSeq, limit uint64) (*CrossChainRequests, error) {
+	contract := utils.CrossChainManagerContractAddress
+	chainIDBytes := utils.GetUint64Bytes(toChainID)
+	seqBytes, err := getStorage(contract, []byte(ccmcom.REQUEST_SEQUENCE), chainIDBytes)
+	if err != nil {
+		return nil, err
+	}
+	res := &CrossChainRequests{
+		NextSequence: utils.GetBytesUint64(seqBytes),
+		Requests:     make([]*CrossChainRequest, 0),
+	}
+	if limit == 0 || limit > MAX_REQUEST_LIMIT {
+		limit = MAX_REQUEST_LIMIT
+	}
+	for seq := fromSeq; seq < res.NextSequence && seq-fromSeq < limit; seq++ {
+		indexBytes, err := getStorage(contract, []byte(ccmcom.REQUEST_INDEX), chainIDBytes, utils.GetUint64Bytes(seq))
+		if err != nil {
+			return nil, err
+		}
+		if indexBytes == nil {
+			continue
+		}
+		index := new(ccmcom.RequestIndex)
+		if err := index.Deserialization(common.NewZeroCopySource(indexBytes)); err != nil {
+			return nil, err
+		}
+		value, err := getStorage(contract, []byte(ccmcom.REQUEST), chainIDBytes, index.TxHash)
+		if err != nil {
+			return nil, err
+		}
+		txHash, err := common.Uint256ParseFromBytes(index.TxHash)
+		if err != nil {
+			return nil, err
+		}
+		res.Requests = append(res.Requests, &CrossChainRequest{
+			Sequence: seq,
+			TxHash:   txHash.ToHexString(),
+			Height:   index.Height,
+			Key:      hex.EncodeToString(utils.ConcatKey(contract, []byte(ccmcom.REQUEST), chainIDBytes, index.TxHash)),
+			Value:    hex.EncodeToString(value),
+		})
+	}
+	return res, nil
+}
+
+// GetCrossChainProofBundle collects everything needed by target chain to verify the request
+// made by poly tx txHash. The header proof is only added if anchorHeight is above the header
+// committing the request.
+func GetCrossChainProofBundle(txHash common.Uint256, anchorHeight uint32) (*CrossChainProofBundle, error) {
+	contract := utils.CrossChainManagerContractAddress
+	index, err := getStorage(contract, []byte(ccmcom.STATUS_BY_POLY_TX), txHash[:])
+	if err != nil {
+		return nil, err
+	}
+	if index == nil {
+		return nil, fmt.Errorf("cross chain tx %s not found", txHash.ToHexString())
+	}
+	value, err := getStorage(contract, []byte(ccmcom.CROSS_CHAIN_STATUS), index)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, fmt.Errorf("status of cross chain tx %s not found", txHash.ToHexString())
+	}
+	status := new(ccmcom.CrossChainStatus)
+	if err := status.Deserialization(common.NewZeroCopySource(value)); err != nil {
+		return nil, err
+	}
+	chainIDBytes := utils.GetUint64Bytes(status.ToChainID)
+	toMerkleValue, err := getStorage(contract, []byte(ccmcom.REQUEST), chainIDBytes, txHash[:])
+	if err != nil {
+		return nil, err
+	}
+	if toMerkleValue == nil {
+		return nil, fmt.Errorf("no request of cross chain tx %s to chain %d", txHash.ToHexString(), status.ToChainID)
+	}
+
+	height, _, err := bactor.GetTxnWithHeightByTxHash(txHash)
+	if err != nil {
+		return nil, err
+	}
+	if height+1 > bactor.GetCurrentBlockHeight() {
+		return nil, fmt.Errorf("cross state root of height %d is not committed yet", height)
+	}
+	key := utils.ConcatKey(contract, []byte(ccmcom.REQUEST), chainIDBytes, txHash[:])
+	auditPath, err := bactor.GetCrossStatesProof(height, key)
+	if err != nil {
+		return nil, err
+	}
+	header, err := bactor.GetHeaderByHeight(height + 1)
+	if err != nil {
+		return nil, err
+	}
+	bundle := &CrossChainProofBundle{
+		TxHash:        txHash.ToHexString(),
+		Height:        height,
+		Key:           hex.EncodeToString(key),
+		ToMerkleValue: hex.EncodeToString(toMerkleValue),
+		AuditPath:     hex.EncodeToString(auditPath),
+		Header:        hex.EncodeToString(header.ToArray()),
+	}
+	if anchorHeight > height+1 {
+		anchor, err := bactor.GetHeaderByHeight(anchorHeight)
+		if err != nil {
+			return nil, err
+		}
+		headerProof, err := bactor.GetMerkleProof(height+1, anchorHeight)
+		if err != nil {
+			return nil, err
+		}
+		bundle.AnchorHeader = hex.EncodeToString(anchor.ToArray())
+		bundle.HeaderProof = hex.EncodeToString(headerProof)
+	}
+	return bundle, nil
+}
+
+// GetChainForkInfo returns the synced tip of side chain chainID and its recent reorgs, the
+// tip is only filled for routers keeping a canonical chain of headers.
+func GetChainForkInfo(chainID uint64) (*ChainForkInfo, error) {
+	chainIDBytes := utils.GetUint64Bytes(chainID)
+	sideChainBytes, err := getStorage(utils.SideChainManagerContractAddress, []byte(side_chain_manager.SIDE_CHAIN), chainIDBytes)
+	if err != nil {
+		return nil, err
+	}
+	if sideChainBytes == nil {
+		return nil, fmt.Errorf("side chain %d not found", chainID)
+	}
+	sideChain := new(side_chain_manager.SideChain)
+	if err := sideChain.Deserialization(common.NewZeroCopySource(sideChainBytes)); err != nil {
+		return nil, err
+	}
+	info := &ChainForkInfo{
+		ChainID: chainID,
+		Router:  sideChain.Router,
+		Reorgs:  make([]*ChainReorgInfo, 0),
+	}
+
+	contract := utils.HeaderSyncContractAddress
+	tipBytes, err := getStorage(contract, []byte(hscom.CURRENT_HEADER_HEIGHT), chainIDBytes)
+	if err != nil {
+		return nil, err
+	}
+	if tipBytes != nil {
+		switch sideChain.Router {
+		case utils.BTC_ROUTER, utils.BCH_ROUTER, utils.LTC_ROUTER:
+			tip := new(hsbtc.StoredHeader)
+			if err := tip.Deserialization(common.NewZeroCopySource(tipBytes)); err != nil {
+				return nil, err
+			}
+			tipHash := tip.Header.BlockHash()
+			info.TipHeight, info.TipHash = uint64(tip.Height), hex.EncodeToString(tipHash.CloneBytes())
+		case utils.ETH_ROUTER, utils.BSC_ROUTER, utils.HECO_ROUTER, utils.EVM_ROUTER:
+			info.TipHeight = utils.GetBytesUint64(tipBytes)
+			tipHash, err := getStorage(contract, []byte(hscom.MAIN_CHAIN), chainIDBytes, utils.GetUint64Bytes(info.TipHeight))
+			if err != nil {
+				return nil, err
+			}
+			info.TipHash = hex.EncodeToString(tipHash)
+		}
+	}
+
+	historyBytes, err := getStorage(contract, []byte(hscom.REORG_HISTORY), chainIDBytes)
+	if err != nil {
+		return nil, err
+	}
+	if historyBytes == nil {
+		return info, nil
+	}
+	history := new(hscom.ReorgHistory)
+	if err := history.Deserialization(common.NewZeroCopySource(historyBytes)); err != nil {
+		return nil, err
+	}
+	for _, v := range history.Reorgs {
+		info.Reorgs = append(info.Reorgs, &ChainReorgInfo{
+			OldTip:     hex.EncodeToString(v.OldTip),
+			OldHeight:  v.OldHeight,
+			NewTip:     hex.EncodeToString(v.NewTip),
+			NewHeight:  v.NewHeight,
+			ForkPoint:  hex.EncodeToString(v.ForkPoint),
+			ForkHeight: v.ForkHeight,
+			Depth:      v.Depth,
+			PolyHeight: v.PolyHeight,
+		})
+	}
+	return info, nil
+}
+
+// GetBtcPsbt returns the pending btc withdrawal txHash of redeemKey as a base64 psbt, carrying amounts
+// and scripts of inputs and the partial signatures collected by MultiSign
+func GetBtcPsbt(chainID uint64, redeemKey string, txHash []byte) (*BtcPsbtInfo, error) {
+	chainIDBytes := utils.GetUint64Bytes(chainID)
+	sideChainBytes, err := getStorage(utils.SideChainManagerContractAddress, []byte(side_chain_manager.SIDE_CHAIN), chainIDBytes)
+	if err != nil {
+		return nil, err
+	}
+	if sideChainBytes == nil {
+		return nil, fmt.Errorf("side chain %d not found", chainID)
+	}
+	sideChain := new(side_chain_manager.SideChain)
+	if err := sideChain.Deserialization(common.NewZeroCopySource(sideChainBytes)); err != nil {
+		return nil, err
+	}
+	netParam, err := hsbtc.GetNetParam(sideChain.Router, sideChain.CCMCAddress)
+	if err != nil {
+		return nil, err
+	}
+	redeem, err := getStorage(utils.SideChainManagerContractAddress, []byte(side_chain_manager.REDEEM_SCRIPT),
+		chainIDBytes, []byte(redeemKey))
+	if err != nil {
+		return nil, err
+	}
+	if redeem == nil {
+		return nil, fmt.Errorf("redeem %s not found", redeemKey)
+	}
+
+	contract := utils.CrossChainManagerContractAddress
+	// the unsigned tx is stored without storage item wrapping
+	rawTx, err := bactor.GetRawStorageItem(contract, append([]byte(btc.BTC_TX_PREFIX), txHash...))
+	if err != nil && err != storecom.ErrNotFound {
+		return nil, err
+	}
+	if len(rawTx) == 0 {
+		return nil, fmt.Errorf("btc tx %s not found", hex.EncodeToString(txHash))
+	}
+	mtx := wire.NewMsgTx(wire.TxVersion)
+	if err := mtx.BtcDecode(bytes.NewBuffer(rawTx), wire.ProtocolVersion, wire.LatestEncoding); err != nil {
+		return nil, err
+	}
+	inputsBytes, err := getStorage(contract, []byte(btc.BTC_TX_INPUTS), txHash)
+	if err != nil {
+		return nil, err
+	}
+	if inputsBytes == nil {
+		// inputs of the tx made before they are recorded are still locked in stxos
+		inputsBytes, err = getStorage(contract, []byte(btc.STXOS), chainIDBytes, []byte(redeemKey))
+		if err != nil {
+			return nil, err
+		}
+	}
+	inputs := &btc.Utxos{Utxos: make([]*btc.Utxo, 0)}
+	if inputsBytes != nil {
+		if err := inputs.Deserialization(common.NewZeroCopySource(inputsBytes)); err != nil {
+			return nil, err
+		}
+	}
+	// the stored tx keeps pkScripts of its inputs in signature scripts
+	pkScripts := make([][]byte, len(mtx.TxIn))
+	amts := make([]uint64, len(mtx.TxIn))
+	prevTxs := make([]*wire.MsgTx, len(mtx.TxIn))
+	for i, in := range mtx.TxIn {
+		pkScripts[i] = in.SignatureScript
+		for _, v := range inputs.Utxos {
+			if bytes.Equal(in.PreviousOutPoint.Hash[:], v.Op.Hash) && in.PreviousOutPoint.Index == v.Op.Index {
+				amts[i] = v.Value
+				break
+			}
+		}
+		if amts[i] == 0 {
+			return nil, fmt.Errorf("amount of no.%d input not found", i)
+		}
+		prevBytes, err := getStorage(contract, []byte(btc.BTC_UTXO_TX), in.PreviousOutPoint.Hash[:])
+		if err != nil {
+			return nil, err
+		}
+		if prevBytes != nil {
+			prevTxs[i] = wire.NewMsgTx(wire.TxVersion)
+			if err := prevTxs[i].BtcDecode(bytes.NewBuffer(prevBytes), wire.ProtocolVersion, wire.LatestEncoding); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	multiSignInfo := &btc.MultiSignInfo{MultiSignInfo: make(map[string][][]byte)}
+	infoBytes, err := getStorage(contract, []byte(btc.MULTI_SIGN_INFO), txHash)
+	if err != nil {
+		return nil, err
+	}
+	if infoBytes != nil {
+		if err := multiSignInfo.Deserialization(common.NewZeroCopySource(infoBytes)); err != nil {
+			return nil, err
+		}
+	}
+	_, _, required, err := txscript.ExtractPkScriptAddrs(redeem, netParam)
+	if err != nil {
+		return nil, err
+	}
+	p, err := btc.NewMultiSignPsbt(mtx, redeem, pkScripts, amts, prevTxs, multiSignInfo, netParam)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := p.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	return &BtcPsbtInfo{
+		TxHash:   hex.EncodeToString(txHash),
+		Psbt:     base64.StdEncoding.EncodeToString(raw),
+		Signed:   len(multiSignInfo.MultiSignInfo),
+		Required: required,
+	}, nil
+}
+
+// GetBtcUtxoInfo summarizes the utxos of redeemKey on btc chain chainID by age against the synced tip,
+// utxos with no recorded height like change of withdrawals are counted as UnknownAge. Stxos are those
+// locked by withdrawals not yet fully signed.
+func GetBtcUtxoInfo(chainID uint64, redeemKey string) (*BtcUtxoInfo, error) {
+	chainIDBytes := utils.GetUint64Bytes(chainID)
+	utxos, err := getBtcTxos(btc.UTXOS, chainIDBytes, redeemKey)
+	if err != nil {
+		return nil, err
+	}
+	stxos, err := getBtcTxos(btc.STXOS, chainIDBytes, redeemKey)
+	if err != nil {
+		return nil, err
+	}
+	info := &BtcUtxoInfo{
+		ChainID:   chainID,
+		RedeemKey: redeemKey,
+		Ages:      make([]*BtcUtxoAgeInfo, len(BTC_UTXO_AGE_BOUNDS)+1),
+		Stxos:     make([]*BtcTxoInfo, 0, len(stxos.Utxos)),
+	}
+	var lower uint32
+	for i := range info.Ages {
+		info.Ages[i] = &BtcUtxoAgeInfo{MinAge: lower}
+		if i < len(BTC_UTXO_AGE_BOUNDS) {
+			info.Ages[i].MaxAge, lower = BTC_UTXO_AGE_BOUNDS[i], BTC_UTXO_AGE_BOUNDS[i]
+		}
+	}
+
+	tipBytes, err := getStorage(utils.HeaderSyncContractAddress, []byte(hscom.CURRENT_HEADER_HEIGHT), chainIDBytes)
+	if err != nil {
+		return nil, err
+	}
+	if tipBytes != nil {
+		tip := new(hsbtc.StoredHeader)
+		if err := tip.Deserialization(common.NewZeroCopySource(tipBytes)); err != nil {
+			return nil, err
+		}
+		info.TipHeight = tip.Height
+	}
+
+	for _, u := range utxos.Utxos {
+		info.Count++
+		info.Total += u.Value
+		if u.AtHeight == 0 || info.TipHeight == 0 {
+			info.UnknownAge++
+			continue
+		}
+		var age uint32
+		if info.TipHeight > u.AtHeight {
+			age = info.TipHeight - u.AtHeight
+		}
+		idx := sort.Search(len(BTC_UTXO_AGE_BOUNDS), func(i int) bool { return age < BTC_UTXO_AGE_BOUNDS[i] })
+		info.Ages[idx].Count++
+		info.Ages[idx].Value += u.Value
+	}
+	for _, u := range stxos.Utxos {
+		info.StxoCount++
+		info.StxoTotal += u.Value
+		info.Stxos = append(info.Stxos, &BtcTxoInfo{
+			OutPoint: u.Op.String(),
+			Value:    u.Value,
+			AtHeight: u.AtHeight,
+		})
+	}
+	return info, nil
+}
+
+func getBtcTxos(prefix string, chainIDBytes []byte, redeemKey string) (*btc.Utxos, error) {
+	txos := &btc.Utxos{Utxos: make([]*btc.Utxo, 0)}
+	raw, err := getStorage(utils.CrossChainManagerContractAddress, []byte(prefix), chainIDBytes, []byte(redeemKey))
+	if err != nil {
+		return nil, err
+	}
+	if raw != nil {
+		if err := txos.Deserialization(common.NewZeroCopySource(raw)); err != nil {
+			return nil, err
+		}
+	}
+	return txos, nil
+}
+
+// GetProposals lists at most limit open governance proposals starting from id fromID with their
+// tallies against current consensus peers, passed and expired proposals are skipped
+func GetProposals(fromID, limit uint64) (*Proposals, error) {
+	contract := utils.NodeManagerContractAddress
+	seqBytes, err := getStorage(contract, []byte(node_manager.PROPOSAL_SEQ))
+	if err != nil {
+		return nil, err
+	}
+	seq := utils.GetBytesUint64(seqBytes)
+	res := &Proposals{
+		Proposals: make([]*ProposalInfo, 0),
+	}
+	if limit == 0 || limit > MAX_REQUEST_LIMIT {
+		limit = MAX_REQUEST_LIMIT
+	}
+
+	viewBytes, err := getStorage(contract, []byte(node_manager.GOVERNANCE_VIEW))
+	if err != nil {
+		return nil, err
+	}
+	view := new(node_manager.GovernanceView)
+	if err := view.Deserialization(common.NewZeroCopySource(viewBytes)); err != nil {
+		return nil, err
+	}
+	peerPoolMapBytes, err := getStorage(contract, []byte(node_manager.PEER_POOL), utils.GetUint32Bytes(view.View))
+	if err != nil {
+		return nil, err
+	}
+	peerPoolMap := &node_manager.PeerPoolMap{PeerPoolMap: make(map[string]*node_manager.PeerPoolItem)}
+	if err := peerPoolMap.Deserialization(common.NewZeroCopySource(peerPoolMapBytes)); err != nil {
+		return nil, err
+	}
+
+	height := bactor.GetCurrentBlockHeight()
+	id := fromID
+	for ; id < seq && uint64(len(res.Proposals)) < limit; id++ {
+		value, err := getStorage(contract, []byte(node_manager.PROPOSAL), utils.GetUint64Bytes(id))
+		if err != nil {
+			return nil, err
+		}
+		if value == nil {
+			continue
+		}
+		proposal := new(node_manager.Proposal)
+		if err := proposal.Deserialization(common.NewZeroCopySource(value)); err != nil {
+			return nil, err
+		}
+		if proposal.IsExpired(height) {
+			continue
+		}
+		votes, required, err := node_manager.CountConsensusVotes(peerPoolMap, proposal.Voters)
+		if err != nil {
+			return nil, err
+		}
+		voters := make([]string, 0, len(proposal.Voters))
+		for _, v := range proposal.Voters {
+			voters = append(voters, v.ToBase58())
+		}
+		res.Proposals = append(res.Proposals, &ProposalInfo{
+			ID:           proposal.ID,
+			Hash:         proposal.Hash.ToHexString(),
+			Method:       proposal.Method,
+			Input:        hex.EncodeToString(proposal.Input),
+			Proposer:     proposal.Proposer.ToBase58(),
+			Height:       proposal.Height,
+			ExpiryHeight: proposal.ExpiryHeight,
+			Voters:       voters,
+			Votes:        votes,
+			Required:     required,
+		})
+	}
+	res.NextID = id
+	if res.NextID > seq {
+		res.NextID = seq
+	}
+	return res, nil
+}
+
+// findSideChainItems returns the values of side chain manager whose key is prefix followed by a
+// chain id, keys of other prefixes sharing the same leading bytes are skipped
+func findSideChainItems(prefix string) ([]uint64, [][]byte, error) {
+	keys, values, err := bactor.FindStorageItems(utils.SideChainManagerContractAddress, []byte(prefix))
+	if err != nil {
+		return nil, nil, err
+	}
+	chainIDs := make([]uint64, 0, len(keys))
+	res := make([][]byte, 0, len(values))
+	for i, key := range keys {
+		if len(key) != len(prefix)+8 {
+			continue
+		}
+		chainIDs = append(chainIDs, utils.GetBytesUint64(key[len(prefix):]))
+		res = append(res, values[i])
+	}
+	return chainIDs, res, nil
+}
+
+func sideChainExtraInfo(extraInfo []byte) (json.RawMessage, error) {
+	if json.Valid(extraInfo) {
+		return extraInfo, nil
+	}
+	return json.Marshal(hex.EncodeToString(extraInfo))
+}
+
+func getSideChainInfos(prefix string, routers map[uint64]string) ([]*SideChainInfo, error) {
+	_, values, err := findSideChainItems(prefix)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]*SideChainInfo, 0, len(values))
+	for _, value := range values {
+		sideChain := new(side_chain_manager.SideChain)
+		if err := sideChain.Deserialization(common.NewZeroCopySource(value)); err != nil {
+			return nil, fmt.Errorf("deserialize side chain error: %v", err)
+		}
+		extraInfo, err := sideChainExtraInfo(sideChain.ExtraInfo)
+		if err != nil {
+			return nil, fmt.Errorf("marshal side chain extra info error: %v", err)
+		}
+		infos = append(infos, &SideChainInfo{
+			ChainID:      sideChain.ChainId,
+			Router:       sideChain.Router,
+			RouterName:   routers[sideChain.Router],
+			Name:         sideChain.Name,
+			Address:      sideChain.Address.ToBase58(),
+			BlocksToWait: sideChain.BlocksToWait,
+			CCMCAddress:  hex.EncodeToString(sideChain.CCMCAddress),
+			ExtraInfo:    extraInfo,
+		})
+	}
+	return infos, nil
+}
+
+// GetSideChains lists registered side chains and pending registrations, updates and quits
+func GetSideChains() (*SideChains, error) {
+	routers := ccmcom.GetRegisteredRouters()
+	for router, name := range hscom.GetRegisteredRouters() {
+		if _, ok := routers[router]; !ok {
+			routers[router] = name
+		}
+	}
+	res := new(SideChains)
+	var err error
+	if res.Chains, err = getSideChainInfos(side_chain_manager.SIDE_CHAIN, routers); err != nil {
+		return nil, err
+	}
+	if res.Applications, err = getSideChainInfos(side_chain_manager.SIDE_CHAIN_APPLY, routers); err != nil {
+		return nil, err
+	}
+	if res.Updates, err = getSideChainInfos(side_chain_manager.UPDATE_SIDE_CHAIN_REQUEST, routers); err != nil {
+		return nil, err
+	}
+	chainIDs, _, err := findSideChainItems(side_chain_manager.QUIT_SIDE_CHAIN_REQUEST)
+	if err != nil {
+		return nil, err
+	}
+	// quit requests approved before they were cleared up are left in storage, only those of registered
+	// chains are pending
+	registered := make(map[uint64]bool, len(res.Chains))
+	for _, v := range res.Chains {
+		registered[v.ChainID] = true
+	}
+	res.Quits = make([]uint64, 0, len(chainIDs))
+	for _, id := range chainIDs {
+		if registered[id] {
+			res.Quits = append(res.Quits, id)
+		}
+	}
+	return res, nil
+}
+
+// GetRelayers lists the relayer policy of chainID and the registered relayers allowed to serve
+// it, expired relayers are skipped
+func GetRelayers(chainID uint64) (*Relayers, error) {
+	contract := utils.RelayerManagerContractAddress
+	res := &Relayers{
+		ChainID:   chainID,
+		Policy:    relayer_manager.POLICY_OPEN,
+		AllowList: make([]string, 0),
+		Relayers:  make([]*RelayerInfo, 0),
+	}
+	policyBytes, err := getStorage(contract, []byte(relayer_manager.RELAYER_POLICY), utils.GetUint64Bytes(chainID))
+	if err != nil {
+		return nil, err
+	}
+	if policyBytes != nil {
+		policy := new(relayer_manager.RelayerPolicy)
+		if err := policy.Deserialization(common.NewZeroCopySource(policyBytes)); err != nil {
+			return nil, fmt.Errorf("deserialize relayer policy error: %v", err)
+		}
+		res.Policy = policy.Mode
+		for _, v := range policy.AllowList {
+			res.AllowList = append(res.AllowList, v.ToBase58())
+		}
+	}
+
+	keys, values, err := bactor.FindStorageItems(contract, []byte(relayer_manager.RELAYER))
+	if err != nil {
+		return nil, err
+	}
+	height := bactor.GetCurrentBlockHeight()
+	for i, key := range keys {
+		// skip keys of other prefixes starting with RELAYER
+		if len(key) != len(relayer_manager.RELAYER)+common.ADDR_LEN {
+			continue
+		}
+		relayer := new(relayer_manager.Relayer)
+		if err := relayer.Deserialization(common.NewZeroCopySource(values[i])); err != nil {
+			return nil, fmt.Errorf("deserialize relayer error: %v", err)
+		}
+		if relayer.IsExpired(height) || !relayer.CanServe(chainID) {
+			continue
+		}
+		chainIDs := relayer.ChainIDs
+		if chainIDs == nil {
+			chainIDs = make([]uint64, 0)
+		}
+		res.Relayers = append(res.Relayers, &RelayerInfo{
+			Address:      relayer.Address.ToBase58(),
+			ChainIDs:     chainIDs,
+			ExpiryHeight: relayer.ExpiryHeight,
+		})
+	}
+	return res, nil
+}
@@ -53,6 +53,13 @@ func GetNetworkId(cmd map[string]interface{}) map[string]interface{} {
 	return resp
 }
 
+// get routers supported by this node
+func GetSupportedRouters(cmd map[string]interface{}) map[string]interface{} {
+	resp := ResponsePack(berr.SUCCESS)
+	resp["Result"] = bcomn.GetSupportedRouters()
+	return resp
+}
+
 //get connection node count
 func GetConnectionCount(cmd map[string]interface{}) map[string]interface{} {
 	resp := ResponsePack(berr.SUCCESS)
@@ -347,6 +354,180 @@ func GetSmartCodeEventByTxHash(cmd map[string]interface{}) map[string]interface{
 	return resp
 }
 
+// get status of cross chain tx by poly tx hash, or by source chain tx hash with the source chain id
+func GetCrossChainStatus(cmd map[string]interface{}) map[string]interface{} {
+	resp := ResponsePack(berr.SUCCESS)
+	str, ok := cmd["Hash"].(string)
+	if !ok {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	var status *bcomn.CrossChainStatusInfo
+	var err error
+	if param, ok := cmd["ChainID"].(string); ok && len(param) > 0 {
+		chainID, perr := strconv.ParseUint(param, 10, 64)
+		if perr != nil {
+			return ResponsePack(berr.INVALID_PARAMS)
+		}
+		status, err = bcomn.GetCrossChainStatusBySourceTx(chainID, str)
+	} else {
+		status, err = bcomn.GetCrossChainStatus(str)
+	}
+	if err != nil {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	if status == nil {
+		return ResponsePack(berr.UNKNOWN_TRANSACTION)
+	}
+	resp["Result"] = status
+	return resp
+}
+
+// get requests to target chain in order of sequence
+func GetCrossChainRequests(cmd map[string]interface{}) map[string]interface{} {
+	resp := ResponsePack(berr.SUCCESS)
+	args := make([]uint64, 3)
+	for i, name := range []string{"ChainID", "From", "Limit"} {
+		param, ok := cmd[name].(string)
+		if !ok {
+			return ResponsePack(berr.INVALID_PARAMS)
+		}
+		if len(param) == 0 && name != "ChainID" {
+			continue
+		}
+		v, err := strconv.ParseUint(param, 10, 64)
+		if err != nil {
+			return ResponsePack(berr.INVALID_PARAMS)
+		}
+		args[i] = v
+	}
+	requests, err := bcomn.GetCrossChainRequests(args[0], args[1], args[2])
+	if err != nil {
+		return ResponsePack(berr.INTERNAL_ERROR)
+	}
+	resp["Result"] = requests
+	return resp
+}
+
+// get all proofs of a poly to target chain request
+func GetCrossChainProofBundle(cmd map[string]interface{}) map[string]interface{} {
+	resp := ResponsePack(berr.SUCCESS)
+	str, ok := cmd["Hash"].(string)
+	if !ok {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	hash, err := common.Uint256FromHexString(str)
+	if err != nil {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	var anchorHeight uint64
+	if param, ok := cmd["Anchor"].(string); ok && len(param) > 0 {
+		anchorHeight, err = strconv.ParseUint(param, 10, 32)
+		if err != nil {
+			return ResponsePack(berr.INVALID_PARAMS)
+		}
+	}
+	bundle, err := bcomn.GetCrossChainProofBundle(hash, uint32(anchorHeight))
+	if err != nil {
+		return ResponsePack(berr.INTERNAL_ERROR)
+	}
+	resp["Result"] = bundle
+	return resp
+}
+
+// get synced tip and recent reorgs of side chain
+func GetChainForkInfo(cmd map[string]interface{}) map[string]interface{} {
+	resp := ResponsePack(berr.SUCCESS)
+	param, ok := cmd["ChainID"].(string)
+	if !ok {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	chainID, err := strconv.ParseUint(param, 10, 64)
+	if err != nil {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	info, err := bcomn.GetChainForkInfo(chainID)
+	if err != nil {
+		return ResponsePack(berr.INTERNAL_ERROR)
+	}
+	resp["Result"] = info
+	return resp
+}
+
+func GetBtcUtxoInfo(cmd map[string]interface{}) map[string]interface{} {
+	resp := ResponsePack(berr.SUCCESS)
+	param, ok := cmd["ChainID"].(string)
+	if !ok {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	chainID, err := strconv.ParseUint(param, 10, 64)
+	if err != nil {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	redeemKey, ok := cmd["RedeemKey"].(string)
+	if !ok {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	info, err := bcomn.GetBtcUtxoInfo(chainID, redeemKey)
+	if err != nil {
+		return ResponsePack(berr.INTERNAL_ERROR)
+	}
+	resp["Result"] = info
+	return resp
+}
+
+// get open governance proposals in order of id with their vote tallies
+func GetProposals(cmd map[string]interface{}) map[string]interface{} {
+	resp := ResponsePack(berr.SUCCESS)
+	args := make([]uint64, 2)
+	for i, name := range []string{"From", "Limit"} {
+		param, ok := cmd[name].(string)
+		if !ok || len(param) == 0 {
+			continue
+		}
+		v, err := strconv.ParseUint(param, 10, 64)
+		if err != nil {
+			return ResponsePack(berr.INVALID_PARAMS)
+		}
+		args[i] = v
+	}
+	proposals, err := bcomn.GetProposals(args[0], args[1])
+	if err != nil {
+		return ResponsePack(berr.INTERNAL_ERROR)
+	}
+	resp["Result"] = proposals
+	return resp
+}
+
+// get registered side chains and pending registrations, updates and quits
+func GetSideChains(cmd map[string]interface{}) map[string]interface{} {
+	resp := ResponsePack(berr.SUCCESS)
+	sideChains, err := bcomn.GetSideChains()
+	if err != nil {
+		return ResponsePack(berr.INTERNAL_ERROR)
+	}
+	resp["Result"] = sideChains
+	return resp
+}
+
+// get relayer policy of a side chain and registered relayers allowed to serve it
+func GetRelayers(cmd map[string]interface{}) map[string]interface{} {
+	resp := ResponsePack(berr.SUCCESS)
+	param, ok := cmd["ChainID"].(string)
+	if !ok {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	chainID, err := strconv.ParseUint(param, 10, 64)
+	if err != nil {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	relayers, err := bcomn.GetRelayers(chainID)
+	if err != nil {
+		return ResponsePack(berr.INTERNAL_ERROR)
+	}
+	resp["Result"] = relayers
+	return resp
+}
+
 //get storage from contract
 func GetStorage(cmd map[string]interface{}) map[string]interface{} {
 	resp := ResponsePack(berr.SUCCESS)
@@ -310,6 +310,213 @@ func GetNetworkId(params []interface{}) map[string]interface{} {
 	return responseSuccess(config.DefConfig.P2PNode.NetworkId)
 }
 
+// get routers supported by this node
+func GetSupportedRouters(params []interface{}) map[string]interface{} {
+	return responseSuccess(bcomn.GetSupportedRouters())
+}
+
+// get status of cross chain tx by poly tx hash, or by source chain tx hash with the source chain id
+// Input JSON string examples for getcrosschainstatus method as following:
+//   {"jsonrpc": "2.0", "method": "getcrosschainstatus", "params": ["<poly tx hash>"], "id": 0}
+//   {"jsonrpc": "2.0", "method": "getcrosschainstatus", "params": ["<source tx hash>", 2], "id": 0}
+func GetCrossChainStatus(params []interface{}) map[string]interface{} {
+	if len(params) < 1 {
+		return responsePack(berr.INVALID_PARAMS, nil)
+	}
+	str, ok := params[0].(string)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	var status *bcomn.CrossChainStatusInfo
+	var err error
+	if len(params) > 1 {
+		chainID, ok := params[1].(float64)
+		if !ok || chainID < 0 {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		status, err = bcomn.GetCrossChainStatusBySourceTx(uint64(chainID), str)
+	} else {
+		status, err = bcomn.GetCrossChainStatus(str)
+	}
+	if err != nil {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	if status == nil {
+		return responsePack(berr.UNKNOWN_TRANSACTION, "unknown cross chain transaction")
+	}
+	return responseSuccess(status)
+}
+
+// get requests to target chain in order of sequence
+// Input JSON string examples for getcrosschainrequests method as following:
+//   {"jsonrpc": "2.0", "method": "getcrosschainrequests", "params": [2, 0, 100], "id": 0}
+func GetCrossChainRequests(params []interface{}) map[string]interface{} {
+	if len(params) < 3 {
+		return responsePack(berr.INVALID_PARAMS, nil)
+	}
+	args := make([]uint64, 3)
+	for i := range args {
+		v, ok := params[i].(float64)
+		if !ok || v < 0 {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		args[i] = uint64(v)
+	}
+	requests, err := bcomn.GetCrossChainRequests(args[0], args[1], args[2])
+	if err != nil {
+		return responsePack(berr.INTERNAL_ERROR, "")
+	}
+	return responseSuccess(requests)
+}
+
+// get all proofs of a poly to target chain request
+// Input JSON string examples for getcrosschainproofbundle method as following:
+//   {"jsonrpc": "2.0", "method": "getcrosschainproofbundle", "params": ["aabbcc..", 1000], "id": 0}
+func GetCrossChainProofBundle(params []interface{}) map[string]interface{} {
+	if len(params) < 1 {
+		return responsePack(berr.INVALID_PARAMS, nil)
+	}
+	str, ok := params[0].(string)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	hash, err := common.Uint256FromHexString(str)
+	if err != nil {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	var anchorHeight uint32
+	if len(params) >= 2 {
+		height, ok := params[1].(float64)
+		if !ok {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		anchorHeight = uint32(height)
+	}
+	bundle, err := bcomn.GetCrossChainProofBundle(hash, anchorHeight)
+	if err != nil {
+		return responsePack(berr.INTERNAL_ERROR, err.Error())
+	}
+	return responseSuccess(bundle)
+}
+
+// get synced tip and recent reorgs of side chain
+// Input JSON string examples for getchainforkinfo method as following:
+//   {"jsonrpc": "2.0", "method": "getchainforkinfo", "params": [2], "id": 0}
+func GetChainForkInfo(params []interface{}) map[string]interface{} {
+	if len(params) < 1 {
+		return responsePack(berr.INVALID_PARAMS, nil)
+	}
+	chainID, ok := params[0].(float64)
+	if !ok || chainID < 0 {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	info, err := bcomn.GetChainForkInfo(uint64(chainID))
+	if err != nil {
+		return responsePack(berr.INTERNAL_ERROR, err.Error())
+	}
+	return responseSuccess(info)
+}
+
+// get pending btc withdrawal as psbt with its partial signatures
+// Input JSON string examples for getbtcpsbt method as following:
+//   {"jsonrpc": "2.0", "method": "getbtcpsbt", "params": [1, "c330431496364497d7257839737b5e4596f5ac06", "<txhash hex>"], "id": 0}
+func GetBtcPsbt(params []interface{}) map[string]interface{} {
+	if len(params) < 3 {
+		return responsePack(berr.INVALID_PARAMS, nil)
+	}
+	chainID, ok := params[0].(float64)
+	if !ok || chainID < 0 {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	redeemKey, ok := params[1].(string)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	str, ok := params[2].(string)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	txHash, err := hex.DecodeString(str)
+	if err != nil {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	info, err := bcomn.GetBtcPsbt(uint64(chainID), redeemKey, txHash)
+	if err != nil {
+		return responsePack(berr.INTERNAL_ERROR, err.Error())
+	}
+	return responseSuccess(info)
+}
+
+// get count, value and ages of utxos and the pending stxos of btc redeem
+// Input JSON string examples for getbtcutxoinfo method as following:
+//   {"jsonrpc": "2.0", "method": "getbtcutxoinfo", "params": [1, "c330431496364497d7257839737b5e4596f5ac06"], "id": 0}
+func GetBtcUtxoInfo(params []interface{}) map[string]interface{} {
+	if len(params) < 2 {
+		return responsePack(berr.INVALID_PARAMS, nil)
+	}
+	chainID, ok := params[0].(float64)
+	if !ok || chainID < 0 {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	redeemKey, ok := params[1].(string)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	info, err := bcomn.GetBtcUtxoInfo(uint64(chainID), redeemKey)
+	if err != nil {
+		return responsePack(berr.INTERNAL_ERROR, err.Error())
+	}
+	return responseSuccess(info)
+}
+
+// get open governance proposals in order of id with their vote tallies
+// Input JSON string examples for getproposals method as following:
+//   {"jsonrpc": "2.0", "method": "getproposals", "params": [0, 100], "id": 0}
+func GetProposals(params []interface{}) map[string]interface{} {
+	args := make([]uint64, 2)
+	for i := 0; i < len(params) && i < len(args); i++ {
+		v, ok := params[i].(float64)
+		if !ok || v < 0 {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		args[i] = uint64(v)
+	}
+	proposals, err := bcomn.GetProposals(args[0], args[1])
+	if err != nil {
+		return responsePack(berr.INTERNAL_ERROR, "")
+	}
+	return responseSuccess(proposals)
+}
+
+// get registered side chains and pending registrations, updates and quits
+// Input JSON string examples for getsidechains method as following:
+//   {"jsonrpc": "2.0", "method": "getsidechains", "params": [], "id": 0}
+func GetSideChains(params []interface{}) map[string]interface{} {
+	sideChains, err := bcomn.GetSideChains()
+	if err != nil {
+		return responsePack(berr.INTERNAL_ERROR, "")
+	}
+	return responseSuccess(sideChains)
+}
+
+// get relayer policy of a side chain and registered relayers allowed to serve it
+// Input JSON string examples for getrelayers method as following:
+//   {"jsonrpc": "2.0", "method": "getrelayers", "params": [2], "id": 0}
+func GetRelayers(params []interface{}) map[string]interface{} {
+	if len(params) < 1 {
+		return responsePack(berr.INVALID_PARAMS, nil)
+	}
+	chainID, ok := params[0].(float64)
+	if !ok || chainID < 0 {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	relayers, err := bcomn.GetRelayers(uint64(chainID))
+	if err != nil {
+		return responsePack(berr.INTERNAL_ERROR, "")
+	}
+	return responseSuccess(relayers)
+}
+
 //get smartconstract event
 func GetSmartCodeEvent(params []interface{}) map[string]interface{} {
 	if !config.DefConfig.Common.EnableEventLog {
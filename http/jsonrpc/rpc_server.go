@@ -46,6 +46,16 @@ func StartRPCServer() error {
 	rpc.HandleFunc("getstorage", rpc.GetStorage)
 	rpc.HandleFunc("getversion", rpc.GetNodeVersion)
 	rpc.HandleFunc("getnetworkid", rpc.GetNetworkId)
+	rpc.HandleFunc("getsupportedrouters", rpc.GetSupportedRouters)
+	rpc.HandleFunc("getcrosschainstatus", rpc.GetCrossChainStatus)
+	rpc.HandleFunc("getcrosschainrequests", rpc.GetCrossChainRequests)
+	rpc.HandleFunc("getcrosschainproofbundle", rpc.GetCrossChainProofBundle)
+	rpc.HandleFunc("getchainforkinfo", rpc.GetChainForkInfo)
+	rpc.HandleFunc("getbtcpsbt", rpc.GetBtcPsbt)
+	rpc.HandleFunc("getbtcutxoinfo", rpc.GetBtcUtxoInfo)
+	rpc.HandleFunc("getproposals", rpc.GetProposals)
+	rpc.HandleFunc("getsidechains", rpc.GetSideChains)
+	rpc.HandleFunc("getrelayers", rpc.GetRelayers)
 
 	rpc.HandleFunc("getmempooltxcount", rpc.GetMemPoolTxCount)
 	rpc.HandleFunc("getmempooltxstate", rpc.GetMemPoolTxState)
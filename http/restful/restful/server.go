@@ -74,6 +74,15 @@ const (
 	GET_MEMPOOL_TXSTATE   = "/api/v1/mempool/txstate/:hash"
 	GET_VERSION           = "/api/v1/version"
 	GET_NETWORKID         = "/api/v1/networkid"
+	GET_ROUTERS           = "/api/v1/routers"
+	GET_CROSS_STATUS      = "/api/v1/crosschainstatus/:hash"
+	GET_CROSS_REQUESTS    = "/api/v1/crosschainrequests/:chainid"
+	GET_PROOF_BUNDLE      = "/api/v1/crosschainproofbundle/:hash"
+	GET_FORK_INFO         = "/api/v1/chainforkinfo/:chainid"
+	GET_BTC_UTXO_INFO     = "/api/v1/btcutxoinfo/:chainid/:redeemkey"
+	GET_PROPOSALS         = "/api/v1/proposals"
+	GET_SIDE_CHAINS       = "/api/v1/sidechains"
+	GET_RELAYERS          = "/api/v1/relayers/:chainid"
 
 	POST_RAW_TX = "/api/v1/transaction"
 )
@@ -148,6 +157,15 @@ func (this *restServer) registryMethod() {
 		GET_MEMPOOL_TXSTATE:   {name: "getmempooltxstate", handler: rest.GetMemPoolTxState},
 		GET_VERSION:           {name: "getversion", handler: rest.GetNodeVersion},
 		GET_NETWORKID:         {name: "getnetworkid", handler: rest.GetNetworkId},
+		GET_ROUTERS:           {name: "getsupportedrouters", handler: rest.GetSupportedRouters},
+		GET_CROSS_STATUS:      {name: "getcrosschainstatus", handler: rest.GetCrossChainStatus},
+		GET_CROSS_REQUESTS:    {name: "getcrosschainrequests", handler: rest.GetCrossChainRequests},
+		GET_PROOF_BUNDLE:      {name: "getcrosschainproofbundle", handler: rest.GetCrossChainProofBundle},
+		GET_FORK_INFO:         {name: "getchainforkinfo", handler: rest.GetChainForkInfo},
+		GET_BTC_UTXO_INFO:     {name: "getbtcutxoinfo", handler: rest.GetBtcUtxoInfo},
+		GET_PROPOSALS:         {name: "getproposals", handler: rest.GetProposals},
+		GET_SIDE_CHAINS:       {name: "getsidechains", handler: rest.GetSideChains},
+		GET_RELAYERS:          {name: "getrelayers", handler: rest.GetRelayers},
 	}
 
 	postMethodMap := map[string]Action{
@@ -190,6 +208,18 @@ func (this *restServer) getPath(url string) string {
 		return GET_GRANTONG
 	} else if strings.Contains(url, strings.TrimRight(GET_MEMPOOL_TXSTATE, ":hash")) {
 		return GET_MEMPOOL_TXSTATE
+	} else if strings.Contains(url, strings.TrimRight(GET_CROSS_STATUS, ":hash")) {
+		return GET_CROSS_STATUS
+	} else if strings.Contains(url, strings.TrimRight(GET_CROSS_REQUESTS, ":chainid")) {
+		return GET_CROSS_REQUESTS
+	} else if strings.Contains(url, strings.TrimRight(GET_PROOF_BUNDLE, ":hash")) {
+		return GET_PROOF_BUNDLE
+	} else if strings.Contains(url, strings.TrimRight(GET_FORK_INFO, ":chainid")) {
+		return GET_FORK_INFO
+	} else if strings.Contains(url, strings.TrimRight(GET_BTC_UTXO_INFO, ":chainid/:redeemkey")) {
+		return GET_BTC_UTXO_INFO
+	} else if strings.Contains(url, strings.TrimRight(GET_RELAYERS, ":chainid")) {
+		return GET_RELAYERS
 	}
 	return url
 }
@@ -234,6 +264,21 @@ func (this *restServer) getParams(r *http.Request, url string, req map[string]in
 		req["Addr"] = getParam(r, "addr")
 	case GET_MEMPOOL_TXSTATE:
 		req["Hash"] = getParam(r, "hash")
+	case GET_CROSS_STATUS:
+		req["Hash"], req["ChainID"] = getParam(r, "hash"), r.FormValue("chainid")
+	case GET_CROSS_REQUESTS:
+		req["ChainID"] = getParam(r, "chainid")
+		req["From"], req["Limit"] = r.FormValue("from"), r.FormValue("limit")
+	case GET_PROOF_BUNDLE:
+		req["Hash"], req["Anchor"] = getParam(r, "hash"), r.FormValue("anchor")
+	case GET_FORK_INFO:
+		req["ChainID"] = getParam(r, "chainid")
+	case GET_BTC_UTXO_INFO:
+		req["ChainID"], req["RedeemKey"] = getParam(r, "chainid"), getParam(r, "redeemkey")
+	case GET_RELAYERS:
+		req["ChainID"] = getParam(r, "chainid")
+	case GET_PROPOSALS:
+		req["From"], req["Limit"] = r.FormValue("from"), r.FormValue("limit")
 	default:
 	}
 	return req
@@ -20,6 +20,7 @@ package native
 import (
 	"fmt"
 	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/common/config"
 	"github.com/polynetwork/poly/common/log"
 	"github.com/polynetwork/poly/core/types"
 	"github.com/polynetwork/poly/merkle"
@@ -210,6 +211,16 @@ func (this *NativeService) GetHeight() uint32 {
 	return this.height
 }
 
+// GetBlockTime returns the timestamp of the block executing the transaction
+func (this *NativeService) GetBlockTime() uint32 {
+	return this.time
+}
+
+// IsForkActive tells if fork name of config is active at the block executing the transaction
+func (this *NativeService) IsForkActive(name string) bool {
+	return config.IsForkActive(config.DefConfig.P2PNode.NetworkId, name, this.height)
+}
+
 func (this *NativeService) GetChainID() uint64 {
 	return this.chainID
 }
@@ -21,6 +21,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"github.com/polynetwork/poly/native/service/utils"
 	"math/big"
 
 	ecommon "github.com/ethereum/go-ethereum/common"
@@ -41,6 +42,10 @@ import (
 type Handler struct {
 }
 
+func init() {
+	scom.RegisterChainHandler(utils.BSC_ROUTER, "bsc", func() scom.ChainHandler { return NewHandler() })
+}
+
 // NewHandler ...
 func NewHandler() *Handler {
 	return &Handler{}
@@ -72,18 +77,16 @@ func (h *Handler) MakeDepositProposal(service *native.NativeService) (*scom.Make
 	return value, nil
 }
 
-func verifyFromTx(native *native.NativeService, proof, extra []byte, fromChainID uint64, height uint32, sideChain *side_chain_manager.SideChain) (param *scom.MakeTxParam, err error) {
-	cheight, err := bsc.GetCanonicalHeight(native, fromChainID)
+// GetSyncedHeight returns the canonical height
+func (h *Handler) GetSyncedHeight(service *native.NativeService, chainID uint64) (uint64, bool, error) {
+	height, err := bsc.GetCanonicalHeight(service, chainID)
 	if err != nil {
-		return
-	}
-
-	cheight32 := uint32(cheight)
-
-	if cheight32 < height || cheight32-height < uint32(sideChain.BlocksToWait-1) {
-		return nil, fmt.Errorf("verifyFromTx, transaction is not confirmed, current height: %d, input height: %d", cheight, height)
+		return 0, false, fmt.Errorf("bsc GetSyncedHeight, %v", err)
 	}
+	return height, true, nil
+}
 
+func verifyFromTx(native *native.NativeService, proof, extra []byte, fromChainID uint64, height uint32, sideChain *side_chain_manager.SideChain) (param *scom.MakeTxParam, err error) {
 	headerWithSum, err := bsc.GetCanonicalHeader(native, fromChainID, uint64(height))
 	if err != nil {
 		return nil, fmt.Errorf("verifyFromTx, GetCanonicalHeader height:%d, error:%s", height, err)
@@ -22,6 +22,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
+	"sort"
 
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/txscript"
@@ -31,12 +32,19 @@ import (
 	"github.com/polynetwork/poly/native"
 	"github.com/polynetwork/poly/native/event"
 	crosscommon "github.com/polynetwork/poly/native/service/cross_chain_manager/common"
+	"github.com/polynetwork/poly/native/service/header_sync/btc"
 	"github.com/polynetwork/poly/native/service/utils"
 )
 
 type BTCHandler struct {
 }
 
+func init() {
+	crosscommon.RegisterChainHandler(utils.BTC_ROUTER, "btc", func() crosscommon.ChainHandler { return NewBTCHandler() })
+	crosscommon.RegisterChainHandler(utils.BCH_ROUTER, "bch", func() crosscommon.ChainHandler { return NewBTCHandler() })
+	crosscommon.RegisterChainHandler(utils.LTC_ROUTER, "ltc", func() crosscommon.ChainHandler { return NewBTCHandler() })
+}
+
 func NewBTCHandler() *BTCHandler {
 	return &BTCHandler{}
 }
@@ -46,6 +54,87 @@ func (this *BTCHandler) MultiSign(service *native.NativeService) error {
 	if err := params.Deserialization(common.NewZeroCopySource(service.GetInput())); err != nil {
 		return fmt.Errorf("MultiSign, contract params deserialize error: %v", err)
 	}
+	return this.multiSign(service, params)
+}
+
+// MultiSignPsbt applies the signatures in a psbt of the unsigned tx, every signer of redeem who signs
+// all inputs is taken as a MultiSign until the tx is fully signed
+func (this *BTCHandler) MultiSignPsbt(service *native.NativeService) error {
+	params := new(crosscommon.MultiSignPsbtParam)
+	if err := params.Deserialization(common.NewZeroCopySource(service.GetInput())); err != nil {
+		return fmt.Errorf("MultiSignPsbt, contract params deserialize error: %v", err)
+	}
+	p, err := DecodePsbt(params.Psbt)
+	if err != nil {
+		return fmt.Errorf("MultiSignPsbt, %v", err)
+	}
+	mtx, err := getBtcTx(service, params.TxHash)
+	if err != nil {
+		return fmt.Errorf("MultiSignPsbt, %v", err)
+	}
+	for _, in := range mtx.TxIn {
+		in.SignatureScript = nil
+	}
+	var expected, actual bytes.Buffer
+	if err = mtx.SerializeNoWitness(&expected); err != nil {
+		return fmt.Errorf("MultiSignPsbt, failed to serialize stored tx: %v", err)
+	}
+	if err = p.UnsignedTx.SerializeNoWitness(&actual); err != nil {
+		return fmt.Errorf("MultiSignPsbt, failed to serialize tx of psbt: %v", err)
+	}
+	if !bytes.Equal(expected.Bytes(), actual.Bytes()) {
+		return fmt.Errorf("MultiSignPsbt, tx of psbt is not the stored tx %s", hex.EncodeToString(params.TxHash))
+	}
+
+	redeemScript, err := side_chain_manager.GetBtcRedeemScriptBytes(service, params.RedeemKey, params.ChainID)
+	if err != nil {
+		return fmt.Errorf("MultiSignPsbt, get btc redeem script with redeem key %v from db error: %v", params.RedeemKey, err)
+	}
+	netParam, err := getNetParam(service, params.ChainID)
+	if err != nil {
+		return fmt.Errorf("MultiSignPsbt, %v", err)
+	}
+	_, addrs, n, err := txscript.ExtractPkScriptAddrs(redeemScript, netParam)
+	if err != nil {
+		return fmt.Errorf("MultiSignPsbt, failed to extract pkscript addrs: %v", err)
+	}
+
+	signs := p.GetMultiSigns(addrs)
+	applied := 0
+	for _, addr := range addrs {
+		sigs, ok := signs[addr.EncodeAddress()]
+		if !ok {
+			continue
+		}
+		multiSignInfo, err := getBtcMultiSignInfo(service, params.TxHash)
+		if err != nil {
+			return fmt.Errorf("MultiSignPsbt, getBtcMultiSignInfo error: %v", err)
+		}
+		if len(multiSignInfo.MultiSignInfo) == n {
+			break
+		}
+		if _, ok := multiSignInfo.MultiSignInfo[addr.EncodeAddress()]; ok {
+			continue
+		}
+		err = this.multiSign(service, &crosscommon.MultiSignParam{
+			ChainID:   params.ChainID,
+			RedeemKey: params.RedeemKey,
+			TxHash:    params.TxHash,
+			Address:   addr.EncodeAddress(),
+			Signs:     sigs,
+		})
+		if err != nil {
+			return fmt.Errorf("MultiSignPsbt, signatures of %s: %v", addr.EncodeAddress(), err)
+		}
+		applied++
+	}
+	if applied == 0 {
+		return fmt.Errorf("MultiSignPsbt, no new signature in psbt")
+	}
+	return nil
+}
+
+func (this *BTCHandler) multiSign(service *native.NativeService, params *crosscommon.MultiSignParam) error {
 	multiSignInfo, err := getBtcMultiSignInfo(service, params.TxHash)
 	if err != nil {
 		return fmt.Errorf("MultiSign, getBtcMultiSignInfo error: %v", err)
@@ -73,15 +162,9 @@ func (this *BTCHandler) MultiSign(service *native.NativeService) error {
 		return fmt.Errorf("MultiSign, already enough signature: %d", n)
 	}
 
-	txb, err := service.GetCacheDB().Get(utils.ConcatKey(utils.CrossChainManagerContractAddress, []byte(BTC_TX_PREFIX),
-		params.TxHash))
+	mtx, err := getBtcTx(service, params.TxHash)
 	if err != nil {
-		return fmt.Errorf("MultiSign, failed to get tx %s from cacheDB: %v", hex.EncodeToString(params.TxHash), err)
-	}
-	mtx := wire.NewMsgTx(wire.TxVersion)
-	err = mtx.BtcDecode(bytes.NewBuffer(txb), wire.ProtocolVersion, wire.LatestEncoding)
-	if err != nil {
-		return fmt.Errorf("MultiSign, failed to decode tx: %v", err)
+		return fmt.Errorf("MultiSign, %v", err)
 	}
 
 	pkScripts := make([][]byte, len(mtx.TxIn))
@@ -93,7 +176,8 @@ func (this *BTCHandler) MultiSign(service *native.NativeService) error {
 	if err != nil {
 		return fmt.Errorf("MultiSign, failed to get stxos: %v", err)
 	}
-	err = verifySigs(params.Signs, params.Address, addrs, redeemScript, mtx, pkScripts, amts)
+	err = verifySigs(params.Signs, params.Address, addrs, redeemScript, mtx, pkScripts, amts,
+		btc.FamilyOf(netParam).ForkID)
 	if err != nil {
 		return fmt.Errorf("MultiSign, failed to verify: %v", err)
 	}
@@ -120,36 +204,41 @@ func (this *BTCHandler) MultiSign(service *native.NativeService) error {
 		if err != nil {
 			return fmt.Errorf("MultiSign, failed to encode msgtx to bytes: %v", err)
 		}
+		signedTxid := mtx.TxHash()
+		putBtcSignedTxid(service, params.TxHash, signedTxid[:])
 
 		witScript, err := getLockScript(redeemScript, netParam)
 		if err != nil {
 			return fmt.Errorf("MultiSign, failed to get lock script: %v", err)
 		}
-		utxos, err := getUtxos(service, params.ChainID, params.RedeemKey)
+		if err = addTxOutUtxos(service, params.ChainID, params.RedeemKey, mtx, witScript); err != nil {
+			return fmt.Errorf("MultiSign, %v", err)
+		}
+		sweepTo, err := getBtcSweepTo(service, params.TxHash)
 		if err != nil {
-			return fmt.Errorf("MultiSign, getUtxos error: %v", err)
-		}
-		txid := mtx.TxHash()
-		for i, v := range mtx.TxOut {
-			if bytes.Equal(witScript, v.PkScript) {
-				newUtxo := &Utxo{
-					Op: &OutPoint{
-						Hash:  txid[:],
-						Index: uint32(i),
-					},
-					Value:        uint64(v.Value),
-					ScriptPubkey: v.PkScript,
-				}
-				utxos.Utxos = append(utxos.Utxos, newUtxo)
+			return fmt.Errorf("MultiSign, %v", err)
+		}
+		if sweepTo != "" {
+			successorScript, err := getRedeemLockScript(service, params.ChainID, sweepTo, netParam)
+			if err != nil {
+				return fmt.Errorf("MultiSign, %v", err)
+			}
+			if err = addTxOutUtxos(service, params.ChainID, sweepTo, mtx, successorScript); err != nil {
+				return fmt.Errorf("MultiSign, %v", err)
 			}
 		}
-		putUtxos(service, params.ChainID, params.RedeemKey, utxos)
 		btcFromTxInfo, err := getBtcFromInfo(service, params.TxHash)
 		if err != nil {
 			return fmt.Errorf("MultiSign, failed to get from tx hash %s from cacheDB: %v",
 				hex.EncodeToString(params.TxHash), err)
 		}
 		putStxos(service, params.ChainID, params.RedeemKey, stxos)
+		if err = deleteBtcUtxoTxs(service, params.ChainID, params.RedeemKey, mtx.TxIn, stxos); err != nil {
+			return fmt.Errorf("MultiSign, %v", err)
+		}
+		if err := crosscommon.MarkForwarded(service, btcFromTxInfo.FromChainID, btcFromTxInfo.FromTxHash); err != nil {
+			return fmt.Errorf("MultiSign, %v", err)
+		}
 		service.AddNotify(
 			&event.NotifyEventInfo{
 				ContractAddress: utils.CrossChainManagerContractAddress,
@@ -198,6 +287,533 @@ func (this *BTCHandler) MakeDepositProposal(service *native.NativeService) (*cro
 	return value, nil
 }
 
+// GetSyncedHeight returns the height of the best header
+func (this *BTCHandler) GetSyncedHeight(service *native.NativeService, chainID uint64) (uint64, bool, error) {
+	bestHeader, err := btc.GetBestBlockHeader(service, chainID)
+	if err != nil {
+		return 0, false, fmt.Errorf("btc GetSyncedHeight, %v", err)
+	}
+	return uint64(bestHeader.Height), true, nil
+}
+
+// BumpTransaction replaces the btc tx with a new one spending the same inputs at a higher fee rate,
+// the extra fee is taken from the change so receivers get what the old tx pays them, the output to
+// the successor redeem is the change of a sweep. The multisign round starts over for the new tx
+// and, if the old one is already signed, its inputs go back to stxos and its change leaves utxos.
+// A signed tx may be broadcast already, so it's replaced only if it signals replaceability and the
+// new fee pays for relaying the replacement as BIP125 requires. It's recorded as replaced by the new
+// tx then, ReleaseBumpedTransaction undoes the bump if it's confirmed on bitcoin after all.
+func (this *BTCHandler) BumpTransaction(service *native.NativeService) error {
+	params := new(crosscommon.BumpBtcTxParam)
+	if err := params.Deserialization(common.NewZeroCopySource(service.GetInput())); err != nil {
+		return fmt.Errorf("BumpTransaction, contract params deserialize error: %v", err)
+	}
+
+	oldHash := params.TxHash
+	mtx, err := getBtcTx(service, oldHash)
+	if err != nil {
+		return fmt.Errorf("BumpTransaction, %v", err)
+	}
+	multiSignInfo, err := getBtcMultiSignInfo(service, oldHash)
+	if err != nil {
+		return fmt.Errorf("BumpTransaction, getBtcMultiSignInfo error: %v", err)
+	}
+	inputs, err := getBtcTxInputs(service, oldHash)
+	if err != nil {
+		return fmt.Errorf("BumpTransaction, %v", err)
+	}
+	redeemScript, err := side_chain_manager.GetBtcRedeemScriptBytes(service, params.RedeemKey, params.ChainID)
+	if err != nil {
+		return fmt.Errorf("BumpTransaction, get btc redeem script with redeem key %v from db error: %v", params.RedeemKey, err)
+	}
+	netParam, err := getNetParam(service, params.ChainID)
+	if err != nil {
+		return fmt.Errorf("BumpTransaction, %v", err)
+	}
+	_, addrs, m, err := txscript.ExtractPkScriptAddrs(redeemScript, netParam)
+	if err != nil {
+		return fmt.Errorf("BumpTransaction, failed to extract pkscript addrs: %v", err)
+	}
+	signed := len(multiSignInfo.MultiSignInfo) == m
+	if len(inputs.Utxos) == 0 {
+		// txs made before inputs were recorded, their inputs stay in stxos until fully signed
+		if signed {
+			return fmt.Errorf("BumpTransaction, tx %s is signed before its inputs were recorded, it can't be bumped",
+				hex.EncodeToString(oldHash))
+		}
+		if inputs, err = getStxoInputs(service, params.ChainID, mtx.TxIn, params.RedeemKey); err != nil {
+			return fmt.Errorf("BumpTransaction, %v", err)
+		}
+	}
+	if len(inputs.Utxos) != len(mtx.TxIn) {
+		return fmt.Errorf("BumpTransaction, inputs of tx %s are not recorded", hex.EncodeToString(oldHash))
+	}
+	if signed && !signalsReplaceability(mtx) {
+		return fmt.Errorf("BumpTransaction, signed tx %s doesn't signal replaceability", hex.EncodeToString(oldHash))
+	}
+	btcFromInfo, err := getBtcFromInfo(service, params.TxHash)
+	if err != nil {
+		return fmt.Errorf("BumpTransaction, %v", err)
+	}
+
+	changeScript, err := getLockScript(redeemScript, netParam)
+	if err != nil {
+		return fmt.Errorf("BumpTransaction, failed to get lock script: %v", err)
+	}
+	rk, err := hex.DecodeString(params.RedeemKey)
+	if err != nil {
+		return fmt.Errorf("BumpTransaction, hex.DecodeString error: %v", err)
+	}
+
+	sweepTo, err := getBtcSweepTo(service, oldHash)
+	if err != nil {
+		return fmt.Errorf("BumpTransaction, %v", err)
+	}
+	payerScript := changeScript
+	if sweepTo != "" {
+		if payerScript, err = getRedeemLockScript(service, params.ChainID, sweepTo, netParam); err != nil {
+			return fmt.Errorf("BumpTransaction, %v", err)
+		}
+	}
+
+	var sumIn, sumOut int64
+	var change *wire.TxOut
+	amts := make([]uint64, len(inputs.Utxos))
+	for i, u := range inputs.Utxos {
+		sumIn += int64(u.Value)
+		amts[i] = u.Value
+	}
+	for _, out := range mtx.TxOut {
+		sumOut += out.Value
+		if change == nil && bytes.Equal(out.PkScript, payerScript) {
+			change = out
+		}
+	}
+	if change == nil {
+		return fmt.Errorf("BumpTransaction, tx %s has no change to pay the extra fee", hex.EncodeToString(oldHash))
+	}
+	cs := &CoinSelector{
+		txOuts:  mtx.TxOut,
+		feeRate: params.FeeRate,
+		m:       m,
+		n:       len(addrs),
+		rk:      rk,
+	}
+	oldFee, newFee := sumIn-sumOut, int64(cs.estimateTxFee(inputs.Utxos))
+	if minFee := oldFee + MIN_RELAY_FEE_RATE*int64(cs.estimateTxSize(inputs.Utxos)); newFee < minFee {
+		return fmt.Errorf("BumpTransaction, fee %d at rate %d is lower than %d, the current fee %d plus the relay fee",
+			newFee, params.FeeRate, minFee, oldFee)
+	}
+	extra := newFee - oldFee
+	if change.Value <= extra {
+		return fmt.Errorf("BumpTransaction, change %d can't afford the extra fee %d", change.Value, extra)
+	}
+	change.Value -= extra
+	if btc.FamilyOf(netParam).ReplaceByFee {
+		for _, in := range mtx.TxIn {
+			in.Sequence = MAX_REPLACEABLE_SEQUENCE
+		}
+	}
+
+	replaced, err := getBtcReplacedTxs(service, oldHash)
+	if err != nil {
+		return fmt.Errorf("BumpTransaction, %v", err)
+	}
+	if signed {
+		signedTxid, err := getBtcSignedTxid(service, oldHash)
+		if err != nil {
+			return fmt.Errorf("BumpTransaction, %v", err)
+		}
+		if signedTxid == nil {
+			return fmt.Errorf("BumpTransaction, txid of signed tx %s is not recorded", hex.EncodeToString(oldHash))
+		}
+		// outputs of old tx are superseded, refuse if any is already spent by another tx
+		if err = removeTxOutUtxos(service, params.ChainID, params.RedeemKey, signedTxid, mtx.TxOut, changeScript); err != nil {
+			return fmt.Errorf("BumpTransaction, %v", err)
+		}
+		if sweepTo != "" {
+			if err = removeTxOutUtxos(service, params.ChainID, sweepTo, signedTxid, mtx.TxOut, payerScript); err != nil {
+				return fmt.Errorf("BumpTransaction, %v", err)
+			}
+		}
+
+		stxos, err := getStxos(service, params.ChainID, params.RedeemKey)
+		if err != nil {
+			return fmt.Errorf("BumpTransaction, getStxos error: %v", err)
+		}
+		stxos.Utxos = append(stxos.Utxos, inputs.Utxos...)
+		putStxos(service, params.ChainID, params.RedeemKey, stxos)
+		replaced = append(replaced, signedTxid)
+	}
+	deleteBtcTx(service, oldHash)
+
+	var buf bytes.Buffer
+	err = mtx.BtcEncode(&buf, wire.ProtocolVersion, wire.LatestEncoding)
+	if err != nil {
+		return fmt.Errorf("BumpTransaction, serialize rawtransaction fail: %v", err)
+	}
+	txHash := mtx.TxHash()
+	putBtcTx(service, txHash[:], buf.Bytes())
+	if err = putBtcFromInfo(service, txHash[:], btcFromInfo); err != nil {
+		return fmt.Errorf("BumpTransaction, putBtcFromInfo failed: %v", err)
+	}
+	putBtcTxInputs(service, txHash[:], inputs)
+	if sweepTo != "" {
+		putBtcSweepTo(service, txHash[:], sweepTo)
+	}
+	if len(replaced) > 0 {
+		putBtcReplacedTxs(service, txHash[:], replaced)
+	}
+
+	service.AddNotify(
+		&event.NotifyEventInfo{
+			ContractAddress: utils.CrossChainManagerContractAddress,
+			States:          []interface{}{"bumpBtcTx", hex.EncodeToString(oldHash), hex.EncodeToString(txHash[:]), params.FeeRate},
+		})
+	service.AddNotify(
+		&event.NotifyEventInfo{
+			ContractAddress: utils.CrossChainManagerContractAddress,
+			States:          []interface{}{"makeBtcTx", params.RedeemKey, hex.EncodeToString(buf.Bytes()), amts},
+		})
+	return nil
+}
+
+// ReleaseBumpedTransaction undoes the bump of a signed tx once the tx is proved confirmed on bitcoin,
+// the bumped one spending the same inputs can never be confirmed then. Inputs locked in stxos for the
+// bumped tx are released as spent, or its change leaves utxos if it's signed already, and the change of
+// the confirmed tx goes back to utxos.
+func (this *BTCHandler) ReleaseBumpedTransaction(service *native.NativeService) error {
+	params := new(crosscommon.ReleaseBumpedBtcTxParam)
+	if err := params.Deserialization(common.NewZeroCopySource(service.GetInput())); err != nil {
+		return fmt.Errorf("ReleaseBumpedTransaction, contract params deserialize error: %v", err)
+	}
+
+	confirmed := wire.NewMsgTx(wire.TxVersion)
+	if err := confirmed.BtcDecode(bytes.NewReader(params.Tx), wire.ProtocolVersion, wire.LatestEncoding); err != nil {
+		return fmt.Errorf("ReleaseBumpedTransaction, failed to decode the transaction %s: %v",
+			hex.EncodeToString(params.Tx), err)
+	}
+	confirmedHash := confirmed.TxHash()
+	replaced, err := getBtcReplacedTxs(service, params.TxHash)
+	if err != nil {
+		return fmt.Errorf("ReleaseBumpedTransaction, %v", err)
+	}
+	isReplaced := false
+	for _, v := range replaced {
+		if bytes.Equal(v, confirmedHash[:]) {
+			isReplaced = true
+			break
+		}
+	}
+	if !isReplaced {
+		return fmt.Errorf("ReleaseBumpedTransaction, tx %s is not replaced by %s", hex.EncodeToString(confirmedHash[:]),
+			hex.EncodeToString(params.TxHash))
+	}
+	header, err := btc.GetHeaderByHeight(service, params.ChainID, params.Height)
+	if err != nil {
+		return fmt.Errorf("ReleaseBumpedTransaction, get header at height %d error: %v", params.Height, err)
+	}
+	if verified, err := verifyBtcMerkleProof(confirmed, header.Header, params.Proof); !verified {
+		return fmt.Errorf("ReleaseBumpedTransaction, verify merkle proof error: %v", err)
+	}
+
+	bumped, err := getBtcTx(service, params.TxHash)
+	if err != nil {
+		return fmt.Errorf("ReleaseBumpedTransaction, %v", err)
+	}
+	multiSignInfo, err := getBtcMultiSignInfo(service, params.TxHash)
+	if err != nil {
+		return fmt.Errorf("ReleaseBumpedTransaction, getBtcMultiSignInfo error: %v", err)
+	}
+	redeemScript, err := side_chain_manager.GetBtcRedeemScriptBytes(service, params.RedeemKey, params.ChainID)
+	if err != nil {
+		return fmt.Errorf("ReleaseBumpedTransaction, get btc redeem script with redeem key %v from db error: %v",
+			params.RedeemKey, err)
+	}
+	netParam, err := getNetParam(service, params.ChainID)
+	if err != nil {
+		return fmt.Errorf("ReleaseBumpedTransaction, %v", err)
+	}
+	_, _, m, err := txscript.ExtractPkScriptAddrs(redeemScript, netParam)
+	if err != nil {
+		return fmt.Errorf("ReleaseBumpedTransaction, failed to extract pkscript addrs: %v", err)
+	}
+	changeScript, err := getLockScript(redeemScript, netParam)
+	if err != nil {
+		return fmt.Errorf("ReleaseBumpedTransaction, failed to get lock script: %v", err)
+	}
+	sweepTo, err := getBtcSweepTo(service, params.TxHash)
+	if err != nil {
+		return fmt.Errorf("ReleaseBumpedTransaction, %v", err)
+	}
+	var successorScript []byte
+	if sweepTo != "" {
+		if successorScript, err = getRedeemLockScript(service, params.ChainID, sweepTo, netParam); err != nil {
+			return fmt.Errorf("ReleaseBumpedTransaction, %v", err)
+		}
+	}
+
+	if len(multiSignInfo.MultiSignInfo) == m {
+		signedTxid, err := getBtcSignedTxid(service, params.TxHash)
+		if err != nil {
+			return fmt.Errorf("ReleaseBumpedTransaction, %v", err)
+		}
+		if err = removeTxOutUtxos(service, params.ChainID, params.RedeemKey, signedTxid, bumped.TxOut,
+			changeScript); err != nil {
+			return fmt.Errorf("ReleaseBumpedTransaction, %v", err)
+		}
+		if sweepTo != "" {
+			if err = removeTxOutUtxos(service, params.ChainID, sweepTo, signedTxid, bumped.TxOut,
+				successorScript); err != nil {
+				return fmt.Errorf("ReleaseBumpedTransaction, %v", err)
+			}
+		}
+	} else {
+		_, stxos, err := getStxoAmts(service, params.ChainID, bumped.TxIn, params.RedeemKey)
+		if err != nil {
+			return fmt.Errorf("ReleaseBumpedTransaction, failed to get stxos: %v", err)
+		}
+		putStxos(service, params.ChainID, params.RedeemKey, stxos)
+	}
+	if err = addTxOutUtxos(service, params.ChainID, params.RedeemKey, confirmed, changeScript); err != nil {
+		return fmt.Errorf("ReleaseBumpedTransaction, %v", err)
+	}
+	if sweepTo != "" {
+		if err = addTxOutUtxos(service, params.ChainID, sweepTo, confirmed, successorScript); err != nil {
+			return fmt.Errorf("ReleaseBumpedTransaction, %v", err)
+		}
+	}
+	deleteBtcTx(service, params.TxHash)
+
+	service.AddNotify(
+		&event.NotifyEventInfo{
+			ContractAddress: utils.CrossChainManagerContractAddress,
+			States:          []interface{}{"releaseBumpedBtcTx", hex.EncodeToString(params.TxHash), hex.EncodeToString(confirmedHash[:])},
+		})
+	return nil
+}
+
+// SweepUtxos moves utxos of a rotated redeem to the redeem holding custody now. The sweep tx
+// spends at most MAX_SWEEP_INPUTS utxos and is signed by old signers through MultiSign as usual,
+// so it's called repeatedly until no utxo left, deposits to the old redeem are swept the same way.
+func (this *BTCHandler) SweepUtxos(service *native.NativeService) error {
+	params := new(crosscommon.SweepBtcUtxosParam)
+	if err := params.Deserialization(common.NewZeroCopySource(service.GetInput())); err != nil {
+		return fmt.Errorf("SweepUtxos, contract params deserialize error: %v", err)
+	}
+	activeKey, err := getActiveRedeem(service, params.ChainID, params.RedeemKey)
+	if err != nil {
+		return fmt.Errorf("SweepUtxos, %v", err)
+	}
+	if activeKey == params.RedeemKey {
+		return fmt.Errorf("SweepUtxos, redeem %s is not rotated", params.RedeemKey)
+	}
+	redeemScript, err := side_chain_manager.GetBtcRedeemScriptBytes(service, params.RedeemKey, params.ChainID)
+	if err != nil {
+		return fmt.Errorf("SweepUtxos, get btc redeem script with redeem key %v from db error: %v", params.RedeemKey, err)
+	}
+	netParam, err := getNetParam(service, params.ChainID)
+	if err != nil {
+		return fmt.Errorf("SweepUtxos, %v", err)
+	}
+	_, addrs, m, err := txscript.ExtractPkScriptAddrs(redeemScript, netParam)
+	if err != nil {
+		return fmt.Errorf("SweepUtxos, failed to extract pkscript addrs: %v", err)
+	}
+	successorScript, err := getRedeemLockScript(service, params.ChainID, activeKey, netParam)
+	if err != nil {
+		return fmt.Errorf("SweepUtxos, %v", err)
+	}
+	rk := btcutil.Hash160(redeemScript)
+	detail, err := side_chain_manager.GetBtcTxParam(service, rk, params.ChainID)
+	if err != nil {
+		return fmt.Errorf("SweepUtxos, failed to get btcTxParam: %v", err)
+	}
+	if detail == nil {
+		return fmt.Errorf("SweepUtxos, no btcTxParam is set for redeem key %s", params.RedeemKey)
+	}
+
+	utxos, err := getUtxos(service, params.ChainID, params.RedeemKey)
+	if err != nil {
+		return fmt.Errorf("SweepUtxos, getUtxos error: %v", err)
+	}
+	if len(utxos.Utxos) == 0 {
+		return fmt.Errorf("SweepUtxos, no utxo of redeem %s to sweep", params.RedeemKey)
+	}
+	sort.Sort(sort.Reverse(utxos))
+	num := len(utxos.Utxos)
+	if num > MAX_SWEEP_INPUTS {
+		num = MAX_SWEEP_INPUTS
+	}
+	swept := &Utxos{Utxos: utxos.Utxos[:num]}
+	utxos.Utxos = utxos.Utxos[num:]
+
+	cs := &CoinSelector{
+		feeRate: detail.FeeRate,
+		m:       m,
+		n:       len(addrs),
+		rk:      rk,
+	}
+	txHash, rawTx, amts, err := putMergeTx(service, params.ChainID, params.RedeemKey, swept, utxos, successorScript, cs,
+		detail.MinChange, btc.FamilyOf(netParam).ReplaceByFee)
+	if err != nil {
+		return fmt.Errorf("SweepUtxos, %v", err)
+	}
+	putBtcSweepTo(service, txHash, activeKey)
+
+	service.AddNotify(
+		&event.NotifyEventInfo{
+			ContractAddress: utils.CrossChainManagerContractAddress,
+			States:          []interface{}{"sweepBtcUtxos", params.RedeemKey, activeKey, hex.EncodeToString(txHash)},
+		})
+	service.AddNotify(
+		&event.NotifyEventInfo{
+			ContractAddress: utils.CrossChainManagerContractAddress,
+			States:          []interface{}{"makeBtcTx", params.RedeemKey, hex.EncodeToString(rawTx), amts},
+		})
+	return nil
+}
+
+// ConsolidateUtxos merges utxos of the active redeem worth no more than a threshold into one output
+// back to the redeem. It's meant to run when fees are low, so the rate must not exceed the one of
+// withdrawals, and the tx is signed through MultiSign like any other.
+func (this *BTCHandler) ConsolidateUtxos(service *native.NativeService) error {
+	params := new(crosscommon.ConsolidateBtcUtxosParam)
+	if err := params.Deserialization(common.NewZeroCopySource(service.GetInput())); err != nil {
+		return fmt.Errorf("ConsolidateUtxos, contract params deserialize error: %v", err)
+	}
+	activeKey, err := getActiveRedeem(service, params.ChainID, params.RedeemKey)
+	if err != nil {
+		return fmt.Errorf("ConsolidateUtxos, %v", err)
+	}
+	if activeKey != params.RedeemKey {
+		return fmt.Errorf("ConsolidateUtxos, redeem %s is rotated to %s, sweep it instead", params.RedeemKey, activeKey)
+	}
+	redeemScript, err := side_chain_manager.GetBtcRedeemScriptBytes(service, params.RedeemKey, params.ChainID)
+	if err != nil {
+		return fmt.Errorf("ConsolidateUtxos, get btc redeem script with redeem key %v from db error: %v", params.RedeemKey, err)
+	}
+	netParam, err := getNetParam(service, params.ChainID)
+	if err != nil {
+		return fmt.Errorf("ConsolidateUtxos, %v", err)
+	}
+	_, addrs, m, err := txscript.ExtractPkScriptAddrs(redeemScript, netParam)
+	if err != nil {
+		return fmt.Errorf("ConsolidateUtxos, failed to extract pkscript addrs: %v", err)
+	}
+	lockScript, err := getLockScript(redeemScript, netParam)
+	if err != nil {
+		return fmt.Errorf("ConsolidateUtxos, failed to get lock script: %v", err)
+	}
+	rk := btcutil.Hash160(redeemScript)
+	detail, err := side_chain_manager.GetBtcTxParam(service, rk, params.ChainID)
+	if err != nil {
+		return fmt.Errorf("ConsolidateUtxos, failed to get btcTxParam: %v", err)
+	}
+	if detail == nil {
+		return fmt.Errorf("ConsolidateUtxos, no btcTxParam is set for redeem key %s", params.RedeemKey)
+	}
+	if params.FeeRate == 0 || params.FeeRate > detail.FeeRate {
+		return fmt.Errorf("ConsolidateUtxos, fee rate %d should be positive and not higher than %d", params.FeeRate,
+			detail.FeeRate)
+	}
+
+	utxos, err := getUtxos(service, params.ChainID, params.RedeemKey)
+	if err != nil {
+		return fmt.Errorf("ConsolidateUtxos, getUtxos error: %v", err)
+	}
+	// smallest first so the dust goes before anything else
+	sort.Sort(utxos)
+	num := 0
+	for num < len(utxos.Utxos) && num < MAX_SWEEP_INPUTS && utxos.Utxos[num].Value <= params.MaxValue {
+		num++
+	}
+	if num < 2 {
+		return fmt.Errorf("ConsolidateUtxos, only %d utxo worth no more than %d", num, params.MaxValue)
+	}
+	merged := &Utxos{Utxos: utxos.Utxos[:num]}
+	utxos.Utxos = utxos.Utxos[num:]
+
+	cs := &CoinSelector{
+		feeRate: params.FeeRate,
+		m:       m,
+		n:       len(addrs),
+		rk:      rk,
+	}
+	txHash, rawTx, amts, err := putMergeTx(service, params.ChainID, params.RedeemKey, merged, utxos, lockScript, cs,
+		detail.MinChange, btc.FamilyOf(netParam).ReplaceByFee)
+	if err != nil {
+		return fmt.Errorf("ConsolidateUtxos, %v", err)
+	}
+
+	service.AddNotify(
+		&event.NotifyEventInfo{
+			ContractAddress: utils.CrossChainManagerContractAddress,
+			States:          []interface{}{"consolidateBtcUtxos", params.RedeemKey, hex.EncodeToString(txHash), num},
+		})
+	service.AddNotify(
+		&event.NotifyEventInfo{
+			ContractAddress: utils.CrossChainManagerContractAddress,
+			States:          []interface{}{"makeBtcTx", params.RedeemKey, hex.EncodeToString(rawTx), amts},
+		})
+	return nil
+}
+
+// putMergeTx makes and stores the tx spending all of merged to one output paying lockScript, merged
+// are taken as stxos and left are kept as utxos of redeemKey
+func putMergeTx(service *native.NativeService, chainID uint64, redeemKey string, merged, left *Utxos, lockScript []byte,
+	cs *CoinSelector, minChange uint64, replaceable bool) ([]byte, []byte, []uint64, error) {
+	out := wire.NewTxOut(0, lockScript)
+	cs.txOuts = []*wire.TxOut{out}
+	var sum int64
+	amts := make([]uint64, len(merged.Utxos))
+	txIns := make([]*wire.TxIn, len(merged.Utxos))
+	for i, u := range merged.Utxos {
+		hash, err := chainhash.NewHash(u.Op.Hash)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("putMergeTx, chainhash.NewHash error: %v", err)
+		}
+		txIns[i] = wire.NewTxIn(wire.NewOutPoint(hash, u.Op.Index), u.ScriptPubkey, nil)
+		amts[i] = u.Value
+		sum += int64(u.Value)
+	}
+	fee := int64(cs.estimateTxFee(merged.Utxos))
+	if sum-fee < int64(minChange) {
+		return nil, nil, nil, fmt.Errorf("putMergeTx, sum %d of utxos can't afford the fee %d", sum, fee)
+	}
+	out.Value = sum - fee
+	mtx, err := getUnsignedTx(txIns, nil, out, nil, replaceable)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("putMergeTx, get rawtransaction fail: %v", err)
+	}
+
+	putUtxos(service, chainID, redeemKey, left)
+	stxos, err := getStxos(service, chainID, redeemKey)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("putMergeTx, getStxos error: %v", err)
+	}
+	stxos.Utxos = append(stxos.Utxos, merged.Utxos...)
+	putStxos(service, chainID, redeemKey, stxos)
+
+	var buf bytes.Buffer
+	err = mtx.BtcEncode(&buf, wire.ProtocolVersion, wire.LatestEncoding)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("putMergeTx, serialize rawtransaction fail: %v", err)
+	}
+	txHash := mtx.TxHash()
+	putBtcTx(service, txHash[:], buf.Bytes())
+	// no cross chain tx behind, it's the source of itself
+	btcFromInfo := &BtcFromInfo{
+		FromTxHash:  txHash[:],
+		FromChainID: chainID,
+	}
+	if err = putBtcFromInfo(service, txHash[:], btcFromInfo); err != nil {
+		return nil, nil, nil, fmt.Errorf("putMergeTx, putBtcFromInfo failed: %v", err)
+	}
+	putBtcTxInputs(service, txHash[:], merged)
+	return txHash[:], buf.Bytes(), amts, nil
+}
+
 func (this *BTCHandler) MakeTransaction(service *native.NativeService, param *crosscommon.MakeTxParam,
 	fromChainID uint64) error {
 	amounts := make(map[string]int64)
@@ -229,6 +845,18 @@ func (this *BTCHandler) MakeTransaction(service *native.NativeService, param *cr
 		return fmt.Errorf("btc MakeTransaction, your contract %s is not match with %s registered",
 			hex.EncodeToString(param.FromContractAddress), hex.EncodeToString(contractBind.Contract))
 	}
+	// withdraw from the successor if the redeem is rotated
+	activeKey, err := getActiveRedeem(service, param.ToChainID, hex.EncodeToString(redeemKey))
+	if err != nil {
+		return fmt.Errorf("btc MakeTransaction, %v", err)
+	}
+	if activeKey != hex.EncodeToString(redeemKey) {
+		redeemScriptBytes, err = side_chain_manager.GetBtcRedeemScriptBytes(service, activeKey, param.ToChainID)
+		if err != nil {
+			return fmt.Errorf("btc MakeTransaction, get successor redeem script %s error: %v", activeKey, err)
+		}
+		redeemKey = btcutil.Hash160(redeemScriptBytes)
+	}
 	err = makeBtcTx(service, param.ToChainID, amounts, param.TxHash, fromChainID, redeemScriptBytes, redeemKey)
 	if err != nil {
 		return fmt.Errorf("btc MakeTransaction, failed to make transaction: %v", err)
@@ -285,7 +913,7 @@ func makeBtcTx(service *native.NativeService, chainID uint64, amounts map[string
 		outs[i].Value = outs[i].Value - int64(float64(gasFee)/float64(amountSum)*float64(outs[i].Value))
 	}
 	out.Value = sum - amountSum
-	mtx, err := getUnsignedTx(txIns, outs, out, nil)
+	mtx, err := getUnsignedTx(txIns, outs, out, nil, btc.FamilyOf(netParam).ReplaceByFee)
 	if err != nil {
 		return fmt.Errorf("makeBtcTx, get rawtransaction fail: %v", err)
 	}
@@ -296,8 +924,7 @@ func makeBtcTx(service *native.NativeService, chainID uint64, amounts map[string
 		return fmt.Errorf("makeBtcTx, serialize rawtransaction fail: %v", err)
 	}
 	txHash := mtx.TxHash()
-	service.GetCacheDB().Put(utils.ConcatKey(utils.CrossChainManagerContractAddress, []byte(BTC_TX_PREFIX),
-		txHash[:]), buf.Bytes())
+	putBtcTx(service, txHash[:], buf.Bytes())
 
 	btcFromInfo := &BtcFromInfo{
 		FromTxHash:  fromTxHash,
@@ -306,6 +933,7 @@ func makeBtcTx(service *native.NativeService, chainID uint64, amounts map[string
 	if err = putBtcFromInfo(service, txHash[:], btcFromInfo); err != nil {
 		return fmt.Errorf("makeBtcTx, putBtcFromInfo failed: %v", err)
 	}
+	putBtcTxInputs(service, txHash[:], &Utxos{Utxos: choosed})
 	service.AddNotify(
 		&event.NotifyEventInfo{
 			ContractAddress: utils.CrossChainManagerContractAddress,
@@ -21,9 +21,14 @@ import (
 	"bytes"
 	"encoding/binary"
 	"encoding/hex"
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
+	chainhash_bch "github.com/gcash/bchd/chaincfg/chainhash"
+	wire_bch "github.com/gcash/bchd/wire"
 	"github.com/polynetwork/poly/account"
 	"github.com/polynetwork/poly/common"
 	"github.com/polynetwork/poly/core/states"
@@ -56,22 +61,6 @@ var (
 	toEthAddr         = "0x5cD3143f91a13Fe971043E1e4605C1c23b46bF44"
 	ebtcxAddr         = "0x9702640a6b971CA18EFC20AD73CA4e8bA390C910"
 
-	sigs = []string{
-		"3045022100f77b28268bfed3c0ddc8d35e556164d7ce2f571715b5afbf79373e699f4023e102200f86442f305528088caca96453d7f4fd782c831198c51e7637a7024ca844e10d01",
-		"30450221009a320292de5b0881f2988b603d7dac98c666c9653c177e1add6e26b2ae7902860220211d58d5e261e9ce80fa1b12892f02eb1a10c04712fe1609464629a794f5eaa001",
-		"30440220269ba3ab6e8d2513bb908e13c73b89fad28743136e56434bce7f9467c92a70cc02207149b2ae0b8648d5048d2505464582972f12522b36f1517af16af5408c0ed01a01",
-		"3044022046e9fa4e00ace70f5f1136a97257144cb83947d2685cfb6167fb3d61e563896802202f085fd5a202b1ccd471b4341effb788e36a5beed9b1bed4865e2c9a8a5f86e501",
-		"3045022100b457c88a42276924eafbb07e48f642410a07d72516739ab077386088e31ed6dc0220679a39f6aef46d980d031a0df301d74ee750d82ac2c8e0951d2ab1d8444346ec01",
-	}
-
-	signers = []string{
-		"mxtJn3aRsKLrWRLLAhu2nCBuK2brfazedj",
-		"msu1qgtn4FsQh7xDP15ggStwW4yHquUTYE",
-		"mzr5T4PmqzNtusmM2S889LUySC9BBbwRzd",
-		"mmDSSyis1sjysaCKZ9eK1ww92Vr9S1CNEX",
-		"n1WmUbJ4dQfvzvtRaNcjmxH6nADhhv7W8c",
-	}
-
 	getNativeFunc = func(args []byte, db *storage.CacheDB) *native.NativeService {
 		if db == nil {
 			store, _ := leveldbstore.NewMemLevelDBStore()
@@ -82,29 +71,6 @@ var (
 		return service
 	}
 
-	setSideChain = func(ns *native.NativeService) {
-		side := &side_chain_manager.SideChain{
-			Name:         "btc",
-			ChainId:      1,
-			BlocksToWait: 1,
-			Router:       0,
-		}
-		sink := common.NewZeroCopySink(nil)
-		_ = side.Serialization(sink)
-
-		ns.GetCacheDB().Put(utils.ConcatKey(utils.SideChainManagerContractAddress,
-			[]byte(side_chain_manager.SIDE_CHAIN), utils.GetUint64Bytes(1)), states.GenRawStorageItem(sink.Bytes()))
-	}
-
-	getSigs = func() [][]byte {
-		res := make([][]byte, len(sigs))
-		for i, sig := range sigs {
-			raw, _ := hex.DecodeString(sig)
-			res[i] = raw
-		}
-		return res
-	}
-
 	registerRC = func(db *storage.CacheDB) *storage.CacheDB {
 		ca, _ := hex.DecodeString(strings.Replace(ebtcxAddr, "0x", "", 1))
 		cb := &side_chain_manager.ContractBinded{
@@ -122,6 +88,22 @@ var (
 		return db
 	}
 
+	setTestnetSideChain = func(ns *native.NativeService) {
+		netType := make([]byte, 8)
+		binary.LittleEndian.PutUint64(netType, uint64(utils.TyTestnet3))
+		side := &side_chain_manager.SideChain{
+			Name:         "btc",
+			ChainId:      1,
+			BlocksToWait: 1,
+			Router:       utils.BTC_ROUTER,
+			CCMCAddress:  netType,
+		}
+		sink := common.NewZeroCopySink(nil)
+		_ = side.Serialization(sink)
+		ns.GetCacheDB().Put(utils.ConcatKey(utils.SideChainManagerContractAddress,
+			[]byte(side_chain_manager.SIDE_CHAIN), utils.GetUint64Bytes(1)), states.GenRawStorageItem(sink.Bytes()))
+	}
+
 	setBtcTxParam = func(db *storage.CacheDB, redeemK string) *storage.CacheDB {
 		detail := &side_chain_manager.BtcTxParamDetial{
 			FeeRate:   2,
@@ -137,6 +119,9 @@ var (
 )
 
 func TestBTCHandler_MakeDepositProposal(t *testing.T) {
+	ccmc := make([]byte, 8)
+	binary.LittleEndian.PutUint64(ccmc, uint64(utils.TyTestnet3))
+	netParam, _ := btc.GetNetParam(utils.BTC_ROUTER, ccmc)
 	gh := netParam.GenesisBlock.Header
 	mr, _ := chainhash.NewHashFromStr("502e1d655973488e2394b56865f46cf204e5e2fdd0ea5873c51c65a3125ab3dd")
 	gh.MerkleRoot = *mr
@@ -164,7 +149,7 @@ func TestBTCHandler_MakeDepositProposal(t *testing.T) {
 	sink := common.NewZeroCopySink(nil)
 	params.Serialization(sink)
 	ns := getNativeFunc(sink.Bytes(), db)
-	setSideChain(ns)
+	setTestnetSideChain(ns)
 	p, err := handler.MakeDepositProposal(ns)
 	assert.Error(t, err)
 
@@ -212,7 +197,7 @@ func TestBTCHandler_MakeTransaction(t *testing.T) {
 	_ = mtx.BtcDecode(bytes.NewBuffer(rawTx), wire.ProtocolVersion, wire.LatestEncoding)
 	ns := getNativeFunc(nil, nil)
 	_ = addUtxos(ns, 1, 0, mtx)
-	setSideChain(ns)
+	setTestnetSideChain(ns)
 	registerRC(ns.GetCacheDB())
 	setBtcTxParam(ns.GetCacheDB(), utxoKey)
 
@@ -242,33 +227,57 @@ func TestBTCHandler_MakeTransaction(t *testing.T) {
 }
 
 func TestBTCHandler_MultiSign(t *testing.T) {
-	rawTx, _ := hex.DecodeString(fromBtcRawTx)
-	mtx := wire.NewMsgTx(wire.TxVersion)
-	_ = mtx.BtcDecode(bytes.NewBuffer(rawTx), wire.ProtocolVersion, wire.LatestEncoding)
+	keys := make([]*btcec.PrivateKey, 7)
+	signers := make([]string, len(keys))
+	builder := txscript.NewScriptBuilder().AddOp(txscript.OP_5)
+	for i := range keys {
+		keys[i], _ = btcec.NewPrivateKey(btcec.S256())
+		pk, _ := btcutil.NewAddressPubKey(keys[i].PubKey().SerializeCompressed(), &chaincfg.TestNet3Params)
+		signers[i] = pk.EncodeAddress()
+		builder.AddData(keys[i].PubKey().SerializeCompressed())
+	}
+	rb, _ := builder.AddOp(txscript.OP_7).AddOp(txscript.OP_CHECKMULTISIG).Script()
+	rk := hex.EncodeToString(btcutil.Hash160(rb))
+	lockScript, _ := getLockScript(rb, &chaincfg.TestNet3Params)
+	prevHash, _ := chainhash.NewHashFromStr(fromBtcTxid)
+
 	ns := getNativeFunc(nil, nil)
-	_ = addUtxos(ns, 1, 0, mtx)
-	setBtcTxParam(ns.GetCacheDB(), utxoKey)
-	registerRC(ns.GetCacheDB())
+	setBtcTxParam(ns.GetCacheDB(), rk)
+	setTestnetSideChain(ns)
+	ns.GetCacheDB().Put(utils.ConcatKey(utils.SideChainManagerContractAddress, []byte(side_chain_manager.REDEEM_SCRIPT),
+		utils.GetUint64Bytes(1), []byte(rk)), states.GenRawStorageItem(rb))
+	putUtxos(ns, 1, rk, &Utxos{
+		Utxos: []*Utxo{{
+			Op:           &OutPoint{Hash: prevHash[:], Index: 0},
+			Value:        10000,
+			ScriptPubkey: lockScript,
+		}},
+	})
 
-	rb, _ := hex.DecodeString(rdm)
 	err := makeBtcTx(ns, 1, map[string]int64{"mjEoyyCPsLzJ23xMX6Mti13zMyN36kzn57": 6000}, []byte{123},
 		2, rb, btcutil.Hash160(rb))
 	assert.NoError(t, err)
 	stateArr := ns.GetNotify()[0].States.([]interface{})
 	assert.Equal(t, "makeBtcTx", stateArr[0].(string))
-	assert.Equal(t, utxoKey, stateArr[1].(string))
+	assert.Equal(t, rk, stateArr[1].(string))
 
-	stxos, err := getStxos(ns, 1, utxoKey)
+	stxos, err := getStxos(ns, 1, rk)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(stxos.Utxos))
 	assert.Equal(t, uint64(10000), stxos.Utxos[0].Value)
 	assert.Equal(t, fromBtcTxid+":0", stxos.Utxos[0].Op.String())
 
-	rawTx, _ = hex.DecodeString(stateArr[2].(string))
+	rawTx, _ := hex.DecodeString(stateArr[2].(string))
+	mtx := wire.NewMsgTx(wire.TxVersion)
 	_ = mtx.BtcDecode(bytes.NewBuffer(rawTx), wire.ProtocolVersion, wire.LatestEncoding)
 	assert.Equal(t, int64(4000), mtx.TxOut[1].Value)
 	handler := NewBTCHandler()
-	sigArr := getSigs()
+	hashes := txscript.NewTxSigHashes(mtx)
+	sigArr := make([][]byte, len(keys))
+	for i, key := range keys {
+		sigArr[i], err = txscript.RawTxInWitnessSignature(mtx, hashes, 0, 10000, rb, txscript.SigHashAll, key)
+		assert.NoError(t, err)
+	}
 	txid := mtx.TxHash()
 	// commit no.1 to 4 sig
 	for i, sig := range sigArr[:4] {
@@ -276,7 +285,7 @@ func TestBTCHandler_MultiSign(t *testing.T) {
 			ChainID:   1,
 			TxHash:    txid.CloneBytes(),
 			Address:   signers[i],
-			RedeemKey: utxoKey,
+			RedeemKey: rk,
 			Signs:     [][]byte{sig},
 		}
 		sink := common.NewZeroCopySink(nil)
@@ -292,7 +301,7 @@ func TestBTCHandler_MultiSign(t *testing.T) {
 		ChainID:   1,
 		TxHash:    txid.CloneBytes(),
 		Address:   signers[3],
-		RedeemKey: utxoKey,
+		RedeemKey: rk,
 		Signs:     [][]byte{sigArr[3]},
 	}
 	sink := common.NewZeroCopySink(nil)
@@ -306,7 +315,7 @@ func TestBTCHandler_MultiSign(t *testing.T) {
 		ChainID:   1,
 		TxHash:    txid.CloneBytes(),
 		Address:   signers[3],
-		RedeemKey: utxoKey,
+		RedeemKey: rk,
 		Signs:     [][]byte{sigArr[4]},
 	}
 	sink.Reset()
@@ -320,7 +329,7 @@ func TestBTCHandler_MultiSign(t *testing.T) {
 		ChainID:   1,
 		TxHash:    txid.CloneBytes(),
 		Address:   signers[4],
-		RedeemKey: utxoKey,
+		RedeemKey: rk,
 		Signs:     [][]byte{sigArr[4]},
 	}
 	sink.Reset()
@@ -334,16 +343,562 @@ func TestBTCHandler_MultiSign(t *testing.T) {
 
 	rawTx, err = hex.DecodeString(stateArr[3].(string))
 	assert.NoError(t, err)
-	err = mtx.BtcDecode(bytes.NewBuffer(rawTx), wire.ProtocolVersion, wire.LatestEncoding)
+	signed := wire.NewMsgTx(wire.TxVersion)
+	err = signed.BtcDecode(bytes.NewBuffer(rawTx), wire.ProtocolVersion, wire.LatestEncoding)
 	assert.NoError(t, err)
-	txid = mtx.TxHash()
-	utxos, err = getUtxos(ns, 1, utxoKey)
+	vm, err := txscript.NewEngine(lockScript, signed, 0, txscript.StandardVerifyFlags, nil, nil, 10000)
+	assert.NoError(t, err)
+	assert.NoError(t, vm.Execute())
+	txid = signed.TxHash()
+	utxos, err := getUtxos(ns, 1, rk)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(utxos.Utxos))
 	assert.Equal(t, uint64(4000), utxos.Utxos[0].Value)
 	assert.Equal(t, txid.String()+":1", utxos.Utxos[0].Op.String())
 }
 
+func TestBTCHandler_BumpTransaction(t *testing.T) {
+	rawTx, _ := hex.DecodeString(fromBtcRawTx)
+	mtx := wire.NewMsgTx(wire.TxVersion)
+	_ = mtx.BtcDecode(bytes.NewBuffer(rawTx), wire.ProtocolVersion, wire.LatestEncoding)
+	ns := getNativeFunc(nil, nil)
+	_ = addUtxos(ns, 1, 0, mtx)
+	setBtcTxParam(ns.GetCacheDB(), utxoKey)
+	registerRC(ns.GetCacheDB())
+	setTestnetSideChain(ns)
+
+	rb, _ := hex.DecodeString(rdm)
+	err := makeBtcTx(ns, 1, map[string]int64{"mjEoyyCPsLzJ23xMX6Mti13zMyN36kzn57": 6000}, []byte{123},
+		2, rb, btcutil.Hash160(rb))
+	assert.NoError(t, err)
+	rawTx, _ = hex.DecodeString(ns.GetNotify()[0].States.([]interface{})[2].(string))
+	_ = mtx.BtcDecode(bytes.NewBuffer(rawTx), wire.ProtocolVersion, wire.LatestEncoding)
+	oldTxid := mtx.TxHash()
+	assert.Equal(t, 2, len(mtx.TxOut))
+	oldVal, oldChange := mtx.TxOut[0].Value, mtx.TxOut[1].Value
+
+	handler := NewBTCHandler()
+	param := &ccmcom.BumpBtcTxParam{
+		ChainID:   1,
+		RedeemKey: utxoKey,
+		TxHash:    oldTxid.CloneBytes(),
+		FeeRate:   1,
+	}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	ns = getNativeFunc(sink.Bytes(), ns.GetCacheDB())
+	err = handler.BumpTransaction(ns)
+	assert.Error(t, err)
+
+	// the change can't pay the extra fee
+	param.FeeRate = 100000
+	sink = common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	ns = getNativeFunc(sink.Bytes(), ns.GetCacheDB())
+	err = handler.BumpTransaction(ns)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "can't afford")
+
+	param.FeeRate = 4
+	sink = common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	ns = getNativeFunc(sink.Bytes(), ns.GetCacheDB())
+	err = handler.BumpTransaction(ns)
+	assert.NoError(t, err)
+	stateArr := ns.GetNotify()[0].States.([]interface{})
+	assert.Equal(t, "bumpBtcTx", stateArr[0].(string))
+	assert.Equal(t, hex.EncodeToString(oldTxid[:]), stateArr[1].(string))
+	stateArr = ns.GetNotify()[1].States.([]interface{})
+	assert.Equal(t, "makeBtcTx", stateArr[0].(string))
+	rawTx, _ = hex.DecodeString(stateArr[2].(string))
+	newMtx := wire.NewMsgTx(wire.TxVersion)
+	_ = newMtx.BtcDecode(bytes.NewBuffer(rawTx), wire.ProtocolVersion, wire.LatestEncoding)
+	assert.Equal(t, oldVal, newMtx.TxOut[0].Value)
+	assert.True(t, newMtx.TxOut[1].Value < oldChange)
+	assert.Equal(t, mtx.TxIn[0].PreviousOutPoint, newMtx.TxIn[0].PreviousOutPoint)
+	assert.True(t, signalsReplaceability(newMtx))
+
+	// the replaced tx is gone and its inputs are still locked for the new one
+	_, err = getBtcFromInfo(ns, oldTxid[:])
+	assert.Error(t, err)
+	stxos, err := getStxos(ns, 1, utxoKey)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(stxos.Utxos))
+
+	// a tx made before inputs were recorded finds them in stxos while unsigned
+	newTxid := newMtx.TxHash()
+	ns.GetCacheDB().Delete(utils.ConcatKey(utils.CrossChainManagerContractAddress, []byte(BTC_TX_INPUTS), newTxid[:]))
+	param.TxHash = newTxid.CloneBytes()
+	param.FeeRate = 6
+	sink = common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	ns = getNativeFunc(sink.Bytes(), ns.GetCacheDB())
+	assert.NoError(t, handler.BumpTransaction(ns))
+}
+
+// newTestRedeem makes a m-of-n multisig redeem of new keys and registers it on chain 1
+func TestBTCHandler_ReleaseBumpedTransaction(t *testing.T) {
+	ns := getNativeFunc(nil, nil)
+	setTestnetSideChain(ns)
+	keys, rs, rk := newTestRedeem(ns, 3, 2)
+	setBtcTxParam(ns.GetCacheDB(), rk)
+	lockScript, _ := getLockScript(rs, &chaincfg.TestNet3Params)
+	old := make([]*Utxo, 2)
+	for i := range old {
+		old[i] = &Utxo{
+			Op:           &OutPoint{Hash: bytes.Repeat([]byte{byte(i + 1)}, 32), Index: 0},
+			Value:        uint64(30000 * (i + 1)),
+			ScriptPubkey: lockScript,
+		}
+	}
+	putUtxos(ns, 1, rk, &Utxos{Utxos: old})
+
+	handler := NewBTCHandler()
+	consolidate := &ccmcom.ConsolidateBtcUtxosParam{
+		ChainID:   1,
+		RedeemKey: rk,
+		MaxValue:  100000,
+		FeeRate:   1,
+	}
+	sink := common.NewZeroCopySink(nil)
+	consolidate.Serialization(sink)
+	ns = getNativeFunc(sink.Bytes(), ns.GetCacheDB())
+	assert.NoError(t, handler.ConsolidateUtxos(ns))
+	rawTx, _ := hex.DecodeString(ns.GetNotify()[1].States.([]interface{})[2].(string))
+	mtx := wire.NewMsgTx(wire.TxVersion)
+	_ = mtx.BtcDecode(bytes.NewBuffer(rawTx), wire.ProtocolVersion, wire.LatestEncoding)
+	oldTxid := mtx.TxHash()
+	amts := make([]int64, len(mtx.TxIn))
+	for i, in := range mtx.TxIn {
+		amts[i] = int64(old[in.PreviousOutPoint.Hash[0]-1].Value)
+	}
+	ns = signTestTx(t, ns, keys[:2], rs, rk, mtx, amts)
+	rawTx, _ = hex.DecodeString(ns.GetNotify()[0].States.([]interface{})[3].(string))
+	signed := wire.NewMsgTx(wire.TxVersion)
+	_ = signed.BtcDecode(bytes.NewBuffer(rawTx), wire.ProtocolVersion, wire.LatestEncoding)
+
+	// the signed tx is bumped and recorded as replaced by the new one
+	bump := &ccmcom.BumpBtcTxParam{
+		ChainID:   1,
+		RedeemKey: rk,
+		TxHash:    oldTxid.CloneBytes(),
+		FeeRate:   3,
+	}
+	sink = common.NewZeroCopySink(nil)
+	bump.Serialization(sink)
+	ns = getNativeFunc(sink.Bytes(), ns.GetCacheDB())
+	assert.NoError(t, handler.BumpTransaction(ns))
+	rawTx, _ = hex.DecodeString(ns.GetNotify()[1].States.([]interface{})[2].(string))
+	bumped := wire.NewMsgTx(wire.TxVersion)
+	_ = bumped.BtcDecode(bytes.NewBuffer(rawTx), wire.ProtocolVersion, wire.LatestEncoding)
+	bumpedTxid := bumped.TxHash()
+	replaced, err := getBtcReplacedTxs(ns, bumpedTxid[:])
+	assert.NoError(t, err)
+	signedTxid := signed.TxHash()
+	assert.Equal(t, [][]byte{signedTxid[:]}, replaced)
+	stxos, err := getStxos(ns, 1, rk)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(stxos.Utxos))
+
+	// the replaced tx is confirmed in the genesis block after all
+	gh := chaincfg.TestNet3Params.GenesisBlock.Header
+	gh.MerkleRoot = signed.TxHash()
+	var buf bytes.Buffer
+	_ = gh.BtcEncode(&buf, wire.ProtocolVersion, wire.LatestEncoding)
+	genesis := &hscom.SyncGenesisHeaderParam{
+		ChainID:       1,
+		GenesisHeader: append(buf.Bytes(), 0, 0, 0, 0),
+	}
+	sink = common.NewZeroCopySink(nil)
+	genesis.Serialization(sink)
+	assert.NoError(t, btc.NewBTCHandler().SyncGenesisHeader(getNativeFunc(sink.Bytes(), ns.GetCacheDB())))
+	hash, _ := chainhash_bch.NewHash(signedTxid[:])
+	merkleBlock := &wire_bch.MsgMerkleBlock{
+		Transactions: 1,
+		Hashes:       []*chainhash_bch.Hash{hash},
+		Flags:        []byte{1},
+	}
+	buf.Reset()
+	_ = merkleBlock.BchEncode(&buf, wire_bch.ProtocolVersion, wire_bch.LatestEncoding)
+	release := func(tx *wire.MsgTx) error {
+		var raw bytes.Buffer
+		_ = tx.BtcEncode(&raw, wire.ProtocolVersion, wire.LatestEncoding)
+		param := &ccmcom.ReleaseBumpedBtcTxParam{
+			ChainID:   1,
+			RedeemKey: rk,
+			TxHash:    bumpedTxid.CloneBytes(),
+			Tx:        raw.Bytes(),
+			Proof:     buf.Bytes(),
+		}
+		sink := common.NewZeroCopySink(nil)
+		param.Serialization(sink)
+		ns = getNativeFunc(sink.Bytes(), ns.GetCacheDB())
+		return handler.ReleaseBumpedTransaction(ns)
+	}
+	assert.Error(t, release(bumped))
+
+	// inputs locked for the bumped tx are spent and the change of the confirmed tx is back
+	assert.NoError(t, release(signed))
+	stateArr := ns.GetNotify()[0].States.([]interface{})
+	assert.Equal(t, "releaseBumpedBtcTx", stateArr[0].(string))
+	assert.Equal(t, hex.EncodeToString(signedTxid[:]), stateArr[2].(string))
+	stxos, err = getStxos(ns, 1, rk)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(stxos.Utxos))
+	utxos, err := getUtxos(ns, 1, rk)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(utxos.Utxos))
+	assert.Equal(t, signedTxid.String()+":0", utxos.Utxos[0].Op.String())
+	assert.Equal(t, uint64(signed.TxOut[0].Value), utxos.Utxos[0].Value)
+	_, err = getBtcTx(ns, bumpedTxid[:])
+	assert.Error(t, err)
+}
+
+func newTestRedeem(ns *native.NativeService, n, m int) ([]*btcec.PrivateKey, []byte, string) {
+	keys := make([]*btcec.PrivateKey, n)
+	addrs := make([]*btcutil.AddressPubKey, n)
+	for i := range keys {
+		keys[i], _ = btcec.NewPrivateKey(btcec.S256())
+		addrs[i], _ = btcutil.NewAddressPubKey(keys[i].PubKey().SerializeCompressed(), &chaincfg.TestNet3Params)
+	}
+	rs, _ := txscript.MultiSigScript(addrs, m)
+	rk := hex.EncodeToString(btcutil.Hash160(rs))
+	ns.GetCacheDB().Put(utils.ConcatKey(utils.SideChainManagerContractAddress, []byte(side_chain_manager.REDEEM_SCRIPT),
+		utils.GetUint64Bytes(1), []byte(rk)), states.GenRawStorageItem(rs))
+	return keys, rs, rk
+}
+
+// signTestTx lets each of keys commit its witness signatures of the tx by MultiSign
+func signTestTx(t *testing.T, ns *native.NativeService, keys []*btcec.PrivateKey, rs []byte, rk string,
+	mtx *wire.MsgTx, amts []int64) *native.NativeService {
+	hashes := txscript.NewTxSigHashes(mtx)
+	txid := mtx.TxHash()
+	for _, key := range keys {
+		sigs := make([][]byte, len(mtx.TxIn))
+		for i := range mtx.TxIn {
+			sig, err := txscript.RawTxInWitnessSignature(mtx, hashes, i, amts[i], rs, txscript.SigHashAll, key)
+			assert.NoError(t, err)
+			sigs[i] = sig
+		}
+		addr, _ := btcutil.NewAddressPubKey(key.PubKey().SerializeCompressed(), &chaincfg.TestNet3Params)
+		msp := ccmcom.MultiSignParam{
+			ChainID:   1,
+			TxHash:    txid.CloneBytes(),
+			Address:   addr.EncodeAddress(),
+			RedeemKey: rk,
+			Signs:     sigs,
+		}
+		sink := common.NewZeroCopySink(nil)
+		msp.Serialization(sink)
+		ns = getNativeFunc(sink.Bytes(), ns.GetCacheDB())
+		assert.NoError(t, NewBTCHandler().MultiSign(ns))
+	}
+	return ns
+}
+
+func TestBTCHandler_SweepUtxos(t *testing.T) {
+	ns := getNativeFunc(nil, nil)
+	setTestnetSideChain(ns)
+	keys, rs, rk := newTestRedeem(ns, 3, 2)
+	setBtcTxParam(ns.GetCacheDB(), rk)
+	lockScript, _ := getLockScript(rs, &chaincfg.TestNet3Params)
+	old := make([]*Utxo, 3)
+	for i := range old {
+		old[i] = &Utxo{
+			Op:           &OutPoint{Hash: make([]byte, 32), Index: uint32(i)},
+			Value:        uint64(10000 * (i + 1)),
+			ScriptPubkey: lockScript,
+		}
+	}
+	putUtxos(ns, 1, rk, &Utxos{Utxos: old})
+
+	param := &ccmcom.SweepBtcUtxosParam{
+		ChainID:   1,
+		RedeemKey: rk,
+	}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	handler := NewBTCHandler()
+	ns = getNativeFunc(sink.Bytes(), ns.GetCacheDB())
+	// not rotated yet
+	err := handler.SweepUtxos(ns)
+	assert.Error(t, err)
+
+	_, successor, successorKey := newTestRedeem(ns, 3, 2)
+	ns.GetCacheDB().Put(utils.ConcatKey(utils.SideChainManagerContractAddress, []byte(side_chain_manager.REDEEM_SUCCESSOR),
+		utils.GetUint64Bytes(1), []byte(rk)), states.GenRawStorageItem([]byte(successorKey)))
+	activeKey, err := getActiveRedeem(ns, 1, rk)
+	assert.NoError(t, err)
+	assert.Equal(t, successorKey, activeKey)
+
+	err = handler.SweepUtxos(ns)
+	assert.NoError(t, err)
+	stateArr := ns.GetNotify()[0].States.([]interface{})
+	assert.Equal(t, "sweepBtcUtxos", stateArr[0].(string))
+	assert.Equal(t, successorKey, stateArr[2].(string))
+	stateArr = ns.GetNotify()[1].States.([]interface{})
+	assert.Equal(t, "makeBtcTx", stateArr[0].(string))
+	rawTx, _ := hex.DecodeString(stateArr[2].(string))
+	sweepTx := wire.NewMsgTx(wire.TxVersion)
+	_ = sweepTx.BtcDecode(bytes.NewBuffer(rawTx), wire.ProtocolVersion, wire.LatestEncoding)
+	successorScript, _ := getLockScript(successor, &chaincfg.TestNet3Params)
+	assert.Equal(t, 3, len(sweepTx.TxIn))
+	assert.Equal(t, 1, len(sweepTx.TxOut))
+	assert.Equal(t, successorScript, sweepTx.TxOut[0].PkScript)
+	txid := sweepTx.TxHash()
+	sweepTo, err := getBtcSweepTo(ns, txid[:])
+	assert.NoError(t, err)
+	assert.Equal(t, successorKey, sweepTo)
+
+	// old utxos are locked by the sweep tx
+	utxos, err := getUtxos(ns, 1, rk)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(utxos.Utxos))
+	stxos, err := getStxos(ns, 1, rk)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(stxos.Utxos))
+	err = handler.SweepUtxos(ns)
+	assert.Error(t, err)
+
+	// signed by old signers, the swept value goes to the successor
+	amts := make([]int64, len(sweepTx.TxIn))
+	for i, in := range sweepTx.TxIn {
+		amts[i] = int64(old[in.PreviousOutPoint.Index].Value)
+	}
+	ns = signTestTx(t, ns, keys[:2], rs, rk, sweepTx, amts)
+	stateArr = ns.GetNotify()[0].States.([]interface{})
+	assert.Equal(t, "btcTxToRelay", stateArr[0].(string))
+	rawTx, _ = hex.DecodeString(stateArr[3].(string))
+	_ = sweepTx.BtcDecode(bytes.NewBuffer(rawTx), wire.ProtocolVersion, wire.LatestEncoding)
+	txid = sweepTx.TxHash()
+	stxos, err = getStxos(ns, 1, rk)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(stxos.Utxos))
+	utxos, err = getUtxos(ns, 1, rk)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(utxos.Utxos))
+	utxos, err = getUtxos(ns, 1, successorKey)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(utxos.Utxos))
+	assert.Equal(t, uint64(sweepTx.TxOut[0].Value), utxos.Utxos[0].Value)
+	assert.Equal(t, txid.String()+":0", utxos.Utxos[0].Op.String())
+}
+
+func TestBTCHandler_SweepUtxosCap(t *testing.T) {
+	ns := getNativeFunc(nil, nil)
+	setTestnetSideChain(ns)
+	_, rs, rk := newTestRedeem(ns, 3, 2)
+	setBtcTxParam(ns.GetCacheDB(), rk)
+	_, _, successorKey := newTestRedeem(ns, 3, 2)
+	ns.GetCacheDB().Put(utils.ConcatKey(utils.SideChainManagerContractAddress, []byte(side_chain_manager.REDEEM_SUCCESSOR),
+		utils.GetUint64Bytes(1), []byte(rk)), states.GenRawStorageItem([]byte(successorKey)))
+	lockScript, _ := getLockScript(rs, &chaincfg.TestNet3Params)
+	old := make([]*Utxo, MAX_SWEEP_INPUTS+1)
+	for i := range old {
+		old[i] = &Utxo{
+			Op:           &OutPoint{Hash: make([]byte, 32), Index: uint32(i)},
+			Value:        uint64(10000 + i),
+			ScriptPubkey: lockScript,
+		}
+	}
+	putUtxos(ns, 1, rk, &Utxos{Utxos: old})
+
+	param := &ccmcom.SweepBtcUtxosParam{
+		ChainID:   1,
+		RedeemKey: rk,
+	}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	sweep := func() *wire.MsgTx {
+		ns = getNativeFunc(sink.Bytes(), ns.GetCacheDB())
+		assert.NoError(t, NewBTCHandler().SweepUtxos(ns))
+		rawTx, _ := hex.DecodeString(ns.GetNotify()[1].States.([]interface{})[2].(string))
+		mtx := wire.NewMsgTx(wire.TxVersion)
+		_ = mtx.BtcDecode(bytes.NewBuffer(rawTx), wire.ProtocolVersion, wire.LatestEncoding)
+		return mtx
+	}
+
+	// the largest utxos are swept first and the rest waits for the next sweep
+	mtx := sweep()
+	assert.Equal(t, MAX_SWEEP_INPUTS, len(mtx.TxIn))
+	for _, in := range mtx.TxIn {
+		assert.NotEqual(t, uint32(0), in.PreviousOutPoint.Index)
+	}
+	utxos, err := getUtxos(ns, 1, rk)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(utxos.Utxos))
+	assert.Equal(t, old[0].Op.String(), utxos.Utxos[0].Op.String())
+
+	mtx = sweep()
+	assert.Equal(t, 1, len(mtx.TxIn))
+	utxos, err = getUtxos(ns, 1, rk)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(utxos.Utxos))
+	stxos, err := getStxos(ns, 1, rk)
+	assert.NoError(t, err)
+	assert.Equal(t, MAX_SWEEP_INPUTS+1, len(stxos.Utxos))
+}
+
+func TestBTCHandler_MultiSignPsbt(t *testing.T) {
+	keys := make([]*btcec.PrivateKey, 3)
+	builder := txscript.NewScriptBuilder().AddOp(txscript.OP_2)
+	for i := range keys {
+		keys[i], _ = btcec.NewPrivateKey(btcec.S256())
+		builder.AddData(keys[i].PubKey().SerializeCompressed())
+	}
+	rs, _ := builder.AddOp(txscript.OP_3).AddOp(txscript.OP_CHECKMULTISIG).Script()
+	rk := hex.EncodeToString(btcutil.Hash160(rs))
+	lockScript, _ := getLockScript(rs, &chaincfg.TestNet3Params)
+
+	ns := getNativeFunc(nil, nil)
+	setTestnetSideChain(ns)
+	setBtcTxParam(ns.GetCacheDB(), rk)
+	ns.GetCacheDB().Put(utils.ConcatKey(utils.SideChainManagerContractAddress, []byte(side_chain_manager.REDEEM_SCRIPT),
+		utils.GetUint64Bytes(1), []byte(rk)), states.GenRawStorageItem(rs))
+	putUtxos(ns, 1, rk, &Utxos{
+		Utxos: []*Utxo{{
+			Op:           &OutPoint{Hash: make([]byte, 32), Index: 0},
+			Value:        100000,
+			ScriptPubkey: lockScript,
+		}},
+	})
+	err := makeBtcTx(ns, 1, map[string]int64{"mjEoyyCPsLzJ23xMX6Mti13zMyN36kzn57": 6000}, []byte{123},
+		2, rs, btcutil.Hash160(rs))
+	assert.NoError(t, err)
+	stateArr := ns.GetNotify()[0].States.([]interface{})
+	rawTx, _ := hex.DecodeString(stateArr[2].(string))
+	mtx := wire.NewMsgTx(wire.TxVersion)
+	_ = mtx.BtcDecode(bytes.NewBuffer(rawTx), wire.ProtocolVersion, wire.LatestEncoding)
+	txid := mtx.TxHash()
+
+	sign := func(signers ...int) []byte {
+		multiSignInfo, _ := getBtcMultiSignInfo(ns, txid.CloneBytes())
+		p, err := NewMultiSignPsbt(mtx, rs, [][]byte{lockScript}, []uint64{100000}, []*wire.MsgTx{nil}, multiSignInfo,
+			&chaincfg.TestNet3Params)
+		assert.NoError(t, err)
+		hashes := txscript.NewTxSigHashes(p.UnsignedTx)
+		for _, i := range signers {
+			sig, err := txscript.RawTxInWitnessSignature(p.UnsignedTx, hashes, 0, 100000, rs, txscript.SigHashAll, keys[i])
+			assert.NoError(t, err)
+			p.Inputs[0].PartialSigs[hex.EncodeToString(keys[i].PubKey().SerializeCompressed())] = sig
+		}
+		raw, err := p.Serialize()
+		assert.NoError(t, err)
+		return raw
+	}
+	multiSignPsbt := func(raw []byte) error {
+		param := &ccmcom.MultiSignPsbtParam{
+			ChainID:   1,
+			RedeemKey: rk,
+			TxHash:    txid.CloneBytes(),
+			Psbt:      raw,
+		}
+		sink := common.NewZeroCopySink(nil)
+		param.Serialization(sink)
+		ns = getNativeFunc(sink.Bytes(), ns.GetCacheDB())
+		return NewBTCHandler().MultiSignPsbt(ns)
+	}
+
+	// no signature in psbt
+	assert.Error(t, multiSignPsbt(sign()))
+
+	// psbt of another tx
+	p, _ := DecodePsbt(sign(0))
+	p.UnsignedTx.LockTime++
+	raw, _ := p.Serialize()
+	assert.Error(t, multiSignPsbt(raw))
+
+	assert.NoError(t, multiSignPsbt(sign(0)))
+	stateArr = ns.GetNotify()[0].States.([]interface{})
+	assert.Equal(t, "btcTxMultiSign", stateArr[0].(string))
+	// signature of no.0 already taken
+	assert.Error(t, multiSignPsbt(sign()))
+
+	assert.NoError(t, multiSignPsbt(sign(1, 2)))
+	stateArr = ns.GetNotify()[0].States.([]interface{})
+	assert.Equal(t, "btcTxToRelay", stateArr[0].(string))
+	rawTx, _ = hex.DecodeString(stateArr[3].(string))
+	signed := wire.NewMsgTx(wire.TxVersion)
+	_ = signed.BtcDecode(bytes.NewBuffer(rawTx), wire.ProtocolVersion, wire.LatestEncoding)
+	vm, err := txscript.NewEngine(lockScript, signed, 0, txscript.StandardVerifyFlags, nil, nil, 100000)
+	assert.NoError(t, err)
+	assert.NoError(t, vm.Execute())
+}
+
+func TestBTCHandler_ConsolidateUtxos(t *testing.T) {
+	ns := getNativeFunc(nil, nil)
+	setBtcTxParam(ns.GetCacheDB(), utxoKey)
+	registerRC(ns.GetCacheDB())
+	setTestnetSideChain(ns)
+	rb, _ := hex.DecodeString(rdm)
+	lockScript, _ := getLockScript(rb, &chaincfg.TestNet3Params)
+	utxos := &Utxos{Utxos: make([]*Utxo, 0)}
+	for i, v := range []uint64{100000, 5000, 3000} {
+		utxos.Utxos = append(utxos.Utxos, &Utxo{
+			Op:           &OutPoint{Hash: bytes.Repeat([]byte{byte(i + 1)}, 32), Index: 0},
+			Value:        v,
+			ScriptPubkey: lockScript,
+		})
+	}
+	putUtxos(ns, 1, utxoKey, utxos)
+
+	handler := NewBTCHandler()
+	consolidate := func(maxValue, feeRate uint64) error {
+		param := &ccmcom.ConsolidateBtcUtxosParam{
+			ChainID:   1,
+			RedeemKey: utxoKey,
+			MaxValue:  maxValue,
+			FeeRate:   feeRate,
+		}
+		sink := common.NewZeroCopySink(nil)
+		param.Serialization(sink)
+		ns = getNativeFunc(sink.Bytes(), ns.GetCacheDB())
+		return handler.ConsolidateUtxos(ns)
+	}
+	// rate higher than withdrawals
+	assert.Error(t, consolidate(5000, 3))
+	// only one utxo under threshold
+	assert.Error(t, consolidate(4000, 1))
+
+	assert.NoError(t, consolidate(5000, 1))
+	stateArr := ns.GetNotify()[0].States.([]interface{})
+	assert.Equal(t, "consolidateBtcUtxos", stateArr[0].(string))
+	assert.Equal(t, 2, stateArr[3].(int))
+	stateArr = ns.GetNotify()[1].States.([]interface{})
+	assert.Equal(t, "makeBtcTx", stateArr[0].(string))
+	rawTx, _ := hex.DecodeString(stateArr[2].(string))
+	mtx := wire.NewMsgTx(wire.TxVersion)
+	_ = mtx.BtcDecode(bytes.NewBuffer(rawTx), wire.ProtocolVersion, wire.LatestEncoding)
+	assert.Equal(t, 2, len(mtx.TxIn))
+	assert.Equal(t, 1, len(mtx.TxOut))
+	assert.Equal(t, lockScript, mtx.TxOut[0].PkScript)
+
+	utxos, err := getUtxos(ns, 1, utxoKey)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(utxos.Utxos))
+	assert.Equal(t, uint64(100000), utxos.Utxos[0].Value)
+	stxos, err := getStxos(ns, 1, utxoKey)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(stxos.Utxos))
+
+	// no recipient to pay for the bump, the merged output does
+	txid := mtx.TxHash()
+	param := &ccmcom.BumpBtcTxParam{
+		ChainID:   1,
+		RedeemKey: utxoKey,
+		TxHash:    txid.CloneBytes(),
+		FeeRate:   2,
+	}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	ns = getNativeFunc(sink.Bytes(), ns.GetCacheDB())
+	assert.NoError(t, handler.BumpTransaction(ns))
+	rawTx, _ = hex.DecodeString(ns.GetNotify()[1].States.([]interface{})[2].(string))
+	bumped := wire.NewMsgTx(wire.TxVersion)
+	_ = bumped.BtcDecode(bytes.NewBuffer(rawTx), wire.ProtocolVersion, wire.LatestEncoding)
+	assert.True(t, bumped.TxOut[0].Value < mtx.TxOut[0].Value)
+}
+
 func syncGenesisHeader(genesisHeader *wire.BlockHeader) (*storage.CacheDB, error) {
 	var buf bytes.Buffer
 	_ = genesisHeader.BtcEncode(&buf, wire.ProtocolVersion, wire.LatestEncoding)
@@ -361,6 +916,7 @@ func syncGenesisHeader(genesisHeader *wire.BlockHeader) (*storage.CacheDB, error
 	params.Serialization(sink)
 
 	ns := getNativeFunc(sink.Bytes(), nil)
+	setTestnetSideChain(ns)
 	err := btcHander.SyncGenesisHeader(ns)
 	if err != nil {
 		return nil, err
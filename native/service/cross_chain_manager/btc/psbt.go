@@ -0,0 +1,355 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package btc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
+	"github.com/polynetwork/poly/native/service/header_sync/btc"
+)
+
+// key types of BIP174 used in multisign rounds, others are skipped when decoding
+const (
+	PSBT_GLOBAL_UNSIGNED_TX  = 0x00
+	PSBT_IN_NON_WITNESS_UTXO = 0x00
+	PSBT_IN_WITNESS_UTXO     = 0x01
+	PSBT_IN_PARTIAL_SIG      = 0x02
+	PSBT_IN_SIGHASH_TYPE     = 0x03
+	PSBT_IN_REDEEM_SCRIPT    = 0x04
+	PSBT_IN_WITNESS_SCRIPT   = 0x05
+	PSBT_OUT_REDEEM_SCRIPT   = 0x00
+	PSBT_OUT_WITNESS_SCRIPT  = 0x01
+)
+
+var psbtMagic = []byte{0x70, 0x73, 0x62, 0x74, 0xff}
+
+type PsbtInput struct {
+	NonWitnessUtxo *wire.MsgTx
+	WitnessUtxo    *wire.TxOut
+	PartialSigs    map[string][]byte // hex of public key to signature
+	SighashType    uint32
+	RedeemScript   []byte
+	WitnessScript  []byte
+}
+
+type PsbtOutput struct {
+	RedeemScript  []byte
+	WitnessScript []byte
+}
+
+// Psbt is the partially signed bitcoin transaction of BIP174
+type Psbt struct {
+	UnsignedTx *wire.MsgTx
+	Inputs     []*PsbtInput
+	Outputs    []*PsbtOutput
+}
+
+// NewMultiSignPsbt makes the psbt of tx with signatures collected in multiSignInfo, pkScripts and amts
+// are of the utxos spent by tx. Legacy inputs take the previous txs in prevTxs as non-witness utxos, a nil
+// one is for the utxo kept before previous txs are, and they carry the amount as witness utxo on chains
+// signing with SIGHASH_FORKID, which commits to it.
+func NewMultiSignPsbt(tx *wire.MsgTx, redeem []byte, pkScripts [][]byte, amts []uint64, prevTxs []*wire.MsgTx,
+	multiSignInfo *MultiSignInfo, netParam *chaincfg.Params) (*Psbt, error) {
+	if len(pkScripts) != len(tx.TxIn) || len(amts) != len(tx.TxIn) || len(prevTxs) != len(tx.TxIn) {
+		return nil, fmt.Errorf("NewMultiSignPsbt, %d inputs but %d scripts, %d amounts and %d previous txs",
+			len(tx.TxIn), len(pkScripts), len(amts), len(prevTxs))
+	}
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(redeem, netParam)
+	if err != nil {
+		return nil, fmt.Errorf("NewMultiSignPsbt, failed to extract pkscript addrs: %v", err)
+	}
+	lockScript, err := getLockScript(redeem, netParam)
+	if err != nil {
+		return nil, fmt.Errorf("NewMultiSignPsbt, %v", err)
+	}
+
+	forkID := btc.FamilyOf(netParam).ForkID
+	sighashType := txscript.SigHashAll
+	if forkID {
+		sighashType |= btc.SigHashForkID
+	}
+	p := &Psbt{
+		UnsignedTx: tx.Copy(),
+		Inputs:     make([]*PsbtInput, len(tx.TxIn)),
+		Outputs:    make([]*PsbtOutput, len(tx.TxOut)),
+	}
+	for i, in := range p.UnsignedTx.TxIn {
+		in.SignatureScript, in.Witness = nil, nil
+		input := &PsbtInput{
+			PartialSigs: make(map[string][]byte),
+			SighashType: uint32(sighashType),
+		}
+		switch c := txscript.GetScriptClass(pkScripts[i]); {
+		case isNestedWitness(pkScripts[i], redeem):
+			input.WitnessUtxo = wire.NewTxOut(int64(amts[i]), pkScripts[i])
+			input.RedeemScript = side_chain_manager.GetWitnessProgram(redeem)
+			input.WitnessScript = redeem
+		case c == txscript.WitnessV0ScriptHashTy:
+			input.WitnessUtxo = wire.NewTxOut(int64(amts[i]), pkScripts[i])
+			input.WitnessScript = redeem
+		case c == txscript.ScriptHashTy || c == txscript.MultiSigTy:
+			if c == txscript.ScriptHashTy {
+				input.RedeemScript = redeem
+			}
+			if prev := prevTxs[i]; prev != nil {
+				if prev.TxHash() != in.PreviousOutPoint.Hash {
+					return nil, fmt.Errorf("NewMultiSignPsbt, previous tx %s is not of no.%d input",
+						prev.TxHash().String(), i)
+				}
+				input.NonWitnessUtxo = prev
+			}
+			if forkID {
+				input.WitnessUtxo = wire.NewTxOut(int64(amts[i]), pkScripts[i])
+			}
+		default:
+			return nil, fmt.Errorf("NewMultiSignPsbt, type of no.%d utxo is %s which is not supported", i, c)
+		}
+		for _, addr := range addrs {
+			sigs, ok := multiSignInfo.MultiSignInfo[addr.EncodeAddress()]
+			if ok && i < len(sigs) {
+				input.PartialSigs[hex.EncodeToString(addr.ScriptAddress())] = sigs[i]
+			}
+		}
+		p.Inputs[i] = input
+	}
+	for i, out := range p.UnsignedTx.TxOut {
+		p.Outputs[i] = &PsbtOutput{}
+		if bytes.Equal(out.PkScript, lockScript) {
+			p.Outputs[i].WitnessScript = redeem
+		}
+	}
+	return p, nil
+}
+
+// GetMultiSigns returns signatures of signers in addrs who sign all inputs, keyed by address as MultiSignInfo
+func (this *Psbt) GetMultiSigns(addrs []btcutil.Address) map[string][][]byte {
+	res := make(map[string][][]byte)
+	for _, addr := range addrs {
+		pk := hex.EncodeToString(addr.ScriptAddress())
+		sigs := make([][]byte, 0, len(this.Inputs))
+		for _, in := range this.Inputs {
+			sig, ok := in.PartialSigs[pk]
+			if !ok {
+				break
+			}
+			sigs = append(sigs, sig)
+		}
+		if len(sigs) == len(this.Inputs) {
+			res[addr.EncodeAddress()] = sigs
+		}
+	}
+	return res
+}
+
+func (this *Psbt) Serialize() ([]byte, error) {
+	w := new(bytes.Buffer)
+	w.Write(psbtMagic)
+	var tx bytes.Buffer
+	if err := this.UnsignedTx.SerializeNoWitness(&tx); err != nil {
+		return nil, fmt.Errorf("Psbt Serialize, failed to serialize unsigned tx: %v", err)
+	}
+	writePsbtPair(w, []byte{PSBT_GLOBAL_UNSIGNED_TX}, tx.Bytes())
+	w.WriteByte(0)
+
+	for _, in := range this.Inputs {
+		if in.NonWitnessUtxo != nil {
+			var prev bytes.Buffer
+			if err := in.NonWitnessUtxo.Serialize(&prev); err != nil {
+				return nil, fmt.Errorf("Psbt Serialize, failed to serialize non-witness utxo: %v", err)
+			}
+			writePsbtPair(w, []byte{PSBT_IN_NON_WITNESS_UTXO}, prev.Bytes())
+		}
+		if in.WitnessUtxo != nil {
+			var utxo bytes.Buffer
+			if err := wire.WriteTxOut(&utxo, 0, 0, in.WitnessUtxo); err != nil {
+				return nil, fmt.Errorf("Psbt Serialize, failed to serialize witness utxo: %v", err)
+			}
+			writePsbtPair(w, []byte{PSBT_IN_WITNESS_UTXO}, utxo.Bytes())
+		}
+		pks := make([]string, 0, len(in.PartialSigs))
+		for k := range in.PartialSigs {
+			pks = append(pks, k)
+		}
+		sort.Strings(pks)
+		for _, k := range pks {
+			pk, err := hex.DecodeString(k)
+			if err != nil {
+				return nil, fmt.Errorf("Psbt Serialize, wrong public key %s: %v", k, err)
+			}
+			writePsbtPair(w, append([]byte{PSBT_IN_PARTIAL_SIG}, pk...), in.PartialSigs[k])
+		}
+		if in.SighashType != 0 {
+			ty := make([]byte, 4)
+			binary.LittleEndian.PutUint32(ty, in.SighashType)
+			writePsbtPair(w, []byte{PSBT_IN_SIGHASH_TYPE}, ty)
+		}
+		if len(in.RedeemScript) > 0 {
+			writePsbtPair(w, []byte{PSBT_IN_REDEEM_SCRIPT}, in.RedeemScript)
+		}
+		if len(in.WitnessScript) > 0 {
+			writePsbtPair(w, []byte{PSBT_IN_WITNESS_SCRIPT}, in.WitnessScript)
+		}
+		w.WriteByte(0)
+	}
+
+	for _, out := range this.Outputs {
+		if len(out.RedeemScript) > 0 {
+			writePsbtPair(w, []byte{PSBT_OUT_REDEEM_SCRIPT}, out.RedeemScript)
+		}
+		if len(out.WitnessScript) > 0 {
+			writePsbtPair(w, []byte{PSBT_OUT_WITNESS_SCRIPT}, out.WitnessScript)
+		}
+		w.WriteByte(0)
+	}
+	return w.Bytes(), nil
+}
+
+func DecodePsbt(raw []byte) (*Psbt, error) {
+	if !bytes.HasPrefix(raw, psbtMagic) {
+		return nil, errors.New("DecodePsbt, wrong magic bytes")
+	}
+	r := bytes.NewReader(raw[len(psbtMagic):])
+	p := new(Psbt)
+	err := readPsbtMap(r, func(k, v []byte) error {
+		if k[0] != PSBT_GLOBAL_UNSIGNED_TX {
+			return nil
+		}
+		if len(k) != 1 {
+			return errors.New("wrong key of unsigned tx")
+		}
+		tx := wire.NewMsgTx(wire.TxVersion)
+		if err := tx.DeserializeNoWitness(bytes.NewReader(v)); err != nil {
+			return fmt.Errorf("failed to decode unsigned tx: %v", err)
+		}
+		p.UnsignedTx = tx
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("DecodePsbt, global map: %v", err)
+	}
+	if p.UnsignedTx == nil {
+		return nil, errors.New("DecodePsbt, no unsigned tx in psbt")
+	}
+	for i, in := range p.UnsignedTx.TxIn {
+		if len(in.SignatureScript) != 0 || len(in.Witness) != 0 {
+			return nil, fmt.Errorf("DecodePsbt, no.%d input of unsigned tx is signed", i)
+		}
+	}
+
+	p.Inputs = make([]*PsbtInput, len(p.UnsignedTx.TxIn))
+	for i := range p.Inputs {
+		input := &PsbtInput{
+			PartialSigs: make(map[string][]byte),
+		}
+		err = readPsbtMap(r, func(k, v []byte) error {
+			switch k[0] {
+			case PSBT_IN_NON_WITNESS_UTXO:
+				prev := wire.NewMsgTx(wire.TxVersion)
+				if err := prev.Deserialize(bytes.NewReader(v)); err != nil {
+					return fmt.Errorf("failed to decode non-witness utxo: %v", err)
+				}
+				input.NonWitnessUtxo = prev
+			case PSBT_IN_WITNESS_UTXO:
+				if len(v) < 8 {
+					return errors.New("wrong witness utxo")
+				}
+				script, err := wire.ReadVarBytes(bytes.NewReader(v[8:]), 0, wire.MaxMessagePayload, "pkScript")
+				if err != nil {
+					return fmt.Errorf("failed to decode witness utxo: %v", err)
+				}
+				input.WitnessUtxo = wire.NewTxOut(int64(binary.LittleEndian.Uint64(v[:8])), script)
+			case PSBT_IN_PARTIAL_SIG:
+				input.PartialSigs[hex.EncodeToString(k[1:])] = v
+			case PSBT_IN_SIGHASH_TYPE:
+				if len(v) != 4 {
+					return errors.New("wrong sighash type")
+				}
+				input.SighashType = binary.LittleEndian.Uint32(v)
+			case PSBT_IN_REDEEM_SCRIPT:
+				input.RedeemScript = v
+			case PSBT_IN_WITNESS_SCRIPT:
+				input.WitnessScript = v
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("DecodePsbt, no.%d input map: %v", i, err)
+		}
+		p.Inputs[i] = input
+	}
+
+	p.Outputs = make([]*PsbtOutput, len(p.UnsignedTx.TxOut))
+	for i := range p.Outputs {
+		output := &PsbtOutput{}
+		err = readPsbtMap(r, func(k, v []byte) error {
+			switch k[0] {
+			case PSBT_OUT_REDEEM_SCRIPT:
+				output.RedeemScript = v
+			case PSBT_OUT_WITNESS_SCRIPT:
+				output.WitnessScript = v
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("DecodePsbt, no.%d output map: %v", i, err)
+		}
+		p.Outputs[i] = output
+	}
+	return p, nil
+}
+
+func writePsbtPair(w io.Writer, key, value []byte) {
+	_ = wire.WriteVarBytes(w, 0, key)
+	_ = wire.WriteVarBytes(w, 0, value)
+}
+
+// readPsbtMap reads key-value pairs until the separator, duplicated keys are rejected
+func readPsbtMap(r io.Reader, handle func(k, v []byte) error) error {
+	seen := make(map[string]bool)
+	for {
+		k, err := wire.ReadVarBytes(r, 0, wire.MaxMessagePayload, "key")
+		if err != nil {
+			return fmt.Errorf("failed to read key: %v", err)
+		}
+		if len(k) == 0 {
+			return nil
+		}
+		v, err := wire.ReadVarBytes(r, 0, wire.MaxMessagePayload, "value")
+		if err != nil {
+			return fmt.Errorf("failed to read value: %v", err)
+		}
+		if seen[string(k)] {
+			return fmt.Errorf("duplicated key %s", hex.EncodeToString(k))
+		}
+		seen[string(k)] = true
+		if err = handle(k, v); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,151 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package btc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/polynetwork/poly/native/service/header_sync/btc"
+)
+
+func TestPsbt_RoundTrip(t *testing.T) {
+	rs, _ := hex.DecodeString(redeem)
+	_, addrs, _, _ := txscript.ExtractPkScriptAddrs(rs, &chaincfg.TestNet3Params)
+	sigMap := &MultiSignInfo{MultiSignInfo: make(map[string][][]byte)}
+	for i, s := range wsigs {
+		if i == 3 {
+			break
+		}
+		sb, _ := hex.DecodeString(s)
+		sigMap.MultiSignInfo[addrs[i].EncodeAddress()] = [][]byte{sb}
+	}
+
+	txb, _ := hex.DecodeString(wTx)
+	mtx := wire.NewMsgTx(wire.TxVersion)
+	mtx.BtcDecode(bytes.NewBuffer(txb), wire.TxVersion, wire.LatestEncoding)
+	prev := wire.NewMsgTx(wire.TxVersion)
+	prev.AddTxIn(wire.NewTxIn(&wire.OutPoint{}, []byte{txscript.OP_TRUE}, nil))
+	prev.AddTxOut(wire.NewTxOut(btcutil.SatoshiPerBitcoin, getPkSs("p2sh")[0]))
+	mtx.TxIn[0].PreviousOutPoint.Hash = prev.TxHash()
+
+	for _, ty := range []string{"p2sh", "wit", "nested"} {
+		p, err := NewMultiSignPsbt(mtx, rs, getPkSs(ty), []uint64{btcutil.SatoshiPerBitcoin}, []*wire.MsgTx{prev}, sigMap,
+			&chaincfg.TestNet3Params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw, err := p.Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := DecodePsbt(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reraw, err := decoded.Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(raw, reraw) || !reflect.DeepEqual(p.Inputs, decoded.Inputs) {
+			t.Fatalf("%s: decoded psbt not equal", ty)
+		}
+		if ty == "p2sh" && (decoded.Inputs[0].WitnessUtxo != nil || !bytes.Equal(decoded.Inputs[0].RedeemScript, rs)) {
+			t.Fatal("wrong scripts of p2sh input")
+		}
+		if ty == "p2sh" && decoded.Inputs[0].NonWitnessUtxo.TxHash() != prev.TxHash() {
+			t.Fatal("wrong non-witness utxo of p2sh input")
+		}
+		if ty != "p2sh" && decoded.Inputs[0].WitnessUtxo.Value != btcutil.SatoshiPerBitcoin {
+			t.Fatalf("%s: wrong amount of witness utxo", ty)
+		}
+		if !reflect.DeepEqual(decoded.GetMultiSigns(addrs), sigMap.MultiSignInfo) {
+			t.Fatalf("%s: signatures not equal", ty)
+		}
+	}
+}
+
+func TestNewMultiSignPsbt_Legacy(t *testing.T) {
+	rs, _ := hex.DecodeString(redeem)
+	txb, _ := hex.DecodeString(wTx)
+	mtx := wire.NewMsgTx(wire.TxVersion)
+	mtx.BtcDecode(bytes.NewBuffer(txb), wire.TxVersion, wire.LatestEncoding)
+	prev := wire.NewMsgTx(wire.TxVersion)
+	prev.AddTxIn(wire.NewTxIn(&wire.OutPoint{}, []byte{txscript.OP_TRUE}, nil))
+	prev.AddTxOut(wire.NewTxOut(btcutil.SatoshiPerBitcoin, getPkSs("p2sh")[0]))
+	sigMap := &MultiSignInfo{MultiSignInfo: make(map[string][][]byte)}
+
+	if _, err := NewMultiSignPsbt(mtx, rs, getPkSs("p2sh"), []uint64{btcutil.SatoshiPerBitcoin}, []*wire.MsgTx{prev},
+		sigMap, &chaincfg.TestNet3Params); err == nil {
+		t.Fatal("previous tx of another input should be rejected")
+	}
+
+	// utxo kept before its previous tx is, the amount is still there for forkid signing
+	for _, netParam := range []*chaincfg.Params{&chaincfg.TestNet3Params, btc.BchTestNet3Params} {
+		p, err := NewMultiSignPsbt(mtx, rs, getPkSs("p2sh"), []uint64{btcutil.SatoshiPerBitcoin}, []*wire.MsgTx{nil},
+			sigMap, netParam)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Inputs[0].NonWitnessUtxo != nil {
+			t.Fatalf("%s: unexpected non-witness utxo", netParam.Name)
+		}
+		forkID := netParam == btc.BchTestNet3Params
+		if forkID != (p.Inputs[0].WitnessUtxo != nil) {
+			t.Fatalf("%s: witness utxo should be carried only by forkid chains", netParam.Name)
+		}
+		if forkID && p.Inputs[0].WitnessUtxo.Value != btcutil.SatoshiPerBitcoin {
+			t.Fatalf("%s: wrong amount", netParam.Name)
+		}
+	}
+}
+
+func TestDecodePsbt_Reject(t *testing.T) {
+	txb, _ := hex.DecodeString(wTx)
+	mtx := wire.NewMsgTx(wire.TxVersion)
+	mtx.BtcDecode(bytes.NewBuffer(txb), wire.TxVersion, wire.LatestEncoding)
+	for _, in := range mtx.TxIn {
+		in.SignatureScript = nil
+	}
+	var tx bytes.Buffer
+	mtx.SerializeNoWitness(&tx)
+
+	if _, err := DecodePsbt(tx.Bytes()); err == nil {
+		t.Fatal("psbt without magic should be rejected")
+	}
+
+	buf := bytes.NewBuffer(append([]byte{}, psbtMagic...))
+	writePsbtPair(buf, []byte{PSBT_GLOBAL_UNSIGNED_TX}, tx.Bytes())
+	writePsbtPair(buf, []byte{PSBT_GLOBAL_UNSIGNED_TX}, tx.Bytes())
+	buf.WriteByte(0)
+	if _, err := DecodePsbt(buf.Bytes()); err == nil {
+		t.Fatal("duplicated key should be rejected")
+	}
+
+	buf = bytes.NewBuffer(append([]byte{}, psbtMagic...))
+	buf.WriteByte(0)
+	if _, err := DecodePsbt(buf.Bytes()); err == nil {
+		t.Fatal("psbt without unsigned tx should be rejected")
+	}
+}
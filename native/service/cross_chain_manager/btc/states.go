@@ -172,6 +172,7 @@ type CoinSelector struct {
 	feeRate     uint64
 	m           int
 	n           int
+	rk          []byte
 }
 
 func (selector *CoinSelector) Select() ([]*Utxo, uint64, uint64) {
@@ -305,20 +306,26 @@ func (selector *CoinSelector) estimateTxSize(selection []*Utxo) int {
 	redeemSize := 1 + selector.m*(1+75) + 1 + 1 + selector.n*(1+33) + 1 + 1
 	p2shInputSize := 43 + redeemSize
 	witnessInputSize := 41 + redeemSize/blockchain.WitnessScaleFactor
+	nestedInputSize := witnessInputSize + 1 + 34
 	outsSize := 0
 	for _, txOut := range selector.txOuts {
 		outsSize += txOut.SerializeSize()
 	}
-	witNum := 0
+	witNum, nestedNum := 0, 0
 	for _, u := range selection {
 		switch txscript.GetScriptClass(u.ScriptPubkey) {
 		case txscript.WitnessV0ScriptHashTy:
 			witNum++
+		case txscript.ScriptHashTy:
+			// script hash of legacy P2SH utxo is the redeem key, otherwise it wraps P2WSH
+			if selector.rk != nil && !bytes.Equal(u.ScriptPubkey[2:22], selector.rk) {
+				nestedNum++
+			}
 		}
 	}
 	return 10 + 2 + wire.VarIntSerializeSize(uint64(len(selection))) +
-		wire.VarIntSerializeSize(uint64(len(selector.txOuts)+1)) + (len(selection)-witNum)*p2shInputSize +
-		witNum*witnessInputSize + outsSize
+		wire.VarIntSerializeSize(uint64(len(selector.txOuts)+1)) + (len(selection)-witNum-nestedNum)*p2shInputSize +
+		witNum*witnessInputSize + nestedNum*nestedInputSize + outsSize
 }
 
 type OutPoint struct {
@@ -19,7 +19,6 @@ package btc
 import (
 	"bytes"
 	"crypto/sha256"
-	"encoding/binary"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -46,12 +45,23 @@ const (
 	OP_RETURN_SCRIPT_FLAG   = byte(0xcc)
 	BTC_TX_PREFIX           = "btctx"
 	BTC_FROM_TX_PREFIX      = "btcfromtx"
+	BTC_TX_INPUTS           = "btctxinputs"
+	BTC_UTXO_TX             = "btcutxotx"
+	BTC_SWEEP_TO            = "btcsweepto"
+	BTC_REPLACED_TXS        = "btcreplacedtxs"
+	BTC_SIGNED_TXID         = "btcsignedtxid"
 	UTXOS                   = "utxos"
 	STXOS                   = "stxos"
 	MULTI_SIGN_INFO         = "multiSignInfo"
 	MAX_FEE_COST_PERCENTS   = 1.0
 	MAX_SELECTING_TRY_LIMIT = 1000000
+	MAX_SWEEP_INPUTS        = 100
 	SELECTING_K             = 4.0
+	// the largest input sequence signaling replaceability by BIP125
+	MAX_REPLACEABLE_SEQUENCE = wire.MaxTxInSequenceNum - 2
+	// the default min relay fee rate of bitcoin core in satoshi per vbyte, which a replacement
+	// pays for its own size besides the fee of the replaced tx, see rule 4 of BIP125
+	MIN_RELAY_FEE_RATE = 1
 )
 
 func getNetParam(service *native.NativeService, chainId uint64) (*chaincfg.Params, error) {
@@ -62,19 +72,7 @@ func getNetParam(service *native.NativeService, chainId uint64) (*chaincfg.Param
 	if side == nil {
 		return nil, fmt.Errorf("side chain info for chainId: %d is not registered", chainId)
 	}
-	if side.CCMCAddress == nil || len(side.CCMCAddress) != 8 {
-		return nil, fmt.Errorf("CCMCAddress is nil or its length is not 8")
-	}
-	switch utils.BtcNetType(binary.LittleEndian.Uint64(side.CCMCAddress)) {
-	case utils.TyTestnet3:
-		return &chaincfg.TestNet3Params, nil
-	case utils.TyRegtest:
-		return &chaincfg.RegressionNetParams, nil
-	case utils.TySimnet:
-		return &chaincfg.SimNetParams, nil
-	default:
-		return &chaincfg.MainNetParams, nil
-	}
+	return btc.GetNetParam(side.Router, side.CCMCAddress)
 }
 
 func verifyFromBtcTx(native *native.NativeService, proof, tx []byte, fromChainID uint64, height uint32) (*crosscommon.MakeTxParam, error) {
@@ -91,23 +89,6 @@ func verifyFromBtcTx(native *native.NativeService, proof, tx []byte, fromChainID
 		return nil, fmt.Errorf("VerifyFromBtcProof, not crosschain btc tx, since failed to resolve parameter: %v", err)
 	}
 
-	// make sure the header with height is already synced, meaning the tx is already confirmed in btc block chain
-	bestHeader, err := btc.GetBestBlockHeader(native, fromChainID)
-	if err != nil {
-		return nil, fmt.Errorf("VerifyFromBtcProof, get best block header error:%s", err)
-	}
-	sideChain, err := side_chain_manager.GetSideChain(native, fromChainID)
-	if err != nil {
-		return nil, fmt.Errorf("VerifyFromBtcProof, side_chain_manager.GetSideChain error: %v", err)
-	}
-	if sideChain == nil {
-		return nil, fmt.Errorf("VerifyFromBtcProof, side chain is not registered")
-	}
-	bestHeight := bestHeader.Height
-	if bestHeight < height || bestHeight-height < uint32(sideChain.BlocksToWait-1) {
-		return nil, fmt.Errorf("verifyFromBtcTx, transaction is not confirmed, current height: %d, input height: %d", bestHeight, height)
-	}
-
 	// verify btc merkle proof
 	header, err := btc.GetHeaderByHeight(native, fromChainID, height)
 	if err != nil {
@@ -123,11 +104,23 @@ func verifyFromBtcTx(native *native.NativeService, proof, tx []byte, fromChainID
 	if err != nil {
 		return nil, fmt.Errorf("verifyFromBtcTx, failed to resolve parameter: %v", err)
 	}
-	rk := GetUtxoKey(mtx.TxOut[0].PkScript)
+	rk, err := getUtxoKey(native, fromChainID, mtx.TxOut[0].PkScript)
+	if err != nil {
+		return nil, fmt.Errorf("verifyFromBtcTx, %v", err)
+	}
 	redeemKey, err := hex.DecodeString(rk)
 	if err != nil {
 		return nil, fmt.Errorf("verifyFromBtcTx, hex.DecodeString error: %v", err)
 	}
+	if txscript.GetScriptClass(mtx.TxOut[0].PkScript) == txscript.WitnessV0ScriptHashTy {
+		redeem, err := side_chain_manager.GetBtcRedeemScriptBytes(native, rk, fromChainID)
+		if err != nil {
+			return nil, fmt.Errorf("verifyFromBtcTx, get redeem of %s error: %v", rk, err)
+		}
+		if !side_chain_manager.IsWitnessRedeem(redeem) {
+			return nil, fmt.Errorf("verifyFromBtcTx, redeem %s can't be spent as witness script", rk)
+		}
+	}
 	toContractAddress, err := side_chain_manager.GetContractBind(native, fromChainID, p.args.ToChainID, redeemKey)
 	if err != nil {
 		return nil, fmt.Errorf("verifyFromBtcTx, side_chain_manager.GetContractBind error: %v", err)
@@ -212,8 +205,10 @@ func (p *targetChainParam) resolve(amount int64, paramOutput *wire.TxOut) error
 // This function needs to input the input and output information of the transaction
 // and the lock time. Function build a raw transaction without signature and return it.
 // This function uses the partial logic and code of btcd to finally return the
-// reference of the transaction object.
-func getUnsignedTx(txIns []*wire.TxIn, outs []*wire.TxOut, changeOut *wire.TxOut, locktime *int64) (*wire.MsgTx, error) {
+// reference of the transaction object. Inputs signal replaceability (BIP125) if replaceable
+// is set, which should only be done on chains whose mempool takes replacements.
+func getUnsignedTx(txIns []*wire.TxIn, outs []*wire.TxOut, changeOut *wire.TxOut, locktime *int64,
+	replaceable bool) (*wire.MsgTx, error) {
 	if locktime != nil && (*locktime < 0 || *locktime > int64(wire.MaxTxInSequenceNum)) {
 		return nil, fmt.Errorf("getUnsignedTx, locktime %d out of range", *locktime)
 	}
@@ -222,7 +217,10 @@ func getUnsignedTx(txIns []*wire.TxIn, outs []*wire.TxOut, changeOut *wire.TxOut
 	// some validity checks.
 	mtx := wire.NewMsgTx(wire.TxVersion)
 	for _, in := range txIns {
-		if locktime != nil && *locktime != 0 {
+		if replaceable {
+			// it enables the locktime as well
+			in.Sequence = MAX_REPLACEABLE_SEQUENCE
+		} else if locktime != nil && *locktime != 0 {
 			in.Sequence = wire.MaxTxInSequenceNum - 1
 		}
 		mtx.AddTxIn(in)
@@ -244,16 +242,12 @@ func getUnsignedTx(txIns []*wire.TxIn, outs []*wire.TxOut, changeOut *wire.TxOut
 func getTxOuts(amounts map[string]int64, netParam *chaincfg.Params) ([]*wire.TxOut, error) {
 	outs := make([]*wire.TxOut, 0)
 	for encodedAddr, amount := range amounts {
-		// Decode the provided address.
-		addr, err := btcutil.DecodeAddress(encodedAddr, netParam)
+		// Decode the provided address in the encoding of its chain.
+		addr, err := btc.FamilyOf(netParam).DecodeAddress(encodedAddr, netParam)
 		if err != nil {
 			return nil, fmt.Errorf("getTxOuts, decode addr fail: %v", err)
 		}
 
-		if !addr.IsForNet(netParam) {
-			return nil, fmt.Errorf("getTxOuts, addr is not for %s", netParam.Name)
-		}
-
 		// Create a new script which pays to the provided address.
 		pkScript, err := txscript.PayToAddrScript(addr)
 		if err != nil {
@@ -267,20 +261,38 @@ func getTxOuts(amounts map[string]int64, netParam *chaincfg.Params) ([]*wire.TxO
 	return outs, nil
 }
 
+// getLockScript returns the P2WSH script of redeem, or the P2SH one for chains without segwit
 func getLockScript(redeem []byte, netParam *chaincfg.Params) ([]byte, error) {
-	hasher := sha256.New()
-	hasher.Write(redeem)
-	witAddr, err := btcutil.NewAddressWitnessScriptHash(hasher.Sum(nil), netParam)
+	var (
+		addr btcutil.Address
+		err  error
+	)
+	if btc.FamilyOf(netParam).Witness && side_chain_manager.IsWitnessRedeem(redeem) {
+		hasher := sha256.New()
+		hasher.Write(redeem)
+		addr, err = btcutil.NewAddressWitnessScriptHash(hasher.Sum(nil), netParam)
+	} else {
+		addr, err = btcutil.NewAddressScriptHash(redeem, netParam)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("getChangeTxOut, failed to get witness address: %v", err)
+		return nil, fmt.Errorf("getChangeTxOut, failed to get lock address: %v", err)
 	}
-	script, err := txscript.PayToAddrScript(witAddr)
+	script, err := txscript.PayToAddrScript(addr)
 	if err != nil {
 		return nil, fmt.Errorf("getChangeTxOut, failed to get p2sh script: %v", err)
 	}
 	return script, nil
 }
 
+// getRedeemLockScript returns the lock script of the registered redeem with key redeemKey
+func getRedeemLockScript(native *native.NativeService, chainID uint64, redeemKey string, netParam *chaincfg.Params) ([]byte, error) {
+	redeem, err := side_chain_manager.GetBtcRedeemScriptBytes(native, redeemKey, chainID)
+	if err != nil {
+		return nil, fmt.Errorf("getRedeemLockScript, get redeem script of %s error: %v", redeemKey, err)
+	}
+	return getLockScript(redeem, netParam)
+}
+
 func GetUtxoKey(scriptPk []byte) string {
 	switch txscript.GetScriptClass(scriptPk) {
 	case txscript.MultiSigTy:
@@ -296,8 +308,31 @@ func GetUtxoKey(scriptPk []byte) string {
 	}
 }
 
+// getUtxoKey is GetUtxoKey which also resolves the P2SH-wrapped P2WSH script to the redeem key
+func getUtxoKey(native *native.NativeService, chainID uint64, scriptPk []byte) (string, error) {
+	if txscript.GetScriptClass(scriptPk) == txscript.ScriptHashTy {
+		rk, err := side_chain_manager.GetRedeemKeyByNestedHash(native, scriptPk[2:22], chainID)
+		if err != nil {
+			return "", fmt.Errorf("getUtxoKey, %v", err)
+		}
+		if rk != "" {
+			return rk, nil
+		}
+	}
+	return GetUtxoKey(scriptPk), nil
+}
+
+// isNestedWitness tells whether the P2SH scriptPk wraps the P2WSH program of redeem
+func isNestedWitness(scriptPk, redeem []byte) bool {
+	return txscript.GetScriptClass(scriptPk) == txscript.ScriptHashTy &&
+		bytes.Equal(scriptPk[2:22], btcutil.Hash160(side_chain_manager.GetWitnessProgram(redeem)))
+}
+
 func addUtxos(native *native.NativeService, chainID uint64, height uint32, mtx *wire.MsgTx) error {
-	utxoKey := GetUtxoKey(mtx.TxOut[0].PkScript)
+	utxoKey, err := getUtxoKey(native, chainID, mtx.TxOut[0].PkScript)
+	if err != nil {
+		return fmt.Errorf("addUtxos, %v", err)
+	}
 
 	utxos, err := getUtxos(native, chainID, utxoKey)
 	if err != nil {
@@ -317,6 +352,11 @@ func addUtxos(native *native.NativeService, chainID uint64, height uint32, mtx *
 
 	utxos.Utxos = append(utxos.Utxos, newUtxo)
 	putUtxos(native, chainID, utxoKey, utxos)
+	if isLegacyUtxo(newUtxo.ScriptPubkey, utxoKey) {
+		if err = putBtcUtxoTx(native, mtx); err != nil {
+			return fmt.Errorf("addUtxos, %v", err)
+		}
+	}
 	return nil
 }
 
@@ -345,6 +385,7 @@ func chooseUtxos(native *native.NativeService, chainID uint64, amount int64, out
 		feeRate:     detail.FeeRate,
 		m:           m,
 		n:           n,
+		rk:          rk,
 	}
 	result, sum, fee := cs.Select()
 	if result == nil || len(result) == 0 {
@@ -444,8 +485,33 @@ func getStxoAmts(service *native.NativeService, chainID uint64, txIns []*wire.Tx
 	return amts, stxos, nil
 }
 
+// getStxoInputs finds the stxos spent by txIns, which are left in stxos
+func getStxoInputs(service *native.NativeService, chainID uint64, txIns []*wire.TxIn, redeemKey string) (*Utxos, error) {
+	stxos, err := getStxos(service, chainID, redeemKey)
+	if err != nil {
+		return nil, fmt.Errorf("getStxoInputs, failed to get stxos: %v", err)
+	}
+	inputs := &Utxos{
+		Utxos: make([]*Utxo, len(txIns)),
+	}
+	for i, in := range txIns {
+		for _, v := range stxos.Utxos {
+			if bytes.Equal(in.PreviousOutPoint.Hash[:], v.Op.Hash) && in.PreviousOutPoint.Index == v.Op.Index {
+				inputs.Utxos[i] = v
+				break
+			}
+		}
+		if inputs.Utxos[i] == nil {
+			return nil, fmt.Errorf("getStxoInputs, %d txIn not found in stxos", i)
+		}
+	}
+	return inputs, nil
+}
+
+// verifySigs checks signatures of addr for all inputs, forkID tells the chain takes only the
+// SIGHASH_FORKID ones which commit to amounts by the BIP143 digest whatever the script is
 func verifySigs(sigs [][]byte, addr string, addrs []btcutil.Address, redeem []byte, tx *wire.MsgTx,
-	pkScripts [][]byte, amts []uint64) error {
+	pkScripts [][]byte, amts []uint64, forkID bool) error {
 	if len(sigs) != len(tx.TxIn) {
 		return fmt.Errorf("not enough sig, only %d sigs but %d required", len(sigs), len(tx.TxIn))
 	}
@@ -460,6 +526,7 @@ func verifySigs(sigs [][]byte, addr string, addrs []btcutil.Address, redeem []by
 		return fmt.Errorf("address %s not found in redeem script", addr)
 	}
 
+	var sh *txscript.TxSigHashes
 	for i, sig := range sigs {
 		if len(sig) < 1 {
 			return fmt.Errorf("length of no.%d sig is less than 1", i)
@@ -470,15 +537,25 @@ func verifySigs(sigs [][]byte, addr string, addrs []btcutil.Address, redeem []by
 			return fmt.Errorf("failed to parse no.%d sig: %v", i, err)
 		}
 		var hash []byte
-		switch c := txscript.GetScriptClass(pkScripts[i]); c {
+		hashType := txscript.SigHashType(sig[len(sig)-1])
+		if forkID != (hashType&btc.SigHashForkID != 0) {
+			return fmt.Errorf("wrong sighash type %x of no.%d sig", byte(hashType), i)
+		}
+		c := txscript.GetScriptClass(pkScripts[i])
+		if isNestedWitness(pkScripts[i], redeem) || forkID {
+			c = txscript.WitnessV0ScriptHashTy
+		}
+		switch c {
 		case txscript.MultiSigTy, txscript.ScriptHashTy:
-			hash, err = txscript.CalcSignatureHash(redeem, txscript.SigHashType(sig[len(sig)-1]), tx, i)
+			hash, err = txscript.CalcSignatureHash(redeem, hashType, tx, i)
 			if err != nil {
 				return fmt.Errorf("failed to calculate sig hash: %v", err)
 			}
 		case txscript.WitnessV0ScriptHashTy:
-			sh := txscript.NewTxSigHashes(tx)
-			hash, err = txscript.CalcWitnessSigHash(redeem, sh, txscript.SigHashType(sig[len(sig)-1]), tx, i, int64(amts[i]))
+			if sh == nil {
+				sh = txscript.NewTxSigHashes(tx)
+			}
+			hash, err = txscript.CalcWitnessSigHash(redeem, sh, hashType, tx, i, int64(amts[i]))
 			if err != nil {
 				return fmt.Errorf("failed to calculate sig hash: %v", err)
 			}
@@ -531,7 +608,12 @@ func addSigToTx(sigMap *MultiSignInfo, addrs []btcutil.Address, redeem []byte, t
 			err    error
 		)
 		builder := txscript.NewScriptBuilder()
-		switch c := txscript.GetScriptClass(pkScripts[i]); c {
+		nested := isNestedWitness(pkScripts[i], redeem)
+		c := txscript.GetScriptClass(pkScripts[i])
+		if nested {
+			c = txscript.WitnessV0ScriptHashTy
+		}
+		switch c {
 		case txscript.MultiSigTy, txscript.ScriptHashTy:
 			builder.AddOp(txscript.OP_FALSE)
 			for _, addr := range addrs {
@@ -563,6 +645,13 @@ func addSigToTx(sigMap *MultiSignInfo, addrs []btcutil.Address, redeem []byte, t
 			}
 			data[idx] = redeem
 			tx.TxIn[i].Witness = wire.TxWitness(data)
+			if nested {
+				script, err = builder.AddData(side_chain_manager.GetWitnessProgram(redeem)).Script()
+				if err != nil {
+					return fmt.Errorf("failed to build sigscript for nested witness input %d: %v", i, err)
+				}
+				tx.TxIn[i].SignatureScript = script
+			}
 		default:
 			return fmt.Errorf("addSigToTx, type of no.%d utxo is %s which is not supported", i, c)
 		}
@@ -614,3 +703,274 @@ func ifCanResolve(paramOutput *wire.TxOut, value int64) error {
 	}
 	return nil
 }
+
+// putBtcTx stores the unsigned btc tx as it is, not wrapped by storage item which signers don't expect
+func putBtcTx(native *native.NativeService, txid []byte, rawTx []byte) {
+	key := utils.ConcatKey(utils.CrossChainManagerContractAddress, []byte(BTC_TX_PREFIX), txid)
+	native.GetCacheDB().Put(key, rawTx)
+}
+
+func getBtcTx(native *native.NativeService, txid []byte) (*wire.MsgTx, error) {
+	key := utils.ConcatKey(utils.CrossChainManagerContractAddress, []byte(BTC_TX_PREFIX), txid)
+	txb, err := native.GetCacheDB().Get(key)
+	if err != nil {
+		return nil, fmt.Errorf("getBtcTx, failed to get tx %s from cacheDB: %v", hex.EncodeToString(txid), err)
+	}
+	if txb == nil {
+		return nil, fmt.Errorf("getBtcTx, tx %s not found", hex.EncodeToString(txid))
+	}
+	mtx := wire.NewMsgTx(wire.TxVersion)
+	if err = mtx.BtcDecode(bytes.NewBuffer(txb), wire.ProtocolVersion, wire.LatestEncoding); err != nil {
+		return nil, fmt.Errorf("getBtcTx, failed to decode tx: %v", err)
+	}
+	return mtx, nil
+}
+
+func putBtcTxInputs(native *native.NativeService, txid []byte, inputs *Utxos) {
+	key := utils.ConcatKey(utils.CrossChainManagerContractAddress, []byte(BTC_TX_INPUTS), txid)
+	sink := common.NewZeroCopySink(nil)
+	inputs.Serialization(sink)
+	native.GetCacheDB().Put(key, cstates.GenRawStorageItem(sink.Bytes()))
+}
+
+func getBtcTxInputs(native *native.NativeService, txid []byte) (*Utxos, error) {
+	key := utils.ConcatKey(utils.CrossChainManagerContractAddress, []byte(BTC_TX_INPUTS), txid)
+	store, err := native.GetCacheDB().Get(key)
+	if err != nil {
+		return nil, fmt.Errorf("getBtcTxInputs, get inputs store error: %v", err)
+	}
+	inputs := &Utxos{
+		Utxos: make([]*Utxo, 0),
+	}
+	if store != nil {
+		inputsBytes, err := cstates.GetValueFromRawStorageItem(store)
+		if err != nil {
+			return nil, fmt.Errorf("getBtcTxInputs, deserialize from raw storage item err:%v", err)
+		}
+		err = inputs.Deserialization(common.NewZeroCopySource(inputsBytes))
+		if err != nil {
+			return nil, fmt.Errorf("getBtcTxInputs, deserialize inputs err:%v", err)
+		}
+	}
+	return inputs, nil
+}
+
+// deleteBtcTx removes everything stored for the btc tx, used when it's replaced
+func deleteBtcTx(native *native.NativeService, txid []byte) {
+	contract := utils.CrossChainManagerContractAddress
+	native.GetCacheDB().Delete(utils.ConcatKey(contract, []byte(BTC_TX_PREFIX), txid))
+	native.GetCacheDB().Delete(utils.ConcatKey(contract, []byte(BTC_FROM_TX_PREFIX), txid))
+	native.GetCacheDB().Delete(utils.ConcatKey(contract, []byte(BTC_TX_INPUTS), txid))
+	native.GetCacheDB().Delete(utils.ConcatKey(contract, []byte(MULTI_SIGN_INFO), txid))
+	native.GetCacheDB().Delete(utils.ConcatKey(contract, []byte(BTC_SWEEP_TO), txid))
+	native.GetCacheDB().Delete(utils.ConcatKey(contract, []byte(BTC_REPLACED_TXS), txid))
+	native.GetCacheDB().Delete(utils.ConcatKey(contract, []byte(BTC_SIGNED_TXID), txid))
+}
+
+// putBtcSignedTxid records the txid of the tx once fully signed, which its outputs are spent by
+func putBtcSignedTxid(native *native.NativeService, txid []byte, signedTxid []byte) {
+	key := utils.ConcatKey(utils.CrossChainManagerContractAddress, []byte(BTC_SIGNED_TXID), txid)
+	native.GetCacheDB().Put(key, cstates.GenRawStorageItem(signedTxid))
+}
+
+// getBtcSignedTxid returns the txid of the fully signed tx, nil returned if it's not recorded
+func getBtcSignedTxid(native *native.NativeService, txid []byte) ([]byte, error) {
+	key := utils.ConcatKey(utils.CrossChainManagerContractAddress, []byte(BTC_SIGNED_TXID), txid)
+	store, err := native.GetCacheDB().Get(key)
+	if err != nil {
+		return nil, fmt.Errorf("getBtcSignedTxid, get signed txid store error: %v", err)
+	}
+	if store == nil {
+		return nil, nil
+	}
+	signedTxid, err := cstates.GetValueFromRawStorageItem(store)
+	if err != nil {
+		return nil, fmt.Errorf("getBtcSignedTxid, deserialize from raw storage item err:%v", err)
+	}
+	return signedTxid, nil
+}
+
+// putBtcReplacedTxs records the signed txids of txs replaced by the bumped tx txid, any of which may
+// still be confirmed on bitcoin instead of it
+func putBtcReplacedTxs(native *native.NativeService, txid []byte, replaced [][]byte) {
+	key := utils.ConcatKey(utils.CrossChainManagerContractAddress, []byte(BTC_REPLACED_TXS), txid)
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteVarUint(uint64(len(replaced)))
+	for _, v := range replaced {
+		sink.WriteVarBytes(v)
+	}
+	native.GetCacheDB().Put(key, cstates.GenRawStorageItem(sink.Bytes()))
+}
+
+func getBtcReplacedTxs(native *native.NativeService, txid []byte) ([][]byte, error) {
+	key := utils.ConcatKey(utils.CrossChainManagerContractAddress, []byte(BTC_REPLACED_TXS), txid)
+	store, err := native.GetCacheDB().Get(key)
+	if err != nil {
+		return nil, fmt.Errorf("getBtcReplacedTxs, get replaced txs store error: %v", err)
+	}
+	replaced := make([][]byte, 0)
+	if store == nil {
+		return replaced, nil
+	}
+	raw, err := cstates.GetValueFromRawStorageItem(store)
+	if err != nil {
+		return nil, fmt.Errorf("getBtcReplacedTxs, deserialize from raw storage item err:%v", err)
+	}
+	source := common.NewZeroCopySource(raw)
+	n, eof := source.NextVarUint()
+	if eof {
+		return nil, fmt.Errorf("getBtcReplacedTxs, deserialize length error")
+	}
+	for i := uint64(0); i < n; i++ {
+		v, eof := source.NextVarBytes()
+		if eof {
+			return nil, fmt.Errorf("getBtcReplacedTxs, deserialize no.%d txid error", i)
+		}
+		replaced = append(replaced, v)
+	}
+	return replaced, nil
+}
+
+func putBtcSweepTo(native *native.NativeService, txid []byte, successorKey string) {
+	key := utils.ConcatKey(utils.CrossChainManagerContractAddress, []byte(BTC_SWEEP_TO), txid)
+	native.GetCacheDB().Put(key, cstates.GenRawStorageItem([]byte(successorKey)))
+}
+
+// getBtcSweepTo returns the redeem key which the sweep tx moves utxos to,
+// empty string returned if the tx is not a sweep
+func getBtcSweepTo(native *native.NativeService, txid []byte) (string, error) {
+	key := utils.ConcatKey(utils.CrossChainManagerContractAddress, []byte(BTC_SWEEP_TO), txid)
+	store, err := native.GetCacheDB().Get(key)
+	if err != nil {
+		return "", fmt.Errorf("getBtcSweepTo, get sweep store error: %v", err)
+	}
+	if store == nil {
+		return "", nil
+	}
+	sk, err := cstates.GetValueFromRawStorageItem(store)
+	if err != nil {
+		return "", fmt.Errorf("getBtcSweepTo, deserialize from raw storage item err:%v", err)
+	}
+	return string(sk), nil
+}
+
+// getActiveRedeem follows the rotations of the redeem and returns the key of the one holding custody now
+func getActiveRedeem(native *native.NativeService, chainID uint64, redeemKey string) (string, error) {
+	for {
+		successor, err := side_chain_manager.GetRedeemSuccessor(native, redeemKey, chainID)
+		if err != nil {
+			return "", fmt.Errorf("getActiveRedeem, %v", err)
+		}
+		if successor == "" {
+			return redeemKey, nil
+		}
+		redeemKey = successor
+	}
+}
+
+// addTxOutUtxos adds the outputs of tx paying to lockScript into utxos of utxoKey
+func addTxOutUtxos(native *native.NativeService, chainID uint64, utxoKey string, mtx *wire.MsgTx, lockScript []byte) error {
+	utxos, err := getUtxos(native, chainID, utxoKey)
+	if err != nil {
+		return fmt.Errorf("addTxOutUtxos, getUtxos error: %v", err)
+	}
+	txid := mtx.TxHash()
+	added := false
+	for i, v := range mtx.TxOut {
+		if bytes.Equal(lockScript, v.PkScript) {
+			utxos.Utxos = append(utxos.Utxos, &Utxo{
+				Op: &OutPoint{
+					Hash:  txid[:],
+					Index: uint32(i),
+				},
+				Value:        uint64(v.Value),
+				ScriptPubkey: v.PkScript,
+			})
+			added = true
+		}
+	}
+	putUtxos(native, chainID, utxoKey, utxos)
+	if added && isLegacyUtxo(lockScript, utxoKey) {
+		if err = putBtcUtxoTx(native, mtx); err != nil {
+			return fmt.Errorf("addTxOutUtxos, %v", err)
+		}
+	}
+	return nil
+}
+
+// isLegacyUtxo tells whether the utxo of utxoKey is spent without witness, the psbt input spending it
+// carries the whole previous tx then
+func isLegacyUtxo(scriptPk []byte, utxoKey string) bool {
+	return txscript.GetScriptClass(scriptPk) != txscript.WitnessV0ScriptHashTy && GetUtxoKey(scriptPk) == utxoKey
+}
+
+// putBtcUtxoTx keeps the tx creating legacy utxos, as the non-witness utxo of psbt inputs spending them
+func putBtcUtxoTx(native *native.NativeService, mtx *wire.MsgTx) error {
+	var buf bytes.Buffer
+	if err := mtx.BtcEncode(&buf, wire.ProtocolVersion, wire.LatestEncoding); err != nil {
+		return fmt.Errorf("putBtcUtxoTx, failed to encode tx: %v", err)
+	}
+	txid := mtx.TxHash()
+	key := utils.ConcatKey(utils.CrossChainManagerContractAddress, []byte(BTC_UTXO_TX), txid[:])
+	native.GetCacheDB().Put(key, cstates.GenRawStorageItem(buf.Bytes()))
+	return nil
+}
+
+// deleteBtcUtxoTxs removes the txs kept for legacy utxos spent by txIns, the one still creating unspent
+// or locked utxos of utxoKey is left
+func deleteBtcUtxoTxs(native *native.NativeService, chainID uint64, utxoKey string, txIns []*wire.TxIn,
+	stxos *Utxos) error {
+	utxos, err := getUtxos(native, chainID, utxoKey)
+	if err != nil {
+		return fmt.Errorf("deleteBtcUtxoTxs, getUtxos error: %v", err)
+	}
+	inUse := make(map[string]bool)
+	for _, v := range append(utxos.Utxos, stxos.Utxos...) {
+		inUse[string(v.Op.Hash)] = true
+	}
+	for _, in := range txIns {
+		if !inUse[string(in.PreviousOutPoint.Hash[:])] {
+			native.GetCacheDB().Delete(utils.ConcatKey(utils.CrossChainManagerContractAddress, []byte(BTC_UTXO_TX),
+				in.PreviousOutPoint.Hash[:]))
+		}
+	}
+	return nil
+}
+
+// removeTxOutUtxos removes the outputs of tx txid paying to lockScript from utxos of utxoKey,
+// error returned if any of them is already spent
+func removeTxOutUtxos(native *native.NativeService, chainID uint64, utxoKey string, txid []byte, outs []*wire.TxOut,
+	lockScript []byte) error {
+	utxos, err := getUtxos(native, chainID, utxoKey)
+	if err != nil {
+		return fmt.Errorf("removeTxOutUtxos, getUtxos error: %v", err)
+	}
+	for i, out := range outs {
+		if !bytes.Equal(out.PkScript, lockScript) {
+			continue
+		}
+		idx := -1
+		for j, u := range utxos.Utxos {
+			if bytes.Equal(u.Op.Hash, txid) && u.Op.Index == uint32(i) {
+				idx = j
+				break
+			}
+		}
+		if idx < 0 {
+			return fmt.Errorf("removeTxOutUtxos, no.%d output of tx %s is already spent", i, hex.EncodeToString(txid))
+		}
+		utxos.Utxos = append(utxos.Utxos[:idx], utxos.Utxos[idx+1:]...)
+	}
+	putUtxos(native, chainID, utxoKey, utxos)
+	return nil
+}
+
+// signalsReplaceability tells whether the tx can be replaced in mempool by BIP125
+func signalsReplaceability(mtx *wire.MsgTx) bool {
+	for _, in := range mtx.TxIn {
+		if in.Sequence <= MAX_REPLACEABLE_SEQUENCE {
+			return true
+		}
+	}
+	return false
+}
@@ -19,10 +19,16 @@ package btc
 import (
 	"bytes"
 	"encoding/hex"
+	"github.com/btcsuite/btcd/btcec"
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
+	bchscript "github.com/gcash/bchd/txscript"
+	bchwire "github.com/gcash/bchd/wire"
+	"github.com/polynetwork/poly/native/service/header_sync/btc"
+	"github.com/polynetwork/poly/native/service/utils"
 	"sort"
 	"testing"
 )
@@ -94,6 +100,11 @@ var (
 			ss := make([][]byte, 1)
 			ss[0] = witlock
 			return ss
+		} else if ty == "nested" {
+			nested, _ := hex.DecodeString("a914f0b747e4699a8097bf4c58c2e75980a86a7370be87")
+			ss := make([][]byte, 1)
+			ss[0] = nested
+			return ss
 		}
 		return nil
 	}
@@ -110,14 +121,14 @@ func TestVerifySigs(t *testing.T) {
 	mtx := wire.NewMsgTx(wire.TxVersion)
 	mtx.BtcDecode(bytes.NewBuffer(txb), wire.TxVersion, wire.LatestEncoding)
 
-	err := verifySigs(sigs, addrs[0].EncodeAddress(), addrs, rs, mtx, getPkSs("p2sh"), []uint64{})
+	err := verifySigs(sigs, addrs[0].EncodeAddress(), addrs, rs, mtx, getPkSs("p2sh"), []uint64{}, false)
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	sig2b, _ := hex.DecodeString(sig2)
 	sigs = [][]byte{sig2b}
-	err = verifySigs(sigs, addrs[0].EncodeAddress(), addrs, rs, mtx, getPkSs("p2sh"), []uint64{})
+	err = verifySigs(sigs, addrs[0].EncodeAddress(), addrs, rs, mtx, getPkSs("p2sh"), []uint64{}, false)
 	if err == nil {
 		t.Fatal("err should not be nil")
 	}
@@ -129,24 +140,85 @@ func TestVerifySigs(t *testing.T) {
 	mtx = wire.NewMsgTx(wire.TxVersion)
 	mtx.BtcDecode(bytes.NewBuffer(txb), wire.TxVersion, wire.LatestEncoding)
 
-	err = verifySigs(sigs, addrs[0].EncodeAddress(), addrs, rs, mtx, getPkSs("wit"), []uint64{btcutil.SatoshiPerBitcoin})
+	err = verifySigs(sigs, addrs[0].EncodeAddress(), addrs, rs, mtx, getPkSs("wit"), []uint64{btcutil.SatoshiPerBitcoin}, false)
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	wsig2b, _ := hex.DecodeString(wsigs[1])
 	sigs = [][]byte{wsig2b}
-	err = verifySigs(sigs, addrs[0].EncodeAddress(), addrs, rs, mtx, getPkSs("wit"), []uint64{btcutil.SatoshiPerBitcoin})
+	err = verifySigs(sigs, addrs[0].EncodeAddress(), addrs, rs, mtx, getPkSs("wit"), []uint64{btcutil.SatoshiPerBitcoin}, false)
 	if err == nil {
 		t.Fatalf("err should not be nil")
 	}
 
-	err = verifySigs(sigs, addrs[1].EncodeAddress(), addrs, rs, mtx, getPkSs("wit"), []uint64{1000})
+	err = verifySigs(sigs, addrs[1].EncodeAddress(), addrs, rs, mtx, getPkSs("wit"), []uint64{1000}, false)
 	if err == nil {
 		t.Fatalf("err should not be nil")
 	}
 }
 
+func TestVerifySigs_ForkID(t *testing.T) {
+	keys := make([]*btcec.PrivateKey, 2)
+	pks := make([]*btcutil.AddressPubKey, 2)
+	for i := range keys {
+		keys[i], _ = btcec.NewPrivateKey(btcec.S256())
+		pks[i], _ = btcutil.NewAddressPubKey(keys[i].PubKey().SerializeCompressed(), btc.BchTestNet3Params)
+	}
+	rs, _ := txscript.MultiSigScript(pks, 2)
+	_, addrs, _, _ := txscript.ExtractPkScriptAddrs(rs, btc.BchTestNet3Params)
+	lock, err := getLockScript(rs, btc.BchTestNet3Params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if txscript.GetScriptClass(lock) != txscript.ScriptHashTy {
+		t.Fatalf("lock script of bch should be p2sh")
+	}
+
+	mtx := wire.NewMsgTx(wire.TxVersion)
+	mtx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{1}, 0), nil, nil))
+	mtx.AddTxOut(wire.NewTxOut(9000, lock))
+	amt := int64(10000)
+
+	ht := txscript.SigHashAll | btc.SigHashForkID
+	hash, err := txscript.CalcWitnessSigHash(rs, txscript.NewTxSigHashes(mtx), ht, mtx, 0, amt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	_ = mtx.Serialize(&buf)
+	btx := bchwire.NewMsgTx(bchwire.TxVersion)
+	if err = btx.Deserialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expected, err := bchscript.CalcSignatureHash(rs, bchscript.NewTxSigHashes(btx), bchscript.SigHashType(ht), btx, 0, amt, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(hash, expected) {
+		t.Fatalf("forkid digest not match: %x, %x", hash, expected)
+	}
+
+	sig, _ := keys[0].Sign(hash)
+	sigs := [][]byte{append(sig.Serialize(), byte(ht))}
+	if err = verifySigs(sigs, addrs[0].EncodeAddress(), addrs, rs, mtx, [][]byte{lock}, []uint64{uint64(amt)}, true); err != nil {
+		t.Fatal(err)
+	}
+	if err = verifySigs(sigs, addrs[0].EncodeAddress(), addrs, rs, mtx, [][]byte{lock}, []uint64{uint64(amt)}, false); err == nil {
+		t.Fatal("forkid sig should be rejected on chains without forkid")
+	}
+	if err = verifySigs(sigs, addrs[0].EncodeAddress(), addrs, rs, mtx, [][]byte{lock}, []uint64{uint64(amt) + 1}, true); err == nil {
+		t.Fatal("forkid sig should commit to the amount")
+	}
+
+	hash, _ = txscript.CalcSignatureHash(rs, txscript.SigHashAll, mtx, 0)
+	sig, _ = keys[0].Sign(hash)
+	sigs = [][]byte{append(sig.Serialize(), byte(txscript.SigHashAll))}
+	if err = verifySigs(sigs, addrs[0].EncodeAddress(), addrs, rs, mtx, [][]byte{lock}, []uint64{uint64(amt)}, true); err == nil {
+		t.Fatal("sig without forkid should be rejected on bch")
+	}
+}
+
 func TestAddSigToTx(t *testing.T) {
 	sigArr := make([][]byte, 0)
 	sig1b, _ := hex.DecodeString(sig1)
@@ -216,6 +288,23 @@ func TestAddSigToTx(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+
+	mtx = wire.NewMsgTx(wire.TxVersion)
+	mtx.BtcDecode(bytes.NewBuffer(txb), wire.TxVersion, wire.LatestEncoding)
+	err = addSigToTx(sigMap, addrs, rs, mtx, getPkSs("nested"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vm, err = txscript.NewEngine(getPkSs("nested")[0], mtx, 0, txscript.StandardVerifyFlags, nil,
+		nil, btcutil.SatoshiPerBitcoin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = vm.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
 }
 
 func TestUtxos_Sort(t *testing.T) {
@@ -268,3 +357,61 @@ func TestUtxos_Choose(t *testing.T) {
 		t.Fatal("err should not be nil")
 	}
 }
+
+func TestBtcUtxoTx(t *testing.T) {
+	ns := getNativeFunc(nil, nil)
+	_, rs, rk := newTestRedeem(ns, 3, 2)
+	addr, _ := btcutil.NewAddressScriptHash(rs, &chaincfg.TestNet3Params)
+	p2sh, _ := txscript.PayToAddrScript(addr)
+	witScript, _ := getLockScript(rs, &chaincfg.TestNet3Params)
+	getUtxoTx := func(txid chainhash.Hash) []byte {
+		store, _ := ns.GetCacheDB().Get(utils.ConcatKey(utils.CrossChainManagerContractAddress, []byte(BTC_UTXO_TX),
+			txid[:]))
+		return store
+	}
+
+	prev := wire.NewMsgTx(wire.TxVersion)
+	prev.AddTxIn(wire.NewTxIn(&wire.OutPoint{}, []byte{txscript.OP_TRUE}, nil))
+	prev.AddTxOut(wire.NewTxOut(100000, p2sh))
+	prev.AddTxOut(wire.NewTxOut(200000, p2sh))
+	if err := addTxOutUtxos(ns, 1, rk, prev, p2sh); err != nil {
+		t.Fatal(err)
+	}
+	if getUtxoTx(prev.TxHash()) == nil {
+		t.Fatal("tx of legacy utxos should be kept")
+	}
+	wit := wire.NewMsgTx(wire.TxVersion)
+	wit.AddTxIn(wire.NewTxIn(&wire.OutPoint{}, []byte{txscript.OP_TRUE}, nil))
+	wit.AddTxOut(wire.NewTxOut(100000, witScript))
+	if err := addTxOutUtxos(ns, 1, rk, wit, witScript); err != nil {
+		t.Fatal(err)
+	}
+	if getUtxoTx(wit.TxHash()) != nil {
+		t.Fatal("tx of witness utxos should not be kept")
+	}
+
+	// the first output of prev is spent while the second is unspent and then locked
+	prevHash := prev.TxHash()
+	spent := []*wire.TxIn{wire.NewTxIn(wire.NewOutPoint(&prevHash, 0), nil, nil)}
+	utxos, _ := getUtxos(ns, 1, rk)
+	putUtxos(ns, 1, rk, &Utxos{Utxos: utxos.Utxos[1:]})
+	if err := deleteBtcUtxoTxs(ns, 1, rk, spent, &Utxos{}); err != nil {
+		t.Fatal(err)
+	}
+	if getUtxoTx(prevHash) == nil {
+		t.Fatal("tx with unspent utxos should be kept")
+	}
+	putUtxos(ns, 1, rk, &Utxos{Utxos: utxos.Utxos[2:]})
+	if err := deleteBtcUtxoTxs(ns, 1, rk, spent, &Utxos{Utxos: utxos.Utxos[1:2]}); err != nil {
+		t.Fatal(err)
+	}
+	if getUtxoTx(prevHash) == nil {
+		t.Fatal("tx with locked utxos should be kept")
+	}
+	if err := deleteBtcUtxoTxs(ns, 1, rk, spent, &Utxos{}); err != nil {
+		t.Fatal(err)
+	}
+	if getUtxoTx(prevHash) != nil {
+		t.Fatal("tx with all utxos spent should be deleted")
+	}
+}
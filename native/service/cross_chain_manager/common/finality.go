@@ -0,0 +1,83 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"fmt"
+
+	"github.com/polynetwork/poly/common/config"
+	"github.com/polynetwork/poly/native"
+)
+
+// FinalityHandler is implemented by chain handlers whose source chain headers are synced to poly
+// and may be reorganized. GetSyncedHeight returns the height of the synced canonical tip, the bool
+// is false if the chain of chainID has no such tip. Handlers of BFT chains whose headers are final
+// once committed, like quorum, ont, neo and cosmos, don't implement it, BlocksToWait of those chains
+// is not checked.
+type FinalityHandler interface {
+	GetSyncedHeight(service *native.NativeService, chainID uint64) (uint64, bool, error)
+}
+
+// ConfirmationsHandler is implemented by chain handlers which let a side chain require more
+// confirmations than SideChain.BlocksToWait, e.g. through the side chain's ExtraInfo.
+type ConfirmationsHandler interface {
+	GetConfirmations(service *native.NativeService, chainID uint64) (uint64, error)
+}
+
+// CheckFinality requires the proof at height to be confirmed by at least max(blocksToWait, confirmations
+// of ConfirmationsHandler) headers on the synced canonical tip of the source chain, counting the header
+// at height itself. So a BlocksToWait of 0 or 1 accepts proofs in the synced tip. Before
+// config.FORK_FINALITY, a BlocksToWait of 0 rejects all proofs as the per router checks did.
+func CheckFinality(service *native.NativeService, handler ChainHandler, chainID, blocksToWait uint64, height uint32) error {
+	fh, ok := handler.(FinalityHandler)
+	if !ok {
+		return nil
+	}
+	if ch, ok := handler.(ConfirmationsHandler); ok {
+		confirmations, err := ch.GetConfirmations(service, chainID)
+		if err != nil {
+			return fmt.Errorf("CheckFinality, get confirmations error: %v", err)
+		}
+		if confirmations > blocksToWait {
+			blocksToWait = confirmations
+		}
+	}
+	tip, synced, err := fh.GetSyncedHeight(service, chainID)
+	if err != nil {
+		return fmt.Errorf("CheckFinality, get synced height error: %v", err)
+	}
+	if !synced {
+		return nil
+	}
+	if !service.IsForkActive(config.FORK_FINALITY) {
+		if uint32(tip) < height || uint32(tip)-height < uint32(blocksToWait-1) {
+			return fmt.Errorf("CheckFinality, transaction is not confirmed, current height: %d, input height: %d", tip, height)
+		}
+		return nil
+	}
+	if tip < uint64(height) {
+		return fmt.Errorf("CheckFinality, transaction is not confirmed, current height: %d, input height: %d, header not synced yet",
+			tip, height)
+	}
+	confirmations := tip - uint64(height) + 1
+	if confirmations < blocksToWait {
+		return fmt.Errorf("CheckFinality, transaction is not confirmed, current height: %d, input height: %d, %d more confirmations needed",
+			tip, height, blocksToWait-confirmations)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"testing"
+
+	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/common/config"
+	"github.com/polynetwork/poly/core/types"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/service/testutils"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockHandler struct{}
+
+func (this *mockHandler) MakeDepositProposal(service *native.NativeService) (*MakeTxParam, error) {
+	return nil, nil
+}
+
+type mockFinalityHandler struct {
+	mockHandler
+	tip    uint64
+	synced bool
+}
+
+func (this *mockFinalityHandler) GetSyncedHeight(service *native.NativeService, chainID uint64) (uint64, bool, error) {
+	return this.tip, this.synced, nil
+}
+
+type mockConfirmationsHandler struct {
+	mockFinalityHandler
+	confirmations uint64
+}
+
+func (this *mockConfirmationsHandler) GetConfirmations(service *native.NativeService, chainID uint64) (uint64, error) {
+	return this.confirmations, nil
+}
+
+func TestCheckFinality(t *testing.T) {
+	defer testutils.SetForkHeights(map[string]uint32{config.FORK_FINALITY: 0})()
+	ns, err := native.NewNativeService(nil, new(types.Transaction), 0, 0, common.Uint256{}, 0, nil, false)
+	assert.NoError(t, err)
+	assert.NoError(t, CheckFinality(ns, &mockHandler{}, 2, 100, 1000))
+	assert.NoError(t, CheckFinality(ns, &mockFinalityHandler{tip: 10}, 2, 100, 1000))
+
+	handler := &mockFinalityHandler{tip: 1000, synced: true}
+	assert.NoError(t, CheckFinality(ns, handler, 2, 0, 1000))
+	assert.NoError(t, CheckFinality(ns, handler, 2, 1, 1000))
+	assert.NoError(t, CheckFinality(ns, handler, 2, 12, 989))
+	assert.Error(t, CheckFinality(ns, handler, 2, 0, 1001))
+
+	err = CheckFinality(ns, handler, 2, 12, 990)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "1 more confirmations needed")
+
+	// the larger one of blocksToWait and confirmations is required
+	confHandler := &mockConfirmationsHandler{mockFinalityHandler: *handler, confirmations: 20}
+	assert.NoError(t, CheckFinality(ns, confHandler, 2, 12, 981))
+	assert.Error(t, CheckFinality(ns, confHandler, 2, 12, 982))
+	assert.NoError(t, CheckFinality(ns, confHandler, 2, 30, 971))
+	assert.Error(t, CheckFinality(ns, confHandler, 2, 30, 972))
+}
+
+func TestCheckFinalityBeforeFork(t *testing.T) {
+	defer testutils.SetForkHeights(map[string]uint32{config.FORK_FINALITY: 1})()
+	ns, err := native.NewNativeService(nil, new(types.Transaction), 0, 0, common.Uint256{}, 0, nil, false)
+	assert.NoError(t, err)
+
+	handler := &mockFinalityHandler{tip: 1000, synced: true}
+	// BlocksToWait of 0 rejects all proofs
+	assert.Error(t, CheckFinality(ns, handler, 2, 0, 1000))
+	assert.Error(t, CheckFinality(ns, handler, 2, 0, 10))
+	assert.NoError(t, CheckFinality(ns, handler, 2, 1, 1000))
+	assert.NoError(t, CheckFinality(ns, handler, 2, 12, 989))
+	assert.Error(t, CheckFinality(ns, handler, 2, 12, 990))
+	assert.Error(t, CheckFinality(ns, handler, 2, 1, 1001))
+}
@@ -27,9 +27,15 @@ var (
 
 	KEY_PREFIX_BTC_VOTE = "btcVote"
 	REQUEST             = "request"
+	REQUEST_SEQUENCE    = "requestSequence"
+	REQUEST_INDEX       = "requestIndex"
 	DONE_TX             = "doneTx"
 
 	NOTIFY_MAKE_PROOF = "makeProof"
+
+	CROSS_CHAIN_STATUS  = "crossChainStatus"
+	STATUS_BY_SOURCE_TX = "statusBySourceTx"
+	STATUS_BY_POLY_TX   = "statusByPolyTx"
 )
 
 type ChainHandler interface {
@@ -224,6 +230,224 @@ func (this *MultiSignParam) Deserialization(source *common.ZeroCopySource) error
 	return nil
 }
 
+type MultiSignPsbtParam struct {
+	ChainID   uint64
+	RedeemKey string
+	TxHash    []byte
+	Psbt      []byte
+}
+
+func (this *MultiSignPsbtParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteUint64(this.ChainID)
+	sink.WriteString(this.RedeemKey)
+	sink.WriteVarBytes(this.TxHash)
+	sink.WriteVarBytes(this.Psbt)
+}
+
+func (this *MultiSignPsbtParam) Deserialization(source *common.ZeroCopySource) error {
+	chainID, eof := source.NextUint64()
+	if eof {
+		return fmt.Errorf("MultiSignPsbtParam deserialize chainID error")
+	}
+	redeemKey, eof := source.NextString()
+	if eof {
+		return fmt.Errorf("MultiSignPsbtParam deserialize redeemKey error")
+	}
+	txHash, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("MultiSignPsbtParam deserialize txHash error")
+	}
+	psbt, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("MultiSignPsbtParam deserialize psbt error")
+	}
+	this.ChainID = chainID
+	this.RedeemKey = redeemKey
+	this.TxHash = txHash
+	this.Psbt = psbt
+	return nil
+}
+
+type BumpBtcTxParam struct {
+	ChainID   uint64
+	RedeemKey string
+	TxHash    []byte
+	FeeRate   uint64
+	Address   common.Address
+}
+
+func (this *BumpBtcTxParam) Serialization(sink *common.ZeroCopySink) {
+	this.SerializeBump(sink)
+	sink.WriteVarBytes(this.Address[:])
+}
+
+// SerializeBump writes the bump without the voter, which is what consensus nodes vote for
+func (this *BumpBtcTxParam) SerializeBump(sink *common.ZeroCopySink) {
+	sink.WriteUint64(this.ChainID)
+	sink.WriteString(this.RedeemKey)
+	sink.WriteVarBytes(this.TxHash)
+	sink.WriteUint64(this.FeeRate)
+}
+
+func (this *BumpBtcTxParam) Deserialization(source *common.ZeroCopySource) error {
+	chainID, eof := source.NextUint64()
+	if eof {
+		return fmt.Errorf("BumpBtcTxParam deserialize chainID error")
+	}
+	redeemKey, eof := source.NextString()
+	if eof {
+		return fmt.Errorf("BumpBtcTxParam deserialize redeemKey error")
+	}
+	txHash, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("BumpBtcTxParam deserialize txHash error")
+	}
+	feeRate, eof := source.NextUint64()
+	if eof {
+		return fmt.Errorf("BumpBtcTxParam deserialize feeRate error")
+	}
+	address, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("BumpBtcTxParam deserialize address error")
+	}
+	addr, err := common.AddressParseFromBytes(address)
+	if err != nil {
+		return fmt.Errorf("BumpBtcTxParam, common.AddressParseFromBytes error: %v", err)
+	}
+
+	this.ChainID = chainID
+	this.RedeemKey = redeemKey
+	this.TxHash = txHash
+	this.FeeRate = feeRate
+	this.Address = addr
+	return nil
+}
+
+type SweepBtcUtxosParam struct {
+	ChainID   uint64
+	RedeemKey string
+}
+
+func (this *SweepBtcUtxosParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteUint64(this.ChainID)
+	sink.WriteString(this.RedeemKey)
+}
+
+func (this *SweepBtcUtxosParam) Deserialization(source *common.ZeroCopySource) error {
+	chainID, eof := source.NextUint64()
+	if eof {
+		return fmt.Errorf("SweepBtcUtxosParam deserialize chainID error")
+	}
+	redeemKey, eof := source.NextString()
+	if eof {
+		return fmt.Errorf("SweepBtcUtxosParam deserialize redeemKey error")
+	}
+
+	this.ChainID = chainID
+	this.RedeemKey = redeemKey
+	return nil
+}
+
+type ConsolidateBtcUtxosParam struct {
+	ChainID   uint64
+	RedeemKey string
+	MaxValue  uint64
+	FeeRate   uint64
+}
+
+func (this *ConsolidateBtcUtxosParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteUint64(this.ChainID)
+	sink.WriteString(this.RedeemKey)
+	sink.WriteUint64(this.MaxValue)
+	sink.WriteUint64(this.FeeRate)
+}
+
+func (this *ConsolidateBtcUtxosParam) Deserialization(source *common.ZeroCopySource) error {
+	chainID, eof := source.NextUint64()
+	if eof {
+		return fmt.Errorf("ConsolidateBtcUtxosParam deserialize chainID error")
+	}
+	redeemKey, eof := source.NextString()
+	if eof {
+		return fmt.Errorf("ConsolidateBtcUtxosParam deserialize redeemKey error")
+	}
+	maxValue, eof := source.NextUint64()
+	if eof {
+		return fmt.Errorf("ConsolidateBtcUtxosParam deserialize maxValue error")
+	}
+	feeRate, eof := source.NextUint64()
+	if eof {
+		return fmt.Errorf("ConsolidateBtcUtxosParam deserialize feeRate error")
+	}
+
+	this.ChainID = chainID
+	this.RedeemKey = redeemKey
+	this.MaxValue = maxValue
+	this.FeeRate = feeRate
+	return nil
+}
+
+// ReleaseBumpedBtcTxParam proves that the tx replaced by the bumped one is confirmed on bitcoin
+type ReleaseBumpedBtcTxParam struct {
+	ChainID        uint64
+	RedeemKey      string
+	TxHash         []byte
+	Tx             []byte
+	Proof          []byte
+	Height         uint32
+	RelayerAddress []byte
+}
+
+func (this *ReleaseBumpedBtcTxParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteUint64(this.ChainID)
+	sink.WriteString(this.RedeemKey)
+	sink.WriteVarBytes(this.TxHash)
+	sink.WriteVarBytes(this.Tx)
+	sink.WriteVarBytes(this.Proof)
+	sink.WriteUint32(this.Height)
+	sink.WriteVarBytes(this.RelayerAddress)
+}
+
+func (this *ReleaseBumpedBtcTxParam) Deserialization(source *common.ZeroCopySource) error {
+	chainID, eof := source.NextUint64()
+	if eof {
+		return fmt.Errorf("ReleaseBumpedBtcTxParam deserialize chainID error")
+	}
+	redeemKey, eof := source.NextString()
+	if eof {
+		return fmt.Errorf("ReleaseBumpedBtcTxParam deserialize redeemKey error")
+	}
+	txHash, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("ReleaseBumpedBtcTxParam deserialize txHash error")
+	}
+	tx, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("ReleaseBumpedBtcTxParam deserialize tx error")
+	}
+	proof, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("ReleaseBumpedBtcTxParam deserialize proof error")
+	}
+	height, eof := source.NextUint32()
+	if eof {
+		return fmt.Errorf("ReleaseBumpedBtcTxParam deserialize height error")
+	}
+	relayerAddress, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("ReleaseBumpedBtcTxParam deserialize relayerAddress error")
+	}
+
+	this.ChainID = chainID
+	this.RedeemKey = redeemKey
+	this.TxHash = txHash
+	this.Tx = tx
+	this.Proof = proof
+	this.Height = height
+	this.RelayerAddress = relayerAddress
+	return nil
+}
+
 type ToMerkleValue struct {
 	TxHash      []byte
 	FromChainID uint64
@@ -22,30 +22,119 @@ import (
 	"testing"
 )
 
-func TestVoteParam(t *testing.T) {
-	param := VoteParam{
-		Address: "1234",
-		TxHash:  []byte{1, 2, 3},
+func TestCrossChainStatus(t *testing.T) {
+	status := &CrossChainStatus{
+		FromChainID:     2,
+		ToChainID:       3,
+		CrossChainID:    []byte{1, 2, 3},
+		SourceTxHash:    []byte{4, 5, 6},
+		PolyTxHash:      common.Uint256{7, 8, 9},
+		Status:          STATUS_FORWARDED,
+		ReceivedHeight:  10,
+		VerifiedHeight:  10,
+		ForwardedHeight: 11,
 	}
+	sink := common.NewZeroCopySink(nil)
+	status.Serialization(sink)
 
+	var s CrossChainStatus
+	err := s.Deserialization(common.NewZeroCopySource(sink.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, *status, s)
+}
+
+func TestRequestIndex(t *testing.T) {
+	index := &RequestIndex{
+		TxHash: []byte{1, 2, 3},
+		Height: 100,
+	}
+	sink := common.NewZeroCopySink(nil)
+	index.Serialization(sink)
+
+	var i RequestIndex
+	err := i.Deserialization(common.NewZeroCopySource(sink.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, *index, i)
+}
+
+func TestBumpBtcTxParam(t *testing.T) {
+	param := &BumpBtcTxParam{
+		ChainID:   1,
+		RedeemKey: "c330431496364497d7257839737b5e4596f5ac06",
+		TxHash:    []byte{1, 2, 3},
+		FeeRate:   20,
+		Address:   common.ADDRESS_EMPTY,
+	}
 	sink := common.NewZeroCopySink(nil)
 	param.Serialization(sink)
 
-	var p VoteParam
+	var p BumpBtcTxParam
 	err := p.Deserialization(common.NewZeroCopySource(sink.Bytes()))
 	assert.NoError(t, err)
+	assert.Equal(t, *param, p)
 }
 
-func TestVote(t *testing.T) {
-	m := make(map[string]string, 0)
-	m["123"] = "123"
-	vote := Vote{
-		VoteMap: m,
+func TestReleaseBumpedBtcTxParam(t *testing.T) {
+	param := &ReleaseBumpedBtcTxParam{
+		ChainID:        1,
+		RedeemKey:      "c330431496364497d7257839737b5e4596f5ac06",
+		TxHash:         []byte{1, 2, 3},
+		Tx:             []byte{4, 5, 6},
+		Proof:          []byte{7, 8, 9},
+		Height:         100,
+		RelayerAddress: common.ADDRESS_EMPTY[:],
 	}
 	sink := common.NewZeroCopySink(nil)
-	vote.Serialization(sink)
+	param.Serialization(sink)
 
-	var v Vote
-	err := v.Deserialization(common.NewZeroCopySource(sink.Bytes()))
+	var p ReleaseBumpedBtcTxParam
+	err := p.Deserialization(common.NewZeroCopySource(sink.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, *param, p)
+}
+
+func TestMultiSignPsbtParam(t *testing.T) {
+	param := &MultiSignPsbtParam{
+		ChainID:   1,
+		RedeemKey: "c330431496364497d7257839737b5e4596f5ac06",
+		TxHash:    []byte{1, 2, 3},
+		Psbt:      []byte{0x70, 0x73, 0x62, 0x74, 0xff},
+	}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+
+	var p MultiSignPsbtParam
+	err := p.Deserialization(common.NewZeroCopySource(sink.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, *param, p)
+}
+
+func TestSweepBtcUtxosParam(t *testing.T) {
+	param := &SweepBtcUtxosParam{
+		ChainID:   1,
+		RedeemKey: "c330431496364497d7257839737b5e4596f5ac06",
+	}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+
+	var p SweepBtcUtxosParam
+	err := p.Deserialization(common.NewZeroCopySource(sink.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, *param, p)
+}
+
+func TestConsolidateBtcUtxosParam(t *testing.T) {
+	param := &ConsolidateBtcUtxosParam{
+		ChainID:   1,
+		RedeemKey: "c330431496364497d7257839737b5e4596f5ac06",
+		MaxValue:  5000,
+		FeeRate:   1,
+	}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+
+	var p ConsolidateBtcUtxosParam
+	err := p.Deserialization(common.NewZeroCopySource(sink.Bytes()))
 	assert.NoError(t, err)
+	assert.Equal(t, *param, p)
 }
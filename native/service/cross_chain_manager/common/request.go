@@ -0,0 +1,91 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"fmt"
+
+	"github.com/polynetwork/poly/common"
+	cstates "github.com/polynetwork/poly/core/states"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/service/utils"
+)
+
+// RequestIndex locates the request with a sequence number of a target chain
+type RequestIndex struct {
+	TxHash []byte
+	Height uint32
+}
+
+func (this *RequestIndex) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarBytes(this.TxHash)
+	sink.WriteUint32(this.Height)
+}
+
+func (this *RequestIndex) Deserialization(source *common.ZeroCopySource) error {
+	txHash, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("RequestIndex deserialize txHash error")
+	}
+	height, eof := source.NextUint32()
+	if eof {
+		return fmt.Errorf("RequestIndex deserialize height error")
+	}
+	this.TxHash = txHash
+	this.Height = height
+	return nil
+}
+
+// GetRequestSequence returns the sequence number of the next request to toChainID
+func GetRequestSequence(native *native.NativeService, toChainID uint64) (uint64, error) {
+	contract := utils.CrossChainManagerContractAddress
+	seqStore, err := native.GetCacheDB().Get(utils.ConcatKey(contract, []byte(REQUEST_SEQUENCE), utils.GetUint64Bytes(toChainID)))
+	if err != nil {
+		return 0, fmt.Errorf("GetRequestSequence, get seqStore error: %v", err)
+	}
+	if seqStore == nil {
+		return 0, nil
+	}
+	seqBytes, err := cstates.GetValueFromRawStorageItem(seqStore)
+	if err != nil {
+		return 0, fmt.Errorf("GetRequestSequence, deserialize from raw storage item err:%v", err)
+	}
+	return utils.GetBytesUint64(seqBytes), nil
+}
+
+// PutRequestIndex appends the request of txHash to the request queue of toChainID
+// and returns its sequence number
+func PutRequestIndex(native *native.NativeService, toChainID uint64, txHash []byte) (uint64, error) {
+	contract := utils.CrossChainManagerContractAddress
+	seq, err := GetRequestSequence(native, toChainID)
+	if err != nil {
+		return 0, fmt.Errorf("PutRequestIndex, %v", err)
+	}
+	chainIDBytes := utils.GetUint64Bytes(toChainID)
+	index := &RequestIndex{
+		TxHash: txHash,
+		Height: native.GetHeight(),
+	}
+	sink := common.NewZeroCopySink(nil)
+	index.Serialization(sink)
+	native.GetCacheDB().Put(utils.ConcatKey(contract, []byte(REQUEST_INDEX), chainIDBytes, utils.GetUint64Bytes(seq)),
+		cstates.GenRawStorageItem(sink.Bytes()))
+	native.GetCacheDB().Put(utils.ConcatKey(contract, []byte(REQUEST_SEQUENCE), chainIDBytes),
+		cstates.GenRawStorageItem(utils.GetUint64Bytes(seq+1)))
+	return seq, nil
+}
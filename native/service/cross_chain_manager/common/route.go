@@ -0,0 +1,247 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/polynetwork/poly/common"
+	cstates "github.com/polynetwork/poly/core/states"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/event"
+	"github.com/polynetwork/poly/native/service/utils"
+)
+
+const (
+	//key prefix
+	ROUTE_LIMIT = "RouteLimit"
+	ROUTE_USAGE = "RouteUsage"
+
+	//notify name
+	ROUTE_LIMIT_REACHED = "RouteLimitReached"
+
+	//longest epoch of a route limit in poly blocks
+	MAX_ROUTE_EPOCH_LENGTH uint32 = 1000000
+)
+
+// RouteLimit throttles messages from a chain to a contract on target chain, zero means no limit.
+// Epochs are counted in poly blocks, epoch n covers heights [n*EpochLength, (n+1)*EpochLength).
+type RouteLimit struct {
+	Paused      bool
+	MaxPerBlock uint64
+	EpochLength uint32
+	MaxPerEpoch uint64
+}
+
+func (this *RouteLimit) IsEmpty() bool {
+	return !this.Paused && this.MaxPerBlock == 0 && this.MaxPerEpoch == 0
+}
+
+// Validate rejects limits which can never trip or are inconsistent with each other
+func (this *RouteLimit) Validate() error {
+	if (this.EpochLength == 0) != (this.MaxPerEpoch == 0) {
+		return fmt.Errorf("epoch length and max per epoch must be set together")
+	}
+	if this.EpochLength > MAX_ROUTE_EPOCH_LENGTH {
+		return fmt.Errorf("epoch length %d is more than %d", this.EpochLength, MAX_ROUTE_EPOCH_LENGTH)
+	}
+	if this.MaxPerBlock != 0 && this.MaxPerEpoch != 0 {
+		if this.MaxPerBlock > this.MaxPerEpoch {
+			return fmt.Errorf("max per block %d is more than max per epoch %d", this.MaxPerBlock, this.MaxPerEpoch)
+		}
+		if this.MaxPerEpoch/uint64(this.EpochLength) >= this.MaxPerBlock {
+			return fmt.Errorf("max per epoch %d is no tighter than max per block %d in %d blocks",
+				this.MaxPerEpoch, this.MaxPerBlock, this.EpochLength)
+		}
+	}
+	return nil
+}
+
+func (this *RouteLimit) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteBool(this.Paused)
+	sink.WriteUint64(this.MaxPerBlock)
+	sink.WriteUint32(this.EpochLength)
+	sink.WriteUint64(this.MaxPerEpoch)
+}
+
+func (this *RouteLimit) Deserialization(source *common.ZeroCopySource) error {
+	var eof bool
+	this.Paused, eof = source.NextBool()
+	if eof {
+		return fmt.Errorf("RouteLimit deserialize paused error")
+	}
+	this.MaxPerBlock, eof = source.NextUint64()
+	if eof {
+		return fmt.Errorf("RouteLimit deserialize max per block error")
+	}
+	this.EpochLength, eof = source.NextUint32()
+	if eof {
+		return fmt.Errorf("RouteLimit deserialize epoch length error")
+	}
+	this.MaxPerEpoch, eof = source.NextUint64()
+	if eof {
+		return fmt.Errorf("RouteLimit deserialize max per epoch error")
+	}
+	return nil
+}
+
+// RouteUsage counts messages of a route in the latest block and epoch
+type RouteUsage struct {
+	Height     uint32
+	BlockCount uint64
+	Epoch      uint32
+	EpochCount uint64
+}
+
+func (this *RouteUsage) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteUint32(this.Height)
+	sink.WriteUint64(this.BlockCount)
+	sink.WriteUint32(this.Epoch)
+	sink.WriteUint64(this.EpochCount)
+}
+
+func (this *RouteUsage) Deserialization(source *common.ZeroCopySource) error {
+	var eof bool
+	this.Height, eof = source.NextUint32()
+	if eof {
+		return fmt.Errorf("RouteUsage deserialize height error")
+	}
+	this.BlockCount, eof = source.NextUint64()
+	if eof {
+		return fmt.Errorf("RouteUsage deserialize block count error")
+	}
+	this.Epoch, eof = source.NextUint32()
+	if eof {
+		return fmt.Errorf("RouteUsage deserialize epoch error")
+	}
+	this.EpochCount, eof = source.NextUint64()
+	if eof {
+		return fmt.Errorf("RouteUsage deserialize epoch count error")
+	}
+	return nil
+}
+
+func routeKey(prefix string, fromChainID, toChainID uint64, toContract []byte) []byte {
+	return utils.ConcatKey(utils.CrossChainManagerContractAddress, []byte(prefix), utils.GetUint64Bytes(fromChainID),
+		utils.GetUint64Bytes(toChainID), toContract)
+}
+
+// PutRouteLimit sets limit of the route, an empty limit removes it
+func PutRouteLimit(native *native.NativeService, fromChainID, toChainID uint64, toContract []byte, limit *RouteLimit) {
+	key := routeKey(ROUTE_LIMIT, fromChainID, toChainID, toContract)
+	if limit.IsEmpty() {
+		native.GetCacheDB().Delete(key)
+		return
+	}
+	sink := common.NewZeroCopySink(nil)
+	limit.Serialization(sink)
+	native.GetCacheDB().Put(key, cstates.GenRawStorageItem(sink.Bytes()))
+}
+
+func GetRouteLimit(native *native.NativeService, fromChainID, toChainID uint64, toContract []byte) (*RouteLimit, error) {
+	store, err := native.GetCacheDB().Get(routeKey(ROUTE_LIMIT, fromChainID, toChainID, toContract))
+	if err != nil {
+		return nil, fmt.Errorf("GetRouteLimit, get route limit store error: %v", err)
+	}
+	if store == nil {
+		return nil, nil
+	}
+	limitBytes, err := cstates.GetValueFromRawStorageItem(store)
+	if err != nil {
+		return nil, fmt.Errorf("GetRouteLimit, deserialize from raw storage item err:%v", err)
+	}
+	limit := new(RouteLimit)
+	if err := limit.Deserialization(common.NewZeroCopySource(limitBytes)); err != nil {
+		return nil, fmt.Errorf("GetRouteLimit, deserialize route limit error: %v", err)
+	}
+	return limit, nil
+}
+
+func getRouteUsage(native *native.NativeService, key []byte) (*RouteUsage, error) {
+	usage := new(RouteUsage)
+	store, err := native.GetCacheDB().Get(key)
+	if err != nil {
+		return nil, fmt.Errorf("getRouteUsage, get route usage store error: %v", err)
+	}
+	if store == nil {
+		return usage, nil
+	}
+	usageBytes, err := cstates.GetValueFromRawStorageItem(store)
+	if err != nil {
+		return nil, fmt.Errorf("getRouteUsage, deserialize from raw storage item err:%v", err)
+	}
+	if err := usage.Deserialization(common.NewZeroCopySource(usageBytes)); err != nil {
+		return nil, fmt.Errorf("getRouteUsage, deserialize route usage error: %v", err)
+	}
+	return usage, nil
+}
+
+// CheckRouteLimit counts the message against the limit of its route and rejects it if the route
+// is paused or the limit is exceeded. ImportExTransfer calls it for every router once the handler
+// verified the message, a rejected message fails the whole tx so it is not marked done and can be
+// relayed again later. Failed txs keep no notifies, so ROUTE_LIMIT_REACHED is notified by the accepted
+// message which fills the quota of its block or epoch, the messages after it are rejected.
+func CheckRouteLimit(native *native.NativeService, fromChainID uint64, param *MakeTxParam) error {
+	limit, err := GetRouteLimit(native, fromChainID, param.ToChainID, param.ToContractAddress)
+	if err != nil {
+		return fmt.Errorf("CheckRouteLimit, %v", err)
+	}
+	if limit == nil {
+		return nil
+	}
+	if limit.Paused {
+		return fmt.Errorf("CheckRouteLimit, route from chain %d to contract %x of chain %d is paused",
+			fromChainID, param.ToContractAddress, param.ToChainID)
+	}
+
+	key := routeKey(ROUTE_USAGE, fromChainID, param.ToChainID, param.ToContractAddress)
+	usage, err := getRouteUsage(native, key)
+	if err != nil {
+		return fmt.Errorf("CheckRouteLimit, %v", err)
+	}
+	height := native.GetHeight()
+	if usage.Height != height {
+		usage.Height, usage.BlockCount = height, 0
+	}
+	if limit.EpochLength != 0 && usage.Epoch != height/limit.EpochLength {
+		usage.Epoch, usage.EpochCount = height/limit.EpochLength, 0
+	}
+	if limit.MaxPerBlock != 0 && usage.BlockCount >= limit.MaxPerBlock {
+		return fmt.Errorf("CheckRouteLimit, route reached max %d messages of block %d", limit.MaxPerBlock, height)
+	}
+	if limit.MaxPerEpoch != 0 && usage.EpochCount >= limit.MaxPerEpoch {
+		return fmt.Errorf("CheckRouteLimit, route reached max %d messages of epoch %d", limit.MaxPerEpoch, usage.Epoch)
+	}
+	usage.BlockCount++
+	usage.EpochCount++
+	sink := common.NewZeroCopySink(nil)
+	usage.Serialization(sink)
+	native.GetCacheDB().Put(key, cstates.GenRawStorageItem(sink.Bytes()))
+
+	if (limit.MaxPerBlock != 0 && usage.BlockCount == limit.MaxPerBlock) ||
+		(limit.MaxPerEpoch != 0 && usage.EpochCount == limit.MaxPerEpoch) {
+		native.AddNotify(
+			&event.NotifyEventInfo{
+				ContractAddress: utils.CrossChainManagerContractAddress,
+				States: []interface{}{ROUTE_LIMIT_REACHED, fromChainID, param.ToChainID, hex.EncodeToString(param.ToContractAddress),
+					height, usage.BlockCount, usage.Epoch, usage.EpochCount},
+			})
+	}
+	return nil
+}
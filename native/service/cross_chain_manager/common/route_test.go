@@ -0,0 +1,80 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"testing"
+
+	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/core/store/leveldbstore"
+	"github.com/polynetwork/poly/core/store/overlaydb"
+	"github.com/polynetwork/poly/core/types"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRouteNative(db *storage.CacheDB, height uint32) *native.NativeService {
+	ns, _ := native.NewNativeService(db, new(types.Transaction), 0, height, common.Uint256{}, 0, nil, false)
+	return ns
+}
+
+func TestRouteLimitValidate(t *testing.T) {
+	assert.NoError(t, (&RouteLimit{Paused: true}).Validate())
+	assert.NoError(t, (&RouteLimit{MaxPerBlock: 2}).Validate())
+	assert.NoError(t, (&RouteLimit{MaxPerBlock: 2, EpochLength: 10, MaxPerEpoch: 3}).Validate())
+	assert.Error(t, (&RouteLimit{MaxPerEpoch: 3}).Validate())
+	assert.Error(t, (&RouteLimit{EpochLength: 10}).Validate())
+	assert.Error(t, (&RouteLimit{EpochLength: MAX_ROUTE_EPOCH_LENGTH + 1, MaxPerEpoch: 3}).Validate())
+	assert.Error(t, (&RouteLimit{MaxPerBlock: 4, EpochLength: 10, MaxPerEpoch: 3}).Validate())
+	assert.Error(t, (&RouteLimit{MaxPerBlock: 2, EpochLength: 10, MaxPerEpoch: 20}).Validate())
+}
+
+func TestCheckRouteLimit(t *testing.T) {
+	store, _ := leveldbstore.NewMemLevelDBStore()
+	db := storage.NewCacheDB(overlaydb.NewOverlayDB(store))
+	txParam := &MakeTxParam{ToChainID: 3, ToContractAddress: []byte{1, 2, 3}}
+
+	ns := newRouteNative(db, 100)
+	assert.NoError(t, CheckRouteLimit(ns, 2, txParam))
+
+	PutRouteLimit(ns, 2, 3, txParam.ToContractAddress, &RouteLimit{MaxPerBlock: 2, EpochLength: 10, MaxPerEpoch: 3})
+	assert.NoError(t, CheckRouteLimit(ns, 2, txParam))
+	assert.NoError(t, CheckRouteLimit(ns, 2, txParam))
+	assert.Error(t, CheckRouteLimit(ns, 2, txParam))
+	assert.Equal(t, 1, len(ns.GetNotify()))
+	// other routes are not limited
+	assert.NoError(t, CheckRouteLimit(ns, 2, &MakeTxParam{ToChainID: 3, ToContractAddress: []byte{4}}))
+
+	ns = newRouteNative(db, 101)
+	assert.NoError(t, CheckRouteLimit(ns, 2, txParam))
+	assert.Error(t, CheckRouteLimit(ns, 2, txParam))
+	assert.Equal(t, ROUTE_LIMIT_REACHED, ns.GetNotify()[0].States.([]interface{})[0])
+
+	ns = newRouteNative(db, 110)
+	assert.NoError(t, CheckRouteLimit(ns, 2, txParam))
+
+	PutRouteLimit(ns, 2, 3, txParam.ToContractAddress, &RouteLimit{Paused: true})
+	assert.Error(t, CheckRouteLimit(ns, 2, txParam))
+
+	PutRouteLimit(ns, 2, 3, txParam.ToContractAddress, &RouteLimit{})
+	limit, err := GetRouteLimit(ns, 2, 3, txParam.ToContractAddress)
+	assert.NoError(t, err)
+	assert.Nil(t, limit)
+	assert.NoError(t, CheckRouteLimit(ns, 2, txParam))
+}
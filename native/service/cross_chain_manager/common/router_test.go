@@ -0,0 +1,57 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// unregisterChainHandler removes router registered by tests from the global registry
+func unregisterChainHandler(router uint64) {
+	routerLock.Lock()
+	defer routerLock.Unlock()
+	delete(routers, router)
+}
+
+func TestRegisterChainHandler(t *testing.T) {
+	var router uint64 = 1000
+	_, err := GetChainHandler(router)
+	assert.Error(t, err)
+	_, ok := GetRegisteredRouters()[router]
+	assert.False(t, ok)
+	defer unregisterChainHandler(router)
+
+	RegisterChainHandler(router, "mock", func() ChainHandler { return &mockHandler{} })
+	handler, err := GetChainHandler(router)
+	assert.NoError(t, err)
+	assert.IsType(t, &mockHandler{}, handler)
+	assert.Equal(t, "mock", GetRegisteredRouters()[router])
+
+	// the returned map is a copy of the registry
+	GetRegisteredRouters()[router] = "changed"
+	assert.Equal(t, "mock", GetRegisteredRouters()[router])
+
+	assert.Panics(t, func() {
+		RegisterChainHandler(router, "mock", func() ChainHandler { return &mockHandler{} })
+	})
+	assert.Panics(t, func() {
+		RegisterChainHandler(router+1, "nil", nil)
+	})
+}
@@ -0,0 +1,189 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"fmt"
+
+	"github.com/polynetwork/poly/common"
+	cstates "github.com/polynetwork/poly/core/states"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/service/utils"
+)
+
+const (
+	// cross chain tx is received from a relayer by ImportOuterTransfer
+	STATUS_RECEIVED uint8 = 1
+	// proof of the cross chain tx is verified against the synced source chain
+	STATUS_VERIFIED uint8 = 2
+	// proof or signed tx for target chain is ready to be relayed
+	STATUS_FORWARDED uint8 = 3
+)
+
+// CrossChainStatus traces a cross chain tx, it is stored under fromChainID and
+// CrossChainID, and indexed by fromChainID and source chain tx hash, and by poly tx hash.
+// A failed verification reverts the poly tx, so a tx is received and verified at the same
+// height, while utxo target chains forward it later once the tx is fully signed.
+type CrossChainStatus struct {
+	FromChainID     uint64
+	ToChainID       uint64
+	CrossChainID    []byte
+	SourceTxHash    []byte
+	PolyTxHash      common.Uint256
+	Status          uint8
+	ReceivedHeight  uint32
+	VerifiedHeight  uint32
+	ForwardedHeight uint32
+}
+
+func (this *CrossChainStatus) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteUint64(this.FromChainID)
+	sink.WriteUint64(this.ToChainID)
+	sink.WriteVarBytes(this.CrossChainID)
+	sink.WriteVarBytes(this.SourceTxHash)
+	sink.WriteHash(this.PolyTxHash)
+	sink.WriteUint8(this.Status)
+	sink.WriteUint32(this.ReceivedHeight)
+	sink.WriteUint32(this.VerifiedHeight)
+	sink.WriteUint32(this.ForwardedHeight)
+}
+
+func (this *CrossChainStatus) Deserialization(source *common.ZeroCopySource) error {
+	fromChainID, eof := source.NextUint64()
+	if eof {
+		return fmt.Errorf("CrossChainStatus deserialize fromChainID error")
+	}
+	toChainID, eof := source.NextUint64()
+	if eof {
+		return fmt.Errorf("CrossChainStatus deserialize toChainID error")
+	}
+	crossChainID, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("CrossChainStatus deserialize crossChainID error")
+	}
+	sourceTxHash, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("CrossChainStatus deserialize sourceTxHash error")
+	}
+	polyTxHash, eof := source.NextHash()
+	if eof {
+		return fmt.Errorf("CrossChainStatus deserialize polyTxHash error")
+	}
+	status, eof := source.NextUint8()
+	if eof {
+		return fmt.Errorf("CrossChainStatus deserialize status error")
+	}
+	receivedHeight, eof := source.NextUint32()
+	if eof {
+		return fmt.Errorf("CrossChainStatus deserialize receivedHeight error")
+	}
+	verifiedHeight, eof := source.NextUint32()
+	if eof {
+		return fmt.Errorf("CrossChainStatus deserialize verifiedHeight error")
+	}
+	forwardedHeight, eof := source.NextUint32()
+	if eof {
+		return fmt.Errorf("CrossChainStatus deserialize forwardedHeight error")
+	}
+	this.FromChainID = fromChainID
+	this.ToChainID = toChainID
+	this.CrossChainID = crossChainID
+	this.SourceTxHash = sourceTxHash
+	this.PolyTxHash = polyTxHash
+	this.Status = status
+	this.ReceivedHeight = receivedHeight
+	this.VerifiedHeight = verifiedHeight
+	this.ForwardedHeight = forwardedHeight
+	return nil
+}
+
+// StatusIndex is the value of the tx hash indexes, it points to a CrossChainStatus
+func StatusIndex(fromChainID uint64, crossChainID []byte) []byte {
+	return append(utils.GetUint64Bytes(fromChainID), crossChainID...)
+}
+
+// PutCrossChainStatus stores status and its tx hash indexes
+func PutCrossChainStatus(native *native.NativeService, status *CrossChainStatus) {
+	contract := utils.CrossChainManagerContractAddress
+	sink := common.NewZeroCopySink(nil)
+	status.Serialization(sink)
+	index := StatusIndex(status.FromChainID, status.CrossChainID)
+	native.GetCacheDB().Put(utils.ConcatKey(contract, []byte(CROSS_CHAIN_STATUS), index), cstates.GenRawStorageItem(sink.Bytes()))
+	native.GetCacheDB().Put(utils.ConcatKey(contract, []byte(STATUS_BY_SOURCE_TX), utils.GetUint64Bytes(status.FromChainID),
+		status.SourceTxHash), cstates.GenRawStorageItem(index))
+	native.GetCacheDB().Put(utils.ConcatKey(contract, []byte(STATUS_BY_POLY_TX), status.PolyTxHash[:]), cstates.GenRawStorageItem(index))
+}
+
+// GetCrossChainStatus returns nil if there is no status for the cross chain tx
+func GetCrossChainStatus(native *native.NativeService, fromChainID uint64, crossChainID []byte) (*CrossChainStatus, error) {
+	return getCrossChainStatusByIndex(native, StatusIndex(fromChainID, crossChainID))
+}
+
+// GetCrossChainStatusBySourceTx returns nil if there is no status for the tx of source chain fromChainID
+func GetCrossChainStatusBySourceTx(native *native.NativeService, fromChainID uint64, sourceTxHash []byte) (*CrossChainStatus, error) {
+	contract := utils.CrossChainManagerContractAddress
+	indexStore, err := native.GetCacheDB().Get(utils.ConcatKey(contract, []byte(STATUS_BY_SOURCE_TX),
+		utils.GetUint64Bytes(fromChainID), sourceTxHash))
+	if err != nil {
+		return nil, fmt.Errorf("GetCrossChainStatusBySourceTx, get indexStore error: %v", err)
+	}
+	if indexStore == nil {
+		return nil, nil
+	}
+	index, err := cstates.GetValueFromRawStorageItem(indexStore)
+	if err != nil {
+		return nil, fmt.Errorf("GetCrossChainStatusBySourceTx, deserialize from raw storage item err:%v", err)
+	}
+	return getCrossChainStatusByIndex(native, index)
+}
+
+func getCrossChainStatusByIndex(native *native.NativeService, index []byte) (*CrossChainStatus, error) {
+	contract := utils.CrossChainManagerContractAddress
+	statusStore, err := native.GetCacheDB().Get(utils.ConcatKey(contract, []byte(CROSS_CHAIN_STATUS), index))
+	if err != nil {
+		return nil, fmt.Errorf("getCrossChainStatusByIndex, get statusStore error: %v", err)
+	}
+	if statusStore == nil {
+		return nil, nil
+	}
+	statusBytes, err := cstates.GetValueFromRawStorageItem(statusStore)
+	if err != nil {
+		return nil, fmt.Errorf("getCrossChainStatusByIndex, deserialize from raw storage item err:%v", err)
+	}
+	status := new(CrossChainStatus)
+	if err := status.Deserialization(common.NewZeroCopySource(statusBytes)); err != nil {
+		return nil, fmt.Errorf("getCrossChainStatusByIndex, deserialize status error: %v", err)
+	}
+	return status, nil
+}
+
+// MarkForwarded updates the status of the tx of source chain fromChainID to STATUS_FORWARDED
+func MarkForwarded(native *native.NativeService, fromChainID uint64, sourceTxHash []byte) error {
+	status, err := GetCrossChainStatusBySourceTx(native, fromChainID, sourceTxHash)
+	if err != nil {
+		return fmt.Errorf("MarkForwarded, %v", err)
+	}
+	if status == nil {
+		// tx imported before status tracking was introduced
+		return nil
+	}
+	status.Status = STATUS_FORWARDED
+	status.ForwardedHeight = native.GetHeight()
+	PutCrossChainStatus(native, status)
+	return nil
+}
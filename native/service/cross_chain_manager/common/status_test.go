@@ -0,0 +1,69 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"testing"
+
+	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/core/store/leveldbstore"
+	"github.com/polynetwork/poly/core/store/overlaydb"
+	"github.com/polynetwork/poly/core/types"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCrossChainStatusBySourceTx(t *testing.T) {
+	store, _ := leveldbstore.NewMemLevelDBStore()
+	db := storage.NewCacheDB(overlaydb.NewOverlayDB(store))
+	ns, _ := native.NewNativeService(db, new(types.Transaction), 0, 10, common.Uint256{}, 0, nil, false)
+
+	// evm chains share the same ccm index as source tx hash
+	sourceTxHash := []byte{0, 0, 0, 1}
+	for _, chainID := range []uint64{2, 6} {
+		PutCrossChainStatus(ns, &CrossChainStatus{
+			FromChainID:    chainID,
+			ToChainID:      3,
+			CrossChainID:   append([]byte{byte(chainID)}, sourceTxHash...),
+			SourceTxHash:   sourceTxHash,
+			PolyTxHash:     common.Uint256{byte(chainID)},
+			Status:         STATUS_VERIFIED,
+			ReceivedHeight: 10,
+			VerifiedHeight: 10,
+		})
+	}
+
+	ns, _ = native.NewNativeService(db, new(types.Transaction), 0, 20, common.Uint256{}, 0, nil, false)
+	assert.NoError(t, MarkForwarded(ns, 2, sourceTxHash))
+	status, err := GetCrossChainStatusBySourceTx(ns, 2, sourceTxHash)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), status.FromChainID)
+	assert.Equal(t, STATUS_FORWARDED, status.Status)
+	assert.Equal(t, uint32(20), status.ForwardedHeight)
+
+	status, err = GetCrossChainStatusBySourceTx(ns, 6, sourceTxHash)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(6), status.FromChainID)
+	assert.Equal(t, STATUS_VERIFIED, status.Status)
+
+	status, err = GetCrossChainStatusBySourceTx(ns, 7, sourceTxHash)
+	assert.NoError(t, err)
+	assert.Nil(t, status)
+	assert.NoError(t, MarkForwarded(ns, 7, sourceTxHash))
+}
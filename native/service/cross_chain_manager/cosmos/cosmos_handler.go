@@ -25,15 +25,21 @@ import (
 	"github.com/polynetwork/poly/native"
 	scom "github.com/polynetwork/poly/native/service/cross_chain_manager/common"
 	"github.com/polynetwork/poly/native/service/header_sync/cosmos"
+	"github.com/polynetwork/poly/native/service/utils"
 	"github.com/tendermint/tendermint/crypto"
 	"github.com/tendermint/tendermint/crypto/ed25519"
 	"github.com/tendermint/tendermint/crypto/merkle"
 	"github.com/tendermint/tendermint/crypto/multisig"
 	"github.com/tendermint/tendermint/crypto/secp256k1"
+	"time"
 )
 
 type CosmosHandler struct{}
 
+func init() {
+	scom.RegisterChainHandler(utils.COSMOS_ROUTER, "cosmos", func() scom.ChainHandler { return NewCosmosHandler() })
+}
+
 func NewCosmosHandler() *CosmosHandler {
 	return &CosmosHandler{}
 }
@@ -73,49 +79,42 @@ func (this *CosmosHandler) MakeDepositProposal(service *native.NativeService) (*
 	if len(params.HeaderOrCrossChainMsg) == 0 {
 		return nil, fmt.Errorf("you must commit the header used to verify transaction's proof and get none")
 	}
-	cdc := newCDC()
-	var myHeader cosmos.CosmosHeader
-	if err := cdc.UnmarshalBinaryBare(params.HeaderOrCrossChainMsg, &myHeader); err != nil {
+	extraInfo, err := cosmos.GetExtraInfo(service, params.SourceChainID)
+	if err != nil {
+		return nil, fmt.Errorf("Cosmos MakeDepositProposal, %v", err)
+	}
+	myHeader, err := cosmos.DecodeHeader(params.HeaderOrCrossChainMsg, extraInfo.Codec)
+	if err != nil {
 		return nil, fmt.Errorf("Cosmos MakeDepositProposal, unmarshal cosmos header failed: %v", err)
 	}
-	if myHeader.Header.Height != int64(params.Height) {
+	if myHeader.GetHeight() != int64(params.Height) {
 		return nil, fmt.Errorf("Cosmos MakeDepositProposal, "+
-			"height of your header is %d not equal to %d in parameter", myHeader.Header.Height, params.Height)
+			"height of your header is %d not equal to %d in parameter", myHeader.GetHeight(), params.Height)
 	}
-	if err = cosmos.VerifyCosmosHeader(&myHeader, info); err != nil {
+	now := time.Unix(int64(service.GetBlockTime()), 0)
+	if err = cosmos.VerifyHeader(myHeader, info, extraInfo, now); err != nil {
 		return nil, fmt.Errorf("Cosmos MakeDepositProposal, failed to verify cosmos header: %v", err)
 	}
-	if !bytes.Equal(myHeader.Header.ValidatorsHash, myHeader.Header.NextValidatorsHash) &&
-		myHeader.Header.Height > info.Height {
+	// headers verified by skipping verification are new epochs as well
+	if (!bytes.Equal(myHeader.GetValidatorsHash(), myHeader.GetNextValidatorsHash()) ||
+		!bytes.Equal(myHeader.GetValidatorsHash(), info.NextValidatorsHash)) && myHeader.GetHeight() > info.Height {
 		cosmos.PutEpochSwitchInfo(service, params.SourceChainID, &cosmos.CosmosEpochSwitchInfo{
-			Height:             myHeader.Header.Height,
-			BlockHash:          myHeader.Header.Hash(),
-			NextValidatorsHash: myHeader.Header.NextValidatorsHash,
-			ChainID:            myHeader.Header.ChainID,
+			Height:             myHeader.GetHeight(),
+			BlockHash:          myHeader.GetHash(),
+			NextValidatorsHash: myHeader.GetNextValidatorsHash(),
+			ChainID:            myHeader.GetChainID(),
+			Time:               myHeader.GetTime().Unix(),
 		})
 	}
 
-	var proofValue CosmosProofValue
-	if err = cdc.UnmarshalBinaryBare(params.Extra, &proofValue); err != nil {
-		return nil, fmt.Errorf("Cosmos MakeDepositProposal, unmarshal proof value err: %v", err)
+	var proofValue *CosmosProofValue
+	if extraInfo.Codec == cosmos.CODEC_PROTO {
+		proofValue, err = verifyProtoProof(params.Proof, params.Extra, myHeader.GetAppHash())
+	} else {
+		proofValue, err = verifyAminoProof(params.Proof, params.Extra, myHeader.GetAppHash())
 	}
-	var proof merkle.Proof
-	err = cdc.UnmarshalBinaryBare(params.Proof, &proof)
 	if err != nil {
-		return nil, fmt.Errorf("Cosmos MakeDepositProposal, unmarshal proof err: %v", err)
-	}
-	if len(proofValue.Kp) != 0 {
-		prt := rootmulti.DefaultProofRuntime()
-		err = prt.VerifyValue(&proof, myHeader.Header.AppHash, proofValue.Kp, proofValue.Value)
-		if err != nil {
-			return nil, fmt.Errorf("Cosmos MakeDepositProposal, proof error: %s", err)
-		}
-	} else {
-		prt := rootmulti.DefaultProofRuntime()
-		err = prt.VerifyAbsence(&proof, myHeader.Header.AppHash, string(proofValue.Value))
-		if err != nil {
-			return nil, fmt.Errorf("Cosmos MakeDepositProposal, proof error: %s", err)
-		}
+		return nil, fmt.Errorf("Cosmos MakeDepositProposal, %v", err)
 	}
 	data := common.NewZeroCopySource(proofValue.Value)
 	txParam := new(scom.MakeTxParam)
@@ -130,3 +129,42 @@ func (this *CosmosHandler) MakeDepositProposal(service *native.NativeService) (*
 	}
 	return txParam, nil
 }
+
+// verifyAminoProof verifies the amino encoded merkle.Proof of cosmos-sdk 0.39
+func verifyAminoProof(rawProof, extra, appHash []byte) (*CosmosProofValue, error) {
+	cdc := newCDC()
+	var proofValue CosmosProofValue
+	if err := cdc.UnmarshalBinaryBare(extra, &proofValue); err != nil {
+		return nil, fmt.Errorf("unmarshal proof value err: %v", err)
+	}
+	var proof merkle.Proof
+	if err := cdc.UnmarshalBinaryBare(rawProof, &proof); err != nil {
+		return nil, fmt.Errorf("unmarshal proof err: %v", err)
+	}
+	prt := rootmulti.DefaultProofRuntime()
+	if len(proofValue.Kp) != 0 {
+		if err := prt.VerifyValue(&proof, appHash, proofValue.Kp, proofValue.Value); err != nil {
+			return nil, fmt.Errorf("proof error: %s", err)
+		}
+	} else {
+		if err := prt.VerifyAbsence(&proof, appHash, string(proofValue.Value)); err != nil {
+			return nil, fmt.Errorf("proof error: %s", err)
+		}
+	}
+	return &proofValue, nil
+}
+
+// verifyProtoProof verifies the protobuf encoded ProofOps of ics23 proofs from cosmos-sdk 0.40 or later
+func verifyProtoProof(rawProof, extra, appHash []byte) (*CosmosProofValue, error) {
+	proofValue, err := decodeProofValue(extra)
+	if err != nil {
+		return nil, err
+	}
+	if len(proofValue.Kp) == 0 {
+		return nil, fmt.Errorf("key path is required, absence proof is not supported")
+	}
+	if err = verifyICS23Value(rawProof, appHash, proofValue.Kp, proofValue.Value); err != nil {
+		return nil, fmt.Errorf("proof error: %s", err)
+	}
+	return proofValue, nil
+}
@@ -0,0 +1,423 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cosmos
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/polynetwork/poly/native/service/header_sync/cosmos"
+	"github.com/tendermint/tendermint/crypto/merkle"
+)
+
+// types of proof ops in the ProofOps of cosmos-sdk 0.40 and later
+const (
+	PROOF_OP_IAVL   = "ics23:iavl"
+	PROOF_OP_SIMPLE = "ics23:simple"
+)
+
+// hash and length ops of ics23 used by cosmos-sdk
+const (
+	hashOpNoHash     = 0
+	hashOpSha256     = 1
+	lengthOpNoPrefix = 0
+	lengthOpVarProto = 1
+)
+
+type leafSpec struct {
+	hash, prehashKey, prehashValue, length uint64
+	prefix                                 []byte
+}
+
+type innerSpec struct {
+	childOrderLen, childSize, minPrefixLength, maxPrefixLength int
+	hash                                                       uint64
+}
+
+// proofSpec is the ProofSpec of ics23 which proofs are checked against
+type proofSpec struct {
+	leaf  leafSpec
+	inner innerSpec
+	iavl  bool
+}
+
+var (
+	iavlSpec = &proofSpec{
+		leaf:  leafSpec{hash: hashOpSha256, prehashKey: hashOpNoHash, prehashValue: hashOpSha256, length: lengthOpVarProto, prefix: []byte{0}},
+		inner: innerSpec{childOrderLen: 2, childSize: 33, minPrefixLength: 4, maxPrefixLength: 12, hash: hashOpSha256},
+		iavl:  true,
+	}
+	tendermintSpec = &proofSpec{
+		leaf:  leafSpec{hash: hashOpSha256, prehashKey: hashOpNoHash, prehashValue: hashOpSha256, length: lengthOpVarProto, prefix: []byte{0}},
+		inner: innerSpec{childOrderLen: 2, childSize: 32, minPrefixLength: 1, maxPrefixLength: 1, hash: hashOpSha256},
+	}
+)
+
+type proofOp struct {
+	Type string
+	Key  []byte
+	Data []byte
+}
+
+type leafOp struct {
+	hash, prehashKey, prehashValue, length uint64
+	prefix                                 []byte
+}
+
+type innerOp struct {
+	hash           uint64
+	prefix, suffix []byte
+}
+
+// existenceProof is the ExistenceProof of ics23
+type existenceProof struct {
+	key, value []byte
+	leaf       *leafOp
+	path       []*innerOp
+}
+
+func decodeProofOps(raw []byte) ([]*proofOp, error) {
+	ops := make([]*proofOp, 0)
+	r := cosmos.NewProtoReader(raw)
+	err := r.ReadFields(func(field, wireType uint64) (bool, error) {
+		if field != 1 {
+			return false, nil
+		}
+		raw, err := r.BytesField(wireType)
+		if err != nil {
+			return true, err
+		}
+		op := new(proofOp)
+		or := cosmos.NewProtoReader(raw)
+		err = or.ReadFields(func(field, wireType uint64) (bool, error) {
+			var (
+				err error
+				raw []byte
+			)
+			switch field {
+			case 1:
+				raw, err = or.BytesField(wireType)
+				op.Type = string(raw)
+			case 2:
+				op.Key, err = or.BytesField(wireType)
+			case 3:
+				op.Data, err = or.BytesField(wireType)
+			default:
+				return false, nil
+			}
+			return true, err
+		})
+		ops = append(ops, op)
+		return true, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("decodeProofOps, %v", err)
+	}
+	return ops, nil
+}
+
+// decodeExistenceProof decodes the CommitmentProof which must be an existence one
+func decodeExistenceProof(raw []byte) (*existenceProof, error) {
+	var proof *existenceProof
+	r := cosmos.NewProtoReader(raw)
+	err := r.ReadFields(func(field, wireType uint64) (bool, error) {
+		switch field {
+		case 1:
+			raw, err := r.BytesField(wireType)
+			if err != nil {
+				return true, err
+			}
+			proof = new(existenceProof)
+			return true, proof.decode(raw)
+		case 2, 3, 4:
+			return true, fmt.Errorf("only existence proof is supported")
+		default:
+			return false, nil
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("decodeExistenceProof, %v", err)
+	}
+	if proof == nil {
+		return nil, fmt.Errorf("decodeExistenceProof, no existence proof")
+	}
+	return proof, nil
+}
+
+func (this *existenceProof) decode(raw []byte) error {
+	r := cosmos.NewProtoReader(raw)
+	return r.ReadFields(func(field, wireType uint64) (bool, error) {
+		var (
+			err error
+			raw []byte
+		)
+		switch field {
+		case 1:
+			this.key, err = r.BytesField(wireType)
+		case 2:
+			this.value, err = r.BytesField(wireType)
+		case 3:
+			if raw, err = r.BytesField(wireType); err != nil {
+				return true, err
+			}
+			this.leaf = new(leafOp)
+			lr := cosmos.NewProtoReader(raw)
+			err = lr.ReadFields(func(field, wireType uint64) (bool, error) {
+				var err error
+				switch field {
+				case 1:
+					this.leaf.hash, err = lr.VarintField(wireType)
+				case 2:
+					this.leaf.prehashKey, err = lr.VarintField(wireType)
+				case 3:
+					this.leaf.prehashValue, err = lr.VarintField(wireType)
+				case 4:
+					this.leaf.length, err = lr.VarintField(wireType)
+				case 5:
+					this.leaf.prefix, err = lr.BytesField(wireType)
+				default:
+					return false, nil
+				}
+				return true, err
+			})
+		case 4:
+			if raw, err = r.BytesField(wireType); err != nil {
+				return true, err
+			}
+			inner := new(innerOp)
+			ir := cosmos.NewProtoReader(raw)
+			err = ir.ReadFields(func(field, wireType uint64) (bool, error) {
+				var err error
+				switch field {
+				case 1:
+					inner.hash, err = ir.VarintField(wireType)
+				case 2:
+					inner.prefix, err = ir.BytesField(wireType)
+				case 3:
+					inner.suffix, err = ir.BytesField(wireType)
+				default:
+					return false, nil
+				}
+				return true, err
+			})
+			this.path = append(this.path, inner)
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+}
+
+// checkAgainstSpec makes sure the proof is in the shape of spec, so that no inner node
+// is able to be taken as a leaf
+func (this *existenceProof) checkAgainstSpec(spec *proofSpec) error {
+	leaf := this.leaf
+	if leaf == nil {
+		return fmt.Errorf("existence proof needs leaf op")
+	}
+	if spec.iavl {
+		if err := validateIavlPrefix(leaf.prefix, 0); err != nil {
+			return fmt.Errorf("leaf, %v", err)
+		}
+	}
+	if leaf.hash != spec.leaf.hash || leaf.prehashKey != spec.leaf.prehashKey ||
+		leaf.prehashValue != spec.leaf.prehashValue || leaf.length != spec.leaf.length {
+		return fmt.Errorf("leaf op not match the spec")
+	}
+	if !bytes.HasPrefix(leaf.prefix, spec.leaf.prefix) {
+		return fmt.Errorf("leaf prefix doesn't start with %x", spec.leaf.prefix)
+	}
+	maxLeftChildBytes := (spec.inner.childOrderLen - 1) * spec.inner.childSize
+	for i, inner := range this.path {
+		if inner.hash != spec.inner.hash {
+			return fmt.Errorf("No.%d inner op has wrong hash op %d", i, inner.hash)
+		}
+		if bytes.HasPrefix(inner.prefix, spec.leaf.prefix) {
+			return fmt.Errorf("No.%d inner prefix starts with %x", i, spec.leaf.prefix)
+		}
+		if len(inner.prefix) < spec.inner.minPrefixLength ||
+			len(inner.prefix) > spec.inner.maxPrefixLength+maxLeftChildBytes {
+			return fmt.Errorf("No.%d inner prefix has wrong length %d", i, len(inner.prefix))
+		}
+		if len(inner.suffix)%spec.inner.childSize != 0 {
+			return fmt.Errorf("No.%d inner suffix has wrong length %d", i, len(inner.suffix))
+		}
+		if spec.iavl {
+			if err := validateIavlPrefix(inner.prefix, i+1); err != nil {
+				return fmt.Errorf("No.%d inner, %v", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateIavlPrefix checks the height, size and version encoded in the prefix of iavl node at layer
+func validateIavlPrefix(prefix []byte, layer int) error {
+	r := bytes.NewReader(prefix)
+	height, err := binary.ReadVarint(r)
+	if err != nil {
+		return fmt.Errorf("failed to read height: %v", err)
+	}
+	if height < int64(layer) {
+		return fmt.Errorf("wrong height %d of layer %d", height, layer)
+	}
+	size, err := binary.ReadVarint(r)
+	if err != nil || size < 0 {
+		return fmt.Errorf("wrong size %d: %v", size, err)
+	}
+	version, err := binary.ReadVarint(r)
+	if err != nil || version < 0 {
+		return fmt.Errorf("wrong version %d: %v", version, err)
+	}
+	if layer == 0 && r.Len() != 0 {
+		return fmt.Errorf("unexpected %d bytes left in leaf prefix", r.Len())
+	}
+	return nil
+}
+
+func doHash(op uint64, data []byte) ([]byte, error) {
+	switch op {
+	case hashOpNoHash:
+		return data, nil
+	case hashOpSha256:
+		hash := sha256.Sum256(data)
+		return hash[:], nil
+	default:
+		return nil, fmt.Errorf("unsupported hash op %d", op)
+	}
+}
+
+func prepareLeafData(prehash, length uint64, data []byte) ([]byte, error) {
+	hashed, err := doHash(prehash, data)
+	if err != nil {
+		return nil, err
+	}
+	switch length {
+	case lengthOpNoPrefix:
+		return hashed, nil
+	case lengthOpVarProto:
+		var buf [binary.MaxVarintLen64]byte
+		n := binary.PutUvarint(buf[:], uint64(len(hashed)))
+		return append(buf[:n], hashed...), nil
+	default:
+		return nil, fmt.Errorf("unsupported length op %d", length)
+	}
+}
+
+// calculate returns the root hash committing the key and value of proof
+func (this *existenceProof) calculate() ([]byte, error) {
+	if len(this.key) == 0 || len(this.value) == 0 {
+		return nil, fmt.Errorf("leaf op needs key and value")
+	}
+	pkey, err := prepareLeafData(this.leaf.prehashKey, this.leaf.length, this.key)
+	if err != nil {
+		return nil, err
+	}
+	pvalue, err := prepareLeafData(this.leaf.prehashValue, this.leaf.length, this.value)
+	if err != nil {
+		return nil, err
+	}
+	data := append(append(append([]byte{}, this.leaf.prefix...), pkey...), pvalue...)
+	res, err := doHash(this.leaf.hash, data)
+	if err != nil {
+		return nil, err
+	}
+	for _, inner := range this.path {
+		data := append(append(append([]byte{}, inner.prefix...), res...), inner.suffix...)
+		if res, err = doHash(inner.hash, data); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// verifyICS23Value verifies the value of key path is committed in root by the protobuf encoded ProofOps,
+// same as ProofRuntime.VerifyValue of cosmos-sdk 0.40 with existence proofs
+func verifyICS23Value(proof []byte, root []byte, keyPath string, value []byte) error {
+	ops, err := decodeProofOps(proof)
+	if err != nil {
+		return err
+	}
+	keys, err := merkle.KeyPathToKeys(keyPath)
+	if err != nil {
+		return fmt.Errorf("verifyICS23Value, wrong key path: %v", err)
+	}
+	if len(ops) == 0 || len(ops) != len(keys) {
+		return fmt.Errorf("verifyICS23Value, %d proof ops for %d keys", len(ops), len(keys))
+	}
+	for i, op := range ops {
+		key := keys[len(keys)-1-i]
+		if !bytes.Equal(key, op.Key) {
+			return fmt.Errorf("verifyICS23Value, key mismatch on No.%d op: %x, %x", i, key, op.Key)
+		}
+		var spec *proofSpec
+		switch op.Type {
+		case PROOF_OP_IAVL:
+			spec = iavlSpec
+		case PROOF_OP_SIMPLE:
+			spec = tendermintSpec
+		default:
+			return fmt.Errorf("verifyICS23Value, unsupported type %s of No.%d op", op.Type, i)
+		}
+		p, err := decodeExistenceProof(op.Data)
+		if err != nil {
+			return fmt.Errorf("verifyICS23Value, No.%d op: %v", i, err)
+		}
+		if !bytes.Equal(p.key, op.Key) || !bytes.Equal(p.value, value) {
+			return fmt.Errorf("verifyICS23Value, key or value of No.%d op not match", i)
+		}
+		if err = p.checkAgainstSpec(spec); err != nil {
+			return fmt.Errorf("verifyICS23Value, No.%d op: %v", i, err)
+		}
+		if value, err = p.calculate(); err != nil {
+			return fmt.Errorf("verifyICS23Value, failed to calculate No.%d op: %v", i, err)
+		}
+	}
+	if !bytes.Equal(root, value) {
+		return fmt.Errorf("verifyICS23Value, calculated root hash %x is not %x", value, root)
+	}
+	return nil
+}
+
+// decodeProofValue decodes the protobuf encoded CosmosProofValue, fields of Kp and Value are 1 and 2
+func decodeProofValue(raw []byte) (*CosmosProofValue, error) {
+	value := new(CosmosProofValue)
+	r := cosmos.NewProtoReader(raw)
+	err := r.ReadFields(func(field, wireType uint64) (bool, error) {
+		var (
+			err error
+			raw []byte
+		)
+		switch field {
+		case 1:
+			raw, err = r.BytesField(wireType)
+			value.Kp = string(raw)
+		case 2:
+			value.Value, err = r.BytesField(wireType)
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("decodeProofValue, %v", err)
+	}
+	return value, nil
+}
@@ -0,0 +1,163 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cosmos
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/tendermint/crypto/merkle"
+	"testing"
+)
+
+func pbKey(field uint64, wireType uint64) []byte {
+	return pbUvarint(field<<3 | wireType)
+}
+
+func pbUvarint(v uint64) []byte {
+	buf := make([]byte, binary.MaxVarintLen64)
+	return buf[:binary.PutUvarint(buf, v)]
+}
+
+func pbBytes(field uint64, v []byte) []byte {
+	buf := append(pbKey(field, 2), pbUvarint(uint64(len(v)))...)
+	return append(buf, v...)
+}
+
+func pbVarint(field uint64, v uint64) []byte {
+	return append(pbKey(field, 0), pbUvarint(v)...)
+}
+
+func concat(bzs ...[]byte) []byte {
+	res := make([]byte, 0)
+	for _, v := range bzs {
+		res = append(res, v...)
+	}
+	return res
+}
+
+func sha256Sum(bz []byte) []byte {
+	hash := sha256.Sum256(bz)
+	return hash[:]
+}
+
+func encodeExistenceProof(key, value, leafPrefix []byte, path [][2][]byte) []byte {
+	leaf := concat(pbVarint(1, hashOpSha256), pbVarint(3, hashOpSha256), pbVarint(4, lengthOpVarProto),
+		pbBytes(5, leafPrefix))
+	proof := concat(pbBytes(1, key), pbBytes(2, value), pbBytes(3, leaf))
+	for _, inner := range path {
+		proof = append(proof, pbBytes(4, concat(pbVarint(1, hashOpSha256), pbBytes(2, inner[0]), pbBytes(3, inner[1])))...)
+	}
+	return pbBytes(1, proof)
+}
+
+func leafHash(prefix, key, value []byte) []byte {
+	return sha256Sum(concat(prefix, pbUvarint(uint64(len(key))), key, pbUvarint(32), sha256Sum(value)))
+}
+
+type testProof struct {
+	key, value, storeRoot, appHash []byte
+	iavlLeafPrefix                 []byte
+	iavlPath, simplePath           [][2][]byte
+}
+
+func newTestProof() *testProof {
+	p := &testProof{
+		key:            []byte("ccm-key"),
+		value:          []byte("cross chain value"),
+		iavlLeafPrefix: []byte{0x00, 0x02, 0x0a}, // height 0, size 1, version 5
+	}
+	// the leaf is the left child of root of iavl tree
+	sibling := sha256Sum([]byte("right"))
+	p.iavlPath = [][2][]byte{{{0x02, 0x04, 0x0a, 0x20}, concat([]byte{0x20}, sibling)}}
+	p.storeRoot = sha256Sum(concat(p.iavlPath[0][0], leafHash(p.iavlLeafPrefix, p.key, p.value), p.iavlPath[0][1]))
+
+	// the store is the right one of two stores in simple tree
+	left := []byte("left store")
+	right := concat(pbUvarint(3), []byte("ccm"), pbUvarint(32), sha256Sum(p.storeRoot))
+	p.appHash = merkle.SimpleHashFromByteSlices([][]byte{left, right})
+	p.simplePath = [][2][]byte{{concat([]byte{0x01}, sha256Sum(concat([]byte{0x00}, left))), nil}}
+	return p
+}
+
+func (this *testProof) encode(iavlType string) []byte {
+	iavl := encodeExistenceProof(this.key, this.value, this.iavlLeafPrefix, this.iavlPath)
+	simple := encodeExistenceProof([]byte("ccm"), this.storeRoot, []byte{0x00}, this.simplePath)
+	return concat(
+		pbBytes(1, concat(pbBytes(1, []byte(iavlType)), pbBytes(2, this.key), pbBytes(3, iavl))),
+		pbBytes(1, concat(pbBytes(1, []byte(PROOF_OP_SIMPLE)), pbBytes(2, []byte("ccm")), pbBytes(3, simple))),
+	)
+}
+
+func (this *testProof) keyPath() string {
+	return merkle.KeyPath{}.AppendKey([]byte("ccm"), merkle.KeyEncodingURL).
+		AppendKey(this.key, merkle.KeyEncodingHex).String()
+}
+
+func TestVerifyICS23Value(t *testing.T) {
+	p := newTestProof()
+	assert.NoError(t, verifyICS23Value(p.encode(PROOF_OP_IAVL), p.appHash, p.keyPath(), p.value))
+
+	assert.Error(t, verifyICS23Value(p.encode(PROOF_OP_IAVL), p.appHash, p.keyPath(), []byte("fake value")))
+	assert.Error(t, verifyICS23Value(p.encode(PROOF_OP_IAVL), sha256Sum([]byte("fake root")), p.keyPath(), p.value))
+	assert.Error(t, verifyICS23Value(p.encode("ics23:unknown"), p.appHash, p.keyPath(), p.value))
+	assert.Error(t, verifyICS23Value(p.encode(PROOF_OP_IAVL), p.appHash,
+		merkle.KeyPath{}.AppendKey([]byte("ccm"), merkle.KeyEncodingURL).
+			AppendKey([]byte("other-key"), merkle.KeyEncodingHex).String(), p.value))
+
+	// the iavl leaf must be at height 0 without anything else in prefix
+	p.iavlLeafPrefix = []byte{0x00, 0x02, 0x0a, 0x00}
+	assert.Error(t, verifyICS23Value(p.encode(PROOF_OP_IAVL), p.appHash, p.keyPath(), p.value))
+
+	// inner nodes can not start with the prefix of leaf
+	p = newTestProof()
+	p.iavlPath[0][0] = []byte{0x00, 0x04, 0x0a, 0x20}
+	assert.Error(t, verifyICS23Value(p.encode(PROOF_OP_IAVL), p.appHash, p.keyPath(), p.value))
+
+	// suffix not in size of children
+	p = newTestProof()
+	p.iavlPath[0][1] = append(p.iavlPath[0][1], 0x00)
+	assert.Error(t, verifyICS23Value(p.encode(PROOF_OP_IAVL), p.appHash, p.keyPath(), p.value))
+}
+
+func TestDecodeExistenceProof(t *testing.T) {
+	p := newTestProof()
+	raw := encodeExistenceProof(p.key, p.value, p.iavlLeafPrefix, p.iavlPath)
+	proof, err := decodeExistenceProof(raw)
+	assert.NoError(t, err)
+	assert.Equal(t, p.key, proof.key)
+	assert.Equal(t, 1, len(proof.path))
+
+	// non-existence proof
+	_, err = decodeExistenceProof(pbBytes(2, raw[2:]))
+	assert.Error(t, err)
+	_, err = decodeExistenceProof(raw[:len(raw)-1])
+	assert.Error(t, err)
+}
+
+func TestDecodeProofValue(t *testing.T) {
+	value, err := decodeProofValue(concat(pbBytes(1, []byte("/ccm/x:00")), pbBytes(2, []byte{1, 2, 3})))
+	assert.NoError(t, err)
+	assert.Equal(t, "/ccm/x:00", value.Kp)
+	assert.Equal(t, []byte{1, 2, 3}, value.Value)
+
+	// same as the amino encoding
+	aminoValue := new(CosmosProofValue)
+	assert.NoError(t, newCDC().UnmarshalBinaryBare(concat(pbBytes(1, []byte("/ccm/x:00")), pbBytes(2, []byte{1, 2, 3})), aminoValue))
+	assert.Equal(t, value, aminoValue)
+}
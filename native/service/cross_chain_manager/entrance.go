@@ -19,27 +19,41 @@ package cross_chain_manager
 import (
 	"encoding/hex"
 	"fmt"
-	"github.com/polynetwork/poly/native/service/cross_chain_manager/quorum"
-	"github.com/polynetwork/poly/native/service/cross_chain_manager/heco"
-	"github.com/polynetwork/poly/native/service/governance/node_manager"
+
 	"github.com/polynetwork/poly/common"
 	"github.com/polynetwork/poly/native"
-	"github.com/polynetwork/poly/native/service/cross_chain_manager/bsc"
+	"github.com/polynetwork/poly/native/event"
 	"github.com/polynetwork/poly/native/service/cross_chain_manager/btc"
 	scom "github.com/polynetwork/poly/native/service/cross_chain_manager/common"
-	"github.com/polynetwork/poly/native/service/cross_chain_manager/cosmos"
-	"github.com/polynetwork/poly/native/service/cross_chain_manager/eth"
-	"github.com/polynetwork/poly/native/service/cross_chain_manager/neo"
-	"github.com/polynetwork/poly/native/service/cross_chain_manager/ont"
+	"github.com/polynetwork/poly/native/service/governance/node_manager"
+	"github.com/polynetwork/poly/native/service/governance/relayer_manager"
 	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
+	hsbtc "github.com/polynetwork/poly/native/service/header_sync/btc"
 	"github.com/polynetwork/poly/native/service/utils"
+
+	// built-in routers register their chain handlers in init
+	_ "github.com/polynetwork/poly/native/service/cross_chain_manager/bsc"
+	_ "github.com/polynetwork/poly/native/service/cross_chain_manager/cosmos"
+	_ "github.com/polynetwork/poly/native/service/cross_chain_manager/eth"
+	_ "github.com/polynetwork/poly/native/service/cross_chain_manager/evm"
+	_ "github.com/polynetwork/poly/native/service/cross_chain_manager/heco"
+	_ "github.com/polynetwork/poly/native/service/cross_chain_manager/neo"
+	_ "github.com/polynetwork/poly/native/service/cross_chain_manager/neo3"
+	_ "github.com/polynetwork/poly/native/service/cross_chain_manager/ont"
+	_ "github.com/polynetwork/poly/native/service/cross_chain_manager/quorum"
 )
 
 const (
 	IMPORT_OUTER_TRANSFER_NAME = "ImportOuterTransfer"
 	MULTI_SIGN                 = "MultiSign"
+	MULTI_SIGN_PSBT            = "MultiSignPsbt"
+	BUMP_BTC_TX                = "BumpBtcTx"
+	RELEASE_BUMPED_BTC_TX      = "ReleaseBumpedBtcTx"
+	SWEEP_BTC_UTXOS            = "SweepBtcUtxos"
+	CONSOLIDATE_BTC_UTXOS      = "ConsolidateBtcUtxos"
 	BLACK_CHAIN                = "BlackChain"
 	WHITE_CHAIN                = "WhiteChain"
+	SET_ROUTE_LIMIT            = "SetRouteLimit"
 
 	BLACKED_CHAIN = "BlackedChain"
 )
@@ -47,32 +61,20 @@ const (
 func RegisterCrossChainManagerContract(native *native.NativeService) {
 	native.Register(IMPORT_OUTER_TRANSFER_NAME, ImportExTransfer)
 	native.Register(MULTI_SIGN, MultiSign)
+	native.Register(MULTI_SIGN_PSBT, MultiSignPsbt)
+	native.Register(BUMP_BTC_TX, BumpBtcTx)
+	native.Register(RELEASE_BUMPED_BTC_TX, ReleaseBumpedBtcTx)
+	native.Register(SWEEP_BTC_UTXOS, SweepBtcUtxos)
+	native.Register(CONSOLIDATE_BTC_UTXOS, ConsolidateBtcUtxos)
 
 	native.Register(BLACK_CHAIN, BlackChain)
 	native.Register(WHITE_CHAIN, WhiteChain)
+	native.Register(SET_ROUTE_LIMIT, SetRouteLimit)
 }
 
+// GetChainHandler returns the handler registered for router by the chain packages
 func GetChainHandler(router uint64) (scom.ChainHandler, error) {
-	switch router {
-	case utils.BTC_ROUTER:
-		return btc.NewBTCHandler(), nil
-	case utils.ETH_ROUTER:
-		return eth.NewETHHandler(), nil
-	case utils.ONT_ROUTER:
-		return ont.NewONTHandler(), nil
-	case utils.NEO_ROUTER:
-		return neo.NewNEOHandler(), nil
-	case utils.COSMOS_ROUTER:
-		return cosmos.NewCosmosHandler(), nil
-	case utils.QUORUM_ROUTER:
-		return quorum.NewQuorumHandler(), nil
-	case utils.BSC_ROUTER:
-		return bsc.NewHandler(), nil
-	case utils.HECO_ROUTER:
-		return heco.NewHecoHandler(), nil
-	default:
-		return nil, fmt.Errorf("not a supported router:%d", router)
-	}
+	return scom.GetChainHandler(router)
 }
 
 func ImportExTransfer(native *native.NativeService) ([]byte, error) {
@@ -99,15 +101,28 @@ func ImportExTransfer(native *native.NativeService) ([]byte, error) {
 		return utils.BYTE_FALSE, fmt.Errorf("ImportExTransfer, side chain %d is not registered", chainID)
 	}
 
+	//check if relayer is allowed to relay for source chain
+	if err := relayer_manager.CheckRelayer(native, chainID, params.RelayerAddress); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("ImportExTransfer, relayer_manager.CheckRelayer error: %v", err)
+	}
+
 	handler, err := GetChainHandler(sideChain.Router)
 	if err != nil {
 		return utils.BYTE_FALSE, err
 	}
+	//check if proof height is deep enough under the synced tip of source chain
+	if err := scom.CheckFinality(native, handler, chainID, sideChain.BlocksToWait, params.Height); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("ImportExTransfer, %v", err)
+	}
 	//1. verify tx
 	txParam, err := handler.MakeDepositProposal(native)
 	if err != nil {
 		return utils.BYTE_FALSE, err
 	}
+	//check the route limit, a throttled tx fails as a whole so it is not marked done by the handler
+	if err := scom.CheckRouteLimit(native, chainID, txParam); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("ImportExTransfer, %v", err)
+	}
 
 	//2. make target chain tx
 	targetid := txParam.ToChainID
@@ -127,11 +142,23 @@ func ImportExTransfer(native *native.NativeService) ([]byte, error) {
 	if sideChain == nil {
 		return utils.BYTE_FALSE, fmt.Errorf("ImportExTransfer, side chain %d is not registered", targetid)
 	}
-	if sideChain.Router == utils.BTC_ROUTER {
+	status := &scom.CrossChainStatus{
+		FromChainID:    chainID,
+		ToChainID:      targetid,
+		CrossChainID:   txParam.CrossChainID,
+		SourceTxHash:   txParam.TxHash,
+		PolyTxHash:     native.GetTx().Hash(),
+		Status:         scom.STATUS_VERIFIED,
+		ReceivedHeight: native.GetHeight(),
+		VerifiedHeight: native.GetHeight(),
+	}
+	if hsbtc.IsUtxoRouter(sideChain.Router) {
 		err := btc.NewBTCHandler().MakeTransaction(native, txParam, chainID)
 		if err != nil {
 			return utils.BYTE_FALSE, err
 		}
+		// forwarded once the btc tx is fully signed in MultiSign
+		scom.PutCrossChainStatus(native, status)
 		return utils.BYTE_TRUE, nil
 	}
 
@@ -140,6 +167,9 @@ func ImportExTransfer(native *native.NativeService) ([]byte, error) {
 	if err != nil {
 		return utils.BYTE_FALSE, err
 	}
+	status.Status = scom.STATUS_FORWARDED
+	status.ForwardedHeight = native.GetHeight()
+	scom.PutCrossChainStatus(native, status)
 	return utils.BYTE_TRUE, nil
 }
 
@@ -154,6 +184,120 @@ func MultiSign(native *native.NativeService) ([]byte, error) {
 	return utils.BYTE_TRUE, nil
 }
 
+// MultiSignPsbt takes the partial signatures of a psbt as MultiSign of each signer
+func MultiSignPsbt(native *native.NativeService) ([]byte, error) {
+	err := btc.NewBTCHandler().MultiSignPsbt(native)
+	if err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	return utils.BYTE_TRUE, nil
+}
+
+// BumpBtcTx lets consensus nodes replace a stuck btc transaction by one paying a higher fee rate,
+// the replacement is made once enough of them voted for the same fee rate
+func BumpBtcTx(native *native.NativeService) ([]byte, error) {
+	params := new(scom.BumpBtcTxParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("BumpBtcTx, contract params deserialize error: %v", err)
+	}
+	//check witness
+	err := utils.ValidateOwner(native, params.Address)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("BumpBtcTx, checkWitness error: %v", err)
+	}
+
+	//check consensus signs
+	sink := common.NewZeroCopySink(nil)
+	params.SerializeBump(sink)
+	ok, err := node_manager.CheckConsensusSigns(native, BUMP_BTC_TX, sink.Bytes(), params.Address)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("BumpBtcTx, CheckConsensusSigns error: %v", err)
+	}
+	if !ok {
+		return utils.BYTE_TRUE, nil
+	}
+
+	err = btc.NewBTCHandler().BumpTransaction(native)
+	if err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	return utils.BYTE_TRUE, nil
+}
+
+// ReleaseBumpedBtcTx lets relayers prove that a btc tx replaced by a bump is confirmed after all,
+// so the bumped tx which can't be confirmed anymore gives back the utxos it holds
+func ReleaseBumpedBtcTx(native *native.NativeService) ([]byte, error) {
+	params := new(scom.ReleaseBumpedBtcTxParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("ReleaseBumpedBtcTx, contract params deserialize error: %v", err)
+	}
+
+	sideChain, err := side_chain_manager.GetSideChain(native, params.ChainID)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("ReleaseBumpedBtcTx, side_chain_manager.GetSideChain error: %v", err)
+	}
+	if sideChain == nil || sideChain.Router != utils.BTC_ROUTER {
+		return utils.BYTE_FALSE, fmt.Errorf("ReleaseBumpedBtcTx, chain %d is not a registered btc chain", params.ChainID)
+	}
+	//check if relayer is allowed to relay for the chain
+	if err := relayer_manager.CheckRelayer(native, params.ChainID, params.RelayerAddress); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("ReleaseBumpedBtcTx, relayer_manager.CheckRelayer error: %v", err)
+	}
+	handler := btc.NewBTCHandler()
+	//check if proof height is deep enough under the synced tip of the chain
+	if err := scom.CheckFinality(native, handler, params.ChainID, sideChain.BlocksToWait, params.Height); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("ReleaseBumpedBtcTx, %v", err)
+	}
+
+	err = handler.ReleaseBumpedTransaction(native)
+	if err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	return utils.BYTE_TRUE, nil
+}
+
+// SweepBtcUtxos lets the operator move utxos of a rotated redeem to its successor
+func SweepBtcUtxos(native *native.NativeService) ([]byte, error) {
+	// Get current epoch operator
+	operatorAddress, err := node_manager.GetCurConOperator(native)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SweepBtcUtxos, get current consensus operator address error: %v", err)
+	}
+
+	//check witness
+	err = utils.ValidateOwner(native, operatorAddress)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SweepBtcUtxos, checkWitness error: %v", err)
+	}
+
+	err = btc.NewBTCHandler().SweepUtxos(native)
+	if err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	return utils.BYTE_TRUE, nil
+}
+
+// ConsolidateBtcUtxos lets the operator merge dust utxos of a redeem when fees are low
+func ConsolidateBtcUtxos(native *native.NativeService) ([]byte, error) {
+	// Get current epoch operator
+	operatorAddress, err := node_manager.GetCurConOperator(native)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("ConsolidateBtcUtxos, get current consensus operator address error: %v", err)
+	}
+
+	//check witness
+	err = utils.ValidateOwner(native, operatorAddress)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("ConsolidateBtcUtxos, checkWitness error: %v", err)
+	}
+
+	err = btc.NewBTCHandler().ConsolidateUtxos(native)
+	if err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	return utils.BYTE_TRUE, nil
+}
+
 func MakeTransaction(service *native.NativeService, params *scom.MakeTxParam, fromChainID uint64) error {
 	txHash := service.GetTx().Hash()
 	merkleValue := &scom.ToMerkleValue{
@@ -179,6 +323,9 @@ func PutRequest(native *native.NativeService, txHash []byte, chainID uint64, req
 	contract := utils.CrossChainManagerContractAddress
 	chainIDBytes := utils.GetUint64Bytes(chainID)
 	utils.PutBytes(native, utils.ConcatKey(contract, []byte(scom.REQUEST), chainIDBytes, txHash), request)
+	if _, err := scom.PutRequestIndex(native, chainID, txHash); err != nil {
+		return fmt.Errorf("PutRequest, %v", err)
+	}
 	return nil
 }
 
@@ -223,3 +370,50 @@ func WhiteChain(native *native.NativeService) ([]byte, error) {
 	RemoveBlackChain(native, params.ChainID)
 	return utils.BYTE_TRUE, nil
 }
+
+func SetRouteLimit(native *native.NativeService) ([]byte, error) {
+	params := new(RouteLimitParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SetRouteLimit, contract params deserialize error: %v", err)
+	}
+	//check witness
+	err := utils.ValidateOwner(native, params.Address)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SetRouteLimit, checkWitness error: %v", err)
+	}
+	if len(params.ToContract) == 0 {
+		return utils.BYTE_FALSE, fmt.Errorf("SetRouteLimit, to contract is empty")
+	}
+	if err := params.Limit.Validate(); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SetRouteLimit, invalid limit: %v", err)
+	}
+	for _, chainID := range []uint64{params.FromChainID, params.ToChainID} {
+		sideChain, err := side_chain_manager.GetSideChain(native, chainID)
+		if err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("SetRouteLimit, side_chain_manager.GetSideChain error: %v", err)
+		}
+		if sideChain == nil {
+			return utils.BYTE_FALSE, fmt.Errorf("SetRouteLimit, side chain %d is not registered", chainID)
+		}
+	}
+
+	//check consensus signs
+	sink := common.NewZeroCopySink(nil)
+	params.serializeRoute(sink)
+	ok, err := node_manager.CheckConsensusSigns(native, SET_ROUTE_LIMIT, sink.Bytes(), params.Address)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SetRouteLimit, CheckConsensusSigns error: %v", err)
+	}
+	if !ok {
+		return utils.BYTE_TRUE, nil
+	}
+
+	scom.PutRouteLimit(native, params.FromChainID, params.ToChainID, params.ToContract, params.Limit)
+	native.AddNotify(
+		&event.NotifyEventInfo{
+			ContractAddress: utils.CrossChainManagerContractAddress,
+			States: []interface{}{SET_ROUTE_LIMIT, params.FromChainID, params.ToChainID, hex.EncodeToString(params.ToContract),
+				params.Limit.Paused, params.Limit.MaxPerBlock, params.Limit.EpochLength, params.Limit.MaxPerEpoch},
+		})
+	return utils.BYTE_TRUE, nil
+}
@@ -22,11 +22,17 @@ import (
 	"github.com/polynetwork/poly/native"
 	scom "github.com/polynetwork/poly/native/service/cross_chain_manager/common"
 	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
+	"github.com/polynetwork/poly/native/service/header_sync/eth"
+	"github.com/polynetwork/poly/native/service/utils"
 )
 
 type ETHHandler struct {
 }
 
+func init() {
+	scom.RegisterChainHandler(utils.ETH_ROUTER, "eth", func() scom.ChainHandler { return NewETHHandler() })
+}
+
 func NewETHHandler() *ETHHandler {
 	return &ETHHandler{}
 }
@@ -54,3 +60,12 @@ func (this *ETHHandler) MakeDepositProposal(service *native.NativeService) (*sco
 	}
 	return value, nil
 }
+
+// GetSyncedHeight returns the height of current header on main chain
+func (this *ETHHandler) GetSyncedHeight(service *native.NativeService, chainID uint64) (uint64, bool, error) {
+	height, err := eth.GetCurrentHeaderHeight(service, chainID)
+	if err != nil {
+		return 0, false, fmt.Errorf("eth GetSyncedHeight, %v", err)
+	}
+	return height, true, nil
+}
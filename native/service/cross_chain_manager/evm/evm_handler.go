@@ -0,0 +1,109 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package evm
+
+import (
+	"fmt"
+
+	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/service/cross_chain_manager/bsc"
+	scom "github.com/polynetwork/poly/native/service/cross_chain_manager/common"
+	"github.com/polynetwork/poly/native/service/cross_chain_manager/eth"
+	"github.com/polynetwork/poly/native/service/cross_chain_manager/heco"
+	"github.com/polynetwork/poly/native/service/cross_chain_manager/quorum"
+	hsbsc "github.com/polynetwork/poly/native/service/header_sync/bsc"
+	hseth "github.com/polynetwork/poly/native/service/header_sync/eth"
+	"github.com/polynetwork/poly/native/service/header_sync/evm"
+	hsheco "github.com/polynetwork/poly/native/service/header_sync/heco"
+	"github.com/polynetwork/poly/native/service/utils"
+)
+
+// Handler verifies cross chain txs of evm compatible chains against the headers synced by
+// the engine chosen by the consensus in the ExtraInfo of the side chain
+type Handler struct {
+}
+
+func init() {
+	scom.RegisterChainHandler(utils.EVM_ROUTER, "evm", func() scom.ChainHandler { return NewHandler() })
+}
+
+// NewHandler ...
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// MakeDepositProposal ...
+func (h *Handler) MakeDepositProposal(service *native.NativeService) (*scom.MakeTxParam, error) {
+	params := new(scom.EntranceParam)
+	if err := params.Deserialization(common.NewZeroCopySource(service.GetInput())); err != nil {
+		return nil, fmt.Errorf("evm MakeDepositProposal, contract params deserialize error: %s", err)
+	}
+	extraInfo, err := evm.GetExtraInfo(service, params.SourceChainID)
+	if err != nil {
+		return nil, fmt.Errorf("evm MakeDepositProposal, %v", err)
+	}
+
+	var handler scom.ChainHandler
+	switch extraInfo.Consensus {
+	case evm.CONSENSUS_ETHASH:
+		handler = eth.NewETHHandler()
+	case evm.CONSENSUS_PARLIA:
+		handler = bsc.NewHandler()
+	case evm.CONSENSUS_CONGRESS:
+		handler = heco.NewHecoHandler()
+	case evm.CONSENSUS_ISTANBUL:
+		handler = quorum.NewQuorumHandler()
+	default:
+		return nil, fmt.Errorf("evm MakeDepositProposal, not a supported consensus: %s", extraInfo.Consensus)
+	}
+	return handler.MakeDepositProposal(service)
+}
+
+// GetSyncedHeight returns the canonical tip of the chain, istanbul chains have none
+// since their blocks are final once committed
+func (h *Handler) GetSyncedHeight(service *native.NativeService, chainID uint64) (uint64, bool, error) {
+	extraInfo, err := evm.GetExtraInfo(service, chainID)
+	if err != nil {
+		return 0, false, fmt.Errorf("evm GetSyncedHeight, %v", err)
+	}
+	var height uint64
+	switch extraInfo.Consensus {
+	case evm.CONSENSUS_ETHASH:
+		height, err = hseth.GetCurrentHeaderHeight(service, chainID)
+	case evm.CONSENSUS_PARLIA:
+		height, err = hsbsc.GetCanonicalHeight(service, chainID)
+	case evm.CONSENSUS_CONGRESS:
+		height, err = hsheco.GetCanonicalHeight(service, chainID)
+	default:
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("evm GetSyncedHeight, get current height error: %v", err)
+	}
+	return height, true, nil
+}
+
+// GetConfirmations returns the confirmations set in the ExtraInfo of the chain
+func (h *Handler) GetConfirmations(service *native.NativeService, chainID uint64) (uint64, error) {
+	extraInfo, err := evm.GetExtraInfo(service, chainID)
+	if err != nil {
+		return 0, fmt.Errorf("evm GetConfirmations, %v", err)
+	}
+	return extraInfo.Confirmations, nil
+}
@@ -22,6 +22,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"github.com/polynetwork/poly/native/service/utils"
 	"math/big"
 
 	ecommon "github.com/ethereum/go-ethereum/common"
@@ -42,6 +43,10 @@ import (
 type HecoHandler struct {
 }
 
+func init() {
+	scom.RegisterChainHandler(utils.HECO_ROUTER, "heco", func() scom.ChainHandler { return NewHecoHandler() })
+}
+
 // NewHandler ...
 func NewHecoHandler() *HecoHandler {
 	return &HecoHandler{}
@@ -73,18 +78,16 @@ func (h *HecoHandler) MakeDepositProposal(service *native.NativeService) (*scom.
 	return value, nil
 }
 
-func verifyFromHecoTx(native *native.NativeService, proof, extra []byte, fromChainID uint64, height uint32, sideChain *side_chain_manager.SideChain) (param *scom.MakeTxParam, err error) {
-	cheight, err := heco.GetCanonicalHeight(native, fromChainID)
+// GetSyncedHeight returns the canonical height
+func (h *HecoHandler) GetSyncedHeight(service *native.NativeService, chainID uint64) (uint64, bool, error) {
+	height, err := heco.GetCanonicalHeight(service, chainID)
 	if err != nil {
-		return
-	}
-
-	cheight32 := uint32(cheight)
-
-	if cheight32 < height || cheight32-height < uint32(sideChain.BlocksToWait-1) {
-		return nil, fmt.Errorf("verifyFromHecoTx, transaction is not confirmed, current height: %d, input height: %d", cheight, height)
+		return 0, false, fmt.Errorf("heco GetSyncedHeight, %v", err)
 	}
+	return height, true, nil
+}
 
+func verifyFromHecoTx(native *native.NativeService, proof, extra []byte, fromChainID uint64, height uint32, sideChain *side_chain_manager.SideChain) (param *scom.MakeTxParam, err error) {
 	headerWithSum, err := heco.GetCanonicalHeader(native, fromChainID, uint64(height))
 	if err != nil {
 		return nil, fmt.Errorf("verifyFromHecoTx, GetCanonicalHeader height:%d, error:%s", height, err)
@@ -0,0 +1,168 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+package neo3
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"github.com/joeqian10/neo-gogogo/crypto"
+	"github.com/polynetwork/poly/common"
+)
+
+// node types of the MPT of neo N3 state service
+const (
+	BRANCH_NODE    = 0x00
+	EXTENSION_NODE = 0x01
+	LEAF_NODE      = 0x02
+	HASH_NODE      = 0x03
+	EMPTY_NODE     = 0x04
+
+	BRANCH_CHILD_COUNT = 17
+	// max storage key length of neo N3 in nibbles, including the contract id
+	MAX_KEY_LENGTH = (64 + 4) * 2
+)
+
+type mptNode struct {
+	Type     byte
+	Children []*mptNode // children of branch or next of extension, all hash or empty nodes
+	Key      []byte     // nibbles of extension
+	Value    []byte     // value of leaf
+	Hash     []byte     // hash of hash node
+}
+
+func (this *mptNode) deserialize(source *common.ZeroCopySource, asChild bool) error {
+	var eof bool
+	if this.Type, eof = source.NextByte(); eof {
+		return fmt.Errorf("read node type error")
+	}
+	if asChild && this.Type != HASH_NODE && this.Type != EMPTY_NODE {
+		return fmt.Errorf("unexpected child node type %d", this.Type)
+	}
+	switch this.Type {
+	case BRANCH_NODE:
+		this.Children = make([]*mptNode, BRANCH_CHILD_COUNT)
+		for i := range this.Children {
+			this.Children[i] = new(mptNode)
+			if err := this.Children[i].deserialize(source, true); err != nil {
+				return fmt.Errorf("read No.%d child of branch error: %v", i, err)
+			}
+		}
+	case EXTENSION_NODE:
+		if this.Key, eof = source.NextVarBytes(); eof {
+			return fmt.Errorf("read key of extension error")
+		}
+		if len(this.Key) == 0 || len(this.Key) > MAX_KEY_LENGTH {
+			return fmt.Errorf("invalid key length %d of extension", len(this.Key))
+		}
+		next := new(mptNode)
+		if err := next.deserialize(source, true); err != nil {
+			return fmt.Errorf("read next of extension error: %v", err)
+		}
+		this.Children = []*mptNode{next}
+	case LEAF_NODE:
+		if this.Value, eof = source.NextVarBytes(); eof {
+			return fmt.Errorf("read value of leaf error")
+		}
+	case HASH_NODE:
+		if this.Hash, eof = source.NextBytes(32); eof {
+			return fmt.Errorf("read hash error")
+		}
+	case EMPTY_NODE:
+	default:
+		return fmt.Errorf("unknown node type %d", this.Type)
+	}
+	return nil
+}
+
+// ResolveProof decodes the proof returned by getproof of neo N3 state service, which is the
+// storage key (contract id followed by key) and the nodes along the path
+func ResolveProof(proof []byte) (key []byte, nodes [][]byte, err error) {
+	source := common.NewZeroCopySource(proof)
+	key, eof := source.NextVarBytes()
+	if eof {
+		return nil, nil, fmt.Errorf("ResolveProof, read key error")
+	}
+	count, eof := source.NextVarUint()
+	if eof {
+		return nil, nil, fmt.Errorf("ResolveProof, read node count error")
+	}
+	for i := uint64(0); i < count; i++ {
+		node, eof := source.NextVarBytes()
+		if eof {
+			return nil, nil, fmt.Errorf("ResolveProof, read No.%d node error", i)
+		}
+		nodes = append(nodes, node)
+	}
+	if source.Len() != 0 {
+		return nil, nil, fmt.Errorf("ResolveProof, trailing bytes after nodes")
+	}
+	return key, nodes, nil
+}
+
+// VerifyProof walks the MPT from root along key with the nodes of proof, and returns the value
+// of the leaf at the end
+func VerifyProof(root []byte, key []byte, nodes [][]byte) ([]byte, error) {
+	cache := make(map[string][]byte, len(nodes))
+	for _, node := range nodes {
+		cache[string(crypto.Hash256(node))] = node
+	}
+	path := make([]byte, 0, len(key)*2)
+	for _, b := range key {
+		path = append(path, b>>4, b&0x0f)
+	}
+	hash := root
+	for {
+		raw, ok := cache[string(hash)]
+		if !ok {
+			return nil, fmt.Errorf("VerifyProof, node %s not found in proof", hex.EncodeToString(hash))
+		}
+		source := common.NewZeroCopySource(raw)
+		node := new(mptNode)
+		if err := node.deserialize(source, false); err != nil {
+			return nil, fmt.Errorf("VerifyProof, decode node %s error: %v", hex.EncodeToString(hash), err)
+		}
+		if source.Len() != 0 {
+			return nil, fmt.Errorf("VerifyProof, trailing bytes in node %s", hex.EncodeToString(hash))
+		}
+		var next *mptNode
+		switch node.Type {
+		case LEAF_NODE:
+			if len(path) != 0 {
+				return nil, fmt.Errorf("VerifyProof, key not found")
+			}
+			return node.Value, nil
+		case BRANCH_NODE:
+			if len(path) == 0 {
+				next = node.Children[BRANCH_CHILD_COUNT-1]
+			} else {
+				next, path = node.Children[path[0]], path[1:]
+			}
+		case EXTENSION_NODE:
+			if !bytes.HasPrefix(path, node.Key) {
+				return nil, fmt.Errorf("VerifyProof, key not found")
+			}
+			next, path = node.Children[0], path[len(node.Key):]
+		default:
+			return nil, fmt.Errorf("VerifyProof, unexpected node type %d in proof", node.Type)
+		}
+		if next.Type == EMPTY_NODE {
+			return nil, fmt.Errorf("VerifyProof, key not found")
+		}
+		hash = next.Hash
+	}
+}
@@ -0,0 +1,128 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+package neo3
+
+import (
+	"github.com/joeqian10/neo-gogogo/crypto"
+	"github.com/polynetwork/poly/common"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func leafNode(value []byte) []byte {
+	sink := common.NewZeroCopySink([]byte{LEAF_NODE})
+	sink.WriteVarBytes(value)
+	return sink.Bytes()
+}
+
+func hashChild(node []byte) []byte {
+	if node == nil {
+		return []byte{EMPTY_NODE}
+	}
+	return append([]byte{HASH_NODE}, crypto.Hash256(node)...)
+}
+
+func branchNode(children map[int][]byte) []byte {
+	node := []byte{BRANCH_NODE}
+	for i := 0; i < BRANCH_CHILD_COUNT; i++ {
+		node = append(node, hashChild(children[i])...)
+	}
+	return node
+}
+
+func extensionNode(key []byte, next []byte) []byte {
+	sink := common.NewZeroCopySink([]byte{EXTENSION_NODE})
+	sink.WriteVarBytes(key)
+	sink.WriteBytes(hashChild(next))
+	return sink.Bytes()
+}
+
+func encodeProof(key []byte, nodes ...[]byte) []byte {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteVarBytes(key)
+	sink.WriteVarUint(uint64(len(nodes)))
+	for _, node := range nodes {
+		sink.WriteVarBytes(node)
+	}
+	return sink.Bytes()
+}
+
+// newTestTrie returns the root and the proof of key1 in a trie of key1 and key2, which differ
+// in the last nibble, and a shorter key being the prefix of them
+func newTestTrie(key1, value1, value2, value3 []byte) (root []byte, nodes [][]byte) {
+	path := make([]byte, 0)
+	for _, b := range key1 {
+		path = append(path, b>>4, b&0x0f)
+	}
+	leaf1, leaf2, leaf3 := leafNode(value1), leafNode(value2), leafNode(value3)
+	last := int(path[len(path)-1])
+	lower := branchNode(map[int][]byte{last: leaf1, (last + 1) % 16: leaf2})
+	upper := branchNode(map[int][]byte{int(path[len(path)-2]): lower, BRANCH_CHILD_COUNT - 1: leaf3})
+	ext := extensionNode(path[:len(path)-2], upper)
+	return crypto.Hash256(ext), [][]byte{ext, upper, lower, leaf1}
+}
+
+func TestVerifyProof(t *testing.T) {
+	key := []byte{0xf8, 0xff, 0xff, 0xff, 0x01, 0x02, 0x03}
+	root, nodes := newTestTrie(key, []byte("value1"), []byte("value2"), []byte("value3"))
+
+	proofKey, proofNodes, err := ResolveProof(encodeProof(key, nodes...))
+	assert.NoError(t, err)
+	assert.Equal(t, key, proofKey)
+	value, err := VerifyProof(root, proofKey, proofNodes)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("value1"), value)
+
+	// the sibling leaf is not in the proof
+	sibling := append(append([]byte{}, key[:len(key)-1]...), key[len(key)-1]+1)
+	_, err = VerifyProof(root, sibling, nodes)
+	assert.Error(t, err)
+
+	// the value of the prefix key is at the last child of branch
+	_, err = VerifyProof(root, key[:len(key)-1], nodes)
+	assert.Error(t, err)
+	value, err = VerifyProof(root, key[:len(key)-1], append(nodes, leafNode([]byte("value3"))))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("value3"), value)
+
+	// path not in trie
+	_, err = VerifyProof(root, []byte{0x00, 0xff, 0xff, 0xff, 0x01, 0x02, 0x03}, nodes)
+	assert.Error(t, err)
+
+	// other root or tampered node
+	_, err = VerifyProof(crypto.Hash256([]byte("root")), key, nodes)
+	assert.Error(t, err)
+	_, err = VerifyProof(root, key, [][]byte{nodes[0], nodes[1], nodes[2], leafNode([]byte("value4"))})
+	assert.Error(t, err)
+
+	// trailing bytes
+	_, _, err = ResolveProof(append(encodeProof(key, nodes...), 0))
+	assert.Error(t, err)
+}
+
+func TestVerifyNeo3CrossChainProof(t *testing.T) {
+	id := []byte{0xf8, 0xff, 0xff, 0xff}
+	key := append(append([]byte{}, id...), 0x01, 0x02, 0x03)
+	root, nodes := newTestTrie(key, []byte("value1"), []byte("value2"), []byte("value3"))
+
+	value, err := VerifyNeo3CrossChainProof(encodeProof(key, nodes...), root, id)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("value1"), value)
+
+	_, err = VerifyNeo3CrossChainProof(encodeProof(key, nodes...), root, []byte{0xf7, 0xff, 0xff, 0xff})
+	assert.Error(t, err)
+}
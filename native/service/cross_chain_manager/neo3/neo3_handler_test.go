@@ -0,0 +1,182 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+package neo3
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"github.com/joeqian10/neo-gogogo/helper"
+	"github.com/joeqian10/neo-gogogo/wallet/keys"
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/polynetwork/poly/account"
+	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/consensus/vbft/config"
+	"github.com/polynetwork/poly/core/states"
+	"github.com/polynetwork/poly/core/store/leveldbstore"
+	"github.com/polynetwork/poly/core/store/overlaydb"
+	"github.com/polynetwork/poly/core/types"
+	"github.com/polynetwork/poly/native"
+	scom "github.com/polynetwork/poly/native/service/cross_chain_manager/common"
+	"github.com/polynetwork/poly/native/service/governance/node_manager"
+	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
+	hscommon "github.com/polynetwork/poly/native/service/header_sync/common"
+	"github.com/polynetwork/poly/native/service/header_sync/neo3"
+	"github.com/polynetwork/poly/native/service/utils"
+	"github.com/polynetwork/poly/native/storage"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const (
+	testChainID = 14
+	testMagic   = 860833102
+)
+
+var (
+	acct   = account.NewAccount("")
+	testId = []byte{0xf8, 0xff, 0xff, 0xff}
+
+	getNativeFunc = func(args []byte, db *storage.CacheDB) *native.NativeService {
+		signAddr, _ := types.AddressFromBookkeepers([]keypair.PublicKey{acct.PublicKey})
+		if db == nil {
+			store, _ := leveldbstore.NewMemLevelDBStore()
+			db = storage.NewCacheDB(overlaydb.NewOverlayDB(store))
+			sink := common.NewZeroCopySink(nil)
+			view := &node_manager.GovernanceView{
+				TxHash: common.UINT256_EMPTY,
+				Height: 0,
+				View:   0,
+			}
+			view.Serialization(sink)
+			db.Put(utils.ConcatKey(utils.NodeManagerContractAddress, []byte(node_manager.GOVERNANCE_VIEW)), states.GenRawStorageItem(sink.Bytes()))
+
+			peerPoolMap := &node_manager.PeerPoolMap{
+				PeerPoolMap: map[string]*node_manager.PeerPoolItem{
+					vconfig.PubkeyID(acct.PublicKey): {
+						Address:    acct.Address,
+						Status:     node_manager.ConsensusStatus,
+						PeerPubkey: vconfig.PubkeyID(acct.PublicKey),
+						Index:      0,
+					},
+				},
+			}
+			sink.Reset()
+			peerPoolMap.Serialization(sink)
+			db.Put(utils.ConcatKey(utils.NodeManagerContractAddress,
+				[]byte(node_manager.PEER_POOL), utils.GetUint32Bytes(0)), states.GenRawStorageItem(sink.Bytes()))
+
+			sc := &side_chain_manager.SideChain{
+				ChainId:      testChainID,
+				Router:       utils.NEO3_ROUTER,
+				BlocksToWait: 1,
+				Address:      signAddr,
+				CCMCAddress:  testId,
+				ExtraInfo:    []byte(fmt.Sprintf(`{"Magic":%d}`, testMagic)),
+			}
+			sink.Reset()
+			_ = sc.Serialization(sink)
+			db.Put(utils.ConcatKey(utils.SideChainManagerContractAddress, []byte(side_chain_manager.SIDE_CHAIN), utils.GetUint64Bytes(testChainID)), states.GenRawStorageItem(sink.Bytes()))
+		}
+
+		ns, _ := native.NewNativeService(db, &types.Transaction{SignedAddr: []common.Address{signAddr}}, 0, 0, common.Uint256{0}, 0, args, false)
+		return ns
+	}
+)
+
+func signWitness(t *testing.T, signData []byte, kps []*keys.KeyPair) *neo3.Witness {
+	m := len(kps) - (len(kps)-1)/3
+	witness := &neo3.Witness{VerificationScript: []byte{byte(neo3.PUSH1 + m - 1)}}
+	for _, kp := range kps {
+		witness.VerificationScript = append(witness.VerificationScript, neo3.PUSHDATA1, 33)
+		witness.VerificationScript = append(witness.VerificationScript, kp.PublicKey.EncodeCompression()...)
+	}
+	checkMultisig := sha256.Sum256([]byte("System.Crypto.CheckMultisig"))
+	witness.VerificationScript = append(witness.VerificationScript, byte(neo3.PUSH1+len(kps)-1), neo3.SYSCALL)
+	witness.VerificationScript = append(witness.VerificationScript, checkMultisig[:4]...)
+	for _, kp := range kps[:m] {
+		sig, err := kp.Sign(signData)
+		assert.NoError(t, err)
+		witness.InvocationScript = append(append(witness.InvocationScript, neo3.PUSHDATA1, 64), sig...)
+	}
+	return witness
+}
+
+func syncGenesis(t *testing.T, kps []*keys.KeyPair) *native.NativeService {
+	header := &neo3.Neo3BlockHeader{Index: 100}
+	header.NextConsensus = signWitness(t, nil, kps).GetScriptHash()
+	header.Witness = signWitness(t, header.GetSignData(testMagic), kps)
+	sink := common.NewZeroCopySink(nil)
+	header.Serialization(sink)
+	param := &hscommon.SyncGenesisHeaderParam{ChainID: testChainID, GenesisHeader: sink.Bytes()}
+	sink = common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	ns := getNativeFunc(sink.Bytes(), nil)
+	assert.NoError(t, neo3.NewNEO3Handler().SyncGenesisHeader(ns))
+	return ns
+}
+
+func makeDepositProposal(ns *native.NativeService, msg *neo3.Neo3CrossChainMsg, proof []byte) (*scom.MakeTxParam, error) {
+	sink := common.NewZeroCopySink(nil)
+	msg.Serialization(sink)
+	param := &scom.EntranceParam{
+		SourceChainID:         testChainID,
+		Height:                msg.Index,
+		Proof:                 proof,
+		HeaderOrCrossChainMsg: sink.Bytes(),
+	}
+	sink = common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	return NewNEO3Handler().MakeDepositProposal(getNativeFunc(sink.Bytes(), ns.GetCacheDB()))
+}
+
+func TestMakeDepositProposal(t *testing.T) {
+	kps := make([]*keys.KeyPair, 4)
+	for i := range kps {
+		kps[i], _ = keys.GenerateKeyPair()
+	}
+	ns := syncGenesis(t, kps)
+
+	txParam := &scom.MakeTxParam{
+		TxHash:              []byte{1},
+		CrossChainID:        []byte{2},
+		FromContractAddress: []byte{3},
+		ToChainID:           2,
+		ToContractAddress:   []byte{4},
+		Method:              "unlock",
+		Args:                []byte{5},
+	}
+	sink := common.NewZeroCopySink(nil)
+	txParam.Serialization(sink)
+	key := append(append([]byte{}, testId...), 0x01, 0x02, 0x03)
+	root, nodes := newTestTrie(key, sink.Bytes(), []byte("value2"), []byte("value3"))
+	proof := encodeProof(key, nodes...)
+
+	msg := &neo3.Neo3CrossChainMsg{Index: 120}
+	msg.RootHash, _ = helper.UInt256FromBytes(root)
+	msg.Witness = signWitness(t, msg.GetSignData(testMagic+1), kps)
+	_, err := makeDepositProposal(ns, msg, proof)
+	assert.Error(t, err)
+
+	msg.Witness = signWitness(t, msg.GetSignData(testMagic), kps)
+	value, err := makeDepositProposal(ns, msg, proof)
+	assert.NoError(t, err)
+	assert.Equal(t, txParam, value)
+
+	// the same tx twice
+	_, err = makeDepositProposal(ns, msg, proof)
+	assert.Error(t, err)
+}
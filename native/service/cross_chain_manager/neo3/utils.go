@@ -0,0 +1,57 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+package neo3
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"github.com/polynetwork/poly/common"
+	scom "github.com/polynetwork/poly/native/service/cross_chain_manager/common"
+	"github.com/polynetwork/poly/native/service/header_sync/neo3"
+)
+
+func verifyFromNeo3Tx(proof []byte, crossChainMsg *neo3.Neo3CrossChainMsg, contractId []byte) (*scom.MakeTxParam, error) {
+	value, err := VerifyNeo3CrossChainProof(proof, crossChainMsg.RootHash.Bytes(), contractId)
+	if err != nil {
+		return nil, fmt.Errorf("verifyFromNeo3Tx, verify neo3 cross chain proof error:%v", err)
+	}
+	txParam := new(scom.MakeTxParam)
+	if err := txParam.Deserialization(common.NewZeroCopySource(value)); err != nil {
+		return nil, fmt.Errorf("verifyFromNeo3Tx, deserialize merkleValue error:%s", err)
+	}
+	return txParam, nil
+}
+
+// VerifyNeo3CrossChainProof returns the value proved under stateRoot, of which the storage key must
+// belong to the CCMC. Storage of neo N3 is keyed by contract id instead of script hash, so the
+// CCMCAddress of a neo3 chain is the little endian contract id of CCMC.
+func VerifyNeo3CrossChainProof(proof []byte, stateRoot []byte, contractId []byte) ([]byte, error) {
+	key, nodes, err := ResolveProof(proof)
+	if err != nil {
+		return nil, fmt.Errorf("VerifyNeo3CrossChainProof, %v", err)
+	}
+	if len(contractId) != 4 || len(key) < 4 || !bytes.Equal(key[:4], contractId) {
+		return nil, fmt.Errorf("VerifyNeo3CrossChainProof, storage key %s does not belong to CCMC of id %s",
+			hex.EncodeToString(key), hex.EncodeToString(contractId))
+	}
+	value, err := VerifyProof(stateRoot, key, nodes)
+	if err != nil {
+		return nil, fmt.Errorf("VerifyNeo3CrossChainProof, %v", err)
+	}
+	return value, nil
+}
@@ -19,6 +19,7 @@ package ont
 
 import (
 	"fmt"
+	"github.com/polynetwork/poly/native/service/utils"
 
 	"github.com/ontio/ontology-crypto/keypair"
 	ocommon "github.com/ontio/ontology/common"
@@ -32,6 +33,10 @@ import (
 type ONTHandler struct {
 }
 
+func init() {
+	scom.RegisterChainHandler(utils.ONT_ROUTER, "ont", func() scom.ChainHandler { return NewONTHandler() })
+}
+
 func NewONTHandler() *ONTHandler {
 	return &ONTHandler{}
 }
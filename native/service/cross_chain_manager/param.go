@@ -20,6 +20,7 @@ package cross_chain_manager
 import (
 	"fmt"
 	"github.com/polynetwork/poly/common"
+	scom "github.com/polynetwork/poly/native/service/cross_chain_manager/common"
 )
 
 type BlackChainParam struct {
@@ -39,3 +40,55 @@ func (this *BlackChainParam) Deserialization(source *common.ZeroCopySource) erro
 	this.ChainID = chainID
 	return nil
 }
+
+type RouteLimitParam struct {
+	FromChainID uint64
+	ToChainID   uint64
+	ToContract  []byte
+	Limit       *scom.RouteLimit
+	Address     common.Address
+}
+
+func (this *RouteLimitParam) Serialization(sink *common.ZeroCopySink) {
+	this.serializeRoute(sink)
+	sink.WriteVarBytes(this.Address[:])
+}
+
+// serializeRoute writes the route and limit, they are the content voted in CheckConsensusSigns
+func (this *RouteLimitParam) serializeRoute(sink *common.ZeroCopySink) {
+	sink.WriteVarUint(this.FromChainID)
+	sink.WriteVarUint(this.ToChainID)
+	sink.WriteVarBytes(this.ToContract)
+	this.Limit.Serialization(sink)
+}
+
+func (this *RouteLimitParam) Deserialization(source *common.ZeroCopySource) error {
+	var eof bool
+	this.FromChainID, eof = source.NextVarUint()
+	if eof {
+		return fmt.Errorf("RouteLimitParam deserialize from chain id error")
+	}
+	this.ToChainID, eof = source.NextVarUint()
+	if eof {
+		return fmt.Errorf("RouteLimitParam deserialize to chain id error")
+	}
+	this.ToContract, eof = source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("RouteLimitParam deserialize to contract error")
+	}
+	limit := new(scom.RouteLimit)
+	if err := limit.Deserialization(source); err != nil {
+		return fmt.Errorf("RouteLimitParam deserialize limit error: %v", err)
+	}
+	this.Limit = limit
+	address, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("RouteLimitParam deserialize address error")
+	}
+	addr, err := common.AddressParseFromBytes(address)
+	if err != nil {
+		return fmt.Errorf("RouteLimitParam, common.AddressParseFromBytes error: %v", err)
+	}
+	this.Address = addr
+	return nil
+}
@@ -26,10 +26,15 @@ import (
 	"github.com/polynetwork/poly/native/service/cross_chain_manager/common"
 	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
 	"github.com/polynetwork/poly/native/service/header_sync/quorum"
+	"github.com/polynetwork/poly/native/service/utils"
 )
 
 type QuorumHandler struct{}
 
+func init() {
+	common.RegisterChainHandler(utils.QUORUM_ROUTER, "quorum", func() common.ChainHandler { return NewQuorumHandler() })
+}
+
 func NewQuorumHandler() *QuorumHandler {
 	return &QuorumHandler{}
 }
@@ -0,0 +1,157 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cross_chain_manager
+
+import (
+	"testing"
+
+	"github.com/polynetwork/poly/account"
+	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/common/config"
+	"github.com/polynetwork/poly/core/store/leveldbstore"
+	"github.com/polynetwork/poly/core/store/overlaydb"
+	"github.com/polynetwork/poly/core/types"
+	"github.com/polynetwork/poly/native"
+	scom "github.com/polynetwork/poly/native/service/cross_chain_manager/common"
+	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
+	"github.com/polynetwork/poly/native/service/testutils"
+	"github.com/polynetwork/poly/native/service/utils"
+	"github.com/polynetwork/poly/native/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+var acct = account.NewAccount("")
+
+func newNative(args []byte, tx *types.Transaction, db *storage.CacheDB) *native.NativeService {
+	if db == nil {
+		store, _ := leveldbstore.NewMemLevelDBStore()
+		db = storage.NewCacheDB(overlaydb.NewOverlayDB(store))
+	}
+	ns, _ := native.NewNativeService(db, tx, 0, 0, common.Uint256{}, 0, args, false)
+	return ns
+}
+
+func TestRouteLimitParam(t *testing.T) {
+	param := &RouteLimitParam{
+		FromChainID: 2,
+		ToChainID:   3,
+		ToContract:  []byte{1, 2, 3},
+		Limit:       &scom.RouteLimit{Paused: true, MaxPerBlock: 1, EpochLength: 60, MaxPerEpoch: 10},
+		Address:     common.Address{1, 2, 3},
+	}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+
+	p := new(RouteLimitParam)
+	assert.NoError(t, p.Deserialization(common.NewZeroCopySource(sink.Bytes())))
+	assert.Equal(t, param, p)
+}
+
+func TestSetRouteLimit(t *testing.T) {
+	defer testutils.SetForkHeights(map[string]uint32{config.FORK_GOVERNANCE_PROPOSAL: 0})()
+	conAccts := []*account.Account{acct}
+	tx := &types.Transaction{SignedAddr: []common.Address{acct.Address}}
+	ns := newNative(nil, tx, nil)
+	testutils.PutPeerPoolAndView(ns.GetCacheDB(), conAccts)
+	for _, chainID := range []uint64{2, 3} {
+		assert.NoError(t, side_chain_manager.PutSideChain(ns, &side_chain_manager.SideChain{ChainId: chainID, Router: utils.ETH_ROUTER}))
+	}
+
+	setRouteLimit := func(param *RouteLimitParam, tx *types.Transaction) error {
+		sink := common.NewZeroCopySink(nil)
+		param.Serialization(sink)
+		ns = newNative(sink.Bytes(), tx, ns.GetCacheDB())
+		_, err := SetRouteLimit(ns)
+		return err
+	}
+	param := &RouteLimitParam{
+		FromChainID: 2,
+		ToChainID:   3,
+		ToContract:  []byte{1, 2, 3},
+		Limit:       &scom.RouteLimit{MaxPerBlock: 2, EpochLength: 10, MaxPerEpoch: 3},
+		Address:     acct.Address,
+	}
+
+	invalid := *param
+	invalid.Limit = &scom.RouteLimit{MaxPerEpoch: 3}
+	assert.Error(t, setRouteLimit(&invalid, tx))
+	invalid.Limit, invalid.ToChainID = param.Limit, 4
+	assert.Error(t, setRouteLimit(&invalid, tx))
+	// only consensus peers can set the limit
+	other := account.NewAccount("")
+	invalid.ToChainID, invalid.Address = param.ToChainID, other.Address
+	assert.Error(t, setRouteLimit(&invalid, &types.Transaction{SignedAddr: []common.Address{other.Address}}))
+
+	assert.NoError(t, setRouteLimit(param, tx))
+	limit, err := scom.GetRouteLimit(ns, 2, 3, param.ToContract)
+	assert.NoError(t, err)
+	assert.Equal(t, param.Limit, limit)
+	notify := ns.GetNotify()
+	assert.Equal(t, SET_ROUTE_LIMIT, notify[len(notify)-1].States.([]interface{})[0])
+}
+
+type routeLimitTestHandler struct{}
+
+func (this *routeLimitTestHandler) MakeDepositProposal(service *native.NativeService) (*scom.MakeTxParam, error) {
+	return &scom.MakeTxParam{
+		TxHash:            []byte{1},
+		CrossChainID:      []byte{1},
+		ToChainID:         3,
+		ToContractAddress: []byte{1, 2, 3},
+	}, nil
+}
+
+func TestImportExTransferRouteLimit(t *testing.T) {
+	defer testutils.SetForkHeights(map[string]uint32{config.FORK_GOVERNANCE_PROPOSAL: 0})()
+	// routers without their own check are limited by ImportExTransfer
+	var router uint64 = 1001
+	scom.RegisterChainHandler(router, "routeLimitTest", func() scom.ChainHandler { return &routeLimitTestHandler{} })
+	tx := &types.Transaction{SignedAddr: []common.Address{acct.Address}}
+	ns := newNative(nil, tx, nil)
+	testutils.PutPeerPoolAndView(ns.GetCacheDB(), []*account.Account{acct})
+	assert.NoError(t, side_chain_manager.PutSideChain(ns, &side_chain_manager.SideChain{ChainId: 2, Router: router}))
+	assert.NoError(t, side_chain_manager.PutSideChain(ns, &side_chain_manager.SideChain{ChainId: 3, Router: utils.ETH_ROUTER}))
+
+	param := &RouteLimitParam{
+		FromChainID: 2,
+		ToChainID:   3,
+		ToContract:  []byte{1, 2, 3},
+		Limit:       &scom.RouteLimit{MaxPerBlock: 1, EpochLength: 10, MaxPerEpoch: 3},
+		Address:     acct.Address,
+	}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	_, err := SetRouteLimit(newNative(sink.Bytes(), tx, ns.GetCacheDB()))
+	assert.NoError(t, err)
+
+	sink = common.NewZeroCopySink(nil)
+	(&scom.EntranceParam{SourceChainID: 2}).Serialization(sink)
+	ns = newNative(sink.Bytes(), tx, ns.GetCacheDB())
+	_, err = ImportExTransfer(ns)
+	assert.NoError(t, err)
+	// the message filling the quota notifies it
+	reached := false
+	for _, notify := range ns.GetNotify() {
+		if states, ok := notify.States.([]interface{}); ok && states[0] == scom.ROUTE_LIMIT_REACHED {
+			reached = true
+		}
+	}
+	assert.True(t, reached)
+	_, err = ImportExTransfer(newNative(sink.Bytes(), tx, ns.GetCacheDB()))
+	assert.Error(t, err)
+}
@@ -18,9 +18,11 @@
 package node_manager
 
 import (
+	"encoding/hex"
 	"fmt"
 	"github.com/polynetwork/poly/native"
 	"github.com/polynetwork/poly/native/service/utils"
+	"sort"
 )
 
 func executeCommitDpos(native *native.NativeService) error {
@@ -41,6 +43,7 @@ func executeCommitDpos(native *native.NativeService) error {
 		return fmt.Errorf("executeCommitDpos, get peerPoolMap error: %v", err)
 	}
 
+	active := make([]string, 0)
 	for k, peerPoolItem := range peerPoolMap.PeerPoolMap {
 		if peerPoolItem.Status == QuitingStatus {
 			delete(peerPoolMap.PeerPoolMap, peerPoolItem.PeerPubkey)
@@ -48,9 +51,63 @@ func executeCommitDpos(native *native.NativeService) error {
 		if peerPoolItem.Status == BlackStatus {
 			delete(peerPoolMap.PeerPoolMap, peerPoolItem.PeerPubkey)
 		}
+		if peerPoolItem.Status == QuitingStatus || peerPoolItem.Status == BlackStatus {
+			if err := deleteRotationApply(native, k); err != nil {
+				return fmt.Errorf("executeCommitDpos, deleteRotationApply error: %v", err)
+			}
+			if err := deleteRotation(native, k); err != nil {
+				return fmt.Errorf("executeCommitDpos, deleteRotation error: %v", err)
+			}
+		}
 
 		if peerPoolItem.Status == CandidateStatus || peerPoolItem.Status == ConsensusStatus {
 			peerPoolMap.PeerPoolMap[k].Status = ConsensusStatus
+			active = append(active, k)
+		}
+	}
+
+	//swap keys of approved rotations, in order so that the result is deterministic
+	sort.Strings(active)
+	for _, k := range active {
+		newPeerPubkey, err := getRotation(native, k)
+		if err != nil {
+			return fmt.Errorf("executeCommitDpos, getRotation error: %v", err)
+		}
+		if newPeerPubkey == "" {
+			continue
+		}
+		if err := deleteRotation(native, k); err != nil {
+			return fmt.Errorf("executeCommitDpos, deleteRotation error: %v", err)
+		}
+		//the new key is taken by another node since approved
+		if _, ok := peerPoolMap.PeerPoolMap[newPeerPubkey]; ok {
+			continue
+		}
+		peer, err := GetPeerApply(native, newPeerPubkey)
+		if err != nil {
+			return fmt.Errorf("executeCommitDpos, GetPeerApply error: %v", err)
+		}
+		if peer != nil {
+			continue
+		}
+		//the new key is blacked since approved
+		newPeerPubkeyPrefix, err := hex.DecodeString(newPeerPubkey)
+		if err != nil {
+			return fmt.Errorf("executeCommitDpos, new peerPubkey format error: %v", err)
+		}
+		blackList, err := native.GetCacheDB().Get(utils.ConcatKey(utils.NodeManagerContractAddress, []byte(BLACK_LIST), newPeerPubkeyPrefix))
+		if err != nil {
+			return fmt.Errorf("executeCommitDpos, get BlackList error: %v", err)
+		}
+		if blackList != nil {
+			continue
+		}
+		peerPoolItem := peerPoolMap.PeerPoolMap[k]
+		delete(peerPoolMap.PeerPoolMap, k)
+		peerPoolItem.PeerPubkey = newPeerPubkey
+		peerPoolMap.PeerPoolMap[newPeerPubkey] = peerPoolItem
+		if err := movePeerIndex(native, k, newPeerPubkey); err != nil {
+			return fmt.Errorf("executeCommitDpos, movePeerIndex error: %v", err)
 		}
 	}
 
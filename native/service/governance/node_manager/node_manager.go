@@ -20,10 +20,12 @@ package node_manager
 import (
 	"encoding/hex"
 	"fmt"
+	"github.com/ontio/ontology-crypto/keypair"
 	"github.com/polynetwork/poly/common"
 	"github.com/polynetwork/poly/common/config"
 	"github.com/polynetwork/poly/core/genesis"
 	cstates "github.com/polynetwork/poly/core/states"
+	"github.com/polynetwork/poly/core/types"
 	"github.com/polynetwork/poly/native"
 	"github.com/polynetwork/poly/native/event"
 	"github.com/polynetwork/poly/native/service/utils"
@@ -45,6 +47,9 @@ const (
 	QUIT_NODE            = "quitNode"
 	UPDATE_CONFIG        = "updateConfig"
 	COMMIT_DPOS          = "commitDpos"
+	REVOKE_VOTE          = "revokeVote"
+	ROTATE_PEER_KEY      = "rotatePeerKey"
+	APPROVE_ROTATE_KEY   = "approveRotatePeerKey"
 
 	//key prefix
 	GOVERNANCE_VIEW = "governanceView"
@@ -55,9 +60,16 @@ const (
 	PEER_INDEX      = "peerIndex"
 	BLACK_LIST      = "blackList"
 	CONSENSUS_SIGNS = "consensusSigns"
+	PROPOSAL        = "proposal"
+	PROPOSAL_HASH   = "proposalHash"
+	PROPOSAL_SEQ    = "proposalSequence"
+	ROTATION_APPLY  = "rotationApply"
+	ROTATION        = "rotation"
 
 	//const
 	MIN_PEER_NUM = 4
+	// blocks a proposal stays open for votes after it is created
+	PROPOSAL_LIFETIME uint32 = 120000
 )
 
 //Register methods of node_manager contract
@@ -71,6 +83,9 @@ func RegisterNodeManagerContract(native *native.NativeService) {
 	native.Register(WHITE_NODE, WhiteNode)
 	native.Register(UPDATE_CONFIG, UpdateConfig)
 	native.Register(COMMIT_DPOS, CommitDpos)
+	native.Register(REVOKE_VOTE, RevokeVote)
+	native.Register(ROTATE_PEER_KEY, RotatePeerKey)
+	native.Register(APPROVE_ROTATE_KEY, ApproveRotatePeerKey)
 }
 
 //Init node_manager contract
@@ -647,3 +662,189 @@ func UpdateConfig(native *native.NativeService) ([]byte, error) {
 		})
 	return utils.BYTE_TRUE, nil
 }
+
+// RevokeVote withdraws the vote of a signer from an open proposal, the proposal is removed
+// once no vote is left
+func RevokeVote(native *native.NativeService) ([]byte, error) {
+	params := new(RevokeVoteParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("revokeVote, contract params deserialize error: %v", err)
+	}
+	if !native.IsForkActive(config.FORK_GOVERNANCE_PROPOSAL) {
+		return utils.BYTE_FALSE, fmt.Errorf("revokeVote, proposals are not active yet")
+	}
+
+	//check witness
+	err := utils.ValidateOwner(native, params.Address)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("revokeVote, checkWitness error: %v", err)
+	}
+
+	proposal, err := GetProposal(native, params.ID)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("revokeVote, GetProposal error: %v", err)
+	}
+	if proposal == nil {
+		return utils.BYTE_FALSE, fmt.Errorf("revokeVote, proposal %d not found", params.ID)
+	}
+	if proposal.IsExpired(native.GetHeight()) {
+		return utils.BYTE_FALSE, fmt.Errorf("revokeVote, proposal %d is expired at height %d", params.ID, proposal.ExpiryHeight)
+	}
+	if !proposal.RemoveVoter(params.Address) {
+		return utils.BYTE_FALSE, fmt.Errorf("revokeVote, address %s has not voted for proposal %d",
+			params.Address.ToBase58(), params.ID)
+	}
+	if len(proposal.Voters) == 0 {
+		deleteProposal(native, proposal)
+	} else {
+		putProposal(native, proposal)
+	}
+
+	native.AddNotify(
+		&event.NotifyEventInfo{
+			ContractAddress: utils.NodeManagerContractAddress,
+			States:          []interface{}{REVOKE_VOTE, params.ID, params.Address.ToBase58(), len(proposal.Voters)},
+		})
+	return utils.BYTE_TRUE, nil
+}
+
+//Apply to rotate the consensus key of a node, used by node owner and signed by both old and new key.
+//The new key replaces the old one at next commitDpos after approved.
+func RotatePeerKey(native *native.NativeService) ([]byte, error) {
+	params := new(RotatePeerKeyParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("rotatePeerKey, contract params deserialize error: %v", err)
+	}
+	contract := utils.NodeManagerContractAddress
+
+	//check witness of owner, old key and new key
+	err := utils.ValidateOwner(native, params.Address)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("rotatePeerKey, checkWitness error: %v", err)
+	}
+	for _, peerPubkey := range []string{params.PeerPubkey, params.NewPeerPubkey} {
+		k, err := hex.DecodeString(peerPubkey)
+		if err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("rotatePeerKey, peerPubkey format error: %v", err)
+		}
+		publicKey, err := keypair.DeserializePublicKey(k)
+		if err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("rotatePeerKey, keypair.DeserializePublicKey error: %v", err)
+		}
+		if err := utils.ValidateOwner(native, types.AddressFromPubKey(publicKey)); err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("rotatePeerKey, checkWitness of peerPubkey %s error: %v", peerPubkey, err)
+		}
+	}
+
+	//check new peerPubkey
+	if err := utils.ValidatePeerPubKeyFormat(params.NewPeerPubkey); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("rotatePeerKey, invalid new peer pubkey")
+	}
+	newPeerPubkeyPrefix, err := hex.DecodeString(params.NewPeerPubkey)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("rotatePeerKey, new peerPubkey format error: %v", err)
+	}
+	blackList, err := native.GetCacheDB().Get(utils.ConcatKey(contract, []byte(BLACK_LIST), newPeerPubkeyPrefix))
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("rotatePeerKey, get BlackList error: %v", err)
+	}
+	if blackList != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("rotatePeerKey, new peerPubkey is in BlackList")
+	}
+	peer, err := GetPeerApply(native, params.NewPeerPubkey)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("rotatePeerKey, GetPeerApply error: %v", err)
+	}
+	if peer != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("rotatePeerKey, new peerPubkey already applied")
+	}
+
+	//get current view
+	view, err := GetView(native)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("rotatePeerKey, get view error: %v", err)
+	}
+	//get peerPoolMap
+	peerPoolMap, err := GetPeerPoolMap(native, view)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("rotatePeerKey, get peerPoolMap error: %v", err)
+	}
+	peerPoolItem, ok := peerPoolMap.PeerPoolMap[params.PeerPubkey]
+	if !ok {
+		return utils.BYTE_FALSE, fmt.Errorf("rotatePeerKey, peerPubkey is not in peerPoolMap")
+	}
+	if peerPoolItem.Status != ConsensusStatus && peerPoolItem.Status != CandidateStatus {
+		return utils.BYTE_FALSE, fmt.Errorf("rotatePeerKey, peerPubkey is not CandidateStatus or ConsensusStatus")
+	}
+	if params.Address != peerPoolItem.Address {
+		return utils.BYTE_FALSE, fmt.Errorf("rotatePeerKey, peerPubkey is not registered by this address")
+	}
+	if _, ok := peerPoolMap.PeerPoolMap[params.NewPeerPubkey]; ok {
+		return utils.BYTE_FALSE, fmt.Errorf("rotatePeerKey, new peerPubkey is already in peerPoolMap")
+	}
+	rotation, err := getRotation(native, params.PeerPubkey)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("rotatePeerKey, getRotation error: %v", err)
+	}
+	if rotation != "" {
+		return utils.BYTE_FALSE, fmt.Errorf("rotatePeerKey, rotation to %s is approved and waiting for commitDpos", rotation)
+	}
+
+	err = putRotationApply(native, params)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("rotatePeerKey, putRotationApply error: %v", err)
+	}
+	native.AddNotify(
+		&event.NotifyEventInfo{
+			ContractAddress: utils.NodeManagerContractAddress,
+			States:          []interface{}{ROTATE_PEER_KEY, params.PeerPubkey, params.NewPeerPubkey},
+		})
+	return utils.BYTE_TRUE, nil
+}
+
+//Approve the key rotation of a node, used by consensus nodes.
+func ApproveRotatePeerKey(native *native.NativeService) ([]byte, error) {
+	params := new(PeerParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("approveRotatePeerKey, contract params deserialize error: %v", err)
+	}
+
+	//check witness
+	err := utils.ValidateOwner(native, params.Address)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("approveRotatePeerKey, checkWitness error: %v", err)
+	}
+
+	//check if applied
+	apply, err := GetRotationApply(native, params.PeerPubkey)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("approveRotatePeerKey, GetRotationApply error: %v", err)
+	}
+	if apply == nil {
+		return utils.BYTE_FALSE, fmt.Errorf("approveRotatePeerKey, rotation is not applied")
+	}
+
+	//check consensus signs, the votes are bound to the new key
+	ok, err := CheckConsensusSigns(native, APPROVE_ROTATE_KEY, []byte(apply.PeerPubkey+apply.NewPeerPubkey), params.Address)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("approveRotatePeerKey, CheckConsensusSigns error: %v", err)
+	}
+	if !ok {
+		return utils.BYTE_TRUE, nil
+	}
+
+	err = putRotation(native, apply.PeerPubkey, apply.NewPeerPubkey)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("approveRotatePeerKey, putRotation error: %v", err)
+	}
+	err = deleteRotationApply(native, apply.PeerPubkey)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("approveRotatePeerKey, deleteRotationApply error: %v", err)
+	}
+	native.AddNotify(
+		&event.NotifyEventInfo{
+			ContractAddress: utils.NodeManagerContractAddress,
+			States:          []interface{}{APPROVE_ROTATE_KEY, apply.PeerPubkey, apply.NewPeerPubkey},
+		})
+	return utils.BYTE_TRUE, nil
+}
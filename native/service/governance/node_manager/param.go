@@ -137,3 +137,67 @@ func (this *UpdateConfigParam) Deserialization(source *common.ZeroCopySource) er
 	this.Configuration = configuration
 	return nil
 }
+
+type RevokeVoteParam struct {
+	ID      uint64
+	Address common.Address
+}
+
+func (this *RevokeVoteParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteUint64(this.ID)
+	sink.WriteVarBytes(this.Address[:])
+}
+
+func (this *RevokeVoteParam) Deserialization(source *common.ZeroCopySource) error {
+	id, eof := source.NextUint64()
+	if eof {
+		return fmt.Errorf("source.NextUint64, deserialize id error")
+	}
+	address, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("source.NextVarBytes, deserialize address error")
+	}
+	addr, err := common.AddressParseFromBytes(address)
+	if err != nil {
+		return fmt.Errorf("common.AddressParseFromBytes, deserialize address error: %s", err)
+	}
+	this.ID = id
+	this.Address = addr
+	return nil
+}
+
+type RotatePeerKeyParam struct {
+	PeerPubkey    string
+	NewPeerPubkey string
+	Address       common.Address
+}
+
+func (this *RotatePeerKeyParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteString(this.PeerPubkey)
+	sink.WriteString(this.NewPeerPubkey)
+	sink.WriteVarBytes(this.Address[:])
+}
+
+func (this *RotatePeerKeyParam) Deserialization(source *common.ZeroCopySource) error {
+	peerPubkey, eof := source.NextString()
+	if eof {
+		return fmt.Errorf("source.NextString, deserialize peerPubkey error")
+	}
+	newPeerPubkey, eof := source.NextString()
+	if eof {
+		return fmt.Errorf("source.NextString, deserialize newPeerPubkey error")
+	}
+	address, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("source.NextVarBytes, deserialize address error")
+	}
+	addr, err := common.AddressParseFromBytes(address)
+	if err != nil {
+		return fmt.Errorf("common.AddressParseFromBytes, deserialize address error: %s", err)
+	}
+
+	this.PeerPubkey = peerPubkey
+	this.NewPeerPubkey = newPeerPubkey
+	this.Address = addr
+	return nil
+}
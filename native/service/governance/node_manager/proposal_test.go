@@ -0,0 +1,241 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package node_manager
+
+import (
+	"crypto/sha256"
+	"github.com/polynetwork/poly/account"
+	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/common/config"
+	"github.com/polynetwork/poly/consensus/vbft/config"
+	"github.com/polynetwork/poly/core/store/leveldbstore"
+	"github.com/polynetwork/poly/core/store/overlaydb"
+	"github.com/polynetwork/poly/core/types"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/storage"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func newProposalTestDB(t *testing.T, accts []*account.Account) *storage.CacheDB {
+	store, err := leveldbstore.NewMemLevelDBStore()
+	assert.Nil(t, err)
+	db := storage.NewCacheDB(overlaydb.NewOverlayDB(store))
+	ns := newProposalTestNative(t, db, nil, nil, 0)
+	peerPoolMap := &PeerPoolMap{PeerPoolMap: make(map[string]*PeerPoolItem)}
+	for i, acct := range accts {
+		pk := vconfig.PubkeyID(acct.PublicKey)
+		peerPoolMap.PeerPoolMap[pk] = &PeerPoolItem{
+			Index:      uint32(i),
+			PeerPubkey: pk,
+			Address:    acct.Address,
+			Status:     ConsensusStatus,
+		}
+	}
+	putPeerPoolMap(ns, peerPoolMap, 0)
+	putGovernanceView(ns, &GovernanceView{TxHash: common.UINT256_EMPTY})
+	return db
+}
+
+func newProposalTestNative(t *testing.T, db *storage.CacheDB, args []byte, signer *account.Account, height uint32) *native.NativeService {
+	tx := new(types.Transaction)
+	if signer != nil {
+		tx.SignedAddr = []common.Address{signer.Address}
+	}
+	ns, err := native.NewNativeService(db, tx, 0, height, common.Uint256{}, 0, args, false)
+	assert.Nil(t, err)
+	return ns
+}
+
+// scheduleProposalFork activates config.FORK_GOVERNANCE_PROPOSAL at height, the returned function
+// restores the config
+func scheduleProposalFork(height uint32) func() {
+	genesis := config.DefConfig.Genesis
+	scheduled := *genesis
+	scheduled.Forks = map[string]uint32{config.FORK_GOVERNANCE_PROPOSAL: height}
+	config.DefConfig.Genesis = &scheduled
+	return func() { config.DefConfig.Genesis = genesis }
+}
+
+func newProposalTestAccounts() []*account.Account {
+	accts := make([]*account.Account, 4)
+	for i := range accts {
+		accts[i] = account.NewAccount("")
+	}
+	return accts
+}
+
+func TestCheckConsensusSigns(t *testing.T) {
+	defer scheduleProposalFork(5)()
+	accts := newProposalTestAccounts()
+	db := newProposalTestDB(t, accts)
+	ns := newProposalTestNative(t, db, nil, nil, 10)
+	input := []byte("peer")
+
+	for i, acct := range []*account.Account{accts[0], accts[0], accts[1]} {
+		ok, err := CheckConsensusSigns(ns, APPROVE_CANDIDATE, input, acct.Address)
+		assert.Nil(t, err)
+		assert.False(t, ok, "vote %d", i)
+	}
+	proposal, err := GetProposal(ns, 0)
+	assert.Nil(t, err)
+	assert.NotNil(t, proposal)
+	assert.Equal(t, APPROVE_CANDIDATE, proposal.Method)
+	assert.Equal(t, input, proposal.Input)
+	assert.Equal(t, accts[0].Address, proposal.Proposer)
+	assert.Equal(t, uint32(10)+PROPOSAL_LIFETIME, proposal.ExpiryHeight)
+	assert.Equal(t, []common.Address{accts[0].Address, accts[1].Address}, proposal.Voters)
+
+	// a non consensus address can not vote
+	_, err = CheckConsensusSigns(ns, APPROVE_CANDIDATE, input, common.Address{9})
+	assert.NotNil(t, err)
+	proposal, err = GetProposal(ns, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, []common.Address{accts[0].Address, accts[1].Address}, proposal.Voters)
+
+	ok, err := CheckConsensusSigns(ns, APPROVE_CANDIDATE, input, accts[2].Address)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	proposal, err = GetProposal(ns, 0)
+	assert.Nil(t, err)
+	assert.Nil(t, proposal)
+
+	// proposing the same action again opens a new proposal
+	ok, err = CheckConsensusSigns(ns, APPROVE_CANDIDATE, input, accts[3].Address)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	proposal, err = GetProposal(ns, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []common.Address{accts[3].Address}, proposal.Voters)
+}
+
+func TestCheckConsensusSignsExpiry(t *testing.T) {
+	defer scheduleProposalFork(5)()
+	accts := newProposalTestAccounts()
+	db := newProposalTestDB(t, accts)
+	input := []byte("peer")
+
+	ns := newProposalTestNative(t, db, nil, nil, 10)
+	for _, acct := range accts[:2] {
+		ok, err := CheckConsensusSigns(ns, BLACK_NODE, input, acct.Address)
+		assert.Nil(t, err)
+		assert.False(t, ok)
+	}
+
+	// the last vote after expiry does not revive the stale votes
+	ns = newProposalTestNative(t, db, nil, nil, 11+PROPOSAL_LIFETIME)
+	ok, err := CheckConsensusSigns(ns, BLACK_NODE, input, accts[2].Address)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	proposal, err := GetProposal(ns, 0)
+	assert.Nil(t, err)
+	assert.Nil(t, proposal)
+	proposal, err = GetProposal(ns, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []common.Address{accts[2].Address}, proposal.Voters)
+	assert.Equal(t, accts[2].Address, proposal.Proposer)
+}
+
+func TestRevokeVote(t *testing.T) {
+	defer scheduleProposalFork(5)()
+	accts := newProposalTestAccounts()
+	db := newProposalTestDB(t, accts)
+	input := []byte("peer")
+
+	ns := newProposalTestNative(t, db, nil, nil, 10)
+	for _, acct := range accts[:2] {
+		ok, err := CheckConsensusSigns(ns, WHITE_NODE, input, acct.Address)
+		assert.Nil(t, err)
+		assert.False(t, ok)
+	}
+
+	revoke := func(signer *account.Account, id uint64, address common.Address, height uint32) error {
+		sink := common.NewZeroCopySink(nil)
+		(&RevokeVoteParam{ID: id, Address: address}).Serialization(sink)
+		_, err := RevokeVote(newProposalTestNative(t, db, sink.Bytes(), signer, height))
+		return err
+	}
+	assert.NotNil(t, revoke(accts[0], 0, accts[1].Address, 10), "witness of another voter")
+	assert.NotNil(t, revoke(accts[2], 0, accts[2].Address, 10), "not voted")
+	assert.NotNil(t, revoke(accts[1], 1, accts[1].Address, 10), "unknown proposal")
+	assert.NotNil(t, revoke(accts[1], 0, accts[1].Address, 11+PROPOSAL_LIFETIME), "expired proposal")
+
+	assert.Nil(t, revoke(accts[1], 0, accts[1].Address, 10))
+	proposal, err := GetProposal(ns, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, []common.Address{accts[0].Address}, proposal.Voters)
+
+	// the revoked vote is needed again to pass
+	for _, acct := range accts[2:] {
+		ok, err := CheckConsensusSigns(ns, WHITE_NODE, input, acct.Address)
+		assert.Nil(t, err)
+		assert.True(t, ok == (acct == accts[3]))
+	}
+
+	ok, err := CheckConsensusSigns(ns, WHITE_NODE, input, accts[0].Address)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	assert.Nil(t, revoke(accts[0], 1, accts[0].Address, 10))
+	proposal, err = GetProposal(ns, 1)
+	assert.Nil(t, err)
+	assert.Nil(t, proposal)
+}
+
+func TestCheckConsensusSignsFork(t *testing.T) {
+	defer scheduleProposalFork(100)()
+	accts := newProposalTestAccounts()
+	db := newProposalTestDB(t, accts)
+	input := []byte("peer")
+	key := sha256.Sum256(append([]byte(APPROVE_CANDIDATE), input...))
+
+	// signs of any address are kept before the fork
+	ns := newProposalTestNative(t, db, nil, nil, 99)
+	for _, address := range []common.Address{accts[0].Address, {9}} {
+		ok, err := CheckConsensusSigns(ns, APPROVE_CANDIDATE, input, address)
+		assert.Nil(t, err)
+		assert.False(t, ok)
+	}
+	assert.Equal(t, []interface{}{"CheckConsensusSigns", 2}, ns.GetNotify()[1].States)
+	sink := common.NewZeroCopySink(nil)
+	(&RevokeVoteParam{ID: 0, Address: accts[0].Address}).Serialization(sink)
+	_, err := RevokeVote(newProposalTestNative(t, db, sink.Bytes(), accts[0], 99))
+	assert.NotNil(t, err)
+	proposal, err := GetProposal(ns, 0)
+	assert.Nil(t, err)
+	assert.Nil(t, proposal)
+
+	// and carried over to a proposal by the first vote after it
+	ns = newProposalTestNative(t, db, nil, nil, 100)
+	_, err = CheckConsensusSigns(ns, APPROVE_CANDIDATE, input, common.Address{10})
+	assert.NotNil(t, err)
+	ok, err := CheckConsensusSigns(ns, APPROVE_CANDIDATE, input, accts[1].Address)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	proposal, err = GetProposal(ns, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(proposal.Voters))
+	assert.True(t, proposal.HasVoter(accts[0].Address))
+	assert.True(t, proposal.HasVoter(common.Address{9}))
+	consensusSigns, err := getConsensusSigns(ns, key)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(consensusSigns.SignsMap))
+
+	ok, err = CheckConsensusSigns(ns, APPROVE_CANDIDATE, input, accts[2].Address)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+}
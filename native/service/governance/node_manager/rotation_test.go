@@ -0,0 +1,136 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package node_manager
+
+import (
+	"encoding/hex"
+	"github.com/polynetwork/poly/account"
+	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/consensus/vbft/config"
+	cstates "github.com/polynetwork/poly/core/states"
+	"github.com/polynetwork/poly/core/types"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/service/utils"
+	"github.com/polynetwork/poly/native/storage"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// newRotationTestNative returns a native service of tx signed by all signers, as rotation is witnessed by both keys
+func newRotationTestNative(t *testing.T, db *storage.CacheDB, args []byte, height uint32, signers ...*account.Account) *native.NativeService {
+	tx := new(types.Transaction)
+	for _, signer := range signers {
+		tx.SignedAddr = append(tx.SignedAddr, signer.Address)
+	}
+	ns, err := native.NewNativeService(db, tx, 0, height, common.Uint256{}, 0, args, false)
+	assert.Nil(t, err)
+	return ns
+}
+
+func TestRotatePeerKey(t *testing.T) {
+	accts := newProposalTestAccounts()
+	db := newProposalTestDB(t, accts)
+	newAcct := account.NewAccount("")
+	oldPubkey, newPubkey := vconfig.PubkeyID(accts[0].PublicKey), vconfig.PubkeyID(newAcct.PublicKey)
+	oldPrefix, _ := hex.DecodeString(oldPubkey)
+	newPrefix, _ := hex.DecodeString(newPubkey)
+	indexKey := func(prefix []byte) []byte {
+		return utils.ConcatKey(utils.NodeManagerContractAddress, []byte(PEER_INDEX), prefix)
+	}
+	db.Put(indexKey(oldPrefix), cstates.GenRawStorageItem(utils.GetUint32Bytes(0)))
+
+	rotate := func(params *RotatePeerKeyParam, signers ...*account.Account) error {
+		sink := common.NewZeroCopySink(nil)
+		params.Serialization(sink)
+		_, err := RotatePeerKey(newRotationTestNative(t, db, sink.Bytes(), 10, signers...))
+		return err
+	}
+	approve := func(voter *account.Account) error {
+		sink := common.NewZeroCopySink(nil)
+		(&PeerParam{PeerPubkey: oldPubkey, Address: voter.Address}).Serialization(sink)
+		_, err := ApproveRotatePeerKey(newRotationTestNative(t, db, sink.Bytes(), 10, voter))
+		return err
+	}
+
+	assert.NotNil(t, approve(accts[1]), "rotation not applied")
+	params := &RotatePeerKeyParam{PeerPubkey: oldPubkey, NewPeerPubkey: newPubkey, Address: accts[0].Address}
+	assert.NotNil(t, rotate(params, accts[0]), "not signed by new key")
+	assert.NotNil(t, rotate(params, newAcct), "not signed by owner and old key")
+	taken := &RotatePeerKeyParam{PeerPubkey: oldPubkey, NewPeerPubkey: vconfig.PubkeyID(accts[1].PublicKey), Address: accts[0].Address}
+	assert.NotNil(t, rotate(taken, accts[0], accts[1]), "new key in peer pool")
+	assert.Nil(t, rotate(params, accts[0], newAcct))
+
+	for _, acct := range accts[:3] {
+		assert.Nil(t, approve(acct))
+	}
+	ns := newRotationTestNative(t, db, nil, 10)
+	apply, err := GetRotationApply(ns, oldPubkey)
+	assert.Nil(t, err)
+	assert.Nil(t, apply)
+	rotation, err := getRotation(ns, oldPubkey)
+	assert.Nil(t, err)
+	assert.Equal(t, newPubkey, rotation)
+	assert.NotNil(t, rotate(params, accts[0], newAcct), "rotation waiting for commitDpos")
+
+	// the key is swapped at the epoch boundary keeping index and status of the node
+	ns = newRotationTestNative(t, db, nil, 20)
+	assert.Nil(t, executeCommitDpos(ns))
+	peerPoolMap, err := GetPeerPoolMap(ns, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, len(accts), len(peerPoolMap.PeerPoolMap))
+	_, ok := peerPoolMap.PeerPoolMap[oldPubkey]
+	assert.False(t, ok)
+	assert.Equal(t, &PeerPoolItem{
+		Index:      0,
+		PeerPubkey: newPubkey,
+		Address:    accts[0].Address,
+		Status:     ConsensusStatus,
+	}, peerPoolMap.PeerPoolMap[newPubkey])
+	rotation, err = getRotation(ns, oldPubkey)
+	assert.Nil(t, err)
+	assert.Equal(t, "", rotation)
+	value, err := db.Get(indexKey(oldPrefix))
+	assert.Nil(t, err)
+	assert.Nil(t, value)
+	value, err = db.Get(indexKey(newPrefix))
+	assert.Nil(t, err)
+	assert.NotNil(t, value)
+}
+
+func TestRotatePeerKeyBlacked(t *testing.T) {
+	accts := newProposalTestAccounts()
+	db := newProposalTestDB(t, accts)
+	newAcct := account.NewAccount("")
+	oldPubkey, newPubkey := vconfig.PubkeyID(accts[0].PublicKey), vconfig.PubkeyID(newAcct.PublicKey)
+	newPrefix, _ := hex.DecodeString(newPubkey)
+
+	// the new key is blacked after the rotation is approved
+	ns := newRotationTestNative(t, db, nil, 20)
+	assert.Nil(t, putRotation(ns, oldPubkey, newPubkey))
+	db.Put(utils.ConcatKey(utils.NodeManagerContractAddress, []byte(BLACK_LIST), newPrefix), cstates.GenRawStorageItem([]byte{1}))
+	assert.Nil(t, executeCommitDpos(ns))
+	peerPoolMap, err := GetPeerPoolMap(ns, 1)
+	assert.Nil(t, err)
+	_, ok := peerPoolMap.PeerPoolMap[oldPubkey]
+	assert.True(t, ok)
+	_, ok = peerPoolMap.PeerPoolMap[newPubkey]
+	assert.False(t, ok)
+	rotation, err := getRotation(ns, oldPubkey)
+	assert.Nil(t, err)
+	assert.Equal(t, "", rotation)
+}
@@ -223,6 +223,116 @@ func (this *ConsensusSigns) Deserialization(source *common.ZeroCopySource) error
 	return nil
 }
 
+// Proposal is a governance action waiting for enough votes of consensus peers, it is keyed by
+// sha256(method||input) of the action
+type Proposal struct {
+	ID           uint64
+	Hash         common.Uint256
+	Method       string
+	Input        []byte
+	Proposer     common.Address
+	Height       uint32
+	ExpiryHeight uint32
+	Voters       []common.Address
+}
+
+func (this *Proposal) IsExpired(height uint32) bool {
+	return height > this.ExpiryHeight
+}
+
+func (this *Proposal) HasVoter(address common.Address) bool {
+	for _, v := range this.Voters {
+		if v == address {
+			return true
+		}
+	}
+	return false
+}
+
+func (this *Proposal) RemoveVoter(address common.Address) bool {
+	for i, v := range this.Voters {
+		if v == address {
+			this.Voters = append(this.Voters[:i], this.Voters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (this *Proposal) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteUint64(this.ID)
+	sink.WriteHash(this.Hash)
+	sink.WriteString(this.Method)
+	sink.WriteVarBytes(this.Input)
+	sink.WriteVarBytes(this.Proposer[:])
+	sink.WriteUint32(this.Height)
+	sink.WriteUint32(this.ExpiryHeight)
+	sink.WriteVarUint(uint64(len(this.Voters)))
+	for _, v := range this.Voters {
+		sink.WriteVarBytes(v[:])
+	}
+}
+
+func (this *Proposal) Deserialization(source *common.ZeroCopySource) error {
+	id, eof := source.NextUint64()
+	if eof {
+		return fmt.Errorf("source.NextUint64, deserialize id error")
+	}
+	hash, eof := source.NextHash()
+	if eof {
+		return fmt.Errorf("source.NextHash, deserialize hash error")
+	}
+	method, eof := source.NextString()
+	if eof {
+		return fmt.Errorf("source.NextString, deserialize method error")
+	}
+	input, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("source.NextVarBytes, deserialize input error")
+	}
+	proposer, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("source.NextVarBytes, deserialize proposer error")
+	}
+	proposerAddr, err := common.AddressParseFromBytes(proposer)
+	if err != nil {
+		return fmt.Errorf("common.AddressParseFromBytes, deserialize proposer error: %s", err)
+	}
+	height, eof := source.NextUint32()
+	if eof {
+		return fmt.Errorf("source.NextUint32, deserialize height error")
+	}
+	expiryHeight, eof := source.NextUint32()
+	if eof {
+		return fmt.Errorf("source.NextUint32, deserialize expiryHeight error")
+	}
+	n, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("source.NextVarUint, deserialize length of voters error")
+	}
+	voters := make([]common.Address, 0)
+	for i := 0; uint64(i) < n; i++ {
+		address, eof := source.NextVarBytes()
+		if eof {
+			return fmt.Errorf("source.NextVarBytes, deserialize voter error")
+		}
+		addr, err := common.AddressParseFromBytes(address)
+		if err != nil {
+			return fmt.Errorf("common.AddressParseFromBytes, deserialize voter error: %s", err)
+		}
+		voters = append(voters, addr)
+	}
+	this.ID = id
+	this.Hash = hash
+	this.Method = method
+	this.Input = input
+	this.Proposer = proposerAddr
+	this.Height = height
+	this.ExpiryHeight = expiryHeight
+	this.Voters = voters
+	return nil
+}
+
 type Configuration struct {
 	BlockMsgDelay        uint32
 	HashMsgDelay         uint32
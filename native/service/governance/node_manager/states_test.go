@@ -38,3 +38,26 @@ func Test_Deserialize_GovernanceView(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, *govView, *govView1)
 }
+
+func Test_Deserialize_Proposal(t *testing.T) {
+	proposal := &Proposal{
+		ID:           3,
+		Hash:         common.Uint256{1, 2, 3},
+		Method:       APPROVE_CANDIDATE,
+		Input:        []byte("peer"),
+		Proposer:     common.Address{1},
+		Height:       10,
+		ExpiryHeight: 10 + PROPOSAL_LIFETIME,
+		Voters:       []common.Address{{1}, {2}},
+	}
+	sink := common.NewZeroCopySink(nil)
+	proposal.Serialization(sink)
+
+	proposal1 := new(Proposal)
+	err := proposal1.Deserialization(common.NewZeroCopySource(sink.Bytes()))
+	assert.Nil(t, err)
+	assert.Equal(t, proposal, proposal1)
+
+	err = proposal1.Deserialization(common.NewZeroCopySource(sink.Bytes()[:sink.Size()-1]))
+	assert.NotNil(t, err)
+}
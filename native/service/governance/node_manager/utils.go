@@ -23,6 +23,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"github.com/polynetwork/poly/native/event"
+	"sort"
 
 	"github.com/ontio/ontology-crypto/keypair"
 	"github.com/polynetwork/poly/common"
@@ -69,6 +70,116 @@ func putPeerApply(native *native.NativeService, peer *RegisterPeerParam) error {
 	return nil
 }
 
+func GetRotationApply(native *native.NativeService, peerPubkey string) (*RotatePeerKeyParam, error) {
+	contract := utils.NodeManagerContractAddress
+	peerPubkeyPrefix, err := hex.DecodeString(peerPubkey)
+	if err != nil {
+		return nil, fmt.Errorf("GetRotationApply, peerPubkey format error: %v", err)
+	}
+	applyStore, err := native.GetCacheDB().Get(utils.ConcatKey(contract, []byte(ROTATION_APPLY), peerPubkeyPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("GetRotationApply, get applyStore error: %v", err)
+	}
+	if applyStore == nil {
+		return nil, nil
+	}
+	applyBytes, err := cstates.GetValueFromRawStorageItem(applyStore)
+	if err != nil {
+		return nil, fmt.Errorf("GetRotationApply, deserialize from raw storage item err:%v", err)
+	}
+	apply := new(RotatePeerKeyParam)
+	if err := apply.Deserialization(common.NewZeroCopySource(applyBytes)); err != nil {
+		return nil, fmt.Errorf("GetRotationApply, deserialize apply error: %v", err)
+	}
+	return apply, nil
+}
+
+func putRotationApply(native *native.NativeService, apply *RotatePeerKeyParam) error {
+	contract := utils.NodeManagerContractAddress
+	peerPubkeyPrefix, err := hex.DecodeString(apply.PeerPubkey)
+	if err != nil {
+		return fmt.Errorf("putRotationApply, peerPubkey format error: %v", err)
+	}
+	sink := common.NewZeroCopySink(nil)
+	apply.Serialization(sink)
+	native.GetCacheDB().Put(utils.ConcatKey(contract, []byte(ROTATION_APPLY), peerPubkeyPrefix), cstates.GenRawStorageItem(sink.Bytes()))
+	return nil
+}
+
+func deleteRotationApply(native *native.NativeService, peerPubkey string) error {
+	contract := utils.NodeManagerContractAddress
+	peerPubkeyPrefix, err := hex.DecodeString(peerPubkey)
+	if err != nil {
+		return fmt.Errorf("deleteRotationApply, peerPubkey format error: %v", err)
+	}
+	native.GetCacheDB().Delete(utils.ConcatKey(contract, []byte(ROTATION_APPLY), peerPubkeyPrefix))
+	return nil
+}
+
+// getRotation returns the approved new key of peerPubkey, it is empty if no rotation is approved
+func getRotation(native *native.NativeService, peerPubkey string) (string, error) {
+	contract := utils.NodeManagerContractAddress
+	peerPubkeyPrefix, err := hex.DecodeString(peerPubkey)
+	if err != nil {
+		return "", fmt.Errorf("getRotation, peerPubkey format error: %v", err)
+	}
+	rotationStore, err := native.GetCacheDB().Get(utils.ConcatKey(contract, []byte(ROTATION), peerPubkeyPrefix))
+	if err != nil {
+		return "", fmt.Errorf("getRotation, get rotationStore error: %v", err)
+	}
+	if rotationStore == nil {
+		return "", nil
+	}
+	newPeerPubkey, err := cstates.GetValueFromRawStorageItem(rotationStore)
+	if err != nil {
+		return "", fmt.Errorf("getRotation, deserialize from raw storage item err:%v", err)
+	}
+	return string(newPeerPubkey), nil
+}
+
+func putRotation(native *native.NativeService, peerPubkey, newPeerPubkey string) error {
+	contract := utils.NodeManagerContractAddress
+	peerPubkeyPrefix, err := hex.DecodeString(peerPubkey)
+	if err != nil {
+		return fmt.Errorf("putRotation, peerPubkey format error: %v", err)
+	}
+	native.GetCacheDB().Put(utils.ConcatKey(contract, []byte(ROTATION), peerPubkeyPrefix), cstates.GenRawStorageItem([]byte(newPeerPubkey)))
+	return nil
+}
+
+func deleteRotation(native *native.NativeService, peerPubkey string) error {
+	contract := utils.NodeManagerContractAddress
+	peerPubkeyPrefix, err := hex.DecodeString(peerPubkey)
+	if err != nil {
+		return fmt.Errorf("deleteRotation, peerPubkey format error: %v", err)
+	}
+	native.GetCacheDB().Delete(utils.ConcatKey(contract, []byte(ROTATION), peerPubkeyPrefix))
+	return nil
+}
+
+// movePeerIndex keeps the index of a node whose key is rotated from peerPubkey to newPeerPubkey
+func movePeerIndex(native *native.NativeService, peerPubkey, newPeerPubkey string) error {
+	contract := utils.NodeManagerContractAddress
+	peerPubkeyPrefix, err := hex.DecodeString(peerPubkey)
+	if err != nil {
+		return fmt.Errorf("movePeerIndex, peerPubkey format error: %v", err)
+	}
+	newPeerPubkeyPrefix, err := hex.DecodeString(newPeerPubkey)
+	if err != nil {
+		return fmt.Errorf("movePeerIndex, new peerPubkey format error: %v", err)
+	}
+	indexBytes, err := native.GetCacheDB().Get(utils.ConcatKey(contract, []byte(PEER_INDEX), peerPubkeyPrefix))
+	if err != nil {
+		return fmt.Errorf("movePeerIndex, get indexBytes error: %v", err)
+	}
+	if indexBytes == nil {
+		return nil
+	}
+	native.GetCacheDB().Put(utils.ConcatKey(contract, []byte(PEER_INDEX), newPeerPubkeyPrefix), indexBytes)
+	native.GetCacheDB().Delete(utils.ConcatKey(contract, []byte(PEER_INDEX), peerPubkeyPrefix))
+	return nil
+}
+
 func GetPeerPoolMap(native *native.NativeService, view uint32) (*PeerPoolMap, error) {
 	contract := utils.NodeManagerContractAddress
 	viewBytes := utils.GetUint32Bytes(view)
@@ -268,7 +379,193 @@ func deleteConsensusSigns(native *native.NativeService, key common.Uint256) {
 	native.GetCacheDB().Delete(utils.ConcatKey(contract, []byte(CONSENSUS_SIGNS), key.ToArray()))
 }
 
+func GetProposal(native *native.NativeService, id uint64) (*Proposal, error) {
+	contract := utils.NodeManagerContractAddress
+	proposalStore, err := native.GetCacheDB().Get(utils.ConcatKey(contract, []byte(PROPOSAL), utils.GetUint64Bytes(id)))
+	if err != nil {
+		return nil, fmt.Errorf("GetProposal, get proposalStore error: %v", err)
+	}
+	if proposalStore == nil {
+		return nil, nil
+	}
+	proposalBytes, err := cstates.GetValueFromRawStorageItem(proposalStore)
+	if err != nil {
+		return nil, fmt.Errorf("GetProposal, deserialize from raw storage item err:%v", err)
+	}
+	proposal := new(Proposal)
+	if err := proposal.Deserialization(common.NewZeroCopySource(proposalBytes)); err != nil {
+		return nil, fmt.Errorf("GetProposal, deserialize proposal error: %v", err)
+	}
+	return proposal, nil
+}
+
+func getProposalByHash(native *native.NativeService, hash common.Uint256) (*Proposal, error) {
+	contract := utils.NodeManagerContractAddress
+	idStore, err := native.GetCacheDB().Get(utils.ConcatKey(contract, []byte(PROPOSAL_HASH), hash.ToArray()))
+	if err != nil {
+		return nil, fmt.Errorf("getProposalByHash, get idStore error: %v", err)
+	}
+	if idStore == nil {
+		return nil, nil
+	}
+	idBytes, err := cstates.GetValueFromRawStorageItem(idStore)
+	if err != nil {
+		return nil, fmt.Errorf("getProposalByHash, deserialize from raw storage item err:%v", err)
+	}
+	return GetProposal(native, utils.GetBytesUint64(idBytes))
+}
+
+func putProposal(native *native.NativeService, proposal *Proposal) {
+	contract := utils.NodeManagerContractAddress
+	sink := common.NewZeroCopySink(nil)
+	proposal.Serialization(sink)
+	native.GetCacheDB().Put(utils.ConcatKey(contract, []byte(PROPOSAL), utils.GetUint64Bytes(proposal.ID)),
+		cstates.GenRawStorageItem(sink.Bytes()))
+	native.GetCacheDB().Put(utils.ConcatKey(contract, []byte(PROPOSAL_HASH), proposal.Hash.ToArray()),
+		cstates.GenRawStorageItem(utils.GetUint64Bytes(proposal.ID)))
+}
+
+func deleteProposal(native *native.NativeService, proposal *Proposal) {
+	contract := utils.NodeManagerContractAddress
+	native.GetCacheDB().Delete(utils.ConcatKey(contract, []byte(PROPOSAL), utils.GetUint64Bytes(proposal.ID)))
+	native.GetCacheDB().Delete(utils.ConcatKey(contract, []byte(PROPOSAL_HASH), proposal.Hash.ToArray()))
+}
+
+// newProposal creates a proposal with the next id, the id is never reused so a passed or expired
+// action proposed again gets a new one
+func newProposal(native *native.NativeService, hash common.Uint256, method string, input []byte,
+	proposer common.Address) (*Proposal, error) {
+	contract := utils.NodeManagerContractAddress
+	seqStore, err := native.GetCacheDB().Get(utils.ConcatKey(contract, []byte(PROPOSAL_SEQ)))
+	if err != nil {
+		return nil, fmt.Errorf("newProposal, get seqStore error: %v", err)
+	}
+	var id uint64
+	if seqStore != nil {
+		seqBytes, err := cstates.GetValueFromRawStorageItem(seqStore)
+		if err != nil {
+			return nil, fmt.Errorf("newProposal, deserialize from raw storage item err:%v", err)
+		}
+		id = utils.GetBytesUint64(seqBytes)
+	}
+	native.GetCacheDB().Put(utils.ConcatKey(contract, []byte(PROPOSAL_SEQ)), cstates.GenRawStorageItem(utils.GetUint64Bytes(id+1)))
+	return &Proposal{
+		ID:           id,
+		Hash:         hash,
+		Method:       method,
+		Input:        input,
+		Proposer:     proposer,
+		Height:       native.GetHeight(),
+		ExpiryHeight: native.GetHeight() + PROPOSAL_LIFETIME,
+		Voters:       make([]common.Address, 0),
+	}, nil
+}
+
+// CountConsensusVotes returns how many voters are consensus peers in peerPoolMap and the votes
+// required to pass a proposal
+func CountConsensusVotes(peerPoolMap *PeerPoolMap, voters []common.Address) (int, int, error) {
+	voted := make(map[common.Address]bool)
+	for _, v := range voters {
+		voted[v] = true
+	}
+	num := 0
+	sum := 0
+	for key, v := range peerPoolMap.PeerPoolMap {
+		if v.Status == ConsensusStatus {
+			k, err := hex.DecodeString(key)
+			if err != nil {
+				return 0, 0, fmt.Errorf("CountConsensusVotes, hex.DecodeString public key error: %v", err)
+			}
+			publicKey, err := keypair.DeserializePublicKey(k)
+			if err != nil {
+				return 0, 0, fmt.Errorf("CountConsensusVotes, keypair.DeserializePublicKey error: %v", err)
+			}
+			if voted[types.AddressFromPubKey(publicKey)] {
+				num = num + 1
+			}
+			sum = sum + 1
+		}
+	}
+	return num, (2*sum + 2) / 3, nil
+}
+
+// CheckConsensusSigns votes for method and input by address, it returns true once approved by 2/3
+// of the current consensus peers. From config.FORK_GOVERNANCE_PROPOSAL on, the votes are kept in a
+// proposal which expires, and only the current consensus peers can propose or vote.
 func CheckConsensusSigns(native *native.NativeService, method string, input []byte, address common.Address) (bool, error) {
+	if !native.IsForkActive(config.FORK_GOVERNANCE_PROPOSAL) {
+		return checkConsensusSignsLegacy(native, method, input, address)
+	}
+	//get view
+	view, err := GetView(native)
+	if err != nil {
+		return false, fmt.Errorf("CheckConsensusSigns, GetView error: %v", err)
+	}
+	//get consensus peer
+	peerPoolMap, err := GetPeerPoolMap(native, view)
+	if err != nil {
+		return false, fmt.Errorf("CheckConsensusSigns, GetPeerPoolMap error: %v", err)
+	}
+	isPeer, _, err := CountConsensusVotes(peerPoolMap, []common.Address{address})
+	if err != nil {
+		return false, fmt.Errorf("CheckConsensusSigns, %v", err)
+	}
+	if isPeer == 0 {
+		return false, fmt.Errorf("CheckConsensusSigns, address %s is not a consensus peer", address.ToBase58())
+	}
+
+	message := append([]byte(method), input...)
+	key := sha256.Sum256(message)
+	proposal, err := getProposalByHash(native, key)
+	if err != nil {
+		return false, fmt.Errorf("CheckConsensusSigns, getProposalByHash error: %v", err)
+	}
+	if proposal != nil && proposal.IsExpired(native.GetHeight()) {
+		deleteProposal(native, proposal)
+		proposal = nil
+	}
+	if proposal == nil {
+		proposal, err = newProposal(native, key, method, input, address)
+		if err != nil {
+			return false, fmt.Errorf("CheckConsensusSigns, newProposal error: %v", err)
+		}
+		// signs voted before the fork are carried over to the proposal
+		consensusSigns, err := getConsensusSigns(native, key)
+		if err != nil {
+			return false, fmt.Errorf("CheckConsensusSigns, getConsensusSigns error: %v", err)
+		}
+		for signer := range consensusSigns.SignsMap {
+			proposal.Voters = append(proposal.Voters, signer)
+		}
+		sort.Slice(proposal.Voters, func(i, j int) bool {
+			return bytes.Compare(proposal.Voters[i][:], proposal.Voters[j][:]) < 0
+		})
+		deleteConsensusSigns(native, key)
+	}
+	if !proposal.HasVoter(address) {
+		proposal.Voters = append(proposal.Voters, address)
+	}
+	native.AddNotify(
+		&event.NotifyEventInfo{
+			ContractAddress: utils.NodeManagerContractAddress,
+			States:          []interface{}{"CheckConsensusSigns", len(proposal.Voters), proposal.ID},
+		})
+	//check signs num
+	num, required, err := CountConsensusVotes(peerPoolMap, proposal.Voters)
+	if err != nil {
+		return false, fmt.Errorf("CheckConsensusSigns, %v", err)
+	}
+	if num >= required {
+		deleteProposal(native, proposal)
+		return true, nil
+	} else {
+		putProposal(native, proposal)
+		return false, nil
+	}
+}
+
+// checkConsensusSignsLegacy keeps the signs of any address before config.FORK_GOVERNANCE_PROPOSAL
+func checkConsensusSignsLegacy(native *native.NativeService, method string, input []byte, address common.Address) (bool, error) {
 	message := append([]byte(method), input...)
 	key := sha256.Sum256(message)
 	consensusSigns, err := getConsensusSigns(native, key)
@@ -292,26 +589,15 @@ func CheckConsensusSigns(native *native.NativeService, method string, input []by
 	if err != nil {
 		return false, fmt.Errorf("CheckConsensusSigns, GetPeerPoolMap error: %v", err)
 	}
-	num := 0
-	sum := 0
-	for key, v := range peerPoolMap.PeerPoolMap {
-		if v.Status == ConsensusStatus {
-			k, err := hex.DecodeString(key)
-			if err != nil {
-				return false, fmt.Errorf("CheckConsensusSigns, hex.DecodeString public key error: %v", err)
-			}
-			publicKey, err := keypair.DeserializePublicKey(k)
-			if err != nil {
-				return false, fmt.Errorf("CheckConsensusSigns, keypair.DeserializePublicKey error: %v", err)
-			}
-			_, ok := consensusSigns.SignsMap[types.AddressFromPubKey(publicKey)]
-			if ok {
-				num = num + 1
-			}
-			sum = sum + 1
-		}
+	signers := make([]common.Address, 0, len(consensusSigns.SignsMap))
+	for signer := range consensusSigns.SignsMap {
+		signers = append(signers, signer)
+	}
+	num, required, err := CountConsensusVotes(peerPoolMap, signers)
+	if err != nil {
+		return false, fmt.Errorf("CheckConsensusSigns, %v", err)
 	}
-	if num >= (2*sum+2)/3 {
+	if num >= required {
 		deleteConsensusSigns(native, key)
 		return true, nil
 	} else {
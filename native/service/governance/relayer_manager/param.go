@@ -25,6 +25,9 @@ import (
 type RelayerListParam struct {
 	AddressList []common.Address
 	Address     common.Address
+	// optional scope of registered relayers, omitted in the legacy format
+	ChainIDs     []uint64 // side chains relayers may serve, empty for all chains
+	ExpiryHeight uint32   // last height relayers are valid, 0 for no expiry
 }
 
 func (this *RelayerListParam) Serialization(sink *common.ZeroCopySink) {
@@ -33,6 +36,14 @@ func (this *RelayerListParam) Serialization(sink *common.ZeroCopySink) {
 		sink.WriteVarBytes(v[:])
 	}
 	sink.WriteVarBytes(this.Address[:])
+	if len(this.ChainIDs) == 0 && this.ExpiryHeight == 0 {
+		return
+	}
+	sink.WriteVarUint(uint64(len(this.ChainIDs)))
+	for _, v := range this.ChainIDs {
+		sink.WriteVarUint(v)
+	}
+	sink.WriteUint32(this.ExpiryHeight)
 }
 
 func (this *RelayerListParam) Deserialization(source *common.ZeroCopySource) error {
@@ -61,11 +72,60 @@ func (this *RelayerListParam) Deserialization(source *common.ZeroCopySource) err
 	if err != nil {
 		return fmt.Errorf("common.AddressParseFromBytes, deserialize address error: %s", err)
 	}
+	var chainIDs []uint64
+	var expiryHeight uint32
+	if source.Len() > 0 {
+		if chainIDs, err = deserializeChainIDs(source); err != nil {
+			return err
+		}
+		expiryHeight, eof = source.NextUint32()
+		if eof {
+			return fmt.Errorf("source.NextUint32, deserialize expiryHeight error")
+		}
+	}
 	this.AddressList = addressList
 	this.Address = addr
+	this.ChainIDs = chainIDs
+	this.ExpiryHeight = expiryHeight
 	return nil
 }
 
+func deserializeChainIDs(source *common.ZeroCopySource) ([]uint64, error) {
+	n, eof := source.NextVarUint()
+	if eof {
+		return nil, fmt.Errorf("source.NextVarUint, deserialize ChainIDs length error")
+	}
+	var chainIDs []uint64
+	for i := 0; uint64(i) < n; i++ {
+		chainID, eof := source.NextVarUint()
+		if eof {
+			return nil, fmt.Errorf("source.NextVarUint, deserialize chainID error")
+		}
+		chainIDs = append(chainIDs, chainID)
+	}
+	return chainIDs, nil
+}
+
+func deserializeAddressList(source *common.ZeroCopySource) ([]common.Address, error) {
+	n, eof := source.NextVarUint()
+	if eof {
+		return nil, fmt.Errorf("source.NextVarUint, deserialize address list length error")
+	}
+	addressList := make([]common.Address, 0)
+	for i := 0; uint64(i) < n; i++ {
+		address, eof := source.NextVarBytes()
+		if eof {
+			return nil, fmt.Errorf("source.NextVarBytes, deserialize address error")
+		}
+		addr, err := common.AddressParseFromBytes(address)
+		if err != nil {
+			return nil, fmt.Errorf("common.AddressParseFromBytes, deserialize address error: %s", err)
+		}
+		addressList = append(addressList, addr)
+	}
+	return addressList, nil
+}
+
 type ApproveRelayerParam struct {
 	ID      uint64
 	Address common.Address
@@ -94,3 +154,191 @@ func (this *ApproveRelayerParam) Deserialization(source *common.ZeroCopySource)
 	this.Address = addr
 	return nil
 }
+
+type RelayerPolicyParam struct {
+	ChainID   uint64
+	Mode      uint8
+	AllowList []common.Address
+	Address   common.Address
+}
+
+func (this *RelayerPolicyParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarUint(this.ChainID)
+	sink.WriteUint8(this.Mode)
+	sink.WriteVarUint(uint64(len(this.AllowList)))
+	for _, v := range this.AllowList {
+		sink.WriteVarBytes(v[:])
+	}
+	sink.WriteVarBytes(this.Address[:])
+}
+
+func (this *RelayerPolicyParam) Deserialization(source *common.ZeroCopySource) error {
+	chainID, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("source.NextVarUint, deserialize chainID error")
+	}
+	mode, eof := source.NextUint8()
+	if eof {
+		return fmt.Errorf("source.NextUint8, deserialize mode error")
+	}
+	n, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("source.NextVarUint, deserialize AllowList length error")
+	}
+	allowList := make([]common.Address, 0)
+	for i := 0; uint64(i) < n; i++ {
+		address, eof := source.NextVarBytes()
+		if eof {
+			return fmt.Errorf("source.NextVarBytes, deserialize allow address error")
+		}
+		addr, err := common.AddressParseFromBytes(address)
+		if err != nil {
+			return fmt.Errorf("common.AddressParseFromBytes, deserialize allow address error: %s", err)
+		}
+		allowList = append(allowList, addr)
+	}
+	address, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("source.NextVarBytes, deserialize address error")
+	}
+	addr, err := common.AddressParseFromBytes(address)
+	if err != nil {
+		return fmt.Errorf("common.AddressParseFromBytes, deserialize address error: %s", err)
+	}
+	this.ChainID = chainID
+	this.Mode = mode
+	this.AllowList = allowList
+	this.Address = addr
+	return nil
+}
+
+type RelayerPolicy struct {
+	Mode      uint8
+	AllowList []common.Address
+}
+
+func (this *RelayerPolicy) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteUint8(this.Mode)
+	sink.WriteVarUint(uint64(len(this.AllowList)))
+	for _, v := range this.AllowList {
+		sink.WriteVarBytes(v[:])
+	}
+}
+
+func (this *RelayerPolicy) Deserialization(source *common.ZeroCopySource) error {
+	mode, eof := source.NextUint8()
+	if eof {
+		return fmt.Errorf("source.NextUint8, deserialize mode error")
+	}
+	n, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("source.NextVarUint, deserialize AllowList length error")
+	}
+	allowList := make([]common.Address, 0)
+	for i := 0; uint64(i) < n; i++ {
+		address, eof := source.NextVarBytes()
+		if eof {
+			return fmt.Errorf("source.NextVarBytes, deserialize allow address error")
+		}
+		addr, err := common.AddressParseFromBytes(address)
+		if err != nil {
+			return fmt.Errorf("common.AddressParseFromBytes, deserialize allow address error: %s", err)
+		}
+		allowList = append(allowList, addr)
+	}
+	this.Mode = mode
+	this.AllowList = allowList
+	return nil
+}
+
+type RotateRelayerParam struct {
+	AddressList    []common.Address
+	NewAddressList []common.Address
+}
+
+func (this *RotateRelayerParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarUint(uint64(len(this.AddressList)))
+	for _, v := range this.AddressList {
+		sink.WriteVarBytes(v[:])
+	}
+	sink.WriteVarUint(uint64(len(this.NewAddressList)))
+	for _, v := range this.NewAddressList {
+		sink.WriteVarBytes(v[:])
+	}
+}
+
+func (this *RotateRelayerParam) Deserialization(source *common.ZeroCopySource) error {
+	addressList, err := deserializeAddressList(source)
+	if err != nil {
+		return fmt.Errorf("deserialize AddressList error: %v", err)
+	}
+	newAddressList, err := deserializeAddressList(source)
+	if err != nil {
+		return fmt.Errorf("deserialize NewAddressList error: %v", err)
+	}
+	this.AddressList = addressList
+	this.NewAddressList = newAddressList
+	return nil
+}
+
+// Relayer is a registered relayer, it is stored as the bare address in the legacy format
+type Relayer struct {
+	Address      common.Address
+	ChainIDs     []uint64 // side chains the relayer may serve, empty for all chains
+	ExpiryHeight uint32   // last height the relayer is valid, 0 for no expiry
+}
+
+func (this *Relayer) IsExpired(height uint32) bool {
+	return this.ExpiryHeight != 0 && height > this.ExpiryHeight
+}
+
+func (this *Relayer) CanServe(chainID uint64) bool {
+	if len(this.ChainIDs) == 0 {
+		return true
+	}
+	for _, v := range this.ChainIDs {
+		if v == chainID {
+			return true
+		}
+	}
+	return false
+}
+
+func (this *Relayer) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarBytes(this.Address[:])
+	sink.WriteVarUint(uint64(len(this.ChainIDs)))
+	for _, v := range this.ChainIDs {
+		sink.WriteVarUint(v)
+	}
+	sink.WriteUint32(this.ExpiryHeight)
+}
+
+func (this *Relayer) Deserialization(source *common.ZeroCopySource) error {
+	if source.Len() == common.ADDR_LEN {
+		address, _ := source.NextBytes(common.ADDR_LEN)
+		copy(this.Address[:], address)
+		this.ChainIDs = nil
+		this.ExpiryHeight = 0
+		return nil
+	}
+	address, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("source.NextVarBytes, deserialize address error")
+	}
+	addr, err := common.AddressParseFromBytes(address)
+	if err != nil {
+		return fmt.Errorf("common.AddressParseFromBytes, deserialize address error: %s", err)
+	}
+	chainIDs, err := deserializeChainIDs(source)
+	if err != nil {
+		return err
+	}
+	expiryHeight, eof := source.NextUint32()
+	if eof {
+		return fmt.Errorf("source.NextUint32, deserialize expiryHeight error")
+	}
+	this.Address = addr
+	this.ChainIDs = chainIDs
+	this.ExpiryHeight = expiryHeight
+	return nil
+}
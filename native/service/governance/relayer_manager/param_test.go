@@ -33,3 +33,68 @@ func TestRelayerListParam_Serialization(t *testing.T) {
 	err := p.Deserialization(source)
 	assert.Nil(t, err)
 }
+
+func TestRelayerPolicyParam_Serialization(t *testing.T) {
+	params := &RelayerPolicyParam{
+		ChainID:   2,
+		Mode:      POLICY_ALLOWLIST,
+		AllowList: []common.Address{{1, 2, 4, 6}, {1, 4, 5, 7}},
+		Address:   common.Address{9, 8, 7},
+	}
+	sink := common.NewZeroCopySink(nil)
+	params.Serialization(sink)
+
+	source := common.NewZeroCopySource(sink.Bytes())
+	var p RelayerPolicyParam
+	err := p.Deserialization(source)
+	assert.Nil(t, err)
+	assert.Equal(t, params, &p)
+}
+
+func TestRelayerListParam_LegacyFormat(t *testing.T) {
+	params := &RelayerListParam{
+		AddressList: []common.Address{{1, 2, 4, 6}},
+		Address:     common.Address{9, 8, 7},
+	}
+	sink := common.NewZeroCopySink(nil)
+	params.Serialization(sink)
+	legacy := common.NewZeroCopySink(nil)
+	legacy.WriteVarUint(1)
+	legacy.WriteVarBytes(params.AddressList[0][:])
+	legacy.WriteVarBytes(params.Address[:])
+	assert.Equal(t, legacy.Bytes(), sink.Bytes())
+
+	params.ChainIDs = []uint64{2, 6}
+	params.ExpiryHeight = 100
+	sink = common.NewZeroCopySink(nil)
+	params.Serialization(sink)
+	var p RelayerListParam
+	err := p.Deserialization(common.NewZeroCopySource(sink.Bytes()))
+	assert.Nil(t, err)
+	assert.Equal(t, params, &p)
+}
+
+func TestRelayer_Serialization(t *testing.T) {
+	relayer := &Relayer{
+		Address:      common.Address{1, 2, 4, 6},
+		ChainIDs:     []uint64{2},
+		ExpiryHeight: 100,
+	}
+	sink := common.NewZeroCopySink(nil)
+	relayer.Serialization(sink)
+	var r Relayer
+	err := r.Deserialization(common.NewZeroCopySource(sink.Bytes()))
+	assert.Nil(t, err)
+	assert.Equal(t, relayer, &r)
+	assert.False(t, r.IsExpired(100))
+	assert.True(t, r.IsExpired(101))
+	assert.True(t, r.CanServe(2))
+	assert.False(t, r.CanServe(3))
+
+	// legacy relayer is stored as the bare address
+	err = r.Deserialization(common.NewZeroCopySource(relayer.Address[:]))
+	assert.Nil(t, err)
+	assert.Equal(t, &Relayer{Address: relayer.Address}, &r)
+	assert.False(t, r.IsExpired(1<<31))
+	assert.True(t, r.CanServe(3))
+}
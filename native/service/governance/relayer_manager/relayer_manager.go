@@ -24,6 +24,7 @@ import (
 	"github.com/polynetwork/poly/common"
 	"github.com/polynetwork/poly/native"
 	"github.com/polynetwork/poly/native/service/governance/node_manager"
+	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
 	"github.com/polynetwork/poly/native/service/utils"
 )
 
@@ -33,6 +34,8 @@ const (
 	APPROVE_REGISTER_RELAYER = "approveRegisterRelayer"
 	REMOVE_RELAYER           = "RemoveRelayer"
 	APPROVE_REMOVE_RELAYER   = "approveRemoveRelayer"
+	SET_RELAYER_POLICY       = "setRelayerPolicy"
+	ROTATE_RELAYER           = "rotateRelayer"
 
 	//key prefix
 	RELAYER        = "relayer"
@@ -40,6 +43,14 @@ const (
 	RELAYER_REMOVE = "relayerRemove"
 	APPLY_ID       = "applyID"
 	REMOVE_ID      = "removeID"
+	RELAYER_POLICY = "relayerPolicy"
+)
+
+const (
+	//relayer policy mode of a side chain
+	POLICY_OPEN       uint8 = 0 // anyone can relay, default for chains without policy
+	POLICY_REGISTERED uint8 = 1 // only relayers approved by registerRelayer
+	POLICY_ALLOWLIST  uint8 = 2 // only relayers in the allow list of the chain
 )
 
 //Register methods of node_manager contract
@@ -48,6 +59,8 @@ func RegisterRelayerManagerContract(native *native.NativeService) {
 	native.Register(APPROVE_REGISTER_RELAYER, ApproveRegisterRelayer)
 	native.Register(REMOVE_RELAYER, RemoveRelayer)
 	native.Register(APPROVE_REMOVE_RELAYER, ApproveRemoveRelayer)
+	native.Register(SET_RELAYER_POLICY, SetRelayerPolicy)
+	native.Register(ROTATE_RELAYER, RotateRelayer)
 }
 
 func RegisterRelayer(native *native.NativeService) ([]byte, error) {
@@ -59,6 +72,24 @@ func RegisterRelayer(native *native.NativeService) ([]byte, error) {
 	if err := utils.ValidateOwner(native, params.Address); err != nil {
 		return utils.BYTE_FALSE, fmt.Errorf("RegisterRelayer, checkWitness: %s, error: %v", params.Address.ToBase58(), err)
 	}
+	if params.ExpiryHeight != 0 && params.ExpiryHeight <= native.GetHeight() {
+		return utils.BYTE_FALSE, fmt.Errorf("RegisterRelayer, expiry height %d is not higher than current height %d",
+			params.ExpiryHeight, native.GetHeight())
+	}
+	chainIDs := make(map[uint64]bool, len(params.ChainIDs))
+	for _, chainID := range params.ChainIDs {
+		if chainIDs[chainID] {
+			return utils.BYTE_FALSE, fmt.Errorf("RegisterRelayer, duplicated chain id %d", chainID)
+		}
+		chainIDs[chainID] = true
+		sideChain, err := side_chain_manager.GetSideChain(native, chainID)
+		if err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("RegisterRelayer, side_chain_manager.GetSideChain error: %v", err)
+		}
+		if sideChain == nil {
+			return utils.BYTE_FALSE, fmt.Errorf("RegisterRelayer, side chain %d is not registered", chainID)
+		}
+	}
 	if err := putRelayerApply(native, params); err != nil {
 		return utils.BYTE_FALSE, fmt.Errorf("RegisterRelayer, putRelayer error: %v", err)
 	}
@@ -92,7 +123,11 @@ func ApproveRegisterRelayer(native *native.NativeService) ([]byte, error) {
 	}
 
 	for _, address := range relayerListParam.AddressList {
-		err = putRelayer(native, address)
+		err = putRelayer(native, &Relayer{
+			Address:      address,
+			ChainIDs:     relayerListParam.ChainIDs,
+			ExpiryHeight: relayerListParam.ExpiryHeight,
+		})
 		if err != nil {
 			return utils.BYTE_FALSE, fmt.Errorf("ApproveRegisterRelayer, putRelayer error: %v", err)
 		}
@@ -158,3 +193,150 @@ func ApproveRemoveRelayer(native *native.NativeService) ([]byte, error) {
 		})
 	return utils.BYTE_TRUE, nil
 }
+
+func SetRelayerPolicy(native *native.NativeService) ([]byte, error) {
+	params := new(RelayerPolicyParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SetRelayerPolicy, contract params deserialize error: %v", err)
+	}
+	if params.Mode > POLICY_ALLOWLIST {
+		return utils.BYTE_FALSE, fmt.Errorf("SetRelayerPolicy, unknown policy mode: %d", params.Mode)
+	}
+
+	//check witness
+	err := utils.ValidateOwner(native, params.Address)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SetRelayerPolicy, checkWitness error: %v", err)
+	}
+
+	//check consensus signs
+	policy := &RelayerPolicy{
+		Mode:      params.Mode,
+		AllowList: params.AllowList,
+	}
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteVarUint(params.ChainID)
+	policy.Serialization(sink)
+	ok, err := node_manager.CheckConsensusSigns(native, SET_RELAYER_POLICY, sink.Bytes(), params.Address)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SetRelayerPolicy, CheckConsensusSigns error: %v", err)
+	}
+	if !ok {
+		return utils.BYTE_TRUE, nil
+	}
+
+	putRelayerPolicy(native, params.ChainID, policy)
+	native.AddNotify(
+		&event.NotifyEventInfo{
+			ContractAddress: utils.RelayerManagerContractAddress,
+			States:          []interface{}{"SetRelayerPolicy", params.ChainID, params.Mode},
+		})
+	return utils.BYTE_TRUE, nil
+}
+
+// RotateRelayer replaces registered relayers with new addresses keeping their chains and expiry,
+// it needs the signatures of both old and new addresses instead of consensus signs
+func RotateRelayer(native *native.NativeService) ([]byte, error) {
+	params := new(RotateRelayerParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("RotateRelayer, contract params deserialize error: %v", err)
+	}
+	if len(params.AddressList) == 0 || len(params.AddressList) != len(params.NewAddressList) {
+		return utils.BYTE_FALSE, fmt.Errorf("RotateRelayer, length of address list %d and new address list %d not match",
+			len(params.AddressList), len(params.NewAddressList))
+	}
+
+	relayers := make([]*Relayer, 0, len(params.AddressList))
+	seen := make(map[common.Address]bool, 2*len(params.AddressList))
+	for i, address := range params.AddressList {
+		newAddress := params.NewAddressList[i]
+		if seen[address] || seen[newAddress] {
+			return utils.BYTE_FALSE, fmt.Errorf("RotateRelayer, duplicated address in No.%d rotation", i)
+		}
+		seen[address], seen[newAddress] = true, true
+
+		//check witness
+		if err := utils.ValidateOwner(native, address); err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("RotateRelayer, checkWitness: %s, error: %v", address.ToBase58(), err)
+		}
+		if err := utils.ValidateOwner(native, newAddress); err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("RotateRelayer, checkWitness: %s, error: %v", newAddress.ToBase58(), err)
+		}
+
+		relayer, err := getRelayer(native, address)
+		if err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("RotateRelayer, getRelayer error: %v", err)
+		}
+		if relayer == nil {
+			return utils.BYTE_FALSE, fmt.Errorf("RotateRelayer, relayer %s is not registered", address.ToBase58())
+		}
+		if relayer.IsExpired(native.GetHeight()) {
+			return utils.BYTE_FALSE, fmt.Errorf("RotateRelayer, relayer %s is expired", address.ToBase58())
+		}
+		newRelayer, err := getRelayer(native, newAddress)
+		if err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("RotateRelayer, getRelayer error: %v", err)
+		}
+		if newRelayer != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("RotateRelayer, relayer %s is already registered", newAddress.ToBase58())
+		}
+		relayers = append(relayers, relayer)
+	}
+
+	for i, relayer := range relayers {
+		native.GetCacheDB().Delete(utils.ConcatKey(utils.RelayerManagerContractAddress, []byte(RELAYER), relayer.Address[:]))
+		relayer.Address = params.NewAddressList[i]
+		if err := putRelayer(native, relayer); err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("RotateRelayer, putRelayer error: %v", err)
+		}
+		native.AddNotify(
+			&event.NotifyEventInfo{
+				ContractAddress: utils.RelayerManagerContractAddress,
+				States:          []interface{}{"RotateRelayer", params.AddressList[i].ToBase58(), relayer.Address.ToBase58()},
+			})
+	}
+	return utils.BYTE_TRUE, nil
+}
+
+// CheckRelayer verifies that relayer is allowed to relay for chainID under the
+// policy of that chain, and that the transaction is signed by relayer.
+func CheckRelayer(native *native.NativeService, chainID uint64, relayer []byte) error {
+	policy, err := GetRelayerPolicy(native, chainID)
+	if err != nil {
+		return fmt.Errorf("CheckRelayer, GetRelayerPolicy error: %v", err)
+	}
+	if policy.Mode == POLICY_OPEN {
+		return nil
+	}
+	address, err := common.AddressParseFromBytes(relayer)
+	if err != nil {
+		return fmt.Errorf("CheckRelayer, relayer address %x is invalid: %v", relayer, err)
+	}
+	if err := utils.ValidateOwner(native, address); err != nil {
+		return fmt.Errorf("CheckRelayer, checkWitness: %s, error: %v", address.ToBase58(), err)
+	}
+	switch policy.Mode {
+	case POLICY_REGISTERED:
+		registered, err := getRelayer(native, address)
+		if err != nil {
+			return fmt.Errorf("CheckRelayer, getRelayer error: %v", err)
+		}
+		if registered == nil {
+			return fmt.Errorf("CheckRelayer, relayer %s is not registered", address.ToBase58())
+		}
+		if registered.IsExpired(native.GetHeight()) {
+			return fmt.Errorf("CheckRelayer, relayer %s is expired", address.ToBase58())
+		}
+		if !registered.CanServe(chainID) {
+			return fmt.Errorf("CheckRelayer, relayer %s is not registered for chain %d", address.ToBase58(), chainID)
+		}
+	case POLICY_ALLOWLIST:
+		for _, v := range policy.AllowList {
+			if v == address {
+				return nil
+			}
+		}
+		return fmt.Errorf("CheckRelayer, relayer %s is not in allow list of chain %d", address.ToBase58(), chainID)
+	}
+	return nil
+}
@@ -20,7 +20,7 @@ import (
 	"github.com/ontio/ontology-crypto/keypair"
 	"github.com/polynetwork/poly/account"
 	"github.com/polynetwork/poly/common"
-	vconfig "github.com/polynetwork/poly/consensus/vbft/config"
+	"github.com/polynetwork/poly/common/config"
 	"github.com/polynetwork/poly/core/genesis"
 	cstates "github.com/polynetwork/poly/core/states"
 	"github.com/polynetwork/poly/core/store/leveldbstore"
@@ -28,6 +28,8 @@ import (
 	"github.com/polynetwork/poly/core/types"
 	"github.com/polynetwork/poly/native"
 	"github.com/polynetwork/poly/native/service/governance/node_manager"
+	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
+	"github.com/polynetwork/poly/native/service/testutils"
 	"github.com/polynetwork/poly/native/service/utils"
 	"github.com/polynetwork/poly/native/storage"
 	"github.com/stretchr/testify/assert"
@@ -62,31 +64,6 @@ var (
 func init() {
 	setBKers()
 }
-func putPeerMapPoolAndView(db *storage.CacheDB, conAccts []*account.Account) {
-	peerPoolMap := new(node_manager.PeerPoolMap)
-	peerPoolMap.PeerPoolMap = make(map[string]*node_manager.PeerPoolItem)
-	for i, conAcct := range conAccts {
-		pkStr := vconfig.PubkeyID(conAcct.PublicKey)
-		peerPoolMap.PeerPoolMap[pkStr] = &node_manager.PeerPoolItem{
-			Index:      uint32(i),
-			PeerPubkey: pkStr,
-			Address:    conAcct.Address,
-			Status:     node_manager.ConsensusStatus,
-		}
-	}
-	viewBytes := utils.GetUint32Bytes(0)
-	sink := common.NewZeroCopySink(nil)
-	peerPoolMap.Serialization(sink)
-	db.Put(utils.ConcatKey(utils.NodeManagerContractAddress, []byte(node_manager.PEER_POOL), viewBytes), cstates.GenRawStorageItem(sink.Bytes()))
-
-	govView := node_manager.GovernanceView{
-		0, 10, common.UINT256_EMPTY,
-	}
-	sink = common.NewZeroCopySink(nil)
-	govView.Serialization(sink)
-	db.Put(utils.ConcatKey(utils.NodeManagerContractAddress, []byte(node_manager.GOVERNANCE_VIEW)), cstates.GenRawStorageItem(sink.Bytes()))
-}
-
 func NewNative(args []byte, tx *types.Transaction, db *storage.CacheDB) *native.NativeService {
 	if db == nil {
 		store, _ := leveldbstore.NewMemLevelDBStore()
@@ -107,7 +84,7 @@ func TestRegisterRelayer(t *testing.T) {
 		SignedAddr: []common.Address{acct.Address},
 	}
 	nativeService = NewNative(sink.Bytes(), tx, nil)
-	putPeerMapPoolAndView(nativeService.GetCacheDB(), conAccts())
+	testutils.PutPeerPoolAndView(nativeService.GetCacheDB(), conAccts())
 
 	res, err := RegisterRelayer(nativeService)
 	assert.Equal(t, res, []byte{1})
@@ -166,7 +143,7 @@ func TestRemoveRelayer(t *testing.T) {
 		SignedAddr: []common.Address{acct.Address},
 	}
 	nativeService = NewNative(sink.Bytes(), tx, nil)
-	putPeerMapPoolAndView(nativeService.GetCacheDB(), conAccts())
+	testutils.PutPeerPoolAndView(nativeService.GetCacheDB(), conAccts())
 
 	res, err := RemoveRelayer(nativeService)
 	assert.Equal(t, res, []byte{1})
@@ -197,3 +174,207 @@ func TestRemoveRelayer(t *testing.T) {
 		}
 	}
 }
+
+func TestApproveRelayerFork(t *testing.T) {
+	accts := conAccts()
+	notConAcct := account.NewAccount("x")
+	approve := func(forkHeight uint32) ([]byte, error) {
+		defer testutils.SetForkHeights(map[string]uint32{config.FORK_GOVERNANCE_PROPOSAL: forkHeight})()
+		params := &RelayerListParam{
+			AddressList: []common.Address{{1, 2, 4, 6}},
+			Address:     acct.Address,
+		}
+		sink := common.NewZeroCopySink(nil)
+		params.Serialization(sink)
+		nativeService = NewNative(sink.Bytes(), &types.Transaction{SignedAddr: []common.Address{acct.Address}}, nil)
+		testutils.PutPeerPoolAndView(nativeService.GetCacheDB(), accts)
+		_, err := RegisterRelayer(nativeService)
+		assert.Nil(t, err)
+
+		sink = common.NewZeroCopySink(nil)
+		(&ApproveRelayerParam{0, notConAcct.Address}).Serialization(sink)
+		tx := &types.Transaction{SignedAddr: []common.Address{notConAcct.Address}}
+		return ApproveRegisterRelayer(NewNative(sink.Bytes(), tx, nativeService.GetCacheDB()))
+	}
+
+	// the vote of a none consensus acct is kept but does not count before the fork
+	res, err := approve(1)
+	assert.Nil(t, err)
+	assert.Equal(t, utils.BYTE_TRUE, res)
+	// and rejected after it
+	_, err = approve(0)
+	assert.NotNil(t, err)
+}
+
+func TestSetRelayerPolicy(t *testing.T) {
+	var chainID uint64 = 2
+	relayer := account.NewAccount("r")
+	allowed := account.NewAccount("a")
+
+	tx := &types.Transaction{
+		SignedAddr: []common.Address{relayer.Address},
+	}
+	accts := conAccts()
+	nativeService = NewNative(nil, tx, nil)
+	testutils.PutPeerPoolAndView(nativeService.GetCacheDB(), accts)
+
+	// chains without policy are open
+	err := CheckRelayer(nativeService, chainID, relayer.Address[:])
+	assert.Nil(t, err)
+
+	setPolicy := func(mode uint8, allowList []common.Address) {
+		for _, conAcct := range accts {
+			param := &RelayerPolicyParam{
+				ChainID:   chainID,
+				Mode:      mode,
+				AllowList: allowList,
+				Address:   conAcct.Address,
+			}
+			sink := common.NewZeroCopySink(nil)
+			param.Serialization(sink)
+			tx := &types.Transaction{
+				SignedAddr: []common.Address{conAcct.Address},
+			}
+			nativeService = NewNative(sink.Bytes(), tx, nativeService.GetCacheDB())
+			res, err := SetRelayerPolicy(nativeService)
+			assert.Nil(t, err)
+			assert.Equal(t, utils.BYTE_TRUE, res)
+		}
+	}
+
+	setPolicy(POLICY_REGISTERED, nil)
+	policy, err := GetRelayerPolicy(nativeService, chainID)
+	assert.Nil(t, err)
+	assert.Equal(t, POLICY_REGISTERED, policy.Mode)
+
+	nativeService = NewNative(nil, tx, nativeService.GetCacheDB())
+	err = CheckRelayer(nativeService, chainID, relayer.Address[:])
+	assert.NotNil(t, err)
+	putRelayer(nativeService, &Relayer{Address: relayer.Address})
+	err = CheckRelayer(nativeService, chainID, relayer.Address[:])
+	assert.Nil(t, err)
+	// relayer address must sign the tx
+	err = CheckRelayer(nativeService, chainID, allowed.Address[:])
+	assert.NotNil(t, err)
+	// other chains are still open
+	err = CheckRelayer(nativeService, chainID+1, allowed.Address[:])
+	assert.Nil(t, err)
+
+	setPolicy(POLICY_ALLOWLIST, []common.Address{allowed.Address})
+	nativeService = NewNative(nil, tx, nativeService.GetCacheDB())
+	err = CheckRelayer(nativeService, chainID, relayer.Address[:])
+	assert.NotNil(t, err)
+	tx = &types.Transaction{
+		SignedAddr: []common.Address{allowed.Address},
+	}
+	nativeService = NewNative(nil, tx, nativeService.GetCacheDB())
+	err = CheckRelayer(nativeService, chainID, allowed.Address[:])
+	assert.Nil(t, err)
+}
+
+func TestScopedRelayer(t *testing.T) {
+	var chainID uint64 = 2
+	relayer := account.NewAccount("r")
+	accts := conAccts()
+
+	params := &RelayerListParam{
+		AddressList:  []common.Address{relayer.Address},
+		Address:      acct.Address,
+		ChainIDs:     []uint64{chainID},
+		ExpiryHeight: 100,
+	}
+	sink := common.NewZeroCopySink(nil)
+	params.Serialization(sink)
+	tx := &types.Transaction{
+		SignedAddr: []common.Address{acct.Address},
+	}
+	nativeService = NewNative(sink.Bytes(), tx, nil)
+	testutils.PutPeerPoolAndView(nativeService.GetCacheDB(), accts)
+
+	// side chains of relayers must be registered
+	_, err := RegisterRelayer(nativeService)
+	assert.NotNil(t, err)
+	err = side_chain_manager.PutSideChain(nativeService, &side_chain_manager.SideChain{ChainId: chainID, Name: "chain"})
+	assert.Nil(t, err)
+	res, err := RegisterRelayer(nativeService)
+	assert.Nil(t, err)
+	assert.Equal(t, utils.BYTE_TRUE, res)
+
+	for _, conAcct := range accts[:(2*len(accts)+2)/3] {
+		arp := &ApproveRelayerParam{0, conAcct.Address}
+		sink := common.NewZeroCopySink(nil)
+		arp.Serialization(sink)
+		tx := &types.Transaction{
+			SignedAddr: []common.Address{conAcct.Address},
+		}
+		nativeService = NewNative(sink.Bytes(), tx, nativeService.GetCacheDB())
+		res, err := ApproveRegisterRelayer(nativeService)
+		assert.Nil(t, err)
+		assert.Equal(t, utils.BYTE_TRUE, res)
+	}
+	registered, err := getRelayer(nativeService, relayer.Address)
+	assert.Nil(t, err)
+	assert.Equal(t, &Relayer{Address: relayer.Address, ChainIDs: []uint64{chainID}, ExpiryHeight: 100}, registered)
+
+	db := nativeService.GetCacheDB()
+	putRelayerPolicy(nativeService, chainID, &RelayerPolicy{Mode: POLICY_REGISTERED})
+	putRelayerPolicy(nativeService, chainID+1, &RelayerPolicy{Mode: POLICY_REGISTERED})
+	checkRelayer := func(address common.Address, chainID uint64, height uint32) error {
+		tx := &types.Transaction{
+			SignedAddr: []common.Address{address},
+		}
+		ns, _ := native.NewNativeService(db, tx, 0, height, common.Uint256{}, 0, nil, false)
+		return CheckRelayer(ns, chainID, address[:])
+	}
+	assert.Nil(t, checkRelayer(relayer.Address, chainID, 100))
+	assert.NotNil(t, checkRelayer(relayer.Address, chainID+1, 100))
+	assert.NotNil(t, checkRelayer(relayer.Address, chainID, 101))
+
+	// relayer rotates its address without consensus signs
+	newRelayer := account.NewAccount("n")
+	rotate := &RotateRelayerParam{
+		AddressList:    []common.Address{relayer.Address},
+		NewAddressList: []common.Address{newRelayer.Address},
+	}
+	sink = common.NewZeroCopySink(nil)
+	rotate.Serialization(sink)
+	tx = &types.Transaction{
+		SignedAddr: []common.Address{relayer.Address},
+	}
+	_, err = RotateRelayer(NewNative(sink.Bytes(), tx, db))
+	assert.NotNil(t, err, "new address must sign the rotation")
+	tx = &types.Transaction{
+		SignedAddr: []common.Address{relayer.Address, newRelayer.Address},
+	}
+	res, err = RotateRelayer(NewNative(sink.Bytes(), tx, db))
+	assert.Nil(t, err)
+	assert.Equal(t, utils.BYTE_TRUE, res)
+
+	assert.NotNil(t, checkRelayer(relayer.Address, chainID, 100))
+	assert.Nil(t, checkRelayer(newRelayer.Address, chainID, 100))
+	registered, err = getRelayer(nativeService, newRelayer.Address)
+	assert.Nil(t, err)
+	assert.Equal(t, []uint64{chainID}, registered.ChainIDs)
+	assert.Equal(t, uint32(100), registered.ExpiryHeight)
+
+	// the old address is no longer a relayer to rotate
+	_, err = RotateRelayer(NewNative(sink.Bytes(), tx, db))
+	assert.NotNil(t, err)
+}
+
+func TestLegacyRelayer(t *testing.T) {
+	relayer := account.NewAccount("r")
+	tx := &types.Transaction{
+		SignedAddr: []common.Address{relayer.Address},
+	}
+	nativeService = NewNative(nil, tx, nil)
+	nativeService.GetCacheDB().Put(utils.ConcatKey(utils.RelayerManagerContractAddress, []byte(RELAYER), relayer.Address[:]),
+		cstates.GenRawStorageItem(relayer.Address[:]))
+	putRelayerPolicy(nativeService, 2, &RelayerPolicy{Mode: POLICY_REGISTERED})
+
+	registered, err := getRelayer(nativeService, relayer.Address)
+	assert.Nil(t, err)
+	assert.Equal(t, &Relayer{Address: relayer.Address}, registered)
+	err = CheckRelayer(nativeService, 2, relayer.Address[:])
+	assert.Nil(t, err)
+}
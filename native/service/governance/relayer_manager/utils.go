@@ -27,12 +27,34 @@ import (
 	"github.com/polynetwork/poly/native/service/utils"
 )
 
-func putRelayer(native *native.NativeService, relayer common.Address) error {
+func putRelayer(native *native.NativeService, relayer *Relayer) error {
 	contract := utils.RelayerManagerContractAddress
-	native.GetCacheDB().Put(utils.ConcatKey(contract, []byte(RELAYER), relayer[:]), cstates.GenRawStorageItem(relayer[:]))
+	sink := common.NewZeroCopySink(nil)
+	relayer.Serialization(sink)
+	native.GetCacheDB().Put(utils.ConcatKey(contract, []byte(RELAYER), relayer.Address[:]), cstates.GenRawStorageItem(sink.Bytes()))
 	return nil
 }
 
+func getRelayer(native *native.NativeService, address common.Address) (*Relayer, error) {
+	contract := utils.RelayerManagerContractAddress
+	relayerStore, err := native.GetCacheDB().Get(utils.ConcatKey(contract, []byte(RELAYER), address[:]))
+	if err != nil {
+		return nil, fmt.Errorf("getRelayer, get relayerStore error: %v", err)
+	}
+	if relayerStore == nil {
+		return nil, nil
+	}
+	relayerBytes, err := cstates.GetValueFromRawStorageItem(relayerStore)
+	if err != nil {
+		return nil, fmt.Errorf("getRelayer, deserialize from raw storage item err:%v", err)
+	}
+	relayer := new(Relayer)
+	if err := relayer.Deserialization(common.NewZeroCopySource(relayerBytes)); err != nil {
+		return nil, fmt.Errorf("getRelayer, deserialize relayer error: %v", err)
+	}
+	return relayer, nil
+}
+
 func putRelayerApply(native *native.NativeService, relayerListParam *RelayerListParam) error {
 	contract := utils.RelayerManagerContractAddress
 	applyID, err := getApplyID(native)
@@ -170,3 +192,33 @@ func putRemoveID(native *native.NativeService, removeID uint64) error {
 	native.GetCacheDB().Put(utils.ConcatKey(contract, []byte(REMOVE_ID)), cstates.GenRawStorageItem(removeIDByte))
 	return nil
 }
+
+func putRelayerPolicy(native *native.NativeService, chainID uint64, policy *RelayerPolicy) {
+	contract := utils.RelayerManagerContractAddress
+	sink := common.NewZeroCopySink(nil)
+	policy.Serialization(sink)
+	native.GetCacheDB().Put(utils.ConcatKey(contract, []byte(RELAYER_POLICY), utils.GetUint64Bytes(chainID)),
+		cstates.GenRawStorageItem(sink.Bytes()))
+}
+
+// GetRelayerPolicy returns the relayer policy of chainID, chains without
+// policy are open to any relayer.
+func GetRelayerPolicy(native *native.NativeService, chainID uint64) (*RelayerPolicy, error) {
+	contract := utils.RelayerManagerContractAddress
+	policy := &RelayerPolicy{Mode: POLICY_OPEN}
+	policyStore, err := native.GetCacheDB().Get(utils.ConcatKey(contract, []byte(RELAYER_POLICY), utils.GetUint64Bytes(chainID)))
+	if err != nil {
+		return nil, fmt.Errorf("GetRelayerPolicy, get policyStore error: %v", err)
+	}
+	if policyStore == nil {
+		return policy, nil
+	}
+	policyBytes, err := cstates.GetValueFromRawStorageItem(policyStore)
+	if err != nil {
+		return nil, fmt.Errorf("GetRelayerPolicy, deserialize from raw storage item err:%v", err)
+	}
+	if err := policy.Deserialization(common.NewZeroCopySource(policyBytes)); err != nil {
+		return nil, fmt.Errorf("GetRelayerPolicy, deserialize policy error: %v", err)
+	}
+	return policy, nil
+}
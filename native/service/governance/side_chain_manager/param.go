@@ -20,8 +20,6 @@ import (
 	"fmt"
 
 	"github.com/polynetwork/poly/common"
-	"github.com/polynetwork/poly/common/config"
-	"github.com/polynetwork/poly/core/ledger"
 )
 
 type RegisterSideChainParam struct {
@@ -41,12 +39,7 @@ func (this *RegisterSideChainParam) Serialization(sink *common.ZeroCopySink) err
 	sink.WriteVarBytes([]byte(this.Name))
 	sink.WriteVarUint(this.BlocksToWait)
 	sink.WriteVarBytes(this.CCMCAddress)
-
-	height := config.GetExtraInfoHeight(config.DefConfig.P2PNode.NetworkId)
-	if !config.EXTRA_INFO_HEIGHT_FORK_CHECK || ledger.DefLedger.GetCurrentBlockHeight() >= height {
-		sink.WriteVarBytes(this.ExtraInfo)
-	}
-
+	sink.WriteVarBytes(this.ExtraInfo)
 	return nil
 }
 
@@ -186,6 +179,80 @@ func (this *RegisterRedeemParam) Deserialization(source *common.ZeroCopySource)
 	return nil
 }
 
+type RotateRedeemParam struct {
+	RedeemChainID uint64
+	Redeem        []byte
+	Successor     []byte
+	Signs         [][]byte
+}
+
+func (this *RotateRedeemParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarUint(this.RedeemChainID)
+	sink.WriteVarBytes(this.Redeem)
+	sink.WriteVarBytes(this.Successor)
+	sink.WriteVarUint(uint64(len(this.Signs)))
+	for _, v := range this.Signs {
+		sink.WriteVarBytes(v)
+	}
+}
+
+func (this *RotateRedeemParam) Deserialization(source *common.ZeroCopySource) error {
+	redeemChainID, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("RotateRedeemParam deserialize redeemChainID error")
+	}
+	redeem, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("RotateRedeemParam deserialize redeem error")
+	}
+	successor, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("RotateRedeemParam deserialize successor error")
+	}
+	n, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("RotateRedeemParam deserialize signs length error")
+	}
+	signs := make([][]byte, 0)
+	for i := 0; uint64(i) < n; i++ {
+		v, eof := source.NextVarBytes()
+		if eof {
+			return fmt.Errorf("deserialize Signs error")
+		}
+		signs = append(signs, v)
+	}
+
+	this.RedeemChainID = redeemChainID
+	this.Redeem = redeem
+	this.Successor = successor
+	this.Signs = signs
+	return nil
+}
+
+type NestedRedeemParam struct {
+	RedeemChainID uint64
+	Redeem        []byte
+}
+
+func (this *NestedRedeemParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarUint(this.RedeemChainID)
+	sink.WriteVarBytes(this.Redeem)
+}
+
+func (this *NestedRedeemParam) Deserialization(source *common.ZeroCopySource) error {
+	redeemChainID, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("NestedRedeemParam deserialize redeemChainID error")
+	}
+	redeem, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("NestedRedeemParam deserialize redeem error")
+	}
+	this.RedeemChainID = redeemChainID
+	this.Redeem = redeem
+	return nil
+}
+
 type BtcTxParamDetial struct {
 	PVersion  uint64
 	FeeRate   uint64
@@ -261,3 +328,38 @@ func (this *BtcTxParam) Deserialization(source *common.ZeroCopySource) error {
 	this.Detial = detial
 	return nil
 }
+
+type HeaderRetentionParam struct {
+	ChainID uint64
+	Window  uint64
+	Address common.Address
+}
+
+func (this *HeaderRetentionParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarUint(this.ChainID)
+	sink.WriteVarUint(this.Window)
+	sink.WriteVarBytes(this.Address[:])
+}
+
+func (this *HeaderRetentionParam) Deserialization(source *common.ZeroCopySource) error {
+	chainID, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("HeaderRetentionParam deserialize chain id error")
+	}
+	window, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("HeaderRetentionParam deserialize window error")
+	}
+	address, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("HeaderRetentionParam deserialize address error")
+	}
+	addr, err := common.AddressParseFromBytes(address)
+	if err != nil {
+		return fmt.Errorf("HeaderRetentionParam, common.AddressParseFromBytes error: %v", err)
+	}
+	this.ChainID = chainID
+	this.Window = window
+	this.Address = addr
+	return nil
+}
@@ -55,3 +55,50 @@ func TestChainidParam(t *testing.T) {
 
 	assert.Equal(t, p, param)
 }
+
+func TestHeaderRetentionParam(t *testing.T) {
+	p := HeaderRetentionParam{
+		ChainID: 2,
+		Window:  MIN_HEADER_RETENTION,
+		Address: common.Address{1, 2, 3},
+	}
+
+	sink := common.NewZeroCopySink(nil)
+	p.Serialization(sink)
+
+	var param HeaderRetentionParam
+	err := param.Deserialization(common.NewZeroCopySource(sink.Bytes()))
+	assert.NoError(t, err)
+
+	assert.Equal(t, p, param)
+}
+
+func TestRotateRedeemParam(t *testing.T) {
+	param := RotateRedeemParam{
+		RedeemChainID: 1,
+		Redeem:        []byte{1, 2, 3},
+		Successor:     []byte{4, 5, 6},
+		Signs:         [][]byte{{7, 8}, {9}},
+	}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+
+	var p RotateRedeemParam
+	err := p.Deserialization(common.NewZeroCopySource(sink.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, param, p)
+}
+
+func TestNestedRedeemParam(t *testing.T) {
+	param := NestedRedeemParam{
+		RedeemChainID: 1,
+		Redeem:        []byte{1, 2, 3},
+	}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+
+	var p NestedRedeemParam
+	err := p.Deserialization(common.NewZeroCopySource(sink.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, param, p)
+}
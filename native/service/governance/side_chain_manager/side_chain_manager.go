@@ -18,6 +18,7 @@
 package side_chain_manager
 
 import (
+	"bytes"
 	"encoding/hex"
 	"fmt"
 
@@ -40,6 +41,9 @@ const (
 	APPROVE_QUIT_SIDE_CHAIN     = "approveQuitSideChain"
 	REGISTER_REDEEM             = "registerRedeem"
 	SET_BTC_TX_PARAM            = "setBtcTxParam"
+	SET_HEADER_RETENTION        = "setHeaderRetention"
+	ROTATE_REDEEM               = "rotateRedeem"
+	INDEX_NESTED_REDEEM         = "indexNestedRedeem"
 
 	//key prefix
 	SIDE_CHAIN_APPLY          = "sideChainApply"
@@ -50,6 +54,12 @@ const (
 	BIND_SIGN_INFO            = "bindSignInfo"
 	BTC_TX_PARAM              = "btcTxParam"
 	REDEEM_SCRIPT             = "redeemScript"
+	HEADER_RETENTION          = "headerRetention"
+	NESTED_REDEEM             = "nestedRedeem"
+	REDEEM_SUCCESSOR          = "redeemSuccessor"
+
+	//the smallest retention window, large enough for fork choice and difficulty adjustment of side chains
+	MIN_HEADER_RETENTION = 4032
 )
 
 //Register methods of node_manager contract
@@ -62,7 +72,10 @@ func RegisterSideChainManagerContract(native *native.NativeService) {
 	native.Register(APPROVE_QUIT_SIDE_CHAIN, ApproveQuitSideChain)
 
 	native.Register(REGISTER_REDEEM, RegisterRedeem)
+	native.Register(ROTATE_REDEEM, RotateRedeem)
+	native.Register(INDEX_NESTED_REDEEM, IndexNestedRedeem)
 	native.Register(SET_BTC_TX_PARAM, SetBtcTxParam)
+	native.Register(SET_HEADER_RETENTION, SetHeaderRetention)
 }
 
 func RegisterSideChain(native *native.NativeService) ([]byte, error) {
@@ -147,6 +160,8 @@ func ApproveRegisterSideChain(native *native.NativeService) ([]byte, error) {
 		return utils.BYTE_FALSE, fmt.Errorf("ApproveRegisterSideChain, putSideChain error: %v", err)
 	}
 	native.GetCacheDB().Delete(utils.ConcatKey(utils.SideChainManagerContractAddress, []byte(SIDE_CHAIN_APPLY), utils.GetUint64Bytes(params.Chainid)))
+	//quit requests of an earlier registration of the chain are not carried over
+	native.GetCacheDB().Delete(utils.ConcatKey(utils.SideChainManagerContractAddress, []byte(QUIT_SIDE_CHAIN_REQUEST), utils.GetUint64Bytes(params.Chainid)))
 	native.AddNotify(
 		&event.NotifyEventInfo{
 			ContractAddress: utils.NodeManagerContractAddress,
@@ -305,7 +320,7 @@ func ApproveQuitSideChain(native *native.NativeService) ([]byte, error) {
 	}
 
 	chainidByte := utils.GetUint64Bytes(params.Chainid)
-	native.GetCacheDB().Delete(utils.ConcatKey(utils.SideChainManagerContractAddress, []byte(QUIT_SIDE_CHAIN), chainidByte))
+	native.GetCacheDB().Delete(utils.ConcatKey(utils.SideChainManagerContractAddress, []byte(QUIT_SIDE_CHAIN_REQUEST), chainidByte))
 	native.GetCacheDB().Delete(utils.ConcatKey(utils.SideChainManagerContractAddress, []byte(SIDE_CHAIN), chainidByte))
 	native.AddNotify(
 		&event.NotifyEventInfo{
@@ -371,6 +386,100 @@ func RegisterRedeem(native *native.NativeService) ([]byte, error) {
 	return utils.BYTE_TRUE, nil
 }
 
+// RotateRedeem moves the custody of a redeem to its successor once enough signers of the old
+// redeem agree. The successor must be registered by RegisterRedeem before, after rotation
+// the cross chain manager sweeps old utxos to the successor and withdraws from it.
+func RotateRedeem(native *native.NativeService) ([]byte, error) {
+	params := new(RotateRedeemParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("RotateRedeem, contract params deserialize error: %v", err)
+	}
+	ty, addrs, m, err := txscript.ExtractPkScriptAddrs(params.Redeem, netParam)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("RotateRedeem, failed to extract addrs: %v", err)
+	}
+	if ty != txscript.MultiSigTy {
+		return utils.BYTE_FALSE, fmt.Errorf("RotateRedeem, wrong type of redeem: %s", ty.String())
+	}
+	rk, sk := btcutil.Hash160(params.Redeem), btcutil.Hash160(params.Successor)
+	if bytes.Equal(rk, sk) {
+		return utils.BYTE_FALSE, fmt.Errorf("RotateRedeem, successor is the same as redeem")
+	}
+	if _, err = GetBtcRedeemScriptBytes(native, hex.EncodeToString(rk), params.RedeemChainID); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("RotateRedeem, redeem is not registered: %v", err)
+	}
+	if _, err = GetBtcRedeemScriptBytes(native, hex.EncodeToString(sk), params.RedeemChainID); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("RotateRedeem, successor is not registered: %v", err)
+	}
+	for _, k := range [][]byte{rk, sk} {
+		successor, err := GetRedeemSuccessor(native, hex.EncodeToString(k), params.RedeemChainID)
+		if err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("RotateRedeem, %v", err)
+		}
+		if successor != "" {
+			return utils.BYTE_FALSE, fmt.Errorf("RotateRedeem, redeem %s is already rotated to %s",
+				hex.EncodeToString(k), successor)
+		}
+	}
+
+	key := append(append(append([]byte(ROTATE_REDEEM), rk...), sk...), utils.GetUint64Bytes(params.RedeemChainID)...)
+	info, err := getBindSignInfo(native, key)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("RotateRedeem, getBindSignInfo error: %v", err)
+	}
+	verified, err := verifyRedeemRotate(params, addrs)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("RotateRedeem, failed to verify: %v", err)
+	}
+	for k, v := range verified {
+		info.BindSignInfo[k] = v
+	}
+	if err = putBindSignInfo(native, key, info); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("RotateRedeem, failed to put bindSignInfo: %v", err)
+	}
+	if len(info.BindSignInfo) >= m {
+		putRedeemSuccessor(native, hex.EncodeToString(rk), hex.EncodeToString(sk), params.RedeemChainID)
+		native.AddNotify(
+			&event.NotifyEventInfo{
+				ContractAddress: utils.SideChainManagerContractAddress,
+				States:          []interface{}{"RotateRedeem", hex.EncodeToString(rk), hex.EncodeToString(sk), params.RedeemChainID},
+			})
+	}
+	return utils.BYTE_TRUE, nil
+}
+
+// IndexNestedRedeem indexes the P2SH-wrapped P2WSH script hash of a redeem registered before
+// nested witness support, so that deposits to it can be resolved to the redeem. The index only
+// derives from the registered redeem, so anyone can call it.
+func IndexNestedRedeem(native *native.NativeService) ([]byte, error) {
+	params := new(NestedRedeemParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("IndexNestedRedeem, contract params deserialize error: %v", err)
+	}
+	rk := hex.EncodeToString(btcutil.Hash160(params.Redeem))
+	redeem, err := GetBtcRedeemScriptBytes(native, rk, params.RedeemChainID)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("IndexNestedRedeem, redeem is not registered: %v", err)
+	}
+	if !IsWitnessRedeem(redeem) {
+		return utils.BYTE_FALSE, fmt.Errorf("IndexNestedRedeem, redeem %s can't be used as witness script", rk)
+	}
+	nested, err := GetRedeemKeyByNestedHash(native, btcutil.Hash160(GetWitnessProgram(redeem)), params.RedeemChainID)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("IndexNestedRedeem, %v", err)
+	}
+	if nested != "" {
+		return utils.BYTE_FALSE, fmt.Errorf("IndexNestedRedeem, redeem %s is already indexed", rk)
+	}
+	putNestedRedeem(native, rk, redeem, params.RedeemChainID)
+	native.AddNotify(
+		&event.NotifyEventInfo{
+			ContractAddress: utils.SideChainManagerContractAddress,
+			States:          []interface{}{"IndexNestedRedeem", rk, params.RedeemChainID},
+		})
+	return utils.BYTE_TRUE, nil
+}
+
 func SetBtcTxParam(native *native.NativeService) ([]byte, error) {
 	params := new(BtcTxParam)
 	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
@@ -431,3 +540,51 @@ func SetBtcTxParam(native *native.NativeService) ([]byte, error) {
 	}
 	return utils.BYTE_TRUE, nil
 }
+
+func SetHeaderRetention(native *native.NativeService) ([]byte, error) {
+	params := new(HeaderRetentionParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SetHeaderRetention, contract params deserialize error: %v", err)
+	}
+
+	//check witness
+	err := utils.ValidateOwner(native, params.Address)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SetHeaderRetention, checkWitness error: %v", err)
+	}
+
+	sideChain, err := GetSideChain(native, params.ChainID)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SetHeaderRetention, GetSideChain error: %v", err)
+	}
+	if sideChain == nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SetHeaderRetention, side chain %d is not registered", params.ChainID)
+	}
+	//quorum header sync only keeps the validators of the latest synced header, there is nothing to prune
+	if sideChain.Router == utils.QUORUM_ROUTER && params.Window != 0 {
+		return utils.BYTE_FALSE, fmt.Errorf("SetHeaderRetention, quorum side chain %d keeps no headers", params.ChainID)
+	}
+	if params.Window != 0 && params.Window < MIN_HEADER_RETENTION {
+		return utils.BYTE_FALSE, fmt.Errorf("SetHeaderRetention, window %d is less than %d", params.Window, MIN_HEADER_RETENTION)
+	}
+
+	//check consensus signs
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteVarUint(params.ChainID)
+	sink.WriteVarUint(params.Window)
+	ok, err := node_manager.CheckConsensusSigns(native, SET_HEADER_RETENTION, sink.Bytes(), params.Address)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SetHeaderRetention, CheckConsensusSigns error: %v", err)
+	}
+	if !ok {
+		return utils.BYTE_TRUE, nil
+	}
+
+	putHeaderRetention(native, params.ChainID, params.Window)
+	native.AddNotify(
+		&event.NotifyEventInfo{
+			ContractAddress: utils.SideChainManagerContractAddress,
+			States:          []interface{}{"SetHeaderRetention", params.ChainID, params.Window},
+		})
+	return utils.BYTE_TRUE, nil
+}
@@ -19,14 +19,19 @@ package side_chain_manager
 
 import (
 	"encoding/hex"
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
 	"github.com/ontio/ontology-crypto/keypair"
 	"github.com/polynetwork/poly/account"
 	"github.com/polynetwork/poly/common"
 	"github.com/polynetwork/poly/core/genesis"
+	cstates "github.com/polynetwork/poly/core/states"
 	"github.com/polynetwork/poly/core/store/leveldbstore"
 	"github.com/polynetwork/poly/core/store/overlaydb"
 	"github.com/polynetwork/poly/core/types"
 	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/service/testutils"
 	"github.com/polynetwork/poly/native/service/utils"
 	"github.com/polynetwork/poly/native/storage"
 	"github.com/stretchr/testify/assert"
@@ -202,6 +207,10 @@ func TestRegisterRedeem(t *testing.T) {
 	assert.Equal(t, "c330431496364497d7257839737b5e4596f5ac06", states[1].(string))
 	assert.Equal(t, strings.ToLower("9a20bEd97360d28AE93c21750e9492ea8f85989f"), states[2].(string))
 
+	rk, err := GetRedeemKeyByNestedHash(ns, btcutil.Hash160(GetWitnessProgram(redeem)), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "c330431496364497d7257839737b5e4596f5ac06", rk)
+
 	ok, err = RegisterRedeem(ns)
 	assert.Error(t, err)
 	assert.Equal(t, utils.BYTE_FALSE, ok)
@@ -242,3 +251,168 @@ func TestSetBtcTxParam(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, utils.BYTE_FALSE, ok)
 }
+
+func TestSetHeaderRetention(t *testing.T) {
+	tx := &types.Transaction{
+		SignedAddr: []common.Address{acct.Address},
+	}
+	ns := NewNative(nil, tx, nil)
+	testutils.PutPeerPoolAndView(ns.GetCacheDB(), []*account.Account{acct})
+	assert.NoError(t, PutSideChain(ns, &SideChain{ChainId: 8, Router: utils.QUORUM_ROUTER, Name: "quorum"}))
+	assert.NoError(t, PutSideChain(ns, &SideChain{ChainId: 2, Router: utils.ETH_ROUTER, Name: "eth", BlocksToWait: 12}))
+
+	setRetention := func(chainID, window uint64) error {
+		param := &HeaderRetentionParam{ChainID: chainID, Window: window, Address: acct.Address}
+		sink := common.NewZeroCopySink(nil)
+		param.Serialization(sink)
+		ns = NewNative(sink.Bytes(), tx, ns.GetCacheDB())
+		_, err := SetHeaderRetention(ns)
+		return err
+	}
+
+	assert.Error(t, setRetention(8, MIN_HEADER_RETENTION))
+	assert.Error(t, setRetention(2, MIN_HEADER_RETENTION-1))
+	assert.NoError(t, setRetention(2, MIN_HEADER_RETENTION))
+	window, err := GetHeaderRetention(ns, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(MIN_HEADER_RETENTION), window)
+}
+
+func TestApproveQuitSideChain(t *testing.T) {
+	tx := &types.Transaction{
+		SignedAddr: []common.Address{acct.Address},
+	}
+	ns := NewNative(nil, tx, nil)
+	testutils.PutPeerPoolAndView(ns.GetCacheDB(), []*account.Account{acct})
+	assert.NoError(t, PutSideChain(ns, &SideChain{Address: acct.Address, ChainId: 2, Router: utils.ETH_ROUTER, Name: "eth"}))
+
+	param := &ChainidParam{Chainid: 2, Address: acct.Address}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	ns = NewNative(sink.Bytes(), tx, ns.GetCacheDB())
+	_, err := QuitSideChain(ns)
+	assert.NoError(t, err)
+	ns = NewNative(sink.Bytes(), tx, ns.GetCacheDB())
+	_, err = ApproveQuitSideChain(ns)
+	assert.NoError(t, err)
+
+	sideChain, err := GetSideChain(ns, 2)
+	assert.NoError(t, err)
+	assert.Nil(t, sideChain)
+	// the request is done with, a registration of the same chain id can not be quit by it
+	assert.Error(t, getQuitSideChain(ns, 2))
+}
+
+func newMultiSigRedeem(t *testing.T, n, m int, compressed bool) []byte {
+	_, redeem := newMultiSigKeys(t, n, m, compressed)
+	return redeem
+}
+
+func newMultiSigKeys(t *testing.T, n, m int, compressed bool) ([]*btcec.PrivateKey, []byte) {
+	keys := make([]*btcec.PrivateKey, n)
+	addrs := make([]*btcutil.AddressPubKey, n)
+	for i := range addrs {
+		var err error
+		keys[i], err = btcec.NewPrivateKey(btcec.S256())
+		assert.NoError(t, err)
+		pk := keys[i].PubKey().SerializeUncompressed()
+		if compressed {
+			pk = keys[i].PubKey().SerializeCompressed()
+		}
+		addrs[i], err = btcutil.NewAddressPubKey(pk, netParam)
+		assert.NoError(t, err)
+	}
+	redeem, err := txscript.MultiSigScript(addrs, m)
+	assert.NoError(t, err)
+	return keys, redeem
+}
+
+func TestRotateRedeem(t *testing.T) {
+	ns := NewNative(nil, new(types.Transaction), nil)
+	keys, redeem := newMultiSigKeys(t, 3, 2, true)
+	successor := newMultiSigRedeem(t, 3, 2, true)
+	rk := hex.EncodeToString(btcutil.Hash160(redeem))
+	sk := hex.EncodeToString(btcutil.Hash160(successor))
+	rotate := func(redeem, successor []byte, signers ...*btcec.PrivateKey) error {
+		param := &RotateRedeemParam{RedeemChainID: 1, Redeem: redeem, Successor: successor}
+		hash := btcutil.Hash160(append(append(append([]byte{}, redeem...), successor...), utils.GetUint64Bytes(1)...))
+		for _, key := range signers {
+			sig, err := key.Sign(hash)
+			assert.NoError(t, err)
+			param.Signs = append(param.Signs, sig.Serialize())
+		}
+		sink := common.NewZeroCopySink(nil)
+		param.Serialization(sink)
+		ns = NewNative(sink.Bytes(), new(types.Transaction), ns.GetCacheDB())
+		_, err := RotateRedeem(ns)
+		return err
+	}
+	successorOf := func(redeemKey string) string {
+		successor, err := GetRedeemSuccessor(ns, redeemKey, 1)
+		assert.NoError(t, err)
+		return successor
+	}
+
+	assert.NoError(t, putBtcRedeemScript(ns, rk, redeem, 1))
+	// successor must be registered first
+	assert.Error(t, rotate(redeem, successor, keys[0], keys[1]))
+	assert.NoError(t, putBtcRedeemScript(ns, sk, successor, 1))
+	assert.Error(t, rotate(redeem, redeem, keys[0], keys[1]))
+	// signatures of others are rejected
+	others, _ := newMultiSigKeys(t, 3, 2, true)
+	assert.Error(t, rotate(redeem, successor, others[0], others[1]))
+
+	// one signature is not enough
+	assert.NoError(t, rotate(redeem, successor, keys[0]))
+	assert.Equal(t, "", successorOf(rk))
+	assert.Equal(t, 0, len(ns.GetNotify()))
+
+	assert.NoError(t, rotate(redeem, successor, keys[1]))
+	assert.Equal(t, sk, successorOf(rk))
+	assert.Equal(t, "", successorOf(sk))
+	states := ns.GetNotify()[0].States.([]interface{})
+	assert.Equal(t, "RotateRedeem", states[0].(string))
+	assert.Equal(t, sk, states[2].(string))
+
+	// rotated only once
+	another := newMultiSigRedeem(t, 3, 2, true)
+	assert.NoError(t, putBtcRedeemScript(ns, hex.EncodeToString(btcutil.Hash160(another)), another, 1))
+	assert.Error(t, rotate(redeem, another, keys...))
+}
+
+func TestIndexNestedRedeem(t *testing.T) {
+	ns := NewNative(nil, new(types.Transaction), nil)
+	indexNestedRedeem := func(redeem []byte) error {
+		param := &NestedRedeemParam{RedeemChainID: 1, Redeem: redeem}
+		sink := common.NewZeroCopySink(nil)
+		param.Serialization(sink)
+		ns = NewNative(sink.Bytes(), new(types.Transaction), ns.GetCacheDB())
+		_, err := IndexNestedRedeem(ns)
+		return err
+	}
+	nestedKey := func(redeem []byte) string {
+		rk, err := GetRedeemKeyByNestedHash(ns, btcutil.Hash160(GetWitnessProgram(redeem)), 1)
+		assert.NoError(t, err)
+		return rk
+	}
+
+	// uncompressed redeems are registered as legacy P2SH only
+	legacy := newMultiSigRedeem(t, 3, 2, false)
+	assert.False(t, IsWitnessRedeem(legacy))
+	assert.NoError(t, putBtcRedeemScript(ns, hex.EncodeToString(btcutil.Hash160(legacy)), legacy, 1))
+	assert.Equal(t, "", nestedKey(legacy))
+	assert.Error(t, indexNestedRedeem(legacy))
+
+	// redeems registered before nested witness support have no index
+	redeem := newMultiSigRedeem(t, 3, 2, true)
+	assert.True(t, IsWitnessRedeem(redeem))
+	rk := hex.EncodeToString(btcutil.Hash160(redeem))
+	ns.GetCacheDB().Put(utils.ConcatKey(utils.SideChainManagerContractAddress, []byte(REDEEM_SCRIPT), utils.GetUint64Bytes(1),
+		[]byte(rk)), cstates.GenRawStorageItem(redeem))
+	assert.Equal(t, "", nestedKey(redeem))
+	assert.NoError(t, indexNestedRedeem(redeem))
+	assert.Equal(t, rk, nestedKey(redeem))
+	assert.Error(t, indexNestedRedeem(redeem))
+
+	assert.Error(t, indexNestedRedeem(newMultiSigRedeem(t, 3, 2, true)))
+}
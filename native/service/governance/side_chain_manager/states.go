@@ -22,8 +22,6 @@ import (
 	"sort"
 
 	"github.com/polynetwork/poly/common"
-	"github.com/polynetwork/poly/common/config"
-	"github.com/polynetwork/poly/core/ledger"
 )
 
 type SideChain struct {
@@ -37,14 +35,18 @@ type SideChain struct {
 }
 
 func (this *SideChain) Serialization(sink *common.ZeroCopySink) error {
+	return this.serialization(sink, true)
+}
+
+// serialization omits ExtraInfo in the format before config.FORK_SIDE_CHAIN_EXTRA_INFO
+func (this *SideChain) serialization(sink *common.ZeroCopySink, withExtraInfo bool) error {
 	sink.WriteVarBytes(this.Address[:])
 	sink.WriteVarUint(this.ChainId)
 	sink.WriteVarUint(this.Router)
 	sink.WriteVarBytes([]byte(this.Name))
 	sink.WriteVarUint(this.BlocksToWait)
 	sink.WriteVarBytes(this.CCMCAddress)
-	height := config.GetExtraInfoHeight(config.DefConfig.P2PNode.NetworkId)
-	if !config.EXTRA_INFO_HEIGHT_FORK_CHECK || ledger.DefLedger.GetCurrentBlockHeight() >= height {
+	if withExtraInfo {
 		sink.WriteVarBytes(this.ExtraInfo)
 	}
 	return nil
@@ -19,6 +19,12 @@ package side_chain_manager
 
 import (
 	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/common/config"
+	"github.com/polynetwork/poly/core/store/leveldbstore"
+	"github.com/polynetwork/poly/core/store/overlaydb"
+	"github.com/polynetwork/poly/core/types"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/storage"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -38,3 +44,34 @@ func TestSideChain_Serialization(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, paramDeserialize, paramSerialize)
 }
+
+func TestPutSideChainExtraInfoFork(t *testing.T) {
+	sideChain := &SideChain{
+		Name:         "own",
+		ChainId:      8,
+		BlocksToWait: 10,
+		ExtraInfo:    []byte{1, 2, 3},
+	}
+	putAndGet := func(height uint32) *SideChain {
+		store, _ := leveldbstore.NewMemLevelDBStore()
+		db := storage.NewCacheDB(overlaydb.NewOverlayDB(store))
+		ns, err := native.NewNativeService(db, new(types.Transaction), 0, height, common.Uint256{}, 0, nil, false)
+		assert.Nil(t, err)
+		assert.Nil(t, PutSideChain(ns, sideChain))
+		res, err := GetSideChain(ns, sideChain.ChainId)
+		assert.Nil(t, err)
+		return res
+	}
+
+	forkHeight := config.GetForkHeight(config.NETWORK_ID_MAIN_NET, config.FORK_SIDE_CHAIN_EXTRA_INFO)
+	assert.Equal(t, 0, len(putAndGet(forkHeight-1).ExtraInfo))
+	assert.Equal(t, sideChain.ExtraInfo, putAndGet(forkHeight).ExtraInfo)
+
+	// private networks schedule the fork in genesis config
+	genesis := config.DefConfig.Genesis
+	defer func() { config.DefConfig.Genesis = genesis }()
+	config.DefConfig.Genesis = config.NewGenesisConfig()
+	config.DefConfig.Genesis.Forks = map[string]uint32{config.FORK_SIDE_CHAIN_EXTRA_INFO: 100}
+	assert.Equal(t, 0, len(putAndGet(99).ExtraInfo))
+	assert.Equal(t, sideChain.ExtraInfo, putAndGet(100).ExtraInfo)
+}
@@ -18,6 +18,8 @@
 package side_chain_manager
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 
 	"github.com/btcsuite/btcd/btcec"
@@ -25,6 +27,7 @@ import (
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcutil"
 	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/common/config"
 	cstates "github.com/polynetwork/poly/core/states"
 	"github.com/polynetwork/poly/native"
 	"github.com/polynetwork/poly/native/service/utils"
@@ -61,7 +64,7 @@ func putSideChainApply(native *native.NativeService, sideChain *SideChain) error
 	chainidByte := utils.GetUint64Bytes(sideChain.ChainId)
 
 	sink := common.NewZeroCopySink(nil)
-	err := sideChain.Serialization(sink)
+	err := sideChain.serialization(sink, native.IsForkActive(config.FORK_SIDE_CHAIN_EXTRA_INFO))
 	if err != nil {
 		return fmt.Errorf("putRegisterSideChain, sideChain.Serialization error: %v", err)
 	}
@@ -100,7 +103,7 @@ func PutSideChain(native *native.NativeService, sideChain *SideChain) error {
 	chainidByte := utils.GetUint64Bytes(sideChain.ChainId)
 
 	sink := common.NewZeroCopySink(nil)
-	err := sideChain.Serialization(sink)
+	err := sideChain.serialization(sink, native.IsForkActive(config.FORK_SIDE_CHAIN_EXTRA_INFO))
 	if err != nil {
 		return fmt.Errorf("putSideChain, sideChain.Serialization error: %v", err)
 	}
@@ -139,7 +142,7 @@ func putUpdateSideChain(native *native.NativeService, sideChain *SideChain) erro
 	chainidByte := utils.GetUint64Bytes(sideChain.ChainId)
 
 	sink := common.NewZeroCopySink(nil)
-	err := sideChain.Serialization(sink)
+	err := sideChain.serialization(sink, native.IsForkActive(config.FORK_SIDE_CHAIN_EXTRA_INFO))
 	if err != nil {
 		return fmt.Errorf("putUpdateSideChain, sideChain.Serialization error: %v", err)
 	}
@@ -290,6 +293,13 @@ func verifyRedeemRegister(param *RegisterRedeemParam, addrs []btcutil.Address) (
 	return verify(param.Signs, addrs, hash)
 }
 
+func verifyRedeemRotate(param *RotateRedeemParam, addrs []btcutil.Address) (map[string][]byte, error) {
+	r := make([]byte, len(param.Redeem))
+	copy(r, param.Redeem)
+	hash := btcutil.Hash160(append(append(r, param.Successor...), utils.GetUint64Bytes(param.RedeemChainID)...))
+	return verify(param.Signs, addrs, hash)
+}
+
 func verifyBtcTxParam(param *BtcTxParam, addrs []btcutil.Address) (map[string][]byte, error) {
 	r := make([]byte, len(param.Redeem))
 	copy(r, param.Redeem)
@@ -332,6 +342,66 @@ func putBtcRedeemScript(native *native.NativeService, redeemScriptKey string, re
 		return fmt.Errorf("putBtcRedeemScript, wrong type of redeem: %s", cls)
 	}
 	native.GetCacheDB().Put(key, cstates.GenRawStorageItem(redeemScriptBytes))
+	if IsWitnessRedeem(redeemScriptBytes) {
+		putNestedRedeem(native, redeemScriptKey, redeemScriptBytes, redeemChainId)
+	}
+	return nil
+}
+
+func putNestedRedeem(native *native.NativeService, redeemScriptKey string, redeemScriptBytes []byte, redeemChainId uint64) {
+	native.GetCacheDB().Put(utils.ConcatKey(utils.SideChainManagerContractAddress, []byte(NESTED_REDEEM),
+		utils.GetUint64Bytes(redeemChainId), btcutil.Hash160(GetWitnessProgram(redeemScriptBytes))),
+		cstates.GenRawStorageItem([]byte(redeemScriptKey)))
+}
+
+// GetWitnessProgram returns the version 0 witness program of redeem, which is the pkScript
+// of P2WSH and the redeem script pushed in the signature script of P2SH-wrapped P2WSH
+func GetWitnessProgram(redeem []byte) []byte {
+	hash := sha256.Sum256(redeem)
+	return append([]byte{txscript.OP_0, txscript.OP_DATA_32}, hash[:]...)
+}
+
+// GetRedeemKeyByNestedHash returns the redeem key whose P2SH-wrapped P2WSH script hash is
+// scriptHash, empty string returned if no redeem registered for it. Redeems registered before
+// nested witness support are not indexed until IndexNestedRedeem is called for them.
+func GetRedeemKeyByNestedHash(native *native.NativeService, scriptHash []byte, redeemChainId uint64) (string, error) {
+	store, err := native.GetCacheDB().Get(utils.ConcatKey(utils.SideChainManagerContractAddress, []byte(NESTED_REDEEM),
+		utils.GetUint64Bytes(redeemChainId), scriptHash))
+	if err != nil {
+		return "", fmt.Errorf("GetRedeemKeyByNestedHash, get nested redeem store error: %v", err)
+	}
+	if store == nil {
+		return "", nil
+	}
+	rk, err := cstates.GetValueFromRawStorageItem(store)
+	if err != nil {
+		return "", fmt.Errorf("GetRedeemKeyByNestedHash, deserialize from raw storage item err:%v", err)
+	}
+	return string(rk), nil
+}
+
+// IsWitnessRedeem tells whether the multisig redeem can be spent as P2WSH or P2SH-wrapped P2WSH,
+// otherwise it is only used as legacy P2SH
+func IsWitnessRedeem(redeem []byte) bool {
+	ty, addrs, _, err := txscript.ExtractPkScriptAddrs(redeem, netParam)
+	if err != nil || ty != txscript.MultiSigTy {
+		return false
+	}
+	return checkWitnessRedeem(addrs) == nil
+}
+
+// checkWitnessRedeem makes sure the multisig redeem is spendable as witness script,
+// which requires all public keys to be compressed
+func checkWitnessRedeem(addrs []btcutil.Address) error {
+	for _, addr := range addrs {
+		pk, ok := addr.(*btcutil.AddressPubKey)
+		if !ok {
+			return fmt.Errorf("checkWitnessRedeem, %s is not a public key", addr.EncodeAddress())
+		}
+		if pk.Format() != btcutil.PKFCompressed {
+			return fmt.Errorf("checkWitnessRedeem, public key %s is not compressed", hex.EncodeToString(pk.ScriptAddress()))
+		}
+	}
 	return nil
 }
 
@@ -351,3 +421,49 @@ func GetBtcRedeemScriptBytes(native *native.NativeService, redeemScriptKey strin
 	}
 	return redeemBytes, nil
 }
+
+func putHeaderRetention(native *native.NativeService, chainID, window uint64) {
+	native.GetCacheDB().Put(utils.ConcatKey(utils.SideChainManagerContractAddress, []byte(HEADER_RETENTION),
+		utils.GetUint64Bytes(chainID)), cstates.GenRawStorageItem(utils.GetUint64Bytes(window)))
+}
+
+// GetHeaderRetention returns how many headers under the finalized one header_sync keeps
+// for the side chain, zero means headers are never pruned.
+func GetHeaderRetention(native *native.NativeService, chainID uint64) (uint64, error) {
+	store, err := native.GetCacheDB().Get(utils.ConcatKey(utils.SideChainManagerContractAddress, []byte(HEADER_RETENTION),
+		utils.GetUint64Bytes(chainID)))
+	if err != nil {
+		return 0, fmt.Errorf("GetHeaderRetention, get retention store error: %v", err)
+	}
+	if store == nil {
+		return 0, nil
+	}
+	windowBytes, err := cstates.GetValueFromRawStorageItem(store)
+	if err != nil {
+		return 0, fmt.Errorf("GetHeaderRetention, deserialize from raw storage item error: %v", err)
+	}
+	return utils.GetBytesUint64(windowBytes), nil
+}
+
+func putRedeemSuccessor(native *native.NativeService, redeemKey, successorKey string, redeemChainId uint64) {
+	native.GetCacheDB().Put(utils.ConcatKey(utils.SideChainManagerContractAddress, []byte(REDEEM_SUCCESSOR),
+		utils.GetUint64Bytes(redeemChainId), []byte(redeemKey)), cstates.GenRawStorageItem([]byte(successorKey)))
+}
+
+// GetRedeemSuccessor returns the key of redeem which the redeem is rotated to,
+// empty string returned if it's not rotated
+func GetRedeemSuccessor(native *native.NativeService, redeemKey string, redeemChainId uint64) (string, error) {
+	store, err := native.GetCacheDB().Get(utils.ConcatKey(utils.SideChainManagerContractAddress, []byte(REDEEM_SUCCESSOR),
+		utils.GetUint64Bytes(redeemChainId), []byte(redeemKey)))
+	if err != nil {
+		return "", fmt.Errorf("GetRedeemSuccessor, get successor store error: %v", err)
+	}
+	if store == nil {
+		return "", nil
+	}
+	sk, err := cstates.GetValueFromRawStorageItem(store)
+	if err != nil {
+		return "", fmt.Errorf("GetRedeemSuccessor, deserialize from raw storage item err:%v", err)
+	}
+	return string(sk), nil
+}
@@ -44,6 +44,10 @@ import (
 type Handler struct {
 }
 
+func init() {
+	scom.RegisterHeaderSyncHandler(utils.BSC_ROUTER, "bsc", func() scom.HeaderSyncHandler { return NewHandler() })
+}
+
 // NewHandler ...
 func NewHandler() *Handler {
 	return &Handler{}
@@ -61,6 +65,20 @@ func (h *Handler) SyncGenesisHeader(native *native.NativeService) (err error) {
 	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
 		return fmt.Errorf("bsc Handler SyncGenesisHeader, contract params deserialize error: %v", err)
 	}
+	return h.syncGenesisHeader(native, params, ExtraInfo{})
+}
+
+// SyncGenesisHeaderWithExtraInfo syncs genesis header with extraInfo given by the caller, the
+// genesis header must be a checkpoint if the epoch is set
+func (h *Handler) SyncGenesisHeaderWithExtraInfo(native *native.NativeService, extraInfo ExtraInfo) error {
+	params := new(scom.SyncGenesisHeaderParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return fmt.Errorf("bsc Handler SyncGenesisHeader, contract params deserialize error: %v", err)
+	}
+	return h.syncGenesisHeader(native, params, extraInfo)
+}
+
+func (h *Handler) syncGenesisHeader(native *native.NativeService, params *scom.SyncGenesisHeaderParam, extraInfo ExtraInfo) (err error) {
 	// Get current epoch operator
 	operatorAddress, err := node_manager.GetCurConOperator(native)
 	if err != nil {
@@ -92,6 +110,10 @@ func (h *Handler) SyncGenesisHeader(native *native.NativeService) (err error) {
 	if signersBytes == 0 || signersBytes%ecommon.AddressLength != 0 {
 		return fmt.Errorf("invalid signer list, signersBytes:%d", signersBytes)
 	}
+	if extraInfo.Epoch != 0 && genesis.Header.Number.Uint64()%extraInfo.Epoch != 0 {
+		return fmt.Errorf("bsc Handler SyncGenesisHeader, genesis header %d is not a checkpoint of epoch %d",
+			genesis.Header.Number.Uint64(), extraInfo.Epoch)
+	}
 
 	if len(genesis.PrevValidators) != 1 {
 		return fmt.Errorf("invalid PrevValidators")
@@ -183,6 +205,7 @@ func storeGenesis(native *native.NativeService, params *scom.SyncGenesisHeaderPa
 // ExtraInfo ...
 type ExtraInfo struct {
 	ChainID *big.Int // for bsc
+	Epoch   uint64   // blocks between two checkpoints, 0 to skip the check of genesis
 }
 
 // Context ...
@@ -221,6 +244,20 @@ func (h *Handler) SyncBlockHeader(native *native.NativeService) error {
 		return fmt.Errorf("bsc Handler SyncBlockHeader, ExtraInfo Unmarshal error: %v", err)
 	}
 
+	return h.syncBlockHeader(native, headerParams, extraInfo)
+}
+
+// SyncBlockHeaderWithExtraInfo verifies headers with extraInfo given by the caller instead of
+// the one decoded from the side chain
+func (h *Handler) SyncBlockHeaderWithExtraInfo(native *native.NativeService, extraInfo ExtraInfo) error {
+	headerParams := new(scom.SyncBlockHeaderParam)
+	if err := headerParams.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return fmt.Errorf("bsc Handler SyncBlockHeader, contract params deserialize error: %v", err)
+	}
+	return h.syncBlockHeader(native, headerParams, extraInfo)
+}
+
+func (h *Handler) syncBlockHeader(native *native.NativeService, headerParams *scom.SyncBlockHeaderParam, extraInfo ExtraInfo) (err error) {
 	ctx := &Context{ExtraInfo: extraInfo, ChainID: headerParams.ChainID}
 
 	for _, v := range headerParams.Headers {
@@ -310,6 +347,43 @@ func (h *Handler) SyncBlockHeader(native *native.NativeService) error {
 
 		scom.NotifyPutHeader(native, headerParams.ChainID, header.Number.Uint64(), header.Hash().Hex())
 	}
+
+	// prune headers out of the retention window
+	err = pruneHeaders(native, headerParams.ChainID)
+	if err != nil {
+		return fmt.Errorf("bsc Handler SyncBlockHeader, pruneHeaders err: %v", err)
+	}
+	return nil
+}
+
+func pruneHeaders(native *native.NativeService, chainID uint64) error {
+	genesis, err := getGenesis(native, chainID)
+	if err != nil {
+		return fmt.Errorf("bsc Handler getGenesis error: %v", err)
+	}
+	if genesis == nil {
+		return fmt.Errorf("bsc Handler genesis not set")
+	}
+	height, err := GetCanonicalHeight(native, chainID)
+	if err != nil {
+		return err
+	}
+	return scom.PruneHeaders(native, chainID, genesis.Header.Number.Uint64()+1, height, func(h uint64) error {
+		return pruneHeader(native, chainID, h)
+	})
+}
+
+// pruneHeader deletes the canonical header at height, headers of forks are left untouched
+func pruneHeader(native *native.NativeService, chainID uint64, height uint64) error {
+	hash, err := getCanonicalHash(native, chainID, height)
+	if err != nil {
+		return err
+	}
+	if hash == (ecommon.Hash{}) {
+		return nil
+	}
+	native.GetCacheDB().Delete(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(scom.HEADER_INDEX), utils.GetUint64Bytes(chainID), hash.Bytes()))
+	deleteCanonicalHash(native, chainID, height)
 	return nil
 }
 
@@ -482,6 +556,21 @@ func addHeader(native *native.NativeService, header *types.Header, phv *HeightAn
 		// Extend the canonical chain with the new header
 		putCanonicalHash(native, ctx.ChainID, header.Number.Uint64(), header.Hash())
 		putCanonicalHeight(native, ctx.ChainID, header.Number.Uint64())
+
+		// Record the reorg if the old head is no longer canonical
+		if header.ParentHash != cheader.Header.Hash() {
+			err = scom.PutChainReorg(native, ctx.ChainID, &scom.ChainReorg{
+				OldTip:     cheader.Header.Hash().Bytes(),
+				OldHeight:  cheader.Header.Number.Uint64(),
+				NewTip:     header.Hash().Bytes(),
+				NewHeight:  header.Number.Uint64(),
+				ForkPoint:  headHash.Bytes(),
+				ForkHeight: cheight,
+			})
+			if err != nil {
+				return
+			}
+		}
 	}
 
 	return nil
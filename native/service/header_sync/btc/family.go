@@ -0,0 +1,310 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package btc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	bchcfg "github.com/gcash/bchd/chaincfg"
+	"github.com/gcash/bchutil"
+	"github.com/polynetwork/poly/native/service/utils"
+	"golang.org/x/crypto/scrypt"
+)
+
+// SigHashForkID is the flag of bitcoin cash signatures committing to the input amount, see BIP143
+const SigHashForkID txscript.SigHashType = 0x40
+
+// AsertAnchor is the block which aserti3-2d difficulty adjustment is calculated from
+type AsertAnchor struct {
+	Height     uint32
+	Bits       uint32
+	ParentTime int64
+}
+
+// ChainFamily describes a bitcoin-like chain sharing header, transaction and script formats with
+// bitcoin, so that its side chains are served by the btc header_sync and cross_chain_manager.
+type ChainFamily struct {
+	Name   string
+	Router uint64
+	// segwit is active, redeems take custody by P2WSH, otherwise by P2SH
+	Witness bool
+	// signatures must be SIGHASH_FORKID ones
+	ForkID bool
+	// mempool takes replacements of txs signaling it by BIP125, so stuck txs can be bumped
+	ReplaceByFee bool
+	// proof of work is checked against the scrypt hash of header instead of the block hash
+	Scrypt         bool
+	TargetSpacing  time.Duration
+	TargetTimespan time.Duration
+	// epoch is measured from the last block of previous epoch, which closes the time warp
+	FixTimeWarp bool
+	// difficulty is adjusted every block by aserti3-2d if anchor of the net is set
+	AsertHalfLife int64
+	asertAnchors  map[utils.BtcNetType]*AsertAnchor
+	nets          map[utils.BtcNetType]*chaincfg.Params
+	cashNets      map[utils.BtcNetType]*bchcfg.Params
+}
+
+var (
+	LtcMainNetParams = deriveParams(&chaincfg.MainNetParams, "ltc-mainnet", 0xdbb6c0fb, ltcPowLimit, 0x1e0fffff,
+		0x30, 0x32, 0xb0, "ltc")
+	LtcTestNet4Params = deriveParams(&chaincfg.TestNet3Params, "ltc-testnet4", 0xf1c8d2fd, ltcPowLimit, 0x1e0fffff,
+		0x6f, 0x3a, 0xef, "tltc")
+	LtcRegressionNetParams = deriveParams(&chaincfg.RegressionNetParams, "ltc-regtest", 0xdab5bffa,
+		chaincfg.RegressionNetParams.PowLimit, chaincfg.RegressionNetParams.PowLimitBits, 0x6f, 0x3a, 0xef, "rltc")
+
+	BchMainNetParams = deriveParams(&chaincfg.MainNetParams, "bch-mainnet", 0xe8f3e1e3,
+		chaincfg.MainNetParams.PowLimit, chaincfg.MainNetParams.PowLimitBits, 0x00, 0x05, 0x80, "")
+	BchTestNet3Params = deriveParams(&chaincfg.TestNet3Params, "bch-testnet3", 0xf4f3e5f4,
+		chaincfg.TestNet3Params.PowLimit, chaincfg.TestNet3Params.PowLimitBits, 0x6f, 0xc4, 0xef, "")
+	BchRegressionNetParams = deriveParams(&chaincfg.RegressionNetParams, "bch-regtest", 0xfabfb5da,
+		chaincfg.RegressionNetParams.PowLimit, chaincfg.RegressionNetParams.PowLimitBits, 0x6f, 0xc4, 0xef, "")
+
+	BTCFamily = &ChainFamily{
+		Name:           "btc",
+		Router:         utils.BTC_ROUTER,
+		Witness:        true,
+		ReplaceByFee:   true,
+		TargetSpacing:  targetSpacing,
+		TargetTimespan: targetTimespan,
+		nets: map[utils.BtcNetType]*chaincfg.Params{
+			utils.TyMainnet:  &chaincfg.MainNetParams,
+			utils.TyTestnet3: &chaincfg.TestNet3Params,
+			utils.TyRegtest:  &chaincfg.RegressionNetParams,
+			utils.TySimnet:   &chaincfg.SimNetParams,
+		},
+	}
+	BCHFamily = &ChainFamily{
+		Name:          "bch",
+		Router:        utils.BCH_ROUTER,
+		ForkID:        true,
+		TargetSpacing: targetSpacing,
+		AsertHalfLife: 2 * 24 * 3600,
+		asertAnchors: map[utils.BtcNetType]*AsertAnchor{
+			utils.TyMainnet:  {Height: 661647, Bits: 0x1804dafe, ParentTime: 1605447844},
+			utils.TyTestnet3: {Height: 1421481, Bits: 0x1d00ffff, ParentTime: 1605445400},
+		},
+		nets: map[utils.BtcNetType]*chaincfg.Params{
+			utils.TyMainnet:  BchMainNetParams,
+			utils.TyTestnet3: BchTestNet3Params,
+			utils.TyRegtest:  BchRegressionNetParams,
+		},
+		cashNets: map[utils.BtcNetType]*bchcfg.Params{
+			utils.TyMainnet:  &bchcfg.MainNetParams,
+			utils.TyTestnet3: &bchcfg.TestNet3Params,
+			utils.TyRegtest:  &bchcfg.RegressionNetParams,
+		},
+	}
+	LTCFamily = &ChainFamily{
+		Name:           "ltc",
+		Router:         utils.LTC_ROUTER,
+		Witness:        true,
+		ReplaceByFee:   true,
+		Scrypt:         true,
+		TargetSpacing:  time.Second * 150,
+		TargetTimespan: time.Hour * 84,
+		FixTimeWarp:    true,
+		nets: map[utils.BtcNetType]*chaincfg.Params{
+			utils.TyMainnet:  LtcMainNetParams,
+			utils.TyTestnet3: LtcTestNet4Params,
+			utils.TyRegtest:  LtcRegressionNetParams,
+		},
+	}
+
+	families = []*ChainFamily{BTCFamily, BCHFamily, LTCFamily}
+)
+
+var ltcPowLimit = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 236), big.NewInt(1))
+
+func init() {
+	// base58 versions of litecoin are known to btcutil only by registering, the regtest shares
+	// its magic with bitcoin and its versions with the testnet
+	for _, p := range []*chaincfg.Params{LtcMainNetParams, LtcTestNet4Params} {
+		if err := chaincfg.Register(p); err != nil {
+			panic(fmt.Errorf("failed to register %s: %v", p.Name, err))
+		}
+	}
+}
+
+func deriveParams(base *chaincfg.Params, name string, net wire.BitcoinNet, powLimit *big.Int, powLimitBits uint32,
+	pubKeyHashAddrID, scriptHashAddrID, privateKeyID byte, hrp string) *chaincfg.Params {
+	p := *base
+	p.Name = name
+	p.Net = net
+	p.PowLimit = powLimit
+	p.PowLimitBits = powLimitBits
+	p.PubKeyHashAddrID = pubKeyHashAddrID
+	p.ScriptHashAddrID = scriptHashAddrID
+	p.PrivateKeyID = privateKeyID
+	p.Bech32HRPSegwit = hrp
+	return &p
+}
+
+// GetChainFamily returns the family of chains served by router
+func GetChainFamily(router uint64) (*ChainFamily, error) {
+	for _, f := range families {
+		if f.Router == router {
+			return f, nil
+		}
+	}
+	return nil, fmt.Errorf("router %d is not of any utxo chain family", router)
+}
+
+// IsUtxoRouter tells whether router is one of the bitcoin-like chains
+func IsUtxoRouter(router uint64) bool {
+	_, err := GetChainFamily(router)
+	return err == nil
+}
+
+// FamilyOf returns the family net parameter p belongs to, parameters unknown are taken as bitcoin ones
+func FamilyOf(p *chaincfg.Params) *ChainFamily {
+	for _, f := range families {
+		if _, ok := f.netTypeOf(p); ok {
+			return f
+		}
+	}
+	return BTCFamily
+}
+
+// GetNetParam returns the net parameter of the chain which CCMCAddress of side chain with router stands for
+func GetNetParam(router uint64, ccmcAddress []byte) (*chaincfg.Params, error) {
+	f, err := GetChainFamily(router)
+	if err != nil {
+		return nil, err
+	}
+	if ccmcAddress == nil || len(ccmcAddress) != 8 {
+		return nil, fmt.Errorf("CCMCAddress is nil or its length is not 8")
+	}
+	netType := utils.BtcNetType(binary.LittleEndian.Uint64(ccmcAddress))
+	p, ok := f.nets[netType]
+	if !ok {
+		if netType != utils.TyTestnet3 && netType != utils.TyRegtest && netType != utils.TySimnet {
+			return f.nets[utils.TyMainnet], nil
+		}
+		return nil, fmt.Errorf("net type %d is not supported by %s", netType, f.Name)
+	}
+	return p, nil
+}
+
+func (this *ChainFamily) netTypeOf(p *chaincfg.Params) (utils.BtcNetType, bool) {
+	for k, v := range this.nets {
+		if v.Name == p.Name {
+			return k, true
+		}
+	}
+	return 0, false
+}
+
+func (this *ChainFamily) epochLength() int32 {
+	return int32(this.TargetTimespan / this.TargetSpacing)
+}
+
+// PowHash returns the hash of header which proof of work is checked against
+func (this *ChainFamily) PowHash(header *wire.BlockHeader) chainhash.Hash {
+	if !this.Scrypt {
+		return header.BlockHash()
+	}
+	var buf bytes.Buffer
+	_ = header.Serialize(&buf)
+	var hash chainhash.Hash
+	// parameters of litecoin, the error is only for invalid ones
+	h, _ := scrypt.Key(buf.Bytes(), buf.Bytes(), 1024, 1, 1, 32)
+	copy(hash[:], h)
+	return hash
+}
+
+// DecodeAddress decodes the address on net p, cash addresses are accepted for bitcoin cash as well
+// as the legacy ones
+func (this *ChainFamily) DecodeAddress(addr string, p *chaincfg.Params) (btcutil.Address, error) {
+	netType, _ := this.netTypeOf(p)
+	if cashNet, ok := this.cashNets[netType]; ok {
+		if cashAddr, err := bchutil.DecodeAddress(addr, cashNet); err == nil {
+			if !cashAddr.IsForNet(cashNet) {
+				return nil, fmt.Errorf("address %s is not for %s", addr, p.Name)
+			}
+			// legacy ones are left to btcutil
+			switch a := cashAddr.(type) {
+			case *bchutil.AddressPubKeyHash:
+				return btcutil.NewAddressPubKeyHash(a.ScriptAddress(), p)
+			case *bchutil.AddressScriptHash:
+				return btcutil.NewAddressScriptHashFromHash(a.ScriptAddress(), p)
+			}
+		}
+	}
+	res, err := btcutil.DecodeAddress(addr, p)
+	if err != nil {
+		return nil, err
+	}
+	if !res.IsForNet(p) {
+		return nil, fmt.Errorf("address %s is not for %s", addr, p.Name)
+	}
+	if !this.Witness {
+		switch res.(type) {
+		case *btcutil.AddressWitnessPubKeyHash, *btcutil.AddressWitnessScriptHash:
+			return nil, fmt.Errorf("witness address %s is not supported by %s", addr, this.Name)
+		}
+	}
+	return res, nil
+}
+
+// calcAsertBits returns the bits of the block next to prevHeader by aserti3-2d
+func calcAsertBits(anchor *AsertAnchor, halfLife int64, spacing time.Duration, prevHeader *StoredHeader,
+	p *chaincfg.Params) (uint32, error) {
+	if prevHeader.Height < anchor.Height {
+		return 0, fmt.Errorf("calcAsertBits, header %d is under the asert anchor %d", prevHeader.Height, anchor.Height)
+	}
+	timeDiff := prevHeader.Header.Timestamp.Unix() - anchor.ParentTime
+	heightDiff := int64(prevHeader.Height - anchor.Height)
+	exponent := (timeDiff - int64(spacing/time.Second)*(heightDiff+1)) * 65536 / halfLife
+	shifts := exponent >> 16
+	frac := big.NewInt(exponent - shifts*65536)
+
+	// factor ~= 2^(frac/65536) * 65536 by the cubic approximation of aserti3-2d
+	factor := new(big.Int).Mul(big.NewInt(195766423245049), frac)
+	frac2 := new(big.Int).Mul(frac, frac)
+	factor.Add(factor, new(big.Int).Mul(big.NewInt(971821376), frac2))
+	factor.Add(factor, new(big.Int).Mul(big.NewInt(5127), new(big.Int).Mul(frac2, frac)))
+	factor.Add(factor, new(big.Int).Lsh(big.NewInt(1), 47))
+	factor.Rsh(factor, 48)
+	factor.Add(factor, big.NewInt(65536))
+
+	target := new(big.Int).Mul(blockchain.CompactToBig(anchor.Bits), factor)
+	if shifts < 0 {
+		target.Rsh(target, uint(-shifts))
+	} else {
+		target.Lsh(target, uint(shifts))
+	}
+	target.Rsh(target, 16)
+	if target.Sign() == 0 {
+		return blockchain.BigToCompact(big.NewInt(1)), nil
+	}
+	if target.Cmp(p.PowLimit) > 0 {
+		return p.PowLimitBits, nil
+	}
+	return blockchain.BigToCompact(target), nil
+}
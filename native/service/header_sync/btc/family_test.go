@@ -0,0 +1,124 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package btc
+
+import (
+	"encoding/binary"
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/polynetwork/poly/native/service/utils"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestGetNetParam(t *testing.T) {
+	ccmc := make([]byte, 8)
+	binary.LittleEndian.PutUint64(ccmc, uint64(utils.TyTestnet3))
+	p, err := GetNetParam(utils.BCH_ROUTER, ccmc)
+	assert.NoError(t, err)
+	assert.Equal(t, BchTestNet3Params.Name, p.Name)
+	assert.Equal(t, BCHFamily, FamilyOf(p))
+
+	binary.LittleEndian.PutUint64(ccmc, uint64(utils.TyMainnet))
+	p, err = GetNetParam(utils.LTC_ROUTER, ccmc)
+	assert.NoError(t, err)
+	assert.Equal(t, LTCFamily, FamilyOf(p))
+	assert.Equal(t, BTCFamily, FamilyOf(&chaincfg.MainNetParams))
+
+	_, err = GetNetParam(utils.ETH_ROUTER, ccmc)
+	assert.Error(t, err)
+	assert.False(t, IsUtxoRouter(utils.ETH_ROUTER))
+}
+
+func TestCalcAsertBits(t *testing.T) {
+	anchor := BCHFamily.asertAnchors[utils.TyMainnet]
+	spacing := int64(BCHFamily.TargetSpacing / time.Second)
+	prev := &StoredHeader{Height: anchor.Height + 10}
+
+	prev.Header.Timestamp = time.Unix(anchor.ParentTime+spacing*11, 0)
+	bits, err := calcAsertBits(anchor, BCHFamily.AsertHalfLife, BCHFamily.TargetSpacing, prev, BchMainNetParams)
+	assert.NoError(t, err)
+	assert.Equal(t, anchor.Bits, bits)
+
+	prev.Header.Timestamp = time.Unix(anchor.ParentTime+spacing*11+BCHFamily.AsertHalfLife, 0)
+	bits, err = calcAsertBits(anchor, BCHFamily.AsertHalfLife, BCHFamily.TargetSpacing, prev, BchMainNetParams)
+	assert.NoError(t, err)
+	doubled := new(big.Int).Lsh(blockchain.CompactToBig(anchor.Bits), 1)
+	assert.Equal(t, blockchain.BigToCompact(doubled), bits)
+
+	prev.Header.Timestamp = time.Unix(anchor.ParentTime+spacing*11-BCHFamily.AsertHalfLife, 0)
+	bits, err = calcAsertBits(anchor, BCHFamily.AsertHalfLife, BCHFamily.TargetSpacing, prev, BchMainNetParams)
+	assert.NoError(t, err)
+	halved := new(big.Int).Rsh(blockchain.CompactToBig(anchor.Bits), 1)
+	assert.Equal(t, blockchain.BigToCompact(halved), bits)
+
+	prev.Height = anchor.Height - 1
+	_, err = calcAsertBits(anchor, BCHFamily.AsertHalfLife, BCHFamily.TargetSpacing, prev, BchMainNetParams)
+	assert.Error(t, err)
+}
+
+func TestPowHash(t *testing.T) {
+	// genesis block of litecoin
+	merkle, _ := chainhash.NewHashFromStr("97ddfbbae6be97fd6cdf3e7ca13232a3afff2353e29badfab7f73011edd4ced9")
+	header := &wire.BlockHeader{
+		Version:    1,
+		MerkleRoot: *merkle,
+		Timestamp:  time.Unix(1317972665, 0),
+		Bits:       0x1e0ffff0,
+		Nonce:      2084524493,
+	}
+	assert.Equal(t, "12a765e31ffd4059bada1e25190f6e98c99d9714d334efa41a195a7e7e04bfe2", header.BlockHash().String())
+
+	target := blockchain.CompactToBig(header.Bits)
+	hash := BTCFamily.PowHash(header)
+	assert.True(t, blockchain.HashToBig(&hash).Cmp(target) > 0)
+	hash = LTCFamily.PowHash(header)
+	assert.True(t, blockchain.HashToBig(&hash).Cmp(target) <= 0)
+}
+
+func TestDecodeAddress(t *testing.T) {
+	addr, err := BCHFamily.DecodeAddress("bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a", BchMainNetParams)
+	assert.NoError(t, err)
+	assert.Equal(t, "1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggu", addr.EncodeAddress())
+	addr, err = BCHFamily.DecodeAddress("1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggu", BchMainNetParams)
+	assert.NoError(t, err)
+	_, err = BCHFamily.DecodeAddress("bchtest:qpm2qsznhks23z7629mms6s4cwef74vcwvpeqdx7ep", BchMainNetParams)
+	assert.Error(t, err)
+
+	wit, _ := btcutil.NewAddressWitnessPubKeyHash(addr.ScriptAddress(), BchMainNetParams)
+	_, err = BCHFamily.DecodeAddress(wit.EncodeAddress(), BchMainNetParams)
+	assert.Error(t, err)
+
+	ltc, _ := btcutil.NewAddressPubKeyHash(addr.ScriptAddress(), LtcMainNetParams)
+	assert.Equal(t, byte('L'), ltc.EncodeAddress()[0])
+	res, err := LTCFamily.DecodeAddress(ltc.EncodeAddress(), LtcMainNetParams)
+	assert.NoError(t, err)
+	assert.Equal(t, ltc.ScriptAddress(), res.ScriptAddress())
+	_, err = BTCFamily.DecodeAddress(ltc.EncodeAddress(), &chaincfg.MainNetParams)
+	assert.Error(t, err)
+
+	wit, _ = btcutil.NewAddressWitnessPubKeyHash(addr.ScriptAddress(), LtcMainNetParams)
+	assert.Equal(t, "ltc1", wit.EncodeAddress()[:4])
+	_, err = LTCFamily.DecodeAddress(wit.EncodeAddress(), LtcMainNetParams)
+	assert.NoError(t, err)
+}
@@ -37,6 +37,12 @@ import (
 type BTCHandler struct {
 }
 
+func init() {
+	scom.RegisterHeaderSyncHandler(utils.BTC_ROUTER, "btc", func() scom.HeaderSyncHandler { return NewBTCHandler() })
+	scom.RegisterHeaderSyncHandler(utils.BCH_ROUTER, "bch", func() scom.HeaderSyncHandler { return NewBTCHandler() })
+	scom.RegisterHeaderSyncHandler(utils.LTC_ROUTER, "ltc", func() scom.HeaderSyncHandler { return NewBTCHandler() })
+}
+
 func NewBTCHandler() *BTCHandler {
 	return &BTCHandler{}
 }
@@ -58,6 +64,17 @@ func (this *BTCHandler) SyncGenesisHeader(native *native.NativeService) error {
 	if headerStore != nil {
 		return fmt.Errorf("BTCHandler GetHeaderByHeight, genesis header had been initialized")
 	}
+	netParam, err := getNetParam(native, params.ChainID)
+	if err != nil {
+		return fmt.Errorf("BTCHandler SyncGenesisHeader, %v", err)
+	}
+	// difficulty is only checked by asert on chains having an anchor, so headers must start from it
+	family := FamilyOf(netParam)
+	if netType, _ := family.netTypeOf(netParam); family.asertAnchors[netType] != nil &&
+		height < family.asertAnchors[netType].Height {
+		return fmt.Errorf("BTCHandler SyncGenesisHeader, genesis height %d is under the asert anchor %d of %s",
+			height, family.asertAnchors[netType].Height, netParam.Name)
+	}
 
 	//block header storage
 	storedHeader := StoredHeader{
@@ -94,6 +111,10 @@ func (this *BTCHandler) SyncBlockHeader(native *native.NativeService) error {
 		}
 
 	}
+	// prune headers out of the retention window
+	if err := pruneHeaders(native, headerParams.ChainID); err != nil {
+		return fmt.Errorf("SyncBlockHeader, prune headers err: %v", err)
+	}
 	return nil
 }
 
@@ -208,6 +229,18 @@ func commitHeader(native *native.NativeService, chainID uint64, header wire.Bloc
 		if err != nil {
 			return newTip, commonAncestor, 0, err
 		}
+		oldTipHash, newTipHash, forkHash := bestHeader.Header.BlockHash(), nb.Header.BlockHash(), commonAncestor.Header.BlockHash()
+		err = scom.PutChainReorg(native, chainID, &scom.ChainReorg{
+			OldTip:     oldTipHash.CloneBytes(),
+			OldHeight:  uint64(bestHeader.Height),
+			NewTip:     newTipHash.CloneBytes(),
+			NewHeight:  uint64(nb.Height),
+			ForkPoint:  forkHash.CloneBytes(),
+			ForkHeight: uint64(commonAncestor.Height),
+		})
+		if err != nil {
+			return newTip, commonAncestor, 0, err
+		}
 	}
 
 	return newTip, commonAncestor, newHeight, nil
@@ -30,7 +30,9 @@ import (
 	"github.com/polynetwork/poly/core/store/overlaydb"
 	"github.com/polynetwork/poly/core/types"
 	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
 	scom "github.com/polynetwork/poly/native/service/header_sync/common"
+	"github.com/polynetwork/poly/native/service/utils"
 	"github.com/polynetwork/poly/native/storage"
 	"github.com/stretchr/testify/assert"
 	"testing"
@@ -81,7 +83,31 @@ var (
 		return res
 	}
 
+	getCCMCAddress = func(netType utils.BtcNetType) []byte {
+		ccmc := make([]byte, 8)
+		binary.LittleEndian.PutUint64(ccmc, uint64(netType))
+		return ccmc
+	}
+
+	getTestNetParam = func(router uint64, netType utils.BtcNetType) *chaincfg.Params {
+		p, _ := GetNetParam(router, getCCMCAddress(netType))
+		return p
+	}
+
+	// putTestSideChain registers side chain 0 on the net of netType with router
+	putTestSideChain = func(db *storage.CacheDB, router uint64, netType utils.BtcNetType) *storage.CacheDB {
+		ns := getNativeFunc(nil, db)
+		_ = side_chain_manager.PutSideChain(ns, &side_chain_manager.SideChain{
+			ChainId:     0,
+			Router:      router,
+			Name:        "btc",
+			CCMCAddress: getCCMCAddress(netType),
+		})
+		return ns.GetCacheDB()
+	}
+
 	syncGHeader = func() (*native.NativeService, *BTCHandler) {
+		netParam := getTestNetParam(utils.BTC_ROUTER, utils.TyRegtest)
 		var buf bytes.Buffer
 		_ = netParam.GenesisBlock.Header.BtcEncode(&buf, wire.ProtocolVersion, wire.LatestEncoding)
 
@@ -95,7 +121,7 @@ var (
 		sink := common.NewZeroCopySink(nil)
 		params.Serialization(sink)
 
-		ns := getNativeFunc(sink.Bytes(), nil)
+		ns := getNativeFunc(sink.Bytes(), putTestSideChain(nil, utils.BTC_ROUTER, utils.TyRegtest))
 		handler := NewBTCHandler()
 		_ = handler.SyncGenesisHeader(ns)
 
@@ -126,6 +152,7 @@ var (
 )
 
 func TestBTCHandler_SyncGenesisHeader(t *testing.T) {
+	netParam := getTestNetParam(utils.BTC_ROUTER, utils.TyTestnet3)
 	var buf bytes.Buffer
 	_ = netParam.GenesisBlock.Header.BtcEncode(&buf, wire.ProtocolVersion, wire.LatestEncoding)
 
@@ -139,14 +166,39 @@ func TestBTCHandler_SyncGenesisHeader(t *testing.T) {
 	sink := common.NewZeroCopySink(nil)
 	params.Serialization(sink)
 
-	ns := getNativeFunc(sink.Bytes(), nil)
 	handler := NewBTCHandler()
+	ns := getNativeFunc(sink.Bytes(), nil)
 	err := handler.SyncGenesisHeader(ns)
+	assert.Error(t, err, "side chain is not registered")
+
+	ns = getNativeFunc(sink.Bytes(), putTestSideChain(nil, utils.BTC_ROUTER, utils.TyTestnet3))
+	err = handler.SyncGenesisHeader(ns)
 	assert.NoError(t, err)
 }
 
+func TestBTCHandler_SyncGenesisHeaderUnderAsertAnchor(t *testing.T) {
+	netParam := getTestNetParam(utils.BCH_ROUTER, utils.TyTestnet3)
+	var buf bytes.Buffer
+	_ = netParam.GenesisBlock.Header.BtcEncode(&buf, wire.ProtocolVersion, wire.LatestEncoding)
+
+	sync := func(height uint32) error {
+		hb := make([]byte, 4)
+		binary.BigEndian.PutUint32(hb, height)
+		params := &scom.SyncGenesisHeaderParam{
+			ChainID:       0,
+			GenesisHeader: append(buf.Bytes(), hb...),
+		}
+		sink := common.NewZeroCopySink(nil)
+		params.Serialization(sink)
+		ns := getNativeFunc(sink.Bytes(), putTestSideChain(nil, utils.BCH_ROUTER, utils.TyTestnet3))
+		return NewBTCHandler().SyncGenesisHeader(ns)
+	}
+	anchor := BCHFamily.asertAnchors[utils.TyTestnet3]
+	assert.Error(t, sync(anchor.Height-1))
+	assert.NoError(t, sync(anchor.Height))
+}
+
 func TestBTCHandler_SyncBlockHeader(t *testing.T) {
-	netParam = &chaincfg.RegressionNetParams
 	ns, handler := syncGHeader()
 
 	// normal case
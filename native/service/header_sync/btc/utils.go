@@ -18,7 +18,6 @@
 package btc
 
 import (
-	"encoding/binary"
 	"encoding/hex"
 	"fmt"
 	"github.com/btcsuite/btcd/blockchain"
@@ -37,12 +36,10 @@ import (
 )
 
 const (
-	targetTimespan      = time.Hour * 24 * 14
-	targetSpacing       = time.Minute * 10
-	epochLength         = int32(targetTimespan / targetSpacing) // 2016
-	maxDiffAdjust       = 4
-	minRetargetTimespan = int64(targetTimespan / maxDiffAdjust)
-	maxRetargetTimespan = int64(targetTimespan * maxDiffAdjust)
+	targetTimespan = time.Hour * 24 * 14
+	targetSpacing  = time.Minute * 10
+	epochLength    = int32(targetTimespan / targetSpacing) // 2016
+	maxDiffAdjust  = 4
 )
 
 func getNetParam(service *native.NativeService, chainId uint64) (*chaincfg.Params, error) {
@@ -53,19 +50,7 @@ func getNetParam(service *native.NativeService, chainId uint64) (*chaincfg.Param
 	if side == nil {
 		return nil, fmt.Errorf("side chain info for chainId: %d is not registered", chainId)
 	}
-	if side.CCMCAddress == nil || len(side.CCMCAddress) != 8 {
-		return nil, fmt.Errorf("CCMCAddress is nil or its length is not 8")
-	}
-	switch utils.BtcNetType(binary.LittleEndian.Uint64(side.CCMCAddress)) {
-	case utils.TyTestnet3:
-		return &chaincfg.TestNet3Params, nil
-	case utils.TyRegtest:
-		return &chaincfg.RegressionNetParams, nil
-	case utils.TySimnet:
-		return &chaincfg.SimNetParams, nil
-	default:
-		return &chaincfg.MainNetParams, nil
-	}
+	return GetNetParam(side.Router, side.CCMCAddress)
 }
 
 func putGenesisBlockHeader(native *native.NativeService, chainID uint64, blockHeader StoredHeader) {
@@ -191,6 +176,59 @@ func GetBestBlockHeader(native *native.NativeService, chainID uint64) (*StoredHe
 	return bestBlockHeader, nil
 }
 
+func getGenesisBlockHeader(native *native.NativeService, chainID uint64) (*StoredHeader, error) {
+	headerStore, err := native.GetCacheDB().Get(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(scom.GENESIS_HEADER), utils.GetUint64Bytes(chainID)))
+	if err != nil {
+		return nil, fmt.Errorf("getGenesisBlockHeader, get genesis header error: %v", err)
+	}
+	if headerStore == nil {
+		return nil, fmt.Errorf("getGenesisBlockHeader, can not find genesis header")
+	}
+	shBs, err := cstates.GetValueFromRawStorageItem(headerStore)
+	if err != nil {
+		return nil, fmt.Errorf("getGenesisBlockHeader, deserialize from raw storage item err: %v", err)
+	}
+	sh := new(StoredHeader)
+	if err := sh.Deserialization(common.NewZeroCopySource(shBs)); err != nil {
+		return nil, fmt.Errorf("getGenesisBlockHeader, deserializeHeader error: %v", err)
+	}
+	return sh, nil
+}
+
+func pruneHeaders(native *native.NativeService, chainID uint64) error {
+	genesis, err := getGenesisBlockHeader(native, chainID)
+	if err != nil {
+		return err
+	}
+	bestHeader, err := GetBestBlockHeader(native, chainID)
+	if err != nil {
+		return err
+	}
+	return scom.PruneHeaders(native, chainID, uint64(genesis.Height)+1, uint64(bestHeader.Height), func(height uint64) error {
+		return pruneHeader(native, chainID, uint32(height))
+	})
+}
+
+// pruneHeader deletes the best chain header at height, headers of forks are left untouched
+func pruneHeader(native *native.NativeService, chainID uint64, height uint32) error {
+	contract := utils.HeaderSyncContractAddress
+	indexKey := utils.ConcatKey(contract, []byte(scom.HEADER_INDEX), utils.GetUint64Bytes(chainID), utils.GetUint32Bytes(height))
+	hashStore, err := native.GetCacheDB().Get(indexKey)
+	if err != nil {
+		return fmt.Errorf("pruneHeader, get heightBlockHashStore error: %v", err)
+	}
+	if hashStore == nil {
+		return nil
+	}
+	hashBs, err := cstates.GetValueFromRawStorageItem(hashStore)
+	if err != nil {
+		return fmt.Errorf("pruneHeader, deserialize blockHashBytes from raw storage item err:%v", err)
+	}
+	native.GetCacheDB().Delete(utils.ConcatKey(contract, []byte(scom.BLOCK_HEADER), utils.GetUint64Bytes(chainID), hashBs))
+	native.GetCacheDB().Delete(indexKey)
+	return nil
+}
+
 func GetPreviousHeader(native *native.NativeService, chainID uint64, header wire.BlockHeader) (*StoredHeader, error) {
 	return GetHeaderByHash(native, chainID, header.PrevBlock)
 }
@@ -210,7 +248,7 @@ func CheckHeader(native *native.NativeService, chainID uint64, header wire.Block
 		return false, fmt.Errorf("CheckHeader error: Headers %d and %d don't link.", height, height+1)
 	}
 
-	if netParam.Name != "regtest" && netParam.Name != "simnet" {
+	if netType, _ := FamilyOf(netParam).netTypeOf(netParam); netType != utils.TyRegtest && netType != utils.TySimnet {
 		// Check the header meets the difficulty requirement
 		diffTarget, err := calcRequiredWork(native, chainID, header, int32(height+1), prevHeader, netParam)
 		if err != nil {
@@ -234,12 +272,24 @@ func CheckHeader(native *native.NativeService, chainID uint64, header wire.Block
 // Get the PoW target this block should meet. We may need to handle a difficulty adjustment
 // or testnet difficulty rules.
 func calcRequiredWork(native *native.NativeService, chainID uint64, header wire.BlockHeader, height int32, prevHeader *StoredHeader, netParam *chaincfg.Params) (uint32, error) {
+	family := FamilyOf(netParam)
+	// If we are on testnet and it's been more than twice the block spacing since the last header
+	// return the minimum difficulty
+	minDiff := netParam.ReduceMinDifficulty && header.Timestamp.After(prevHeader.Header.Timestamp.Add(family.TargetSpacing*2))
+	netType, _ := family.netTypeOf(netParam)
+	if anchor, ok := family.asertAnchors[netType]; ok {
+		if minDiff {
+			return netParam.PowLimitBits, nil
+		}
+		return calcAsertBits(anchor, family.AsertHalfLife, family.TargetSpacing, prevHeader, netParam)
+	}
+
+	epochLength := family.epochLength()
 	// If this is not a difficulty adjustment period
 	if height%epochLength != 0 {
 		// If we are on testnet
 		if netParam.ReduceMinDifficulty {
-			// If it's been more than 20 minutes since the last header return the minimum difficulty
-			if header.Timestamp.After(prevHeader.Header.Timestamp.Add(targetSpacing * 2)) {
+			if minDiff {
 				return netParam.PowLimitBits, nil
 			} else {
 				// Otherwise return the difficulty of the last block not using special difficulty rules
@@ -263,7 +313,11 @@ func calcRequiredWork(native *native.NativeService, chainID uint64, header wire.
 		return prevHeader.Header.Bits, nil
 	}
 	// We are on a difficulty adjustment period so we need to correctly calculate the new difficulty.
-	epoch, err := GetEpoch(native, chainID, prevHeader)
+	back := int(epochLength) - 1
+	if family.FixTimeWarp && height != epochLength {
+		back++
+	}
+	epoch, err := getEpochStart(native, chainID, prevHeader, back)
 	if err != nil {
 		return 0, err
 	}
@@ -271,8 +325,13 @@ func calcRequiredWork(native *native.NativeService, chainID uint64, header wire.
 }
 
 func GetEpoch(native *native.NativeService, chainID uint64, sh *StoredHeader) (*wire.BlockHeader, error) {
+	return getEpochStart(native, chainID, sh, int(epochLength)-1)
+}
+
+// getEpochStart returns the header back blocks before sh
+func getEpochStart(native *native.NativeService, chainID uint64, sh *StoredHeader, back int) (*wire.BlockHeader, error) {
 	var err error
-	for i := 0; i < 2015; i++ {
+	for i := 0; i < back; i++ {
 		sh, err = GetPreviousHeader(native, chainID, sh.Header)
 		if err != nil {
 			return &sh.Header, err
@@ -353,8 +412,8 @@ func checkProofOfWork(header wire.BlockHeader, p *chaincfg.Params) bool {
 		return false
 	}
 	// The header hash must be less than the claimed target in the header.
-	blockHash := header.BlockHash()
-	hashNum := blockchain.HashToBig(&blockHash)
+	powHash := FamilyOf(p).PowHash(&header)
+	hashNum := blockchain.HashToBig(&powHash)
 	if hashNum.Cmp(target) > 0 {
 		log.Debugf("Block hash %064x is higher than "+
 			"required target of %064x", hashNum, target)
@@ -367,15 +426,16 @@ func checkProofOfWork(header wire.BlockHeader, p *chaincfg.Params) bool {
 // to calculate how much of a difficulty adjustment is needed. It returns a new compact
 // difficulty target.
 func calcDiffAdjust(start, end wire.BlockHeader, p *chaincfg.Params) uint32 {
+	timespan := int64(FamilyOf(p).TargetTimespan)
 	duration := end.Timestamp.UnixNano() - start.Timestamp.UnixNano()
-	if duration < minRetargetTimespan {
+	if duration < timespan/maxDiffAdjust {
 		log.Debugf("Whoa there, block %s off-scale high 4X diff adjustment!",
 			end.BlockHash().String())
-		duration = minRetargetTimespan
-	} else if duration > maxRetargetTimespan {
+		duration = timespan / maxDiffAdjust
+	} else if duration > timespan*maxDiffAdjust {
 		log.Debugf("Uh-oh! block %s off-scale low 0.25X diff adjustment!\n",
 			end.BlockHash().String())
-		duration = maxRetargetTimespan
+		duration = timespan * maxDiffAdjust
 	}
 
 	// calculation of new 32-byte difficulty target
@@ -383,8 +443,8 @@ func calcDiffAdjust(start, end wire.BlockHeader, p *chaincfg.Params) uint32 {
 	prevTarget := blockchain.CompactToBig(end.Bits)
 	// new target is old * duration...
 	newTarget := new(big.Int).Mul(prevTarget, big.NewInt(duration))
-	// divided by 2 weeks
-	newTarget.Div(newTarget, big.NewInt(int64(targetTimespan)))
+	// divided by the target timespan, 2 weeks for bitcoin
+	newTarget.Div(newTarget, big.NewInt(timespan))
 	// clip again if above minimum target (too easy)
 	if newTarget.Cmp(p.PowLimit) > 0 {
 		newTarget.Set(p.PowLimit)
@@ -31,6 +31,7 @@ import (
 	"github.com/polynetwork/poly/core/types"
 	"github.com/polynetwork/poly/native"
 	scom "github.com/polynetwork/poly/native/service/header_sync/common"
+	"github.com/polynetwork/poly/native/service/utils"
 	"github.com/polynetwork/poly/native/storage"
 	"github.com/stretchr/testify/assert"
 	"math/big"
@@ -65,7 +66,7 @@ var (
 )
 
 func TestGetEpoch(t *testing.T) {
-	cacheDB, err := syncGenesisHeader(&chaincfg.RegressionNetParams.GenesisBlock.Header)
+	cacheDB, err := syncGenesisHeader(utils.TyRegtest)
 	assert.Nil(t, err)
 	syncAssumedBtcBlockChain(cacheDB)
 	nativeService := getNativeFunc(nil, cacheDB)
@@ -77,8 +78,8 @@ func TestGetEpoch(t *testing.T) {
 }
 
 func TestCalcRequiredWork(t *testing.T) {
-	genesisHeader := chaincfg.TestNet3Params.GenesisBlock.Header
-	cacheDB, err := syncGenesisHeader(&genesisHeader)
+	netParam := *getTestNetParam(utils.BTC_ROUTER, utils.TyTestnet3)
+	cacheDB, err := syncGenesisHeader(utils.TyTestnet3)
 	assert.Nil(t, err)
 	syncAssumedBtcBlockChain(cacheDB)
 
@@ -91,7 +92,7 @@ func TestCalcRequiredWork(t *testing.T) {
 	// Test during difficulty adjust period
 	newHdr := wire.BlockHeader{}
 	newHdr.PrevBlock = bestHeader.Header.BlockHash()
-	work, err := calcRequiredWork(nativeService, 0, newHdr, 2016, bestHeader)
+	work, err := calcRequiredWork(nativeService, 0, newHdr, 2016, bestHeader, &netParam)
 	if err != nil {
 		t.Error(err)
 	}
@@ -114,7 +115,7 @@ func TestCalcRequiredWork(t *testing.T) {
 	netParam.ReduceMinDifficulty = false
 	newHdr1 := wire.BlockHeader{}
 	newHdr1.PrevBlock = newHdr.BlockHash()
-	work1, err := calcRequiredWork(nativeService, 0, newHdr1, 2017, &sh)
+	work1, err := calcRequiredWork(nativeService, 0, newHdr1, 2017, &sh, &netParam)
 	if err != nil {
 		t.Error(err)
 	}
@@ -137,7 +138,7 @@ func TestCalcRequiredWork(t *testing.T) {
 	netParam.ReduceMinDifficulty = true
 	newHdr2 := wire.BlockHeader{}
 	newHdr2.PrevBlock = newHdr1.BlockHash()
-	work2, err := calcRequiredWork(nativeService, 0, newHdr2, 2018, &sh)
+	work2, err := calcRequiredWork(nativeService, 0, newHdr2, 2018, &sh, &netParam)
 	if err != nil {
 		t.Error(err)
 	}
@@ -160,7 +161,7 @@ func TestCalcRequiredWork(t *testing.T) {
 	newHdr3 := wire.BlockHeader{}
 	newHdr3.PrevBlock = newHdr2.BlockHash()
 	newHdr3.Timestamp = newHdr2.Timestamp.Add(time.Minute * 21)
-	work3, err := calcRequiredWork(nativeService, 0, newHdr3, 2019, &sh)
+	work3, err := calcRequiredWork(nativeService, 0, newHdr3, 2019, &sh, &netParam)
 	if err != nil {
 		t.Error(err)
 	}
@@ -183,7 +184,7 @@ func TestCalcRequiredWork(t *testing.T) {
 	netParam.ReduceMinDifficulty = true
 	newHdr4 := wire.BlockHeader{}
 	newHdr4.PrevBlock = newHdr3.BlockHash()
-	work4, err := calcRequiredWork(nativeService, 0, newHdr4, 2020, &sh)
+	work4, err := calcRequiredWork(nativeService, 0, newHdr4, 2020, &sh, &netParam)
 	if err != nil {
 		t.Error(err)
 	}
@@ -236,7 +237,7 @@ func TestBlockchain_checkProofOfWork(t *testing.T) {
 }
 
 func TestGetCommonAncestor(t *testing.T) {
-	db, _ := syncGenesisHeader(&chaincfg.RegressionNetParams.GenesisBlock.Header)
+	db, _ := syncGenesisHeader(utils.TyRegtest)
 	ns := getNativeFunc(nil, db)
 
 	var hdr wire.BlockHeader
@@ -280,11 +281,12 @@ func TestGetCommonAncestor(t *testing.T) {
 }
 
 func TestGetBestBlockHeader(t *testing.T) {
+	netParam := getTestNetParam(utils.BTC_ROUTER, utils.TyTestnet3)
 	ns := getNativeFunc(nil, nil)
 	best, err := GetBestBlockHeader(ns, 0)
 	assert.Error(t, err)
 
-	db, _ := syncGenesisHeader(&netParam.GenesisBlock.Header)
+	db, _ := syncGenesisHeader(utils.TyTestnet3)
 	ns = getNativeFunc(nil, db)
 	best, err = GetBestBlockHeader(ns, 0)
 	assert.NoError(t, err)
@@ -295,6 +297,7 @@ func TestGetBestBlockHeader(t *testing.T) {
 }
 
 func TestGetHeaderByHash(t *testing.T) {
+	netParam := getTestNetParam(utils.BTC_ROUTER, utils.TyTestnet3)
 	ns := getNativeFunc(nil, nil)
 	sh, err := GetHeaderByHash(ns, 0, *netParam.GenesisHash)
 	assert.Error(t, err)
@@ -302,7 +305,7 @@ func TestGetHeaderByHash(t *testing.T) {
 		t.Fatal("wrong hash")
 	}
 
-	db, _ := syncGenesisHeader(&netParam.GenesisBlock.Header)
+	db, _ := syncGenesisHeader(utils.TyTestnet3)
 	ns = getNativeFunc(nil, db)
 	sh, err = GetHeaderByHash(ns, 0, *netParam.GenesisHash)
 	assert.NoError(t, err)
@@ -313,6 +316,7 @@ func TestGetHeaderByHash(t *testing.T) {
 }
 
 func TestGetBlockHashByHeight(t *testing.T) {
+	netParam := getTestNetParam(utils.BTC_ROUTER, utils.TyTestnet3)
 	ns := getNativeFunc(nil, nil)
 	hash, err := GetBlockHashByHeight(ns, 0, 0)
 	assert.Error(t, err)
@@ -320,7 +324,7 @@ func TestGetBlockHashByHeight(t *testing.T) {
 		t.Fatal("wrong hash")
 	}
 
-	db, _ := syncGenesisHeader(&netParam.GenesisBlock.Header)
+	db, _ := syncGenesisHeader(utils.TyTestnet3)
 	ns = getNativeFunc(nil, db)
 	hash, err = GetBlockHashByHeight(ns, 0, 0)
 	assert.NoError(t, err)
@@ -331,8 +335,8 @@ func TestGetBlockHashByHeight(t *testing.T) {
 }
 
 func TestGetPreviousHeader(t *testing.T) {
-	netParam = &chaincfg.RegressionNetParams
-	db, _ := syncGenesisHeader(&netParam.GenesisBlock.Header)
+	netParam := getTestNetParam(utils.BTC_ROUTER, utils.TyRegtest)
+	db, _ := syncGenesisHeader(utils.TyRegtest)
 	ns := getNativeFunc(nil, db)
 
 	_, err := GetPreviousHeader(ns, 0, netParam.GenesisBlock.Header)
@@ -350,9 +354,10 @@ func TestGetPreviousHeader(t *testing.T) {
 	assert.Equal(t, netParam.GenesisHash.String(), gsh.Header.BlockHash().String())
 }
 
-func syncGenesisHeader(genesisHeader *wire.BlockHeader) (*storage.CacheDB, error) {
+// syncGenesisHeader registers side chain 0 on bitcoin net of netType and syncs the genesis block of the net
+func syncGenesisHeader(netType utils.BtcNetType) (*storage.CacheDB, error) {
 	var buf bytes.Buffer
-	_ = genesisHeader.BtcEncode(&buf, wire.ProtocolVersion, wire.LatestEncoding)
+	_ = getTestNetParam(utils.BTC_ROUTER, netType).GenesisBlock.Header.BtcEncode(&buf, wire.ProtocolVersion, wire.LatestEncoding)
 	btcHander := NewBTCHandler()
 
 	hb := make([]byte, 4)
@@ -364,8 +369,10 @@ func syncGenesisHeader(genesisHeader *wire.BlockHeader) (*storage.CacheDB, error
 	}
 	sink = new(common.ZeroCopySink)
 	params.Serialization(sink)
-	ns := getNativeFunc(sink.Bytes(), nil)
-	_ = btcHander.SyncGenesisHeader(ns)
+	ns := getNativeFunc(sink.Bytes(), putTestSideChain(nil, utils.BTC_ROUTER, netType))
+	if err := btcHander.SyncGenesisHeader(ns); err != nil {
+		return nil, err
+	}
 
 	return ns.GetCacheDB(), nil
 }
@@ -401,7 +408,7 @@ func syncAssumedBtcBlockChain(cacheDB *storage.CacheDB) {
 }
 
 func TestReIndexHeaderHeight(t *testing.T) {
-	db, _ := syncGenesisHeader(&chaincfg.RegressionNetParams.GenesisBlock.Header)
+	db, _ := syncGenesisHeader(utils.TyRegtest)
 	ns := getNativeFunc(nil, db)
 
 	var hdr wire.BlockHeader
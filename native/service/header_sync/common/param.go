@@ -18,6 +18,7 @@
 package common
 
 import (
+	"encoding/hex"
 	"fmt"
 
 	"github.com/polynetwork/poly/common"
@@ -43,6 +44,7 @@ const (
 	EPOCH_SWITCH                = "epochSwitch"
 	SYNC_HEADER_NAME            = "syncHeader"
 	SYNC_CROSSCHAIN_MSG         = "syncCrossChainMsg"
+	MISBEHAVIOUR_NAME           = "misbehaviour"
 )
 
 type HeaderSyncHandler interface {
@@ -51,6 +53,20 @@ type HeaderSyncHandler interface {
 	SyncCrossChainMsg(service *native.NativeService) error
 }
 
+// MisbehaviourHandler is implemented by handlers of chains which verify evidence of misbehaviour,
+// the chain is blacked once the evidence is verified
+type MisbehaviourHandler interface {
+	SubmitMisbehaviour(service *native.NativeService) (*Misbehaviour, error)
+}
+
+// Misbehaviour is the verified evidence of two conflicting headers signed at the same height
+type Misbehaviour struct {
+	ChainID uint64
+	Height  uint64
+	Hash1   []byte
+	Hash2   []byte
+}
+
 type SyncGenesisHeaderParam struct {
 	ChainID       uint64
 	GenesisHeader []byte
@@ -161,6 +177,42 @@ func (this *SyncCrossChainMsgParam) Deserialization(source *common.ZeroCopySourc
 	return nil
 }
 
+// MisbehaviourParam carries two conflicting headers of a chain as the evidence of misbehaviour
+type MisbehaviourParam struct {
+	ChainID uint64
+	Address common.Address
+	Header1 []byte
+	Header2 []byte
+}
+
+func (this *MisbehaviourParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteUint64(this.ChainID)
+	sink.WriteAddress(this.Address)
+	sink.WriteVarBytes(this.Header1)
+	sink.WriteVarBytes(this.Header2)
+}
+
+func (this *MisbehaviourParam) Deserialization(source *common.ZeroCopySource) error {
+	var eof bool
+	this.ChainID, eof = source.NextUint64()
+	if eof {
+		return fmt.Errorf("MisbehaviourParam deserialize chainID error")
+	}
+	this.Address, eof = source.NextAddress()
+	if eof {
+		return fmt.Errorf("MisbehaviourParam deserialize address error")
+	}
+	this.Header1, eof = source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("MisbehaviourParam deserialize header1 error")
+	}
+	this.Header2, eof = source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("MisbehaviourParam deserialize header2 error")
+	}
+	return nil
+}
+
 func NotifyPutHeader(native *native.NativeService, chainID uint64, height uint64, blockHash string) {
 	if !config.DefConfig.Common.EnableEventLog {
 		return
@@ -182,3 +234,16 @@ func NotifyPutCrossChainMsg(native *native.NativeService, chainID uint64, height
 			States:          []interface{}{SYNC_CROSSCHAIN_MSG, chainID, height, native.GetHeight()},
 		})
 }
+
+func NotifyMisbehaviour(native *native.NativeService, submitter common.Address, misbehaviour *Misbehaviour) {
+	if !config.DefConfig.Common.EnableEventLog {
+		return
+	}
+	native.AddNotify(
+		&event.NotifyEventInfo{
+			ContractAddress: utils.HeaderSyncContractAddress,
+			States: []interface{}{MISBEHAVIOUR_NAME, misbehaviour.ChainID, misbehaviour.Height,
+				hex.EncodeToString(misbehaviour.Hash1), hex.EncodeToString(misbehaviour.Hash2),
+				submitter.ToBase58(), native.GetHeight()},
+		})
+}
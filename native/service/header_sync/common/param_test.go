@@ -56,3 +56,21 @@ func TestSyncBlockHeaderParam(t *testing.T) {
 
 	assert.Equal(t, p, param)
 }
+
+func TestMisbehaviourParam(t *testing.T) {
+	param := MisbehaviourParam{
+		ChainID: 123,
+		Address: common.Address{1, 2, 3},
+		Header1: []byte{1, 2, 3},
+		Header2: []byte{4, 5, 6},
+	}
+
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+
+	var p MisbehaviourParam
+	err := p.Deserialization(common.NewZeroCopySource(sink.Bytes()))
+	assert.NoError(t, err)
+
+	assert.Equal(t, p, param)
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"fmt"
+
+	cstates "github.com/polynetwork/poly/core/states"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
+	"github.com/polynetwork/poly/native/service/utils"
+)
+
+const (
+	//key prefix
+	PRUNE_HEIGHT = "pruneHeight"
+
+	//max number of heights pruned in one SyncBlockHeader, so that the cost of a sync stays bounded
+	MAX_PRUNE_PER_SYNC = 256
+)
+
+// PruneHeaders deletes headers of heights in [start, tip - blocksToWait - window) through prune, where
+// blocksToWait is the finality depth and window is the header retention of the side chain, so that
+// headers still waiting for finality are never counted in the window. It resumes from where the last
+// call stopped, start is only used for the first time and should be above the genesis height.
+func PruneHeaders(native *native.NativeService, chainID, start, tip uint64, prune func(height uint64) error) error {
+	window, err := side_chain_manager.GetHeaderRetention(native, chainID)
+	if err != nil {
+		return fmt.Errorf("PruneHeaders, GetHeaderRetention error: %v", err)
+	}
+	if window == 0 {
+		return nil
+	}
+	sideChain, err := side_chain_manager.GetSideChain(native, chainID)
+	if err != nil {
+		return fmt.Errorf("PruneHeaders, GetSideChain error: %v", err)
+	}
+	if sideChain == nil {
+		return fmt.Errorf("PruneHeaders, side chain %d is not registered", chainID)
+	}
+	if tip <= sideChain.BlocksToWait+window {
+		return nil
+	}
+	height, err := GetPruneHeight(native, chainID)
+	if err != nil {
+		return fmt.Errorf("PruneHeaders, GetPruneHeight error: %v", err)
+	}
+	if height < start {
+		height = start
+	}
+	end := tip - sideChain.BlocksToWait - window
+	if end > height+MAX_PRUNE_PER_SYNC {
+		end = height + MAX_PRUNE_PER_SYNC
+	}
+	if height >= end {
+		return nil
+	}
+	for ; height < end; height++ {
+		if err := prune(height); err != nil {
+			return fmt.Errorf("PruneHeaders, prune height %d error: %v", height, err)
+		}
+	}
+	putPruneHeight(native, chainID, height)
+	return nil
+}
+
+// GetPruneHeight returns the lowest height whose header is not pruned yet.
+func GetPruneHeight(native *native.NativeService, chainID uint64) (uint64, error) {
+	store, err := native.GetCacheDB().Get(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(PRUNE_HEIGHT),
+		utils.GetUint64Bytes(chainID)))
+	if err != nil {
+		return 0, fmt.Errorf("GetPruneHeight, get prune height store error: %v", err)
+	}
+	if store == nil {
+		return 0, nil
+	}
+	heightBytes, err := cstates.GetValueFromRawStorageItem(store)
+	if err != nil {
+		return 0, fmt.Errorf("GetPruneHeight, deserialize from raw storage item error: %v", err)
+	}
+	return utils.GetBytesUint64(heightBytes), nil
+}
+
+func putPruneHeight(native *native.NativeService, chainID, height uint64) {
+	native.GetCacheDB().Put(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(PRUNE_HEIGHT),
+		utils.GetUint64Bytes(chainID)), cstates.GenRawStorageItem(utils.GetUint64Bytes(height)))
+}
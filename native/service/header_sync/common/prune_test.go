@@ -0,0 +1,85 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"testing"
+
+	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/core/states"
+	"github.com/polynetwork/poly/core/store/leveldbstore"
+	"github.com/polynetwork/poly/core/store/overlaydb"
+	"github.com/polynetwork/poly/core/types"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
+	"github.com/polynetwork/poly/native/service/utils"
+	"github.com/polynetwork/poly/native/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPruneHeaders(t *testing.T) {
+	store, _ := leveldbstore.NewMemLevelDBStore()
+	db := storage.NewCacheDB(overlaydb.NewOverlayDB(store))
+	ns, err := native.NewNativeService(db, new(types.Transaction), 0, 0, common.Uint256{}, 0, nil, false)
+	assert.NoError(t, err)
+
+	var chainID uint64 = 2
+	pruned := make([]uint64, 0)
+	prune := func(height uint64) error {
+		pruned = append(pruned, height)
+		return nil
+	}
+
+	// never prune without retention
+	assert.NoError(t, PruneHeaders(ns, chainID, 11, 100000, prune))
+	assert.Empty(t, pruned)
+
+	window := uint64(side_chain_manager.MIN_HEADER_RETENTION)
+	db.Put(utils.ConcatKey(utils.SideChainManagerContractAddress, []byte(side_chain_manager.HEADER_RETENTION),
+		utils.GetUint64Bytes(chainID)), states.GenRawStorageItem(utils.GetUint64Bytes(window)))
+
+	// side chain must be registered once a retention is set
+	assert.Error(t, PruneHeaders(ns, chainID, 11, window+100, prune))
+	var blocksToWait uint64 = 12
+	assert.NoError(t, side_chain_manager.PutSideChain(ns, &side_chain_manager.SideChain{
+		ChainId:      chainID,
+		Router:       utils.ETH_ROUTER,
+		Name:         "eth",
+		BlocksToWait: blocksToWait,
+	}))
+
+	// headers waiting for finality are not counted in the window
+	assert.NoError(t, PruneHeaders(ns, chainID, 11, window+10, prune))
+	assert.Empty(t, pruned)
+	assert.NoError(t, PruneHeaders(ns, chainID, 11, blocksToWait+window+10, prune))
+	assert.Empty(t, pruned)
+
+	assert.NoError(t, PruneHeaders(ns, chainID, 11, blocksToWait+window+20, prune))
+	assert.Equal(t, []uint64{11, 12, 13, 14, 15, 16, 17, 18, 19}, pruned)
+	height, err := GetPruneHeight(ns, chainID)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(20), height)
+
+	pruned = pruned[:0]
+	assert.NoError(t, PruneHeaders(ns, chainID, 11, blocksToWait+window+1000, prune))
+	assert.Equal(t, MAX_PRUNE_PER_SYNC, len(pruned))
+	assert.Equal(t, uint64(20), pruned[0])
+	height, err = GetPruneHeight(ns, chainID)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(20+MAX_PRUNE_PER_SYNC), height)
+}
@@ -0,0 +1,176 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/polynetwork/poly/common"
+	cstates "github.com/polynetwork/poly/core/states"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/event"
+	"github.com/polynetwork/poly/native/service/utils"
+)
+
+const (
+	//notify name
+	CHAIN_REORG_NAME = "chainReorg"
+
+	//key prefix
+	REORG_HISTORY = "reorgHistory"
+
+	//number of recent reorgs kept for each chain
+	MAX_REORG_HISTORY = 32
+)
+
+// ChainReorg records a switch of the canonical chain from OldTip to NewTip, ForkPoint is their
+// common ancestor and Depth is the number of canonical headers that were replaced.
+type ChainReorg struct {
+	OldTip     []byte
+	OldHeight  uint64
+	NewTip     []byte
+	NewHeight  uint64
+	ForkPoint  []byte
+	ForkHeight uint64
+	Depth      uint64
+	PolyHeight uint32
+}
+
+func (this *ChainReorg) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarBytes(this.OldTip)
+	sink.WriteUint64(this.OldHeight)
+	sink.WriteVarBytes(this.NewTip)
+	sink.WriteUint64(this.NewHeight)
+	sink.WriteVarBytes(this.ForkPoint)
+	sink.WriteUint64(this.ForkHeight)
+	sink.WriteUint64(this.Depth)
+	sink.WriteUint32(this.PolyHeight)
+}
+
+func (this *ChainReorg) Deserialization(source *common.ZeroCopySource) error {
+	var eof bool
+	this.OldTip, eof = source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("ChainReorg deserialize old tip error")
+	}
+	this.OldHeight, eof = source.NextUint64()
+	if eof {
+		return fmt.Errorf("ChainReorg deserialize old height error")
+	}
+	this.NewTip, eof = source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("ChainReorg deserialize new tip error")
+	}
+	this.NewHeight, eof = source.NextUint64()
+	if eof {
+		return fmt.Errorf("ChainReorg deserialize new height error")
+	}
+	this.ForkPoint, eof = source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("ChainReorg deserialize fork point error")
+	}
+	this.ForkHeight, eof = source.NextUint64()
+	if eof {
+		return fmt.Errorf("ChainReorg deserialize fork height error")
+	}
+	this.Depth, eof = source.NextUint64()
+	if eof {
+		return fmt.Errorf("ChainReorg deserialize depth error")
+	}
+	this.PolyHeight, eof = source.NextUint32()
+	if eof {
+		return fmt.Errorf("ChainReorg deserialize poly height error")
+	}
+	return nil
+}
+
+type ReorgHistory struct {
+	Reorgs []*ChainReorg
+}
+
+func (this *ReorgHistory) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarUint(uint64(len(this.Reorgs)))
+	for _, v := range this.Reorgs {
+		v.Serialization(sink)
+	}
+}
+
+func (this *ReorgHistory) Deserialization(source *common.ZeroCopySource) error {
+	n, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("ReorgHistory deserialize length error")
+	}
+	reorgs := make([]*ChainReorg, 0, n)
+	for i := uint64(0); i < n; i++ {
+		reorg := new(ChainReorg)
+		if err := reorg.Deserialization(source); err != nil {
+			return fmt.Errorf("ReorgHistory deserialize No.%d reorg error: %v", i, err)
+		}
+		reorgs = append(reorgs, reorg)
+	}
+	this.Reorgs = reorgs
+	return nil
+}
+
+// PutChainReorg adds reorg to the recent history of chainID and notifies it.
+func PutChainReorg(native *native.NativeService, chainID uint64, reorg *ChainReorg) error {
+	reorg.Depth = reorg.OldHeight - reorg.ForkHeight
+	reorg.PolyHeight = native.GetHeight()
+	history, err := GetReorgHistory(native, chainID)
+	if err != nil {
+		return fmt.Errorf("PutChainReorg, %v", err)
+	}
+	history.Reorgs = append(history.Reorgs, reorg)
+	if len(history.Reorgs) > MAX_REORG_HISTORY {
+		history.Reorgs = history.Reorgs[len(history.Reorgs)-MAX_REORG_HISTORY:]
+	}
+	sink := common.NewZeroCopySink(nil)
+	history.Serialization(sink)
+	native.GetCacheDB().Put(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(REORG_HISTORY), utils.GetUint64Bytes(chainID)),
+		cstates.GenRawStorageItem(sink.Bytes()))
+
+	native.AddNotify(
+		&event.NotifyEventInfo{
+			ContractAddress: utils.HeaderSyncContractAddress,
+			States: []interface{}{CHAIN_REORG_NAME, chainID, hex.EncodeToString(reorg.OldTip), reorg.OldHeight,
+				hex.EncodeToString(reorg.NewTip), reorg.NewHeight, hex.EncodeToString(reorg.ForkPoint), reorg.ForkHeight,
+				reorg.Depth, native.GetHeight()},
+		})
+	return nil
+}
+
+// GetReorgHistory returns the recent reorgs of chainID, the oldest comes first.
+func GetReorgHistory(native *native.NativeService, chainID uint64) (*ReorgHistory, error) {
+	store, err := native.GetCacheDB().Get(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(REORG_HISTORY), utils.GetUint64Bytes(chainID)))
+	if err != nil {
+		return nil, fmt.Errorf("GetReorgHistory, get history store error: %v", err)
+	}
+	history := &ReorgHistory{Reorgs: make([]*ChainReorg, 0)}
+	if store == nil {
+		return history, nil
+	}
+	historyBytes, err := cstates.GetValueFromRawStorageItem(store)
+	if err != nil {
+		return nil, fmt.Errorf("GetReorgHistory, deserialize from raw storage item error: %v", err)
+	}
+	if err := history.Deserialization(common.NewZeroCopySource(historyBytes)); err != nil {
+		return nil, fmt.Errorf("GetReorgHistory, deserialize history error: %v", err)
+	}
+	return history, nil
+}
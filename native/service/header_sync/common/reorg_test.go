@@ -0,0 +1,68 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"testing"
+
+	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/core/store/leveldbstore"
+	"github.com/polynetwork/poly/core/store/overlaydb"
+	"github.com/polynetwork/poly/core/types"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPutChainReorg(t *testing.T) {
+	store, _ := leveldbstore.NewMemLevelDBStore()
+	db := storage.NewCacheDB(overlaydb.NewOverlayDB(store))
+	ns, err := native.NewNativeService(db, new(types.Transaction), 0, 100, common.Uint256{}, 0, nil, false)
+	assert.NoError(t, err)
+
+	var chainID uint64 = 2
+	history, err := GetReorgHistory(ns, chainID)
+	assert.NoError(t, err)
+	assert.Empty(t, history.Reorgs)
+
+	for i := uint64(0); i < MAX_REORG_HISTORY+2; i++ {
+		err = PutChainReorg(ns, chainID, &ChainReorg{
+			OldTip:     []byte{1},
+			OldHeight:  1000 + i,
+			NewTip:     []byte{2},
+			NewHeight:  1001 + i,
+			ForkPoint:  []byte{3},
+			ForkHeight: 998 + i,
+		})
+		assert.NoError(t, err)
+	}
+	notify := ns.GetNotify()
+	assert.Equal(t, MAX_REORG_HISTORY+2, len(notify))
+	states := notify[0].States.([]interface{})
+	assert.Equal(t, CHAIN_REORG_NAME, states[0])
+	assert.Equal(t, chainID, states[1])
+	assert.Equal(t, uint64(2), states[8])
+
+	history, err = GetReorgHistory(ns, chainID)
+	assert.NoError(t, err)
+	assert.Equal(t, MAX_REORG_HISTORY, len(history.Reorgs))
+	assert.Equal(t, uint64(1002), history.Reorgs[0].OldHeight)
+	assert.Equal(t, uint64(2), history.Reorgs[0].Depth)
+	assert.Equal(t, uint32(100), history.Reorgs[0].PolyHeight)
+	assert.Equal(t, []byte{3}, history.Reorgs[0].ForkPoint)
+}
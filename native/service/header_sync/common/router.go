@@ -0,0 +1,72 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"fmt"
+	"sync"
+)
+
+// HeaderSyncHandlerCreator creates the HeaderSyncHandler of a router
+type HeaderSyncHandlerCreator func() HeaderSyncHandler
+
+type routerEntry struct {
+	name    string
+	creator HeaderSyncHandlerCreator
+}
+
+var (
+	routerLock sync.RWMutex
+	routers    = make(map[uint64]*routerEntry)
+)
+
+// RegisterHeaderSyncHandler makes a HeaderSyncHandler available for router, chain
+// packages call it from their init function. It panics if router is registered twice.
+func RegisterHeaderSyncHandler(router uint64, name string, creator HeaderSyncHandlerCreator) {
+	routerLock.Lock()
+	defer routerLock.Unlock()
+	if creator == nil {
+		panic(fmt.Sprintf("RegisterHeaderSyncHandler, creator of router %d is nil", router))
+	}
+	if _, ok := routers[router]; ok {
+		panic(fmt.Sprintf("RegisterHeaderSyncHandler, router %d is already registered", router))
+	}
+	routers[router] = &routerEntry{name: name, creator: creator}
+}
+
+// GetHeaderSyncHandler returns the HeaderSyncHandler registered for router
+func GetHeaderSyncHandler(router uint64) (HeaderSyncHandler, error) {
+	routerLock.RLock()
+	defer routerLock.RUnlock()
+	entry, ok := routers[router]
+	if !ok {
+		return nil, fmt.Errorf("not a supported router:%d", router)
+	}
+	return entry.creator(), nil
+}
+
+// GetRegisteredRouters returns the name of every registered router
+func GetRegisteredRouters() map[uint64]string {
+	routerLock.RLock()
+	defer routerLock.RUnlock()
+	res := make(map[uint64]string, len(routers))
+	for router, entry := range routers {
+		res[router] = entry.name
+	}
+	return res
+}
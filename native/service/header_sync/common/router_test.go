@@ -0,0 +1,55 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"testing"
+
+	"github.com/polynetwork/poly/native"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockHandler struct{}
+
+func (this *mockHandler) SyncGenesisHeader(service *native.NativeService) error { return nil }
+func (this *mockHandler) SyncBlockHeader(service *native.NativeService) error   { return nil }
+func (this *mockHandler) SyncCrossChainMsg(service *native.NativeService) error { return nil }
+
+func TestRegisterHeaderSyncHandler(t *testing.T) {
+	var router uint64 = 1000
+	_, err := GetHeaderSyncHandler(router)
+	assert.Error(t, err)
+	defer unregisterHeaderSyncHandler(router)
+
+	RegisterHeaderSyncHandler(router, "mock", func() HeaderSyncHandler { return &mockHandler{} })
+	handler, err := GetHeaderSyncHandler(router)
+	assert.NoError(t, err)
+	assert.IsType(t, &mockHandler{}, handler)
+	assert.Equal(t, "mock", GetRegisteredRouters()[router])
+
+	assert.Panics(t, func() {
+		RegisterHeaderSyncHandler(router, "mock", func() HeaderSyncHandler { return &mockHandler{} })
+	})
+}
+
+// unregisterHeaderSyncHandler removes router registered by tests from the global registry
+func unregisterHeaderSyncHandler(router uint64) {
+	routerLock.Lock()
+	defer routerLock.Unlock()
+	delete(routers, router)
+}
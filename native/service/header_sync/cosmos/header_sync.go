@@ -31,10 +31,15 @@ import (
 	"github.com/tendermint/tendermint/crypto/ed25519"
 	"github.com/tendermint/tendermint/crypto/multisig"
 	"github.com/tendermint/tendermint/crypto/secp256k1"
+	"time"
 )
 
 type CosmosHandler struct{}
 
+func init() {
+	hscommon.RegisterHeaderSyncHandler(utils.COSMOS_ROUTER, "cosmos", func() hscommon.HeaderSyncHandler { return NewCosmosHandler() })
+}
+
 func NewCosmosHandler() *CosmosHandler {
 	return &CosmosHandler{}
 }
@@ -68,10 +73,12 @@ func (this *CosmosHandler) SyncGenesisHeader(native *native.NativeService) error
 	if err != nil {
 		return fmt.Errorf("CosmosHandler SyncGenesisHeader, checkWitness error: %v", err)
 	}
+	extraInfo, err := GetExtraInfo(native, param.ChainID)
+	if err != nil {
+		return fmt.Errorf("CosmosHandler SyncGenesisHeader, %v", err)
+	}
 	// get genesis header from input parameters
-	cdc := newCDC()
-	var header CosmosHeader
-	err = cdc.UnmarshalBinaryBare(param.GenesisHeader, &header)
+	header, err := DecodeHeader(param.GenesisHeader, extraInfo.Codec)
 	if err != nil {
 		return fmt.Errorf("CosmosHandler SyncGenesisHeader: %s", err)
 	}
@@ -81,10 +88,11 @@ func (this *CosmosHandler) SyncGenesisHeader(native *native.NativeService) error
 		return fmt.Errorf("CosmosHandler SyncGenesisHeader, genesis header had been initialized")
 	}
 	PutEpochSwitchInfo(native, param.ChainID, &CosmosEpochSwitchInfo{
-		Height:             header.Header.Height,
-		NextValidatorsHash: header.Header.NextValidatorsHash,
-		ChainID:            header.Header.ChainID,
-		BlockHash:          header.Header.Hash(),
+		Height:             header.GetHeight(),
+		NextValidatorsHash: header.GetNextValidatorsHash(),
+		ChainID:            header.GetChainID(),
+		BlockHash:          header.GetHash(),
+		Time:               header.GetTime().Unix(),
 	})
 	return nil
 }
@@ -94,32 +102,37 @@ func (this *CosmosHandler) SyncBlockHeader(native *native.NativeService) error {
 	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
 		return fmt.Errorf("SyncBlockHeader, contract params deserialize error: %v", err)
 	}
-	cdc := newCDC()
+	extraInfo, err := GetExtraInfo(native, params.ChainID)
+	if err != nil {
+		return fmt.Errorf("SyncBlockHeader, %v", err)
+	}
 	cnt := 0
 	info, err := GetEpochSwitchInfo(native, params.ChainID)
 	if err != nil {
 		return fmt.Errorf("SyncBlockHeader, get epoch switching height failed: %v", err)
 	}
+	now := time.Unix(int64(native.GetBlockTime()), 0)
 	for _, v := range params.Headers {
-		var myHeader CosmosHeader
-		err := cdc.UnmarshalBinaryBare(v, &myHeader)
+		myHeader, err := DecodeHeader(v, extraInfo.Codec)
 		if err != nil {
 			return fmt.Errorf("SyncBlockHeader failed to unmarshal header: %v", err)
 		}
-		if bytes.Equal(myHeader.Header.NextValidatorsHash, myHeader.Header.ValidatorsHash) {
+		// headers without validators changed are useful to refresh the trusting period
+		if extraInfo.TrustingPeriod == 0 && bytes.Equal(myHeader.GetNextValidatorsHash(), myHeader.GetValidatorsHash()) {
 			continue
 		}
-		if info.Height >= myHeader.Header.Height {
+		if info.Height >= myHeader.GetHeight() {
 			log.Debugf("SyncBlockHeader, height %d is lower or equal than epoch switching height %d",
-				myHeader.Header.Height, info.Height)
+				myHeader.GetHeight(), info.Height)
 			continue
 		}
-		if err = VerifyCosmosHeader(&myHeader, info); err != nil {
+		if err = VerifyHeader(myHeader, info, extraInfo, now); err != nil {
 			return fmt.Errorf("SyncBlockHeader, failed to verify header: %v", err)
 		}
-		info.NextValidatorsHash = myHeader.Header.NextValidatorsHash
-		info.Height = myHeader.Header.Height
-		info.BlockHash = myHeader.Header.Hash()
+		info.NextValidatorsHash = myHeader.GetNextValidatorsHash()
+		info.Height = myHeader.GetHeight()
+		info.BlockHash = myHeader.GetHash()
+		info.Time = myHeader.GetTime().Unix()
 		cnt++
 	}
 	if cnt == 0 {
@@ -132,3 +145,43 @@ func (this *CosmosHandler) SyncBlockHeader(native *native.NativeService) error {
 func (this *CosmosHandler) SyncCrossChainMsg(native *native.NativeService) error {
 	return nil
 }
+
+// SubmitMisbehaviour verifies two different headers of the same height both passing the
+// verification against the trusted epoch info
+func (this *CosmosHandler) SubmitMisbehaviour(native *native.NativeService) (*hscommon.Misbehaviour, error) {
+	params := new(hscommon.MisbehaviourParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return nil, fmt.Errorf("SubmitMisbehaviour, contract params deserialize error: %v", err)
+	}
+	extraInfo, err := GetExtraInfo(native, params.ChainID)
+	if err != nil {
+		return nil, fmt.Errorf("SubmitMisbehaviour, %v", err)
+	}
+	info, err := GetEpochSwitchInfo(native, params.ChainID)
+	if err != nil {
+		return nil, fmt.Errorf("SubmitMisbehaviour, get epoch switching height failed: %v", err)
+	}
+	header1, err := DecodeHeader(params.Header1, extraInfo.Codec)
+	if err != nil {
+		return nil, fmt.Errorf("SubmitMisbehaviour, failed to unmarshal header1: %v", err)
+	}
+	header2, err := DecodeHeader(params.Header2, extraInfo.Codec)
+	if err != nil {
+		return nil, fmt.Errorf("SubmitMisbehaviour, failed to unmarshal header2: %v", err)
+	}
+	if header1.GetHeight() <= 0 || header1.GetHeight() != header2.GetHeight() || bytes.Equal(header1.GetHash(), header2.GetHash()) {
+		return nil, fmt.Errorf("SubmitMisbehaviour, headers are not conflicting ones of the same height")
+	}
+	now := time.Unix(int64(native.GetBlockTime()), 0)
+	for i, header := range []SignedHeader{header1, header2} {
+		if err = VerifyHeader(header, info, extraInfo, now); err != nil {
+			return nil, fmt.Errorf("SubmitMisbehaviour, failed to verify header%d: %v", i+1, err)
+		}
+	}
+	return &hscommon.Misbehaviour{
+		ChainID: params.ChainID,
+		Height:  uint64(header1.GetHeight()),
+		Hash1:   header1.GetHash(),
+		Hash2:   header2.GetHash(),
+	}, nil
+}
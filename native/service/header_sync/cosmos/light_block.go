@@ -0,0 +1,569 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cosmos
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/tendermint/tendermint/crypto"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	"github.com/tendermint/tendermint/crypto/merkle"
+	"github.com/tendermint/tendermint/crypto/secp256k1"
+)
+
+// flags of signatures in commit of tendermint 0.34
+const (
+	BLOCK_ID_FLAG_ABSENT = 1
+	BLOCK_ID_FLAG_COMMIT = 2
+	BLOCK_ID_FLAG_NIL    = 3
+)
+
+const precommitType = 2
+
+// ProtoBlockID is the BlockID of tendermint 0.34
+type ProtoBlockID struct {
+	Hash         []byte
+	PartSetTotal uint32
+	PartSetHash  []byte
+}
+
+// ProtoHeader is the Header of tendermint 0.34
+type ProtoHeader struct {
+	Version            ProtoVersion
+	ChainID            string
+	Height             int64
+	Time               time.Time
+	LastBlockID        ProtoBlockID
+	LastCommitHash     []byte
+	DataHash           []byte
+	ValidatorsHash     []byte
+	NextValidatorsHash []byte
+	ConsensusHash      []byte
+	AppHash            []byte
+	LastResultsHash    []byte
+	EvidenceHash       []byte
+	ProposerAddress    []byte
+}
+
+// ProtoVersion is the consensus version in header
+type ProtoVersion struct {
+	Block uint64
+	App   uint64
+}
+
+// ProtoCommitSig is the CommitSig of tendermint 0.34
+type ProtoCommitSig struct {
+	BlockIDFlag      uint64
+	ValidatorAddress []byte
+	Timestamp        time.Time
+	Signature        []byte
+}
+
+// ProtoCommit is the Commit of tendermint 0.34
+type ProtoCommit struct {
+	Height     int64
+	Round      int32
+	BlockID    ProtoBlockID
+	Signatures []*ProtoCommitSig
+}
+
+// ProtoValidator is the Validator of tendermint 0.34
+type ProtoValidator struct {
+	PubKey      crypto.PubKey
+	VotingPower int64
+}
+
+// CosmosLightBlock is the protobuf encoded LightBlock of tendermint 0.34, which is the
+// header committed by chains of cosmos-sdk 0.40 and later. The Header of ibc tendermint
+// client is accepted as well, of which the trusted validators are used by skipping verification.
+type CosmosLightBlock struct {
+	Header            *ProtoHeader
+	Commit            *ProtoCommit
+	Validators        []*ProtoValidator
+	TrustedValidators []*ProtoValidator
+}
+
+func (this *ProtoBlockID) IsZero() bool {
+	return len(this.Hash) == 0 && this.PartSetTotal == 0 && len(this.PartSetHash) == 0
+}
+
+func (this *ProtoBlockID) Equals(other *ProtoBlockID) bool {
+	return bytes.Equal(this.Hash, other.Hash) && this.PartSetTotal == other.PartSetTotal &&
+		bytes.Equal(this.PartSetHash, other.PartSetHash)
+}
+
+// encode encodes BlockID, same as the CanonicalBlockID in sign bytes
+func (this *ProtoBlockID) encode() []byte {
+	psh := protoVarint(nil, 1, uint64(this.PartSetTotal))
+	psh = protoBytes(psh, 2, this.PartSetHash)
+	return protoMessage(protoBytes(nil, 1, this.Hash), 2, psh)
+}
+
+func (this *ProtoBlockID) decode(raw []byte) error {
+	r := NewProtoReader(raw)
+	return r.ReadFields(func(field, wireType uint64) (bool, error) {
+		var err error
+		switch field {
+		case 1:
+			this.Hash, err = r.BytesField(wireType)
+		case 2:
+			var psh []byte
+			if psh, err = r.BytesField(wireType); err != nil {
+				return true, err
+			}
+			pr := NewProtoReader(psh)
+			err = pr.ReadFields(func(field, wireType uint64) (bool, error) {
+				var err error
+				switch field {
+				case 1:
+					var total uint64
+					total, err = pr.VarintField(wireType)
+					this.PartSetTotal = uint32(total)
+				case 2:
+					this.PartSetHash, err = pr.BytesField(wireType)
+				default:
+					return false, nil
+				}
+				return true, err
+			})
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+}
+
+// Hash returns the hash of header, same as Header.Hash of tendermint 0.34
+func (this *ProtoHeader) Hash() []byte {
+	if len(this.ValidatorsHash) == 0 {
+		return nil
+	}
+	version := protoVarint(nil, 1, this.Version.Block)
+	version = protoVarint(version, 2, this.Version.App)
+	return merkle.SimpleHashFromByteSlices([][]byte{
+		version,
+		protoBytes(nil, 1, []byte(this.ChainID)),
+		protoVarint(nil, 1, uint64(this.Height)),
+		encodeTimestamp(this.Time),
+		this.LastBlockID.encode(),
+		protoBytes(nil, 1, this.LastCommitHash),
+		protoBytes(nil, 1, this.DataHash),
+		protoBytes(nil, 1, this.ValidatorsHash),
+		protoBytes(nil, 1, this.NextValidatorsHash),
+		protoBytes(nil, 1, this.ConsensusHash),
+		protoBytes(nil, 1, this.AppHash),
+		protoBytes(nil, 1, this.LastResultsHash),
+		protoBytes(nil, 1, this.EvidenceHash),
+		protoBytes(nil, 1, this.ProposerAddress),
+	})
+}
+
+func (this *ProtoHeader) decode(raw []byte) error {
+	r := NewProtoReader(raw)
+	return r.ReadFields(func(field, wireType uint64) (bool, error) {
+		var (
+			err error
+			raw []byte
+			v   uint64
+		)
+		switch field {
+		case 1:
+			if raw, err = r.BytesField(wireType); err != nil {
+				return true, err
+			}
+			vr := NewProtoReader(raw)
+			err = vr.ReadFields(func(field, wireType uint64) (bool, error) {
+				var err error
+				switch field {
+				case 1:
+					this.Version.Block, err = vr.VarintField(wireType)
+				case 2:
+					this.Version.App, err = vr.VarintField(wireType)
+				default:
+					return false, nil
+				}
+				return true, err
+			})
+		case 2:
+			raw, err = r.BytesField(wireType)
+			this.ChainID = string(raw)
+		case 3:
+			v, err = r.VarintField(wireType)
+			this.Height = int64(v)
+		case 4:
+			if raw, err = r.BytesField(wireType); err == nil {
+				this.Time, err = decodeTimestamp(raw)
+			}
+		case 5:
+			if raw, err = r.BytesField(wireType); err == nil {
+				err = this.LastBlockID.decode(raw)
+			}
+		case 6:
+			this.LastCommitHash, err = r.BytesField(wireType)
+		case 7:
+			this.DataHash, err = r.BytesField(wireType)
+		case 8:
+			this.ValidatorsHash, err = r.BytesField(wireType)
+		case 9:
+			this.NextValidatorsHash, err = r.BytesField(wireType)
+		case 10:
+			this.ConsensusHash, err = r.BytesField(wireType)
+		case 11:
+			this.AppHash, err = r.BytesField(wireType)
+		case 12:
+			this.LastResultsHash, err = r.BytesField(wireType)
+		case 13:
+			this.EvidenceHash, err = r.BytesField(wireType)
+		case 14:
+			this.ProposerAddress, err = r.BytesField(wireType)
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+}
+
+// VoteSignBytes returns the sign bytes of precommit of No.idx validator, same as Commit.VoteSignBytes
+// of tendermint 0.34
+func (this *ProtoCommit) VoteSignBytes(chainID string, idx int) []byte {
+	sig := this.Signatures[idx]
+	msg := protoVarint(nil, 1, precommitType)
+	msg = protoSfixed64(msg, 2, this.Height)
+	msg = protoSfixed64(msg, 3, int64(this.Round))
+	// votes for nil have no block id
+	if sig.BlockIDFlag == BLOCK_ID_FLAG_COMMIT && !this.BlockID.IsZero() {
+		msg = protoMessage(msg, 4, this.BlockID.encode())
+	}
+	msg = protoMessage(msg, 5, encodeTimestamp(sig.Timestamp))
+	msg = protoBytes(msg, 6, []byte(chainID))
+	return append(protoUvarint(nil, uint64(len(msg))), msg...)
+}
+
+// ValidateBasic checks the commit as Commit.ValidateBasic of tendermint 0.34
+func (this *ProtoCommit) ValidateBasic() error {
+	if this.Height < 0 {
+		return fmt.Errorf("negative height")
+	}
+	if this.Round < 0 {
+		return fmt.Errorf("negative round")
+	}
+	if this.Height >= 1 {
+		if this.BlockID.IsZero() {
+			return fmt.Errorf("commit cannot be for nil block")
+		}
+		if len(this.Signatures) == 0 {
+			return fmt.Errorf("no signatures in commit")
+		}
+	}
+	for i, sig := range this.Signatures {
+		switch sig.BlockIDFlag {
+		case BLOCK_ID_FLAG_ABSENT:
+			if len(sig.ValidatorAddress) != 0 || len(sig.Signature) != 0 {
+				return fmt.Errorf("No.%d absent signature has address or signature", i)
+			}
+		case BLOCK_ID_FLAG_COMMIT, BLOCK_ID_FLAG_NIL:
+			if len(sig.ValidatorAddress) != crypto.AddressSize {
+				return fmt.Errorf("No.%d signature has wrong address size %d", i, len(sig.ValidatorAddress))
+			}
+			if len(sig.Signature) == 0 || len(sig.Signature) > 64 {
+				return fmt.Errorf("No.%d signature has wrong size %d", i, len(sig.Signature))
+			}
+		default:
+			return fmt.Errorf("No.%d signature has unknown BlockIDFlag %d", i, sig.BlockIDFlag)
+		}
+	}
+	return nil
+}
+
+func (this *ProtoCommit) decode(raw []byte) error {
+	r := NewProtoReader(raw)
+	return r.ReadFields(func(field, wireType uint64) (bool, error) {
+		var (
+			err error
+			raw []byte
+			v   uint64
+		)
+		switch field {
+		case 1:
+			v, err = r.VarintField(wireType)
+			this.Height = int64(v)
+		case 2:
+			v, err = r.VarintField(wireType)
+			this.Round = int32(v)
+		case 3:
+			if raw, err = r.BytesField(wireType); err == nil {
+				err = this.BlockID.decode(raw)
+			}
+		case 4:
+			if raw, err = r.BytesField(wireType); err == nil {
+				sig := new(ProtoCommitSig)
+				err = sig.decode(raw)
+				this.Signatures = append(this.Signatures, sig)
+			}
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+}
+
+func (this *ProtoCommitSig) decode(raw []byte) error {
+	r := NewProtoReader(raw)
+	return r.ReadFields(func(field, wireType uint64) (bool, error) {
+		var (
+			err error
+			raw []byte
+		)
+		switch field {
+		case 1:
+			this.BlockIDFlag, err = r.VarintField(wireType)
+		case 2:
+			this.ValidatorAddress, err = r.BytesField(wireType)
+		case 3:
+			if raw, err = r.BytesField(wireType); err == nil {
+				this.Timestamp, err = decodeTimestamp(raw)
+			}
+		case 4:
+			this.Signature, err = r.BytesField(wireType)
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+}
+
+// encode encodes the validator as SimpleValidator which is hashed into the validators hash
+func (this *ProtoValidator) encode() []byte {
+	var pk []byte
+	switch key := this.PubKey.(type) {
+	case ed25519.PubKeyEd25519:
+		pk = protoBytes(nil, 1, key[:])
+	case secp256k1.PubKeySecp256k1:
+		pk = protoBytes(nil, 2, key[:])
+	}
+	return protoVarint(protoMessage(nil, 1, pk), 2, uint64(this.VotingPower))
+}
+
+func (this *ProtoValidator) decode(raw []byte) error {
+	r := NewProtoReader(raw)
+	err := r.ReadFields(func(field, wireType uint64) (bool, error) {
+		var (
+			err error
+			raw []byte
+			v   uint64
+		)
+		switch field {
+		case 2:
+			if raw, err = r.BytesField(wireType); err == nil {
+				this.PubKey, err = decodePubKey(raw)
+			}
+		case 3:
+			v, err = r.VarintField(wireType)
+			this.VotingPower = int64(v)
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+	if err != nil {
+		return err
+	}
+	if this.PubKey == nil {
+		return fmt.Errorf("validator without public key")
+	}
+	if this.VotingPower < 0 {
+		return fmt.Errorf("validator with negative voting power %d", this.VotingPower)
+	}
+	return nil
+}
+
+func decodePubKey(raw []byte) (crypto.PubKey, error) {
+	var pk crypto.PubKey
+	r := NewProtoReader(raw)
+	err := r.ReadFields(func(field, wireType uint64) (bool, error) {
+		if field != 1 && field != 2 {
+			return false, nil
+		}
+		key, err := r.BytesField(wireType)
+		if err != nil {
+			return true, err
+		}
+		if pk != nil {
+			return true, fmt.Errorf("more than one public key")
+		}
+		switch {
+		case field == 1 && len(key) == ed25519.PubKeyEd25519Size:
+			var edKey ed25519.PubKeyEd25519
+			copy(edKey[:], key)
+			pk = edKey
+		case field == 2 && len(key) == secp256k1.PubKeySecp256k1Size:
+			var secpKey secp256k1.PubKeySecp256k1
+			copy(secpKey[:], key)
+			pk = secpKey
+		default:
+			return true, fmt.Errorf("wrong size %d of public key", len(key))
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("decode public key: %v", err)
+	}
+	if pk == nil {
+		return nil, fmt.Errorf("decode public key: unsupported type of public key")
+	}
+	return pk, nil
+}
+
+// DecodeCosmosLightBlock decodes the protobuf encoded LightBlock of tendermint 0.34
+func DecodeCosmosLightBlock(raw []byte) (*CosmosLightBlock, error) {
+	block := &CosmosLightBlock{}
+	r := NewProtoReader(raw)
+	err := r.ReadFields(func(field, wireType uint64) (bool, error) {
+		switch field {
+		case 1:
+			sh, err := r.BytesField(wireType)
+			if err != nil {
+				return true, err
+			}
+			shr := NewProtoReader(sh)
+			return true, shr.ReadFields(func(field, wireType uint64) (bool, error) {
+				if field != 1 && field != 2 {
+					return false, nil
+				}
+				raw, err := shr.BytesField(wireType)
+				if err != nil {
+					return true, err
+				}
+				if field == 1 {
+					block.Header = new(ProtoHeader)
+					return true, block.Header.decode(raw)
+				}
+				block.Commit = new(ProtoCommit)
+				return true, block.Commit.decode(raw)
+			})
+		case 2:
+			vs, err := r.BytesField(wireType)
+			if err != nil {
+				return true, err
+			}
+			block.Validators, err = decodeValidatorSet(vs)
+			return true, err
+		case 4:
+			vs, err := r.BytesField(wireType)
+			if err != nil {
+				return true, err
+			}
+			block.TrustedValidators, err = decodeValidatorSet(vs)
+			return true, err
+		default:
+			return false, nil
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("DecodeCosmosLightBlock, %v", err)
+	}
+	if block.Header == nil || block.Commit == nil || len(block.Validators) == 0 {
+		return nil, fmt.Errorf("DecodeCosmosLightBlock, header, commit or validators missing")
+	}
+	return block, nil
+}
+
+func decodeValidatorSet(raw []byte) ([]*ProtoValidator, error) {
+	vals := make([]*ProtoValidator, 0)
+	r := NewProtoReader(raw)
+	err := r.ReadFields(func(field, wireType uint64) (bool, error) {
+		if field != 1 {
+			return false, nil
+		}
+		raw, err := r.BytesField(wireType)
+		if err != nil {
+			return true, err
+		}
+		val := new(ProtoValidator)
+		vals = append(vals, val)
+		return true, val.decode(raw)
+	})
+	return vals, err
+}
+
+// ValidatorsHash returns the hash of validators, same as ValidatorSet.Hash of tendermint 0.34
+func (this *CosmosLightBlock) ValidatorsHash() []byte {
+	return validatorsHash(this.Validators)
+}
+
+func validatorsHash(vals []*ProtoValidator) []byte {
+	bzs := make([][]byte, len(vals))
+	for i, val := range vals {
+		bzs[i] = val.encode()
+	}
+	return merkle.SimpleHashFromByteSlices(bzs)
+}
+
+// VerifyCosmosLightBlock verifies the light block as VerifyCosmosHeader does for amino encoded headers
+func VerifyCosmosLightBlock(block *CosmosLightBlock, info *CosmosEpochSwitchInfo) error {
+	header, commit := block.Header, block.Commit
+	valsHash := block.ValidatorsHash()
+	if !bytes.Equal(info.NextValidatorsHash, valsHash) {
+		return fmt.Errorf("VerifyCosmosLightBlock, block validator is not right, next validator hash: %s, "+
+			"validator set hash: %s", info.NextValidatorsHash.String(), hex.EncodeToString(valsHash))
+	}
+	if !bytes.Equal(header.ValidatorsHash, valsHash) {
+		return fmt.Errorf("VerifyCosmosLightBlock, block validator is not right!, header validator hash: %s, "+
+			"validator set hash: %s", hex.EncodeToString(header.ValidatorsHash), hex.EncodeToString(valsHash))
+	}
+	if commit.Height != header.Height {
+		return fmt.Errorf("VerifyCosmosLightBlock, commit height is not right! commit height: %d, "+
+			"header height: %d", commit.Height, header.Height)
+	}
+	if !bytes.Equal(commit.BlockID.Hash, header.Hash()) {
+		return fmt.Errorf("VerifyCosmosLightBlock, commit hash is not right!, commit block hash: %s,"+
+			" header hash: %s", hex.EncodeToString(commit.BlockID.Hash), hex.EncodeToString(header.Hash()))
+	}
+	if err := commit.ValidateBasic(); err != nil {
+		return fmt.Errorf("VerifyCosmosLightBlock, commit is not right! err: %s", err.Error())
+	}
+	if len(block.Validators) != len(commit.Signatures) {
+		return fmt.Errorf("VerifyCosmosLightBlock, the size of precommits is not right!")
+	}
+	talliedVotingPower, totalVotingPower := int64(0), int64(0)
+	for idx, commitSig := range commit.Signatures {
+		val := block.Validators[idx]
+		totalVotingPower += val.VotingPower
+		if commitSig.BlockIDFlag == BLOCK_ID_FLAG_ABSENT {
+			continue // OK, some precommits can be missing.
+		}
+		if !bytes.Equal(commitSig.ValidatorAddress, val.PubKey.Address()) {
+			return fmt.Errorf("VerifyCosmosLightBlock, address of No.%d signature is not the validator's", idx)
+		}
+		// Validate signature.
+		if !val.PubKey.VerifyBytes(commit.VoteSignBytes(info.ChainID, idx), commitSig.Signature) {
+			return fmt.Errorf("VerifyCosmosLightBlock, Invalid commit -- invalid signature of No.%d validator", idx)
+		}
+		// Good precommit!
+		if commitSig.BlockIDFlag == BLOCK_ID_FLAG_COMMIT {
+			talliedVotingPower += val.VotingPower
+		}
+	}
+	if talliedVotingPower <= totalVotingPower*2/3 {
+		return fmt.Errorf("VerifyCosmosLightBlock, voteing power is not enough!")
+	}
+	return nil
+}
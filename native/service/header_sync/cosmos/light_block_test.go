@@ -0,0 +1,226 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cosmos
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/tendermint/crypto"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	"github.com/tendermint/tendermint/crypto/merkle"
+	"strings"
+	"testing"
+	"time"
+)
+
+func encodeTestLightBlock(block *CosmosLightBlock) []byte {
+	h := block.Header
+	version := protoVarint(protoVarint(nil, 1, h.Version.Block), 2, h.Version.App)
+	header := protoMessage(nil, 1, version)
+	header = protoBytes(header, 2, []byte(h.ChainID))
+	header = protoVarint(header, 3, uint64(h.Height))
+	header = protoMessage(header, 4, encodeTimestamp(h.Time))
+	header = protoMessage(header, 5, h.LastBlockID.encode())
+	for i, v := range [][]byte{h.LastCommitHash, h.DataHash, h.ValidatorsHash, h.NextValidatorsHash, h.ConsensusHash,
+		h.AppHash, h.LastResultsHash, h.EvidenceHash, h.ProposerAddress} {
+		header = protoBytes(header, uint64(6+i), v)
+	}
+
+	c := block.Commit
+	commit := protoVarint(nil, 1, uint64(c.Height))
+	commit = protoVarint(commit, 2, uint64(c.Round))
+	commit = protoMessage(commit, 3, c.BlockID.encode())
+	for _, sig := range c.Signatures {
+		s := protoVarint(nil, 1, sig.BlockIDFlag)
+		s = protoBytes(s, 2, sig.ValidatorAddress)
+		s = protoMessage(s, 3, encodeTimestamp(sig.Timestamp))
+		s = protoBytes(s, 4, sig.Signature)
+		commit = protoMessage(commit, 4, s)
+	}
+
+	sh := protoMessage(protoMessage(nil, 1, header), 2, commit)
+	raw := protoMessage(protoMessage(nil, 1, sh), 2, encodeTestValidatorSet(block.Validators))
+	if len(block.TrustedValidators) > 0 {
+		raw = protoMessage(raw, 4, encodeTestValidatorSet(block.TrustedValidators))
+	}
+	return raw
+}
+
+func encodeTestValidatorSet(validators []*ProtoValidator) []byte {
+	vals := make([]byte, 0)
+	total := int64(0)
+	for _, val := range validators {
+		pk := val.PubKey.(ed25519.PubKeyEd25519)
+		v := protoBytes(nil, 1, val.PubKey.Address())
+		v = protoMessage(v, 2, protoBytes(nil, 1, pk[:]))
+		v = protoVarint(v, 3, uint64(val.VotingPower))
+		vals = protoMessage(vals, 1, v)
+		total += val.VotingPower
+	}
+	return protoVarint(vals, 3, uint64(total))
+}
+
+func newTestLightBlock(t *testing.T, keys []ed25519.PrivKeyEd25519, nextValsHash []byte) *CosmosLightBlock {
+	block := &CosmosLightBlock{
+		Header: &ProtoHeader{
+			Version: ProtoVersion{Block: 11},
+			ChainID: "cosmoshub-4",
+			Height:  100,
+			Time:    time.Unix(1610000000, 123456789).UTC(),
+			LastBlockID: ProtoBlockID{
+				Hash:         sha256Sum("last_block"),
+				PartSetTotal: 1,
+				PartSetHash:  sha256Sum("last_parts"),
+			},
+			AppHash: sha256Sum("app_hash"),
+		},
+	}
+	for _, key := range keys {
+		block.Validators = append(block.Validators, &ProtoValidator{PubKey: key.PubKey(), VotingPower: 10})
+	}
+	block.Header.ValidatorsHash = block.ValidatorsHash()
+	block.Header.NextValidatorsHash = nextValsHash
+	if nextValsHash == nil {
+		block.Header.NextValidatorsHash = block.Header.ValidatorsHash
+	}
+	signTestLightBlock(t, block, keys)
+	return block
+}
+
+// signTestLightBlock makes the commit of block signed by keys
+func signTestLightBlock(t *testing.T, block *CosmosLightBlock, keys []ed25519.PrivKeyEd25519) {
+	block.Commit = &ProtoCommit{
+		Height: block.Header.Height,
+		BlockID: ProtoBlockID{
+			Hash:         block.Header.Hash(),
+			PartSetTotal: 1,
+			PartSetHash:  sha256Sum("parts"),
+		},
+	}
+	for i, key := range keys {
+		block.Commit.Signatures = append(block.Commit.Signatures, &ProtoCommitSig{
+			BlockIDFlag:      BLOCK_ID_FLAG_COMMIT,
+			ValidatorAddress: key.PubKey().Address(),
+			Timestamp:        block.Header.Time.Add(time.Duration(i) * time.Millisecond),
+		})
+		sig, err := key.Sign(block.Commit.VoteSignBytes(block.Header.ChainID, i))
+		assert.NoError(t, err)
+		block.Commit.Signatures[i].Signature = sig
+	}
+}
+
+func sha256Sum(s string) []byte {
+	hash := sha256.Sum256([]byte(s))
+	return hash[:]
+}
+
+func TestProtoHeaderHash(t *testing.T) {
+	// test vector of Header.Hash in tendermint 0.34
+	header := &ProtoHeader{
+		Version: ProtoVersion{Block: 1, App: 2},
+		ChainID: "chainId",
+		Height:  3,
+		Time:    time.Date(2019, 10, 13, 16, 14, 44, 0, time.UTC),
+		LastBlockID: ProtoBlockID{
+			Hash:         make([]byte, 32),
+			PartSetTotal: 6,
+			PartSetHash:  make([]byte, 32),
+		},
+		LastCommitHash:     sha256Sum("last_commit_hash"),
+		DataHash:           sha256Sum("data_hash"),
+		ValidatorsHash:     sha256Sum("validators_hash"),
+		NextValidatorsHash: sha256Sum("next_validators_hash"),
+		ConsensusHash:      sha256Sum("consensus_hash"),
+		AppHash:            sha256Sum("app_hash"),
+		LastResultsHash:    sha256Sum("last_results_hash"),
+		EvidenceHash:       sha256Sum("evidence_hash"),
+		ProposerAddress:    crypto.AddressHash([]byte("proposer_address")),
+	}
+	assert.Equal(t, "F740121F553B5418C3EFBD343C2DBFE9E007BB67B0D020A0741374BAB65242A4",
+		strings.ToUpper(hex.EncodeToString(header.Hash())))
+
+	header.ValidatorsHash = nil
+	assert.Nil(t, header.Hash())
+}
+
+func TestVoteSignBytes(t *testing.T) {
+	// test vector of Vote.SignBytes in tendermint 0.34
+	commit := &ProtoCommit{
+		Height:     1,
+		Round:      1,
+		Signatures: []*ProtoCommitSig{{BlockIDFlag: BLOCK_ID_FLAG_NIL}},
+	}
+	expected := []byte{0x21, 0x8, 0x2, 0x11, 0x1, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x19, 0x1, 0x0, 0x0, 0x0, 0x0, 0x0,
+		0x0, 0x0, 0x2a, 0xb, 0x8, 0x80, 0x92, 0xb8, 0xc3, 0x98, 0xfe, 0xff, 0xff, 0xff, 0x1}
+	assert.Equal(t, expected, commit.VoteSignBytes("", 0))
+
+	// the block id is only signed by votes for block
+	commit.BlockID = ProtoBlockID{Hash: sha256Sum("block"), PartSetTotal: 1, PartSetHash: sha256Sum("parts")}
+	assert.Equal(t, expected, commit.VoteSignBytes("", 0))
+	commit.Signatures[0].BlockIDFlag = BLOCK_ID_FLAG_COMMIT
+	assert.NotEqual(t, expected, commit.VoteSignBytes("", 0))
+}
+
+func TestVerifyCosmosLightBlock(t *testing.T) {
+	keys := make([]ed25519.PrivKeyEd25519, 4)
+	for i := range keys {
+		keys[i] = ed25519.GenPrivKey()
+	}
+	block := newTestLightBlock(t, keys, nil)
+	raw := encodeTestLightBlock(block)
+
+	header, err := DecodeHeader(raw, CODEC_PROTO)
+	assert.NoError(t, err)
+	decoded := header.(*CosmosLightBlock)
+	assert.Equal(t, block, decoded)
+	assert.Equal(t, block.Header.Hash(), header.GetHash())
+
+	info := &CosmosEpochSwitchInfo{
+		Height:             1,
+		NextValidatorsHash: block.ValidatorsHash(),
+		ChainID:            block.Header.ChainID,
+	}
+	assert.NoError(t, header.Verify(info))
+
+	// a signature missing is fine while three of four are there
+	block.Commit.Signatures[3] = &ProtoCommitSig{BlockIDFlag: BLOCK_ID_FLAG_ABSENT}
+	assert.NoError(t, VerifyCosmosLightBlock(block, info))
+	block.Commit.Signatures[2] = &ProtoCommitSig{BlockIDFlag: BLOCK_ID_FLAG_ABSENT}
+	assert.Error(t, VerifyCosmosLightBlock(block, info))
+
+	// header changed after signed
+	block = newTestLightBlock(t, keys, nil)
+	block.Header.AppHash = sha256Sum("fake_app_hash")
+	assert.Error(t, VerifyCosmosLightBlock(block, info))
+
+	// signature by other chain
+	block = newTestLightBlock(t, keys, nil)
+	info.ChainID = "cosmoshub-3"
+	assert.Error(t, VerifyCosmosLightBlock(block, info))
+
+	// validators not of the epoch
+	info.ChainID = block.Header.ChainID
+	info.NextValidatorsHash = merkle.SimpleHashFromByteSlices(nil)
+	assert.Error(t, VerifyCosmosLightBlock(block, info))
+
+	_, err = DecodeCosmosLightBlock(raw[:len(raw)-1])
+	assert.Error(t, err)
+	_, err = DecodeHeader(raw, CODEC_AMINO)
+	assert.Error(t, err)
+}
@@ -0,0 +1,234 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cosmos
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// wire types of protobuf
+const (
+	WIRE_VARINT  = 0
+	WIRE_FIXED64 = 1
+	WIRE_BYTES   = 2
+	WIRE_FIXED32 = 5
+)
+
+// ProtoReader reads the fields of a protobuf encoded message one by one
+type ProtoReader struct {
+	buf []byte
+}
+
+// NewProtoReader ...
+func NewProtoReader(buf []byte) *ProtoReader {
+	return &ProtoReader{buf: buf}
+}
+
+// Done tells if all fields are read
+func (r *ProtoReader) Done() bool {
+	return len(r.buf) == 0
+}
+
+// Next reads the key of next field
+func (r *ProtoReader) Next() (field uint64, wireType uint64, err error) {
+	key, err := r.Varint()
+	if err != nil {
+		return 0, 0, err
+	}
+	field, wireType = key>>3, key&7
+	if field == 0 {
+		return 0, 0, fmt.Errorf("illegal field number 0")
+	}
+	return field, wireType, nil
+}
+
+func (r *ProtoReader) Varint() (uint64, error) {
+	v, n := binary.Uvarint(r.buf)
+	if n <= 0 {
+		return 0, fmt.Errorf("bad varint")
+	}
+	r.buf = r.buf[n:]
+	return v, nil
+}
+
+func (r *ProtoReader) Fixed64() (uint64, error) {
+	if len(r.buf) < 8 {
+		return 0, fmt.Errorf("unexpected EOF of fixed64")
+	}
+	v := binary.LittleEndian.Uint64(r.buf)
+	r.buf = r.buf[8:]
+	return v, nil
+}
+
+func (r *ProtoReader) Bytes() ([]byte, error) {
+	l, err := r.Varint()
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(r.buf)) < l {
+		return nil, fmt.Errorf("unexpected EOF of %d bytes", l)
+	}
+	v := r.buf[:l]
+	r.buf = r.buf[l:]
+	return v, nil
+}
+
+// Skip drops the value of a field not cared about
+func (r *ProtoReader) Skip(wireType uint64) (err error) {
+	switch wireType {
+	case WIRE_VARINT:
+		_, err = r.Varint()
+	case WIRE_FIXED64:
+		_, err = r.Fixed64()
+	case WIRE_BYTES:
+		_, err = r.Bytes()
+	case WIRE_FIXED32:
+		if len(r.buf) < 4 {
+			return fmt.Errorf("unexpected EOF of fixed32")
+		}
+		r.buf = r.buf[4:]
+	default:
+		return fmt.Errorf("unsupported wire type %d", wireType)
+	}
+	return err
+}
+
+// ReadFields calls fn with each field and skips the ones fn does not read, fn returns false for them
+func (r *ProtoReader) ReadFields(fn func(field, wireType uint64) (bool, error)) error {
+	for !r.Done() {
+		field, wireType, err := r.Next()
+		if err != nil {
+			return err
+		}
+		read, err := fn(field, wireType)
+		if err != nil {
+			return fmt.Errorf("field %d: %v", field, err)
+		}
+		if !read {
+			if err = r.Skip(wireType); err != nil {
+				return fmt.Errorf("field %d: %v", field, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (r *ProtoReader) expect(wireType, expected uint64) error {
+	if wireType != expected {
+		return fmt.Errorf("wrong wire type %d, expected %d", wireType, expected)
+	}
+	return nil
+}
+
+// VarintField reads a field of varint
+func (r *ProtoReader) VarintField(wireType uint64) (uint64, error) {
+	if err := r.expect(wireType, WIRE_VARINT); err != nil {
+		return 0, err
+	}
+	return r.Varint()
+}
+
+// BytesField reads a field of bytes, string or embedded message
+func (r *ProtoReader) BytesField(wireType uint64) ([]byte, error) {
+	if err := r.expect(wireType, WIRE_BYTES); err != nil {
+		return nil, err
+	}
+	return r.Bytes()
+}
+
+// Fixed64Field reads a field of fixed64 or sfixed64
+func (r *ProtoReader) Fixed64Field(wireType uint64) (uint64, error) {
+	if err := r.expect(wireType, WIRE_FIXED64); err != nil {
+		return 0, err
+	}
+	return r.Fixed64()
+}
+
+func protoKey(buf []byte, field, wireType uint64) []byte {
+	return protoUvarint(buf, field<<3|wireType)
+}
+
+func protoUvarint(buf []byte, v uint64) []byte {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], v)
+	return append(buf, b[:n]...)
+}
+
+// protoVarint appends a varint field, omitted as zero value in proto3
+func protoVarint(buf []byte, field, v uint64) []byte {
+	if v == 0 {
+		return buf
+	}
+	return protoUvarint(protoKey(buf, field, WIRE_VARINT), v)
+}
+
+// protoSfixed64 appends a sfixed64 field, omitted as zero value in proto3
+func protoSfixed64(buf []byte, field uint64, v int64) []byte {
+	if v == 0 {
+		return buf
+	}
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], uint64(v))
+	return append(protoKey(buf, field, WIRE_FIXED64), b[:]...)
+}
+
+// protoBytes appends a bytes or string field, omitted when empty in proto3
+func protoBytes(buf []byte, field uint64, v []byte) []byte {
+	if len(v) == 0 {
+		return buf
+	}
+	return protoMessage(buf, field, v)
+}
+
+// protoMessage appends an embedded message, which is always there even if empty
+func protoMessage(buf []byte, field uint64, msg []byte) []byte {
+	buf = protoUvarint(protoKey(buf, field, WIRE_BYTES), uint64(len(msg)))
+	return append(buf, msg...)
+}
+
+// encodeTimestamp encodes t as google.protobuf.Timestamp
+func encodeTimestamp(t time.Time) []byte {
+	buf := protoVarint(nil, 1, uint64(t.Unix()))
+	return protoVarint(buf, 2, uint64(t.Nanosecond()))
+}
+
+func decodeTimestamp(raw []byte) (time.Time, error) {
+	var sec, nanos uint64
+	r := NewProtoReader(raw)
+	err := r.ReadFields(func(field, wireType uint64) (bool, error) {
+		var err error
+		switch field {
+		case 1:
+			sec, err = r.VarintField(wireType)
+		case 2:
+			nanos, err = r.VarintField(wireType)
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("decode timestamp: %v", err)
+	}
+	if int64(nanos) < 0 || int64(nanos) >= int64(time.Second) {
+		return time.Time{}, fmt.Errorf("decode timestamp: nanos %d out of range", int64(nanos))
+	}
+	return time.Unix(int64(sec), int64(nanos)).UTC(), nil
+}
@@ -0,0 +1,193 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+package cosmos
+
+import (
+	"fmt"
+	"github.com/polynetwork/poly/common"
+	cstates "github.com/polynetwork/poly/core/states"
+	"github.com/polynetwork/poly/core/store/leveldbstore"
+	"github.com/polynetwork/poly/core/store/overlaydb"
+	"github.com/polynetwork/poly/core/types"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
+	scom "github.com/polynetwork/poly/native/service/header_sync/common"
+	"github.com/polynetwork/poly/native/service/utils"
+	"github.com/polynetwork/poly/native/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	"testing"
+	"time"
+)
+
+const testTrustingPeriod = 1000
+
+func testKeys(tag string, n int) []ed25519.PrivKeyEd25519 {
+	keys := make([]ed25519.PrivKeyEd25519, n)
+	for i := range keys {
+		keys[i] = ed25519.GenPrivKeyFromSecret([]byte(fmt.Sprintf("%s%d", tag, i)))
+	}
+	return keys
+}
+
+func testValidators(keys []ed25519.PrivKeyEd25519) []*ProtoValidator {
+	vals := make([]*ProtoValidator, len(keys))
+	for i, key := range keys {
+		vals[i] = &ProtoValidator{PubKey: key.PubKey(), VotingPower: 10}
+	}
+	return vals
+}
+
+// newSkippingTest returns a trusted info of validators trusted, and a light block at height 100
+// signed by signers carrying the trusted validators
+func newSkippingTest(t *testing.T, trusted, signers []ed25519.PrivKeyEd25519) (*CosmosEpochSwitchInfo, *CosmosLightBlock) {
+	block := newTestLightBlock(t, signers, nil)
+	block.TrustedValidators = testValidators(trusted)
+	info := &CosmosEpochSwitchInfo{
+		Height:             50,
+		BlockHash:          sha256Sum("trusted_block"),
+		NextValidatorsHash: validatorsHash(block.TrustedValidators),
+		ChainID:            block.Header.ChainID,
+		Time:               block.Header.Time.Unix() - 600,
+	}
+	return info, block
+}
+
+func TestVerifyHeaderSkipping(t *testing.T) {
+	trusted := testKeys("trusted", 4)
+	extraInfo := &ExtraInfo{Codec: CODEC_PROTO, TrustingPeriod: testTrustingPeriod}
+
+	// half of the trusted voting power signed the header of a new validator set
+	info, block := newSkippingTest(t, trusted, append(testKeys("new", 2), trusted[:2]...))
+	now := block.Header.Time.Add(time.Minute)
+	assert.NoError(t, VerifyHeader(block, info, extraInfo, now))
+
+	// no skipping without trusting period
+	assert.Error(t, VerifyHeader(block, info, &ExtraInfo{Codec: CODEC_PROTO}, now))
+
+	// trusting period expired
+	err := VerifyHeader(block, info, extraInfo, time.Unix(info.Time+testTrustingPeriod, 0))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "expired")
+
+	// header too far in the future
+	err = VerifyHeader(block, info, extraInfo, block.Header.Time.Add(-time.Minute))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "future")
+
+	// trusted header of unknown time
+	err = VerifyHeader(block, &CosmosEpochSwitchInfo{NextValidatorsHash: info.NextValidatorsHash, ChainID: info.ChainID},
+		extraInfo, now)
+	assert.Error(t, err)
+
+	// trusted validators not committed or not matching the trusted hash
+	trustedVals := block.TrustedValidators
+	block.TrustedValidators = nil
+	assert.Error(t, VerifyHeader(block, info, extraInfo, now))
+	block.TrustedValidators = testValidators(testKeys("other", 4))
+	assert.Error(t, VerifyHeader(block, info, extraInfo, now))
+	block.TrustedValidators = trustedVals
+
+	// only 1/4 of the trusted voting power signed
+	info, block = newSkippingTest(t, trusted, append(testKeys("new", 3), trusted[0]))
+	err = VerifyHeader(block, info, extraInfo, now)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not more than 1/3")
+
+	// header not signed by 2/3 of its own validators
+	info, block = newSkippingTest(t, trusted, trusted)
+	block.Commit.Signatures = block.Commit.Signatures[:2]
+	assert.Error(t, VerifyHeader(block, info, extraInfo, now))
+}
+
+func TestEpochSwitchInfoWithoutTime(t *testing.T) {
+	info := &CosmosEpochSwitchInfo{
+		Height:             50,
+		BlockHash:          sha256Sum("block"),
+		NextValidatorsHash: sha256Sum("vals"),
+		ChainID:            "cosmoshub-4",
+	}
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteInt64(info.Height)
+	sink.WriteVarBytes(info.BlockHash)
+	sink.WriteVarBytes(info.NextValidatorsHash)
+	sink.WriteString(info.ChainID)
+
+	decoded := new(CosmosEpochSwitchInfo)
+	assert.NoError(t, decoded.Deserialization(common.NewZeroCopySource(sink.Bytes())))
+	assert.Equal(t, info, decoded)
+}
+
+func newMisbehaviourNative(t *testing.T, param *scom.MisbehaviourParam, blockTime uint32, db *storage.CacheDB) *native.NativeService {
+	if db == nil {
+		store, _ := leveldbstore.NewMemLevelDBStore()
+		db = storage.NewCacheDB(overlaydb.NewOverlayDB(store))
+		side := &side_chain_manager.SideChain{
+			Name:      "cosmos",
+			ChainId:   param.ChainID,
+			Router:    utils.COSMOS_ROUTER,
+			ExtraInfo: []byte(fmt.Sprintf(`{"Codec":"proto","TrustingPeriod":%d}`, testTrustingPeriod)),
+		}
+		sink := common.NewZeroCopySink(nil)
+		assert.NoError(t, side.Serialization(sink))
+		db.Put(utils.ConcatKey(utils.SideChainManagerContractAddress, []byte(side_chain_manager.SIDE_CHAIN),
+			utils.GetUint64Bytes(param.ChainID)), cstates.GenRawStorageItem(sink.Bytes()))
+	}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	ns, err := native.NewNativeService(db, &types.Transaction{}, blockTime, 0, common.Uint256{0}, 0, sink.Bytes(), false)
+	assert.NoError(t, err)
+	return ns
+}
+
+func TestSubmitMisbehaviour(t *testing.T) {
+	trusted := testKeys("trusted", 4)
+	info, block1 := newSkippingTest(t, trusted, append(testKeys("new", 2), trusted[:2]...))
+	_, block2 := newSkippingTest(t, trusted, append(testKeys("new", 2), trusted[:2]...))
+	block2.Header.AppHash = sha256Sum("forked_app_hash")
+	signTestLightBlock(t, block2, append(testKeys("new", 2), trusted[:2]...))
+
+	param := &scom.MisbehaviourParam{
+		ChainID: 5,
+		Header1: encodeTestLightBlock(block1),
+		Header2: encodeTestLightBlock(block1),
+	}
+	blockTime := uint32(block1.Header.Time.Unix() + 60)
+	ns := newMisbehaviourNative(t, param, blockTime, nil)
+	PutEpochSwitchInfo(ns, param.ChainID, info)
+	handler := NewCosmosHandler()
+
+	// the same header twice is no misbehaviour
+	_, err := handler.SubmitMisbehaviour(ns)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not conflicting")
+
+	// conflicting header not passing verification
+	invalid := *block2
+	invalid.TrustedValidators = nil
+	param.Header2 = encodeTestLightBlock(&invalid)
+	ns = newMisbehaviourNative(t, param, blockTime, ns.GetCacheDB())
+	_, err = handler.SubmitMisbehaviour(ns)
+	assert.Error(t, err)
+
+	param.Header2 = encodeTestLightBlock(block2)
+	ns = newMisbehaviourNative(t, param, blockTime, ns.GetCacheDB())
+	misbehaviour, err := handler.SubmitMisbehaviour(ns)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(block1.Header.Height), misbehaviour.Height)
+	assert.Equal(t, block2.Header.Hash(), misbehaviour.Hash2)
+}
@@ -1,10 +1,14 @@
 package cosmos
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
 	"github.com/tendermint/tendermint/libs/bytes"
 	"github.com/tendermint/tendermint/types"
+	"time"
 )
 
 type CosmosEpochSwitchInfo struct {
@@ -23,6 +27,10 @@ type CosmosEpochSwitchInfo struct {
 
 	// The cosmos chain-id of this chain basing Cosmos-sdk.
 	ChainID string
+
+	// Unix time of the block at `Height`, the trusting period of skipping
+	// verification starts from it. Zero for infos stored before it's recorded.
+	Time int64
 }
 
 func (info *CosmosEpochSwitchInfo) Serialization(sink *common.ZeroCopySink) {
@@ -30,6 +38,7 @@ func (info *CosmosEpochSwitchInfo) Serialization(sink *common.ZeroCopySink) {
 	sink.WriteVarBytes(info.BlockHash)
 	sink.WriteVarBytes(info.NextValidatorsHash)
 	sink.WriteString(info.ChainID)
+	sink.WriteInt64(info.Time)
 }
 
 func (info *CosmosEpochSwitchInfo) Deserialization(source *common.ZeroCopySource) error {
@@ -50,6 +59,8 @@ func (info *CosmosEpochSwitchInfo) Deserialization(source *common.ZeroCopySource
 	if eof {
 		return fmt.Errorf("deserialize ChainID of CosmosEpochSwitchInfo failed")
 	}
+	// missing in infos stored before
+	info.Time, _ = source.NextInt64()
 	return nil
 }
 
@@ -57,4 +68,50 @@ type CosmosHeader struct {
 	Header  types.Header
 	Commit  *types.Commit
 	Valsets []*types.Validator
+	// validators of epoch switch info, only needed by skipping verification
+	TrustedValsets []*types.Validator
+}
+
+const (
+	// max seconds a header of skipping verification is allowed to be ahead of poly
+	MAX_CLOCK_DRIFT = 10 * time.Second
+)
+
+const (
+	// codecs of headers and proofs supported by cosmos router
+	CODEC_AMINO = "amino" // tendermint 0.33 and cosmos-sdk 0.39, proofs of merkle.Proof
+	CODEC_PROTO = "proto" // tendermint 0.34 and cosmos-sdk 0.40 or later, proofs of ics23
+)
+
+// ExtraInfo is the json encoded SideChain.ExtraInfo of a chain using cosmos router, e.g.
+// {"Codec":"proto","TrustingPeriod":1209600}, chains with empty ExtraInfo are taken as amino ones
+type ExtraInfo struct {
+	Codec          string
+	TrustingPeriod uint64 // seconds a header is trusted for skipping verification, 0 to disable it
+}
+
+// GetExtraInfo returns the ExtraInfo of side chain chainID
+func GetExtraInfo(native *native.NativeService, chainID uint64) (*ExtraInfo, error) {
+	sideChain, err := side_chain_manager.GetSideChain(native, chainID)
+	if err != nil {
+		return nil, fmt.Errorf("GetExtraInfo, side_chain_manager.GetSideChain error: %v", err)
+	}
+	if sideChain == nil {
+		return nil, fmt.Errorf("GetExtraInfo, side chain %d is not registered", chainID)
+	}
+	extraInfo := &ExtraInfo{Codec: CODEC_AMINO}
+	if len(sideChain.ExtraInfo) == 0 {
+		return extraInfo, nil
+	}
+	if err := json.Unmarshal(sideChain.ExtraInfo, extraInfo); err != nil {
+		return nil, fmt.Errorf("GetExtraInfo, unmarshal ExtraInfo error: %v", err)
+	}
+	switch extraInfo.Codec {
+	case "":
+		extraInfo.Codec = CODEC_AMINO
+	case CODEC_AMINO, CODEC_PROTO:
+	default:
+		return nil, fmt.Errorf("GetExtraInfo, not a supported codec of chain %d: %s", chainID, extraInfo.Codec)
+	}
+	return extraInfo, nil
 }
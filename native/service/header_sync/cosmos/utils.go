@@ -29,6 +29,7 @@ import (
 	hscommon "github.com/polynetwork/poly/native/service/header_sync/common"
 	"github.com/polynetwork/poly/native/service/utils"
 	"github.com/tendermint/tendermint/types"
+	"time"
 )
 
 func notifyEpochSwitchInfo(native *native.NativeService, chainID uint64, info *CosmosEpochSwitchInfo) {
@@ -69,6 +70,190 @@ func PutEpochSwitchInfo(service *native.NativeService, chainId uint64, info *Cos
 	notifyEpochSwitchInfo(service, chainId, info)
 }
 
+// SignedHeader is a header along with the commit and validators of it, in the codec of its chain
+type SignedHeader interface {
+	GetHeight() int64
+	GetChainID() string
+	GetHash() []byte
+	GetValidatorsHash() []byte
+	GetNextValidatorsHash() []byte
+	GetAppHash() []byte
+	GetTime() time.Time
+	// GetTrustedValidators returns the validators of epoch info committed with header and
+	// the hash of them, only needed by skipping verification
+	GetTrustedValidators() ([]byte, []*ProtoValidator)
+	// GetVotes returns the signatures in commit except absent ones
+	GetVotes(chainID string) []*Vote
+	// Verify checks the header is committed by the validators of epoch info
+	Verify(info *CosmosEpochSwitchInfo) error
+}
+
+// Vote is a precommit signed by a validator in the commit of header
+type Vote struct {
+	ValidatorAddress []byte
+	SignBytes        []byte
+	Signature        []byte
+	ForBlock         bool // false for votes of nil
+}
+
+func (this *CosmosHeader) GetHeight() int64              { return this.Header.Height }
+func (this *CosmosHeader) GetChainID() string            { return this.Header.ChainID }
+func (this *CosmosHeader) GetHash() []byte               { return this.Header.Hash() }
+func (this *CosmosHeader) GetValidatorsHash() []byte     { return this.Header.ValidatorsHash }
+func (this *CosmosHeader) GetNextValidatorsHash() []byte { return this.Header.NextValidatorsHash }
+func (this *CosmosHeader) GetAppHash() []byte            { return this.Header.AppHash }
+func (this *CosmosHeader) GetTime() time.Time            { return this.Header.Time }
+func (this *CosmosHeader) GetTrustedValidators() ([]byte, []*ProtoValidator) {
+	if len(this.TrustedValsets) == 0 {
+		return nil, nil
+	}
+	valset := &types.ValidatorSet{}
+	if err := valset.UpdateWithChangeSet(this.TrustedValsets); err != nil {
+		return nil, nil
+	}
+	vals := make([]*ProtoValidator, len(this.TrustedValsets))
+	for i, v := range this.TrustedValsets {
+		vals[i] = &ProtoValidator{PubKey: v.PubKey, VotingPower: v.VotingPower}
+	}
+	return valset.Hash(), vals
+}
+func (this *CosmosHeader) GetVotes(chainID string) []*Vote {
+	votes := make([]*Vote, 0, len(this.Commit.Signatures))
+	for idx, commitSig := range this.Commit.Signatures {
+		if commitSig.Absent() {
+			continue
+		}
+		votes = append(votes, &Vote{
+			ValidatorAddress: commitSig.ValidatorAddress,
+			SignBytes:        this.Commit.VoteSignBytes(chainID, idx),
+			Signature:        commitSig.Signature,
+			ForBlock:         commitSig.ForBlock(),
+		})
+	}
+	return votes
+}
+func (this *CosmosHeader) Verify(info *CosmosEpochSwitchInfo) error {
+	return VerifyCosmosHeader(this, info)
+}
+
+func (this *CosmosLightBlock) GetHeight() int64              { return this.Header.Height }
+func (this *CosmosLightBlock) GetChainID() string            { return this.Header.ChainID }
+func (this *CosmosLightBlock) GetHash() []byte               { return this.Header.Hash() }
+func (this *CosmosLightBlock) GetValidatorsHash() []byte     { return this.Header.ValidatorsHash }
+func (this *CosmosLightBlock) GetNextValidatorsHash() []byte { return this.Header.NextValidatorsHash }
+func (this *CosmosLightBlock) GetAppHash() []byte            { return this.Header.AppHash }
+func (this *CosmosLightBlock) GetTime() time.Time            { return this.Header.Time }
+func (this *CosmosLightBlock) GetTrustedValidators() ([]byte, []*ProtoValidator) {
+	if len(this.TrustedValidators) == 0 {
+		return nil, nil
+	}
+	return validatorsHash(this.TrustedValidators), this.TrustedValidators
+}
+func (this *CosmosLightBlock) GetVotes(chainID string) []*Vote {
+	votes := make([]*Vote, 0, len(this.Commit.Signatures))
+	for idx, commitSig := range this.Commit.Signatures {
+		if commitSig.BlockIDFlag == BLOCK_ID_FLAG_ABSENT {
+			continue
+		}
+		votes = append(votes, &Vote{
+			ValidatorAddress: commitSig.ValidatorAddress,
+			SignBytes:        this.Commit.VoteSignBytes(chainID, idx),
+			Signature:        commitSig.Signature,
+			ForBlock:         commitSig.BlockIDFlag == BLOCK_ID_FLAG_COMMIT,
+		})
+	}
+	return votes
+}
+func (this *CosmosLightBlock) Verify(info *CosmosEpochSwitchInfo) error {
+	return VerifyCosmosLightBlock(this, info)
+}
+
+// DecodeHeader decodes raw as amino encoded CosmosHeader or protobuf encoded LightBlock by codec
+func DecodeHeader(raw []byte, codec string) (SignedHeader, error) {
+	switch codec {
+	case CODEC_PROTO:
+		return DecodeCosmosLightBlock(raw)
+	case CODEC_AMINO:
+		header := new(CosmosHeader)
+		if err := newCDC().UnmarshalBinaryBare(raw, header); err != nil {
+			return nil, err
+		}
+		return header, nil
+	default:
+		return nil, fmt.Errorf("not a supported codec: %s", codec)
+	}
+}
+
+// VerifyHeader verifies header by the validators of epoch info, or by skipping verification
+// with the trusted validators committed in header if the chain has a trusting period
+func VerifyHeader(header SignedHeader, info *CosmosEpochSwitchInfo, extraInfo *ExtraInfo, now time.Time) error {
+	if extraInfo.TrustingPeriod == 0 || bytes.Equal(header.GetValidatorsHash(), info.NextValidatorsHash) {
+		return header.Verify(info)
+	}
+	return verifySkipping(header, info, time.Duration(extraInfo.TrustingPeriod)*time.Second, now)
+}
+
+// verifySkipping accepts header signed by more than 2/3 of its own validators and more than 1/3
+// of the trusted ones, as the skipping verification of tendermint light client
+func verifySkipping(header SignedHeader, info *CosmosEpochSwitchInfo, trustingPeriod time.Duration, now time.Time) error {
+	if info.Time == 0 {
+		return fmt.Errorf("verifySkipping, time of trusted header %d is unknown", info.Height)
+	}
+	trustedTime := time.Unix(info.Time, 0)
+	if !now.Before(trustedTime.Add(trustingPeriod)) {
+		return fmt.Errorf("verifySkipping, trusted header %d expired at %s", info.Height,
+			trustedTime.Add(trustingPeriod).UTC())
+	}
+	if !header.GetTime().After(trustedTime) {
+		return fmt.Errorf("verifySkipping, time %s of header is not after the trusted %s",
+			header.GetTime().UTC(), trustedTime.UTC())
+	}
+	if header.GetTime().After(now.Add(MAX_CLOCK_DRIFT)) {
+		return fmt.Errorf("verifySkipping, time %s of header is in the future", header.GetTime().UTC())
+	}
+	hash, trusted := header.GetTrustedValidators()
+	if len(trusted) == 0 {
+		return fmt.Errorf("verifySkipping, no trusted validators committed with header")
+	}
+	if !bytes.Equal(hash, info.NextValidatorsHash) {
+		return fmt.Errorf("verifySkipping, trusted validators hash %s is not %s", hex.EncodeToString(hash),
+			info.NextValidatorsHash.String())
+	}
+	err := header.Verify(&CosmosEpochSwitchInfo{NextValidatorsHash: header.GetValidatorsHash(), ChainID: info.ChainID})
+	if err != nil {
+		return fmt.Errorf("verifySkipping, %v", err)
+	}
+
+	vals := make(map[string]*ProtoValidator)
+	totalVotingPower := int64(0)
+	for _, val := range trusted {
+		vals[string(val.PubKey.Address())] = val
+		totalVotingPower += val.VotingPower
+	}
+	talliedVotingPower := int64(0)
+	for _, vote := range header.GetVotes(info.ChainID) {
+		val, ok := vals[string(vote.ValidatorAddress)]
+		if !ok {
+			continue
+		}
+		// count each trusted validator once
+		delete(vals, string(vote.ValidatorAddress))
+		if !vote.ForBlock {
+			continue
+		}
+		if !val.PubKey.VerifyBytes(vote.SignBytes, vote.Signature) {
+			return fmt.Errorf("verifySkipping, invalid signature of trusted validator %s",
+				hex.EncodeToString(vote.ValidatorAddress))
+		}
+		talliedVotingPower += val.VotingPower
+	}
+	if talliedVotingPower <= totalVotingPower/3 {
+		return fmt.Errorf("verifySkipping, voting power %d of trusted validators is not more than 1/3 of %d",
+			talliedVotingPower, totalVotingPower)
+	}
+	return nil
+}
+
 func VerifyCosmosHeader(myHeader *CosmosHeader, info *CosmosEpochSwitchInfo) error {
 	// now verify this header
 	valset := types.NewValidatorSet(myHeader.Valsets)
@@ -20,27 +20,34 @@ package header_sync
 import (
 	"crypto/ecdsa"
 	"fmt"
-	"github.com/polynetwork/poly/native/service/header_sync/heco"
 
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/polynetwork/poly/common"
 	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/service/cross_chain_manager"
+	"github.com/polynetwork/poly/native/service/governance/relayer_manager"
 	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
-	"github.com/polynetwork/poly/native/service/header_sync/bsc"
-	"github.com/polynetwork/poly/native/service/header_sync/btc"
 	hscommon "github.com/polynetwork/poly/native/service/header_sync/common"
-	"github.com/polynetwork/poly/native/service/header_sync/cosmos"
-	"github.com/polynetwork/poly/native/service/header_sync/eth"
-	"github.com/polynetwork/poly/native/service/header_sync/neo"
-	"github.com/polynetwork/poly/native/service/header_sync/ont"
-	"github.com/polynetwork/poly/native/service/header_sync/quorum"
 	"github.com/polynetwork/poly/native/service/utils"
+
+	// built-in routers register their header sync handlers in init
+	_ "github.com/polynetwork/poly/native/service/header_sync/bsc"
+	_ "github.com/polynetwork/poly/native/service/header_sync/btc"
+	_ "github.com/polynetwork/poly/native/service/header_sync/cosmos"
+	_ "github.com/polynetwork/poly/native/service/header_sync/eth"
+	_ "github.com/polynetwork/poly/native/service/header_sync/evm"
+	_ "github.com/polynetwork/poly/native/service/header_sync/heco"
+	_ "github.com/polynetwork/poly/native/service/header_sync/neo"
+	_ "github.com/polynetwork/poly/native/service/header_sync/neo3"
+	_ "github.com/polynetwork/poly/native/service/header_sync/ont"
+	_ "github.com/polynetwork/poly/native/service/header_sync/quorum"
 )
 
 const (
 	SYNC_GENESIS_HEADER  = "syncGenesisHeader"
 	SYNC_BLOCK_HEADER    = "syncBlockHeader"
 	SYNC_CROSS_CHAIN_MSG = "syncCrossChainMsg"
+	SUBMIT_MISBEHAVIOUR  = "submitMisbehaviour"
 )
 
 //Register methods of node_manager contract
@@ -48,29 +55,12 @@ func RegisterHeaderSyncContract(native *native.NativeService) {
 	native.Register(SYNC_GENESIS_HEADER, SyncGenesisHeader)
 	native.Register(SYNC_BLOCK_HEADER, SyncBlockHeader)
 	native.Register(SYNC_CROSS_CHAIN_MSG, SyncCrossChainMsg)
+	native.Register(SUBMIT_MISBEHAVIOUR, SubmitMisbehaviour)
 }
 
+// GetChainHandler returns the handler registered for router by the chain packages
 func GetChainHandler(router uint64) (hscommon.HeaderSyncHandler, error) {
-	switch router {
-	case utils.BTC_ROUTER:
-		return btc.NewBTCHandler(), nil
-	case utils.ETH_ROUTER:
-		return eth.NewETHHandler(), nil
-	case utils.ONT_ROUTER:
-		return ont.NewONTHandler(), nil
-	case utils.NEO_ROUTER:
-		return neo.NewNEOHandler(), nil
-	case utils.COSMOS_ROUTER:
-		return cosmos.NewCosmosHandler(), nil
-	case utils.QUORUM_ROUTER:
-		return quorum.NewQuorumHandler(), nil
-	case utils.BSC_ROUTER:
-		return bsc.NewHandler(), nil
-	case utils.HECO_ROUTER:
-		return heco.NewHecoHandler(), nil
-	default:
-		return nil, fmt.Errorf("not a supported router:%d", router)
-	}
+	return hscommon.GetHeaderSyncHandler(router)
 }
 
 func SyncGenesisHeader(native *native.NativeService) ([]byte, error) {
@@ -118,6 +108,11 @@ func SyncBlockHeader(native *native.NativeService) ([]byte, error) {
 		return utils.BYTE_FALSE, fmt.Errorf("SyncBlockHeader, side chain is not registered")
 	}
 
+	//check if relayer is allowed to relay for this chain
+	if err := relayer_manager.CheckRelayer(native, chainID, params.Address[:]); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SyncBlockHeader, relayer_manager.CheckRelayer error: %v", err)
+	}
+
 	handler, err := GetChainHandler(sideChain.Router)
 	if err != nil {
 		return utils.BYTE_FALSE, err
@@ -146,6 +141,11 @@ func SyncCrossChainMsg(native *native.NativeService) ([]byte, error) {
 		return utils.BYTE_FALSE, fmt.Errorf("SyncCrossChainMsg, side chain is not registered")
 	}
 
+	//check if relayer is allowed to relay for this chain
+	if err := relayer_manager.CheckRelayer(native, chainID, params.Address[:]); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SyncCrossChainMsg, relayer_manager.CheckRelayer error: %v", err)
+	}
+
 	handler, err := GetChainHandler(sideChain.Router)
 	if err != nil {
 		return utils.BYTE_FALSE, err
@@ -157,3 +157,51 @@ func SyncCrossChainMsg(native *native.NativeService) ([]byte, error) {
 	}
 	return utils.BYTE_TRUE, nil
 }
+
+// SubmitMisbehaviour freezes the chain if the conflicting headers in evidence are both valid
+func SubmitMisbehaviour(native *native.NativeService) ([]byte, error) {
+	params := new(hscommon.MisbehaviourParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SubmitMisbehaviour, contract params deserialize error: %v", err)
+	}
+	chainID := params.ChainID
+
+	//check if chainid exist
+	sideChain, err := side_chain_manager.GetSideChain(native, chainID)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SubmitMisbehaviour, side_chain_manager.GetSideChain error: %v", err)
+	}
+	if sideChain == nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SubmitMisbehaviour, side chain is not registered")
+	}
+	blacked, err := cross_chain_manager.CheckIfChainBlacked(native, chainID)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SubmitMisbehaviour, CheckIfChainBlacked error: %v", err)
+	}
+	if blacked {
+		return utils.BYTE_FALSE, fmt.Errorf("SubmitMisbehaviour, side chain %d is already blacked", chainID)
+	}
+
+	//anyone is allowed to submit evidence, check witness of the submitter only
+	if err := utils.ValidateOwner(native, params.Address); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SubmitMisbehaviour, checkWitness error: %v", err)
+	}
+
+	handler, err := GetChainHandler(sideChain.Router)
+	if err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	mh, ok := handler.(hscommon.MisbehaviourHandler)
+	if !ok {
+		return utils.BYTE_FALSE, fmt.Errorf("SubmitMisbehaviour, misbehaviour of router %d is not supported", sideChain.Router)
+	}
+	misbehaviour, err := mh.SubmitMisbehaviour(native)
+	if err != nil {
+		return utils.BYTE_FALSE, err
+	}
+
+	//validators of the chain signed conflicting headers, stop all cross chain txs from it
+	cross_chain_manager.PutBlackChain(native, chainID)
+	hscommon.NotifyMisbehaviour(native, params.Address, misbehaviour)
+	return utils.BYTE_TRUE, nil
+}
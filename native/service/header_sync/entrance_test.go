@@ -0,0 +1,140 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+package header_sync
+
+import (
+	"encoding/json"
+	"testing"
+
+	ocommon "github.com/ontio/ontology/common"
+	otypes "github.com/ontio/ontology/core/types"
+	"github.com/polynetwork/poly/account"
+	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/consensus/vbft/config"
+	"github.com/polynetwork/poly/core/signature"
+	"github.com/polynetwork/poly/core/store/leveldbstore"
+	"github.com/polynetwork/poly/core/store/overlaydb"
+	"github.com/polynetwork/poly/core/types"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/service/cross_chain_manager"
+	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
+	hscommon "github.com/polynetwork/poly/native/service/header_sync/common"
+	"github.com/polynetwork/poly/native/service/header_sync/ont"
+	"github.com/polynetwork/poly/native/service/utils"
+	"github.com/polynetwork/poly/native/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func newNative(args []byte, signer common.Address, db *storage.CacheDB) *native.NativeService {
+	if db == nil {
+		store, _ := leveldbstore.NewMemLevelDBStore()
+		db = storage.NewCacheDB(overlaydb.NewOverlayDB(store))
+	}
+	tx := &types.Transaction{SignedAddr: []common.Address{signer}}
+	ns, _ := native.NewNativeService(db, tx, 0, 0, common.Uint256{0}, 0, args, false)
+	return ns
+}
+
+// newOntHeader returns the ont header of height signed by signers, peers are elected by it if any
+func newOntHeader(t *testing.T, signers []*account.Account, height uint32, root byte, peers []*account.Account) []byte {
+	blkInfo := &vconfig.VbftBlockInfo{}
+	if len(peers) > 0 {
+		blkInfo.NewChainConfig = &vconfig.ChainConfig{}
+		for i, peer := range peers {
+			blkInfo.NewChainConfig.Peers = append(blkInfo.NewChainConfig.Peers,
+				&vconfig.PeerConfig{Index: uint32(i), ID: vconfig.PubkeyID(peer.PublicKey)})
+		}
+	}
+	payload, err := json.Marshal(blkInfo)
+	assert.NoError(t, err)
+	header := &otypes.Header{
+		Height:           height,
+		TransactionsRoot: ocommon.Uint256{root},
+		ConsensusPayload: payload,
+	}
+	hash := header.Hash()
+	for _, signer := range signers {
+		sig, err := signature.Sign(signer, hash[:])
+		assert.NoError(t, err)
+		header.Bookkeepers = append(header.Bookkeepers, signer.PublicKey)
+		header.SigData = append(header.SigData, sig)
+	}
+	sink := ocommon.NewZeroCopySink(nil)
+	header.Serialization(sink)
+	return sink.Bytes()
+}
+
+func TestSubmitMisbehaviour(t *testing.T) {
+	peers := make([]*account.Account, 4)
+	for i := range peers {
+		peers[i] = account.NewAccount("")
+	}
+	submitter := account.NewAccount("")
+	ns := newNative(nil, submitter.Address, nil)
+	assert.NoError(t, side_chain_manager.PutSideChain(ns, &side_chain_manager.SideChain{
+		ChainId: 3,
+		Router:  utils.ONT_ROUTER,
+		Name:    "ont",
+	}))
+	genesis, err := otypes.HeaderFromRawBytes(newOntHeader(t, nil, 100, 0, peers))
+	assert.NoError(t, err)
+	assert.NoError(t, ont.UpdateConsensusPeer(ns, 3, genesis))
+
+	submit := func(header1, header2 []byte, signer common.Address) (*native.NativeService, error) {
+		param := &hscommon.MisbehaviourParam{
+			ChainID: 3,
+			Address: submitter.Address,
+			Header1: header1,
+			Header2: header2,
+		}
+		sink := common.NewZeroCopySink(nil)
+		param.Serialization(sink)
+		ns := newNative(sink.Bytes(), signer, ns.GetCacheDB())
+		_, err := SubmitMisbehaviour(ns)
+		return ns, err
+	}
+	header1 := newOntHeader(t, peers[:3], 120, 1, nil)
+	header2 := newOntHeader(t, peers[1:], 120, 2, nil)
+
+	// evidence not witnessed by the submitter
+	_, err = submit(header1, header2, peers[0].Address)
+	assert.Error(t, err)
+	// headers which are not conflicting
+	_, err = submit(header1, header1, submitter.Address)
+	assert.Error(t, err)
+	blacked, err := cross_chain_manager.CheckIfChainBlacked(ns, 3)
+	assert.NoError(t, err)
+	assert.False(t, blacked)
+
+	submitted, err := submit(header1, header2, submitter.Address)
+	assert.NoError(t, err)
+	blacked, err = cross_chain_manager.CheckIfChainBlacked(ns, 3)
+	assert.NoError(t, err)
+	assert.True(t, blacked)
+	notifies := submitted.GetNotify()
+	assert.Equal(t, 1, len(notifies))
+	assert.Equal(t, utils.HeaderSyncContractAddress, notifies[0].ContractAddress)
+	states := notifies[0].States.([]interface{})
+	assert.Equal(t, hscommon.MISBEHAVIOUR_NAME, states[0])
+	assert.Equal(t, uint64(3), states[1])
+	assert.Equal(t, uint64(120), states[2])
+	assert.Equal(t, submitter.Address.ToBase58(), states[5])
+
+	// the chain is blacked already
+	_, err = submit(header1, newOntHeader(t, peers[:3], 120, 3, nil), submitter.Address)
+	assert.Error(t, err)
+}
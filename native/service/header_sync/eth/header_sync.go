@@ -54,6 +54,10 @@ var (
 type ETHHandler struct {
 }
 
+func init() {
+	scom.RegisterHeaderSyncHandler(utils.ETH_ROUTER, "eth", func() scom.HeaderSyncHandler { return NewETHHandler() })
+}
+
 func NewETHHandler() *ETHHandler {
 	return &ETHHandler{}
 }
@@ -191,11 +195,28 @@ func (this *ETHHandler) SyncBlockHeader(native *native.NativeService) error {
 		} else {
 			//
 			if hederDifficultySum.Cmp(currentDifficultySum) > 0 {
-				RestructChain(native, currentHeader, &header, headerParams.ChainID)
+				if err := RestructChain(native, currentHeader, &header, headerParams.ChainID); err != nil {
+					return fmt.Errorf("SyncBlockHeader, restruct chain error: %v, header: %s", err, string(v))
+				}
 			}
 		}
 	}
 	caches.deleteCaches()
+	// prune headers out of the retention window
+	currentHeight, err := GetCurrentHeaderHeight(native, headerParams.ChainID)
+	if err != nil {
+		return fmt.Errorf("SyncBlockHeader, get current height error: %v", err)
+	}
+	genesisHeight, err := getGenesisHeight(native, headerParams.ChainID)
+	if err != nil {
+		return fmt.Errorf("SyncBlockHeader, get genesis height error: %v", err)
+	}
+	err = scom.PruneHeaders(native, headerParams.ChainID, genesisHeight+1, currentHeight, func(height uint64) error {
+		return pruneHeader(native, height, headerParams.ChainID)
+	})
+	if err != nil {
+		return fmt.Errorf("SyncBlockHeader, %v", err)
+	}
 	return nil
 }
 
@@ -176,7 +176,48 @@ func IsHeaderExist(native *native.NativeService, hash []byte, chainID uint64) (b
 		return true, nil
 	}
 }
+func getGenesisHeight(native *native.NativeService, chainID uint64) (uint64, error) {
+	headerStore, err := native.GetCacheDB().Get(utils.ConcatKey(utils.HeaderSyncContractAddress,
+		[]byte(scom.GENESIS_HEADER), utils.GetUint64Bytes(chainID)))
+	if err != nil {
+		return 0, fmt.Errorf("getGenesisHeight, get genesis header store error: %v", err)
+	}
+	if headerStore == nil {
+		return 0, fmt.Errorf("getGenesisHeight, genesis header not found")
+	}
+	storeBytes, err := cstates.GetValueFromRawStorageItem(headerStore)
+	if err != nil {
+		return 0, fmt.Errorf("getGenesisHeight, deserialize headerBytes from raw storage item err:%v", err)
+	}
+	var headerWithDifficultySum HeaderWithDifficultySum
+	if err := json.Unmarshal(storeBytes, &headerWithDifficultySum); err != nil {
+		return 0, fmt.Errorf("getGenesisHeight, deserialize header error: %v", err)
+	}
+	return headerWithDifficultySum.Header.Number.Uint64(), nil
+}
+
+// pruneHeader deletes the main chain header at height, headers of forks are left untouched
+func pruneHeader(native *native.NativeService, height, chainID uint64) error {
+	contract := utils.HeaderSyncContractAddress
+	mainKey := utils.ConcatKey(contract, []byte(scom.MAIN_CHAIN), utils.GetUint64Bytes(chainID), utils.GetUint64Bytes(height))
+	hashStore, err := native.GetCacheDB().Get(mainKey)
+	if err != nil {
+		return fmt.Errorf("pruneHeader, get blockHashStore error: %v", err)
+	}
+	if hashStore == nil {
+		return nil
+	}
+	hashBytes, err := cstates.GetValueFromRawStorageItem(hashStore)
+	if err != nil {
+		return fmt.Errorf("pruneHeader, deserialize hashBytes from raw storage item err:%v", err)
+	}
+	native.GetCacheDB().Delete(utils.ConcatKey(contract, []byte(scom.HEADER_INDEX), utils.GetUint64Bytes(chainID), hashBytes))
+	native.GetCacheDB().Delete(mainKey)
+	return nil
+}
+
 func RestructChain(native *native.NativeService, current, new *types.Header, chainID uint64) error {
+	oldTip, newTip := current, new
 	si, ti := current.Number.Uint64(), new.Number.Uint64()
 	var err error
 	if si > ti {
@@ -189,17 +230,19 @@ func RestructChain(native *native.NativeService, current, new *types.Header, cha
 	newHashs := make([]common.Hash, 0)
 	for ti > si {
 		newHashs = append(newHashs, new.Hash())
-		new, _, err = GetHeaderByHash(native, new.ParentHash.Bytes(), chainID)
+		parentHash := new.ParentHash
+		new, _, err = GetHeaderByHash(native, parentHash.Bytes(), chainID)
 		if err != nil {
-			return fmt.Errorf("RestructChain GetHeaderByHash hash:%x error:%s", new.ParentHash.Bytes(), err)
+			return fmt.Errorf("RestructChain GetHeaderByHash hash:%x error:%s", parentHash.Bytes(), err)
 		}
 		ti--
 	}
 	for current.ParentHash != new.ParentHash {
 		newHashs = append(newHashs, new.Hash())
-		new, _, err = GetHeaderByHash(native, new.ParentHash.Bytes(), chainID)
+		parentHash := new.ParentHash
+		new, _, err = GetHeaderByHash(native, parentHash.Bytes(), chainID)
 		if err != nil {
-			return fmt.Errorf("RestructChain GetHeaderByHash hash:%x  error:%s", new.ParentHash.Bytes(), err)
+			return fmt.Errorf("RestructChain GetHeaderByHash hash:%x  error:%s", parentHash.Bytes(), err)
 		}
 		ti--
 		si--
@@ -209,10 +252,25 @@ func RestructChain(native *native.NativeService, current, new *types.Header, cha
 		}
 	}
 	newHashs = append(newHashs, new.Hash())
+	forkPoint, forkHeight := new.ParentHash, ti-1
+	if current.Hash() == new.Hash() {
+		forkPoint, forkHeight = new.Hash(), ti
+	}
 	for i := len(newHashs) - 1; i >= 0; i-- {
 		appendHeader2Main(native, ti, newHashs[i], chainID)
 		ti++
 	}
+	err = scom.PutChainReorg(native, chainID, &scom.ChainReorg{
+		OldTip:     oldTip.Hash().Bytes(),
+		OldHeight:  oldTip.Number.Uint64(),
+		NewTip:     newTip.Hash().Bytes(),
+		NewHeight:  newTip.Number.Uint64(),
+		ForkPoint:  forkPoint.Bytes(),
+		ForkHeight: forkHeight,
+	})
+	if err != nil {
+		return fmt.Errorf("RestructChain PutChainReorg error:%s", err)
+	}
 	return nil
 }
 
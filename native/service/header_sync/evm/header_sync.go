@@ -0,0 +1,150 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package evm
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/service/header_sync/bsc"
+	scom "github.com/polynetwork/poly/native/service/header_sync/common"
+	"github.com/polynetwork/poly/native/service/header_sync/eth"
+	"github.com/polynetwork/poly/native/service/header_sync/heco"
+	"github.com/polynetwork/poly/native/service/header_sync/quorum"
+	"github.com/polynetwork/poly/native/service/utils"
+)
+
+const (
+	extraVanity = 32                     // Fixed number of extra-data prefix bytes reserved for signer vanity
+	extraSeal   = crypto.SignatureLength // Fixed number of extra-data suffix bytes reserved for signer seal
+)
+
+// Handler dispatches header sync of evm compatible chains to the existing eth, bsc, heco
+// and quorum engines by the consensus in the ExtraInfo of the side chain, it adds no
+// verification of its own except the epoch checks of parlia and congress headers.
+// Clique is not supported.
+type Handler struct {
+}
+
+func init() {
+	scom.RegisterHeaderSyncHandler(utils.EVM_ROUTER, "evm", func() scom.HeaderSyncHandler { return NewHandler() })
+}
+
+// NewHandler ...
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// GetConsensusHandler returns the handler verifying headers of consensus
+func GetConsensusHandler(consensus string) (scom.HeaderSyncHandler, error) {
+	switch consensus {
+	case CONSENSUS_ETHASH:
+		return eth.NewETHHandler(), nil
+	case CONSENSUS_PARLIA:
+		return bsc.NewHandler(), nil
+	case CONSENSUS_CONGRESS:
+		return heco.NewHecoHandler(), nil
+	case CONSENSUS_ISTANBUL:
+		return quorum.NewQuorumHandler(), nil
+	default:
+		return nil, fmt.Errorf("not a supported consensus: %s", consensus)
+	}
+}
+
+// SyncGenesisHeader ...
+func (h *Handler) SyncGenesisHeader(native *native.NativeService) error {
+	params := new(scom.SyncGenesisHeaderParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return fmt.Errorf("evm Handler SyncGenesisHeader, contract params deserialize error: %v", err)
+	}
+	handler, extraInfo, err := getHandler(native, params.ChainID)
+	if err != nil {
+		return fmt.Errorf("evm Handler SyncGenesisHeader, %v", err)
+	}
+	switch engine := handler.(type) {
+	case *bsc.Handler:
+		return engine.SyncGenesisHeaderWithExtraInfo(native, extraInfo.BscExtraInfo())
+	case *heco.Handler:
+		return engine.SyncGenesisHeaderWithExtraInfo(native, extraInfo.HecoExtraInfo())
+	}
+	return handler.SyncGenesisHeader(native)
+}
+
+// SyncBlockHeader ...
+func (h *Handler) SyncBlockHeader(native *native.NativeService) error {
+	params := new(scom.SyncBlockHeaderParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return fmt.Errorf("evm Handler SyncBlockHeader, contract params deserialize error: %v", err)
+	}
+	handler, extraInfo, err := getHandler(native, params.ChainID)
+	if err != nil {
+		return fmt.Errorf("evm Handler SyncBlockHeader, %v", err)
+	}
+	if err := checkEpoch(params.Headers, extraInfo); err != nil {
+		return fmt.Errorf("evm Handler SyncBlockHeader, %v", err)
+	}
+	// poa engines take their parameters from the evm ExtraInfo rather than decoding
+	// the side chain ExtraInfo into their own types
+	switch engine := handler.(type) {
+	case *bsc.Handler:
+		return engine.SyncBlockHeaderWithExtraInfo(native, extraInfo.BscExtraInfo())
+	case *heco.Handler:
+		return engine.SyncBlockHeaderWithExtraInfo(native, extraInfo.HecoExtraInfo())
+	}
+	return handler.SyncBlockHeader(native)
+}
+
+// SyncCrossChainMsg ...
+func (h *Handler) SyncCrossChainMsg(native *native.NativeService) error {
+	return nil
+}
+
+func getHandler(native *native.NativeService, chainID uint64) (scom.HeaderSyncHandler, *ExtraInfo, error) {
+	extraInfo, err := GetExtraInfo(native, chainID)
+	if err != nil {
+		return nil, nil, err
+	}
+	handler, err := GetConsensusHandler(extraInfo.Consensus)
+	if err != nil {
+		return nil, nil, err
+	}
+	return handler, extraInfo, nil
+}
+
+// checkEpoch makes sure that poa headers carry the validator list exactly at checkpoints
+func checkEpoch(headers [][]byte, extraInfo *ExtraInfo) error {
+	if extraInfo.Epoch == 0 || (extraInfo.Consensus != CONSENSUS_PARLIA && extraInfo.Consensus != CONSENSUS_CONGRESS) {
+		return nil
+	}
+	for _, v := range headers {
+		var header types.Header
+		if err := json.Unmarshal(v, &header); err != nil {
+			return fmt.Errorf("deserialize header err: %v", err)
+		}
+		checkpoint := header.Number.Uint64()%extraInfo.Epoch == 0
+		hasValidators := len(header.Extra) > extraVanity+extraSeal
+		if checkpoint != hasValidators {
+			return fmt.Errorf("header %d does not match epoch %d, validators in extra: %t", header.Number.Uint64(), extraInfo.Epoch, hasValidators)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package evm
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtraInfoValidate(t *testing.T) {
+	var extraInfo ExtraInfo
+	err := json.Unmarshal([]byte(`{"Consensus":"parlia","ChainID":56,"Epoch":200,"Confirmations":15}`), &extraInfo)
+	assert.NoError(t, err)
+	assert.NoError(t, extraInfo.Validate())
+	assert.Equal(t, uint64(200), extraInfo.Epoch)
+	assert.Equal(t, int64(56), extraInfo.ChainID.Int64())
+	assert.Equal(t, uint64(15), extraInfo.Confirmations)
+
+	assert.Error(t, (&ExtraInfo{Consensus: CONSENSUS_PARLIA}).Validate())
+	assert.Error(t, (&ExtraInfo{Consensus: CONSENSUS_CONGRESS}).Validate())
+	assert.NoError(t, (&ExtraInfo{Consensus: CONSENSUS_CONGRESS, Period: 3}).Validate())
+	assert.NoError(t, (&ExtraInfo{Consensus: CONSENSUS_ETHASH}).Validate())
+	assert.Error(t, (&ExtraInfo{Consensus: "unknown"}).Validate())
+	err = (&ExtraInfo{Consensus: CONSENSUS_CLIQUE}).Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not supported")
+}
+
+func TestExtraInfoEngines(t *testing.T) {
+	extraInfo := &ExtraInfo{Consensus: CONSENSUS_CONGRESS, ChainID: big.NewInt(128), Period: 3, Epoch: 200}
+	assert.Equal(t, big.NewInt(128), extraInfo.BscExtraInfo().ChainID)
+	assert.Equal(t, uint64(200), extraInfo.BscExtraInfo().Epoch)
+	hecoExtraInfo := extraInfo.HecoExtraInfo()
+	assert.Equal(t, big.NewInt(128), hecoExtraInfo.ChainID)
+	assert.Equal(t, uint64(3), hecoExtraInfo.Period)
+	assert.Equal(t, uint64(200), hecoExtraInfo.Epoch)
+
+	raw, err := json.Marshal(&ExtraInfo{Consensus: CONSENSUS_ISTANBUL})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"Consensus":"istanbul"}`, string(raw))
+}
+
+func TestCheckEpoch(t *testing.T) {
+	header := func(number int64, validators int) []byte {
+		h := &types.Header{
+			Number:     big.NewInt(number),
+			Difficulty: big.NewInt(2),
+			Extra:      make([]byte, extraVanity+validators*20+extraSeal),
+		}
+		raw, _ := json.Marshal(h)
+		return raw
+	}
+	extraInfo := &ExtraInfo{Consensus: CONSENSUS_PARLIA, ChainID: big.NewInt(56), Epoch: 200}
+
+	assert.NoError(t, checkEpoch([][]byte{header(400, 3), header(401, 0)}, extraInfo))
+	assert.Error(t, checkEpoch([][]byte{header(400, 0)}, extraInfo))
+	assert.Error(t, checkEpoch([][]byte{header(401, 3)}, extraInfo))
+
+	extraInfo.Epoch = 0
+	assert.NoError(t, checkEpoch([][]byte{header(401, 3)}, extraInfo))
+}
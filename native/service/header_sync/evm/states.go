@@ -0,0 +1,99 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package evm
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
+	"github.com/polynetwork/poly/native/service/header_sync/bsc"
+	"github.com/polynetwork/poly/native/service/header_sync/heco"
+)
+
+const (
+	// consensus engines supported by evm router
+	CONSENSUS_ETHASH   = "ethash"   // proof of work, verified like ethereum
+	CONSENSUS_PARLIA   = "parlia"   // proof of staked authority, verified like bsc
+	CONSENSUS_CONGRESS = "congress" // proof of staked authority, verified like heco
+	CONSENSUS_ISTANBUL = "istanbul" // istanbul bft, verified like quorum
+	// clique is not supported, its signer set changes by votes between checkpoints which can
+	// not be followed from the validator lists of checkpoint headers
+	CONSENSUS_CLIQUE = "clique"
+)
+
+// ExtraInfo is the json encoded SideChain.ExtraInfo of a chain using evm router, e.g.
+// {"Consensus":"parlia","ChainID":56,"Epoch":200,"Confirmations":15}
+type ExtraInfo struct {
+	Consensus     string   `json:"Consensus"`
+	ChainID       *big.Int `json:"ChainID,omitempty"`       // chain id used in the seal hash of parlia
+	Period        uint64   `json:"Period,omitempty"`        // minimum seconds between two blocks of congress
+	Epoch         uint64   `json:"Epoch,omitempty"`         // blocks between two checkpoints of parlia and congress, 0 to skip the check
+	Confirmations uint64   `json:"Confirmations,omitempty"` // blocks to wait if more than SideChain.BlocksToWait, 0 to use BlocksToWait only
+}
+
+// BscExtraInfo returns the parameters of the parlia engine
+func (this *ExtraInfo) BscExtraInfo() bsc.ExtraInfo {
+	return bsc.ExtraInfo{ChainID: this.ChainID, Epoch: this.Epoch}
+}
+
+// HecoExtraInfo returns the parameters of the congress engine
+func (this *ExtraInfo) HecoExtraInfo() heco.ExtraInfo {
+	return heco.ExtraInfo{ChainID: this.ChainID, Period: this.Period, Epoch: this.Epoch}
+}
+
+// Validate checks if the fields needed by the consensus engine are set
+func (this *ExtraInfo) Validate() error {
+	switch this.Consensus {
+	case CONSENSUS_ETHASH, CONSENSUS_ISTANBUL:
+	case CONSENSUS_PARLIA:
+		if this.ChainID == nil || this.ChainID.Sign() <= 0 {
+			return fmt.Errorf("ChainID is required by %s", this.Consensus)
+		}
+	case CONSENSUS_CONGRESS:
+		if this.Period == 0 {
+			return fmt.Errorf("Period is required by %s", this.Consensus)
+		}
+	case CONSENSUS_CLIQUE:
+		return fmt.Errorf("consensus %s is not supported, use %s or %s for poa chains", this.Consensus, CONSENSUS_PARLIA, CONSENSUS_CONGRESS)
+	default:
+		return fmt.Errorf("not a supported consensus: %s", this.Consensus)
+	}
+	return nil
+}
+
+// GetExtraInfo returns the validated ExtraInfo of side chain chainID
+func GetExtraInfo(native *native.NativeService, chainID uint64) (*ExtraInfo, error) {
+	sideChain, err := side_chain_manager.GetSideChain(native, chainID)
+	if err != nil {
+		return nil, fmt.Errorf("GetExtraInfo, side_chain_manager.GetSideChain error: %v", err)
+	}
+	if sideChain == nil {
+		return nil, fmt.Errorf("GetExtraInfo, side chain %d is not registered", chainID)
+	}
+	extraInfo := new(ExtraInfo)
+	if err := json.Unmarshal(sideChain.ExtraInfo, extraInfo); err != nil {
+		return nil, fmt.Errorf("GetExtraInfo, unmarshal ExtraInfo error: %v", err)
+	}
+	if err := extraInfo.Validate(); err != nil {
+		return nil, fmt.Errorf("GetExtraInfo, invalid ExtraInfo of chain %d: %v", chainID, err)
+	}
+	return extraInfo, nil
+}
@@ -46,6 +46,10 @@ var TestFlagNoCheckHecoHeaderSig bool
 type Handler struct {
 }
 
+func init() {
+	scom.RegisterHeaderSyncHandler(utils.HECO_ROUTER, "heco", func() scom.HeaderSyncHandler { return NewHecoHandler() })
+}
+
 // NewHandler ...
 func NewHecoHandler() *Handler {
 	return &Handler{}
@@ -63,6 +67,20 @@ func (h *Handler) SyncGenesisHeader(native *native.NativeService) (err error) {
 	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
 		return fmt.Errorf("heco Handler SyncGenesisHeader, contract params deserialize error: %v", err)
 	}
+	return h.syncGenesisHeader(native, params, ExtraInfo{})
+}
+
+// SyncGenesisHeaderWithExtraInfo syncs genesis header with extraInfo given by the caller, the
+// genesis header must be a checkpoint if the epoch is set
+func (h *Handler) SyncGenesisHeaderWithExtraInfo(native *native.NativeService, extraInfo ExtraInfo) error {
+	params := new(scom.SyncGenesisHeaderParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return fmt.Errorf("heco Handler SyncGenesisHeader, contract params deserialize error: %v", err)
+	}
+	return h.syncGenesisHeader(native, params, extraInfo)
+}
+
+func (h *Handler) syncGenesisHeader(native *native.NativeService, params *scom.SyncGenesisHeaderParam, extraInfo ExtraInfo) (err error) {
 	// Get current epoch operator
 	operatorAddress, err := node_manager.GetCurConOperator(native)
 	if err != nil {
@@ -94,6 +112,10 @@ func (h *Handler) SyncGenesisHeader(native *native.NativeService) (err error) {
 	if signersBytes == 0 || signersBytes%ecommon.AddressLength != 0 {
 		return fmt.Errorf("invalid signer list, signersBytes:%d", signersBytes)
 	}
+	if extraInfo.Epoch != 0 && genesis.Header.Number.Uint64()%extraInfo.Epoch != 0 {
+		return fmt.Errorf("heco Handler SyncGenesisHeader, genesis header %d is not a checkpoint of epoch %d",
+			genesis.Header.Number.Uint64(), extraInfo.Epoch)
+	}
 
 	if len(genesis.PrevValidators) != 1 {
 		return fmt.Errorf("invalid PrevValidators")
@@ -186,6 +208,7 @@ func storeGenesis(native *native.NativeService, params *scom.SyncGenesisHeaderPa
 type ExtraInfo struct {
 	ChainID *big.Int // chainId of heco chain, testnet: 256, mainnet: 128
 	Period  uint64
+	Epoch   uint64 // blocks between two checkpoints, 0 to skip the check of genesis
 }
 
 // Context ...
@@ -229,6 +252,20 @@ func (h *Handler) SyncBlockHeader(native *native.NativeService) error {
 		return fmt.Errorf("heco Handler SyncBlockHeader, ExtraInfo Unmarshal error: %v", err)
 	}
 
+	return h.syncBlockHeader(native, headerParams, extraInfo)
+}
+
+// SyncBlockHeaderWithExtraInfo verifies headers with extraInfo given by the caller instead of
+// the one decoded from the side chain
+func (h *Handler) SyncBlockHeaderWithExtraInfo(native *native.NativeService, extraInfo ExtraInfo) error {
+	headerParams := new(scom.SyncBlockHeaderParam)
+	if err := headerParams.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return fmt.Errorf("heco Handler SyncBlockHeader, contract params deserialize error: %v", err)
+	}
+	return h.syncBlockHeader(native, headerParams, extraInfo)
+}
+
+func (h *Handler) syncBlockHeader(native *native.NativeService, headerParams *scom.SyncBlockHeaderParam, extraInfo ExtraInfo) (err error) {
 	ctx := &Context{ExtraInfo: extraInfo, ChainID: headerParams.ChainID}
 
 	for _, v := range headerParams.Headers {
@@ -319,6 +356,43 @@ func (h *Handler) SyncBlockHeader(native *native.NativeService) error {
 
 		scom.NotifyPutHeader(native, headerParams.ChainID, header.Number.Uint64(), header.Hash().Hex())
 	}
+
+	// prune headers out of the retention window
+	err = pruneHeaders(native, headerParams.ChainID)
+	if err != nil {
+		return fmt.Errorf("heco Handler SyncBlockHeader, pruneHeaders err: %v", err)
+	}
+	return nil
+}
+
+func pruneHeaders(native *native.NativeService, chainID uint64) error {
+	genesis, err := getGenesis(native, chainID)
+	if err != nil {
+		return fmt.Errorf("heco Handler getGenesis error: %v", err)
+	}
+	if genesis == nil {
+		return fmt.Errorf("heco Handler genesis not set")
+	}
+	height, err := GetCanonicalHeight(native, chainID)
+	if err != nil {
+		return err
+	}
+	return scom.PruneHeaders(native, chainID, genesis.Header.Number.Uint64()+1, height, func(h uint64) error {
+		return pruneHeader(native, chainID, h)
+	})
+}
+
+// pruneHeader deletes the canonical header at height, headers of forks are left untouched
+func pruneHeader(native *native.NativeService, chainID uint64, height uint64) error {
+	hash, err := getCanonicalHash(native, chainID, height)
+	if err != nil {
+		return err
+	}
+	if hash == (ecommon.Hash{}) {
+		return nil
+	}
+	native.GetCacheDB().Delete(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(scom.HEADER_INDEX), utils.GetUint64Bytes(chainID), hash.Bytes()))
+	deleteCanonicalHash(native, chainID, height)
 	return nil
 }
 
@@ -491,6 +565,21 @@ func addHeader(native *native.NativeService, header *types.Header, phv *HeightAn
 		// Extend the canonical chain with the new header
 		putCanonicalHash(native, ctx.ChainID, header.Number.Uint64(), header.Hash())
 		putCanonicalHeight(native, ctx.ChainID, header.Number.Uint64())
+
+		// Record the reorg if the old head is no longer canonical
+		if header.ParentHash != cheader.Header.Hash() {
+			err = scom.PutChainReorg(native, ctx.ChainID, &scom.ChainReorg{
+				OldTip:     cheader.Header.Hash().Bytes(),
+				OldHeight:  cheader.Header.Number.Uint64(),
+				NewTip:     header.Hash().Bytes(),
+				NewHeight:  header.Number.Uint64(),
+				ForkPoint:  headHash.Bytes(),
+				ForkHeight: cheight,
+			})
+			if err != nil {
+				return
+			}
+		}
 	}
 
 	return nil
@@ -30,6 +30,10 @@ import (
 type NEOHandler struct {
 }
 
+func init() {
+	hscommon.RegisterHeaderSyncHandler(utils.NEO_ROUTER, "neo", func() hscommon.HeaderSyncHandler { return NewNEOHandler() })
+}
+
 func NewNEOHandler() *NEOHandler {
 	return &NEOHandler{}
 }
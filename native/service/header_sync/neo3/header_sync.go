@@ -0,0 +1,114 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+package neo3
+
+import (
+	"fmt"
+	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/service/governance/node_manager"
+	hscommon "github.com/polynetwork/poly/native/service/header_sync/common"
+	"github.com/polynetwork/poly/native/service/utils"
+)
+
+type NEO3Handler struct {
+}
+
+func init() {
+	hscommon.RegisterHeaderSyncHandler(utils.NEO3_ROUTER, "neo3", func() hscommon.HeaderSyncHandler { return NewNEO3Handler() })
+}
+
+func NewNEO3Handler() *NEO3Handler {
+	return &NEO3Handler{}
+}
+
+func (this *NEO3Handler) SyncGenesisHeader(native *native.NativeService) error {
+	params := new(hscommon.SyncGenesisHeaderParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return fmt.Errorf("Neo3Handler SyncGenesisHeader, contract params deserialize error: %v", err)
+	}
+	// Get current epoch operator
+	operatorAddress, err := node_manager.GetCurConOperator(native)
+	if err != nil {
+		return fmt.Errorf("Neo3Handler SyncGenesisHeader, get current consensus operator address error: %v", err)
+	}
+	//check witness
+	err = utils.ValidateOwner(native, operatorAddress)
+	if err != nil {
+		return fmt.Errorf("Neo3Handler SyncGenesisHeader, checkWitness error: %v", err)
+	}
+	if _, err := GetExtraInfo(native, params.ChainID); err != nil {
+		return fmt.Errorf("Neo3Handler SyncGenesisHeader, %v", err)
+	}
+	// Deserialize neo3 block header
+	header := new(Neo3BlockHeader)
+	if err := header.Deserialization(common.NewZeroCopySource(params.GenesisHeader)); err != nil {
+		return fmt.Errorf("Neo3Handler SyncGenesisHeader, deserialize header err: %v", err)
+	}
+	if neo3Consensus, _ := getConsensusValByChainId(native, params.ChainID); neo3Consensus == nil {
+		// Put Neo3Consensus.NextConsensus into storage
+		putConsensusValByChainId(native, &Neo3Consensus{
+			ChainID:       params.ChainID,
+			Height:        header.Index,
+			NextConsensus: header.NextConsensus,
+		})
+	}
+	return nil
+}
+
+func (this *NEO3Handler) SyncBlockHeader(native *native.NativeService) error {
+	params := new(hscommon.SyncBlockHeaderParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return fmt.Errorf("Neo3Handler SyncBlockHeader, contract params deserialize error: %v", err)
+	}
+	extraInfo, err := GetExtraInfo(native, params.ChainID)
+	if err != nil {
+		return fmt.Errorf("Neo3Handler SyncBlockHeader, %v", err)
+	}
+	neo3Consensus, err := getConsensusValByChainId(native, params.ChainID)
+	if err != nil {
+		return fmt.Errorf("Neo3Handler SyncBlockHeader, the consensus validator has not been initialized, chainId: %d", params.ChainID)
+	}
+	updated := false
+	for i, v := range params.Headers {
+		header := new(Neo3BlockHeader)
+		if err := header.Deserialization(common.NewZeroCopySource(v)); err != nil {
+			return fmt.Errorf("Neo3Handler SyncBlockHeader, deserialize No.%d header error: %v", i, err)
+		}
+		// only the headers switching consensus are needed
+		if header.NextConsensus.Equals(neo3Consensus.NextConsensus) || header.Index <= neo3Consensus.Height {
+			continue
+		}
+		if err = verifyHeader(neo3Consensus, header, extraInfo.Magic); err != nil {
+			return fmt.Errorf("Neo3Handler SyncBlockHeader, verify No.%d header error: %v", i, err)
+		}
+		neo3Consensus = &Neo3Consensus{
+			ChainID:       neo3Consensus.ChainID,
+			Height:        header.Index,
+			NextConsensus: header.NextConsensus,
+		}
+		updated = true
+	}
+	if updated {
+		putConsensusValByChainId(native, neo3Consensus)
+	}
+	return nil
+}
+
+func (this *NEO3Handler) SyncCrossChainMsg(native *native.NativeService) error {
+	return nil
+}
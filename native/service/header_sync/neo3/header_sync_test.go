@@ -0,0 +1,161 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+package neo3
+
+import (
+	"fmt"
+	"github.com/joeqian10/neo-gogogo/wallet/keys"
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/polynetwork/poly/account"
+	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/consensus/vbft/config"
+	"github.com/polynetwork/poly/core/states"
+	"github.com/polynetwork/poly/core/store/leveldbstore"
+	"github.com/polynetwork/poly/core/store/overlaydb"
+	"github.com/polynetwork/poly/core/types"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/service/governance/node_manager"
+	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
+	hscommon "github.com/polynetwork/poly/native/service/header_sync/common"
+	"github.com/polynetwork/poly/native/service/utils"
+	"github.com/polynetwork/poly/native/storage"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const testChainID = 14
+
+var (
+	acct = account.NewAccount("")
+
+	getNativeFunc = func(args []byte, db *storage.CacheDB) *native.NativeService {
+		signAddr, _ := types.AddressFromBookkeepers([]keypair.PublicKey{acct.PublicKey})
+		if db == nil {
+			store, _ := leveldbstore.NewMemLevelDBStore()
+			db = storage.NewCacheDB(overlaydb.NewOverlayDB(store))
+			sink := common.NewZeroCopySink(nil)
+			view := &node_manager.GovernanceView{
+				TxHash: common.UINT256_EMPTY,
+				Height: 0,
+				View:   0,
+			}
+			view.Serialization(sink)
+			db.Put(utils.ConcatKey(utils.NodeManagerContractAddress, []byte(node_manager.GOVERNANCE_VIEW)), states.GenRawStorageItem(sink.Bytes()))
+
+			peerPoolMap := &node_manager.PeerPoolMap{
+				PeerPoolMap: map[string]*node_manager.PeerPoolItem{
+					vconfig.PubkeyID(acct.PublicKey): {
+						Address:    acct.Address,
+						Status:     node_manager.ConsensusStatus,
+						PeerPubkey: vconfig.PubkeyID(acct.PublicKey),
+						Index:      0,
+					},
+				},
+			}
+			sink.Reset()
+			peerPoolMap.Serialization(sink)
+			db.Put(utils.ConcatKey(utils.NodeManagerContractAddress,
+				[]byte(node_manager.PEER_POOL), utils.GetUint32Bytes(0)), states.GenRawStorageItem(sink.Bytes()))
+
+			sc := &side_chain_manager.SideChain{
+				ChainId:      testChainID,
+				Router:       utils.NEO3_ROUTER,
+				BlocksToWait: 1,
+				Address:      signAddr,
+				CCMCAddress:  []byte{0xf8, 0xff, 0xff, 0xff},
+				ExtraInfo:    []byte(fmt.Sprintf(`{"Magic":%d}`, testMagic)),
+			}
+			sink.Reset()
+			_ = sc.Serialization(sink)
+			db.Put(utils.ConcatKey(utils.SideChainManagerContractAddress, []byte(side_chain_manager.SIDE_CHAIN), utils.GetUint64Bytes(testChainID)), states.GenRawStorageItem(sink.Bytes()))
+		}
+
+		ns, _ := native.NewNativeService(db, &types.Transaction{SignedAddr: []common.Address{signAddr}}, 0, 0, common.Uint256{0}, 0, args, false)
+		return ns
+	}
+)
+
+func syncGenesisHeader(t *testing.T, header *Neo3BlockHeader) *native.NativeService {
+	sink := common.NewZeroCopySink(nil)
+	header.Serialization(sink)
+	param := &hscommon.SyncGenesisHeaderParam{ChainID: testChainID, GenesisHeader: sink.Bytes()}
+	sink = common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	ns := getNativeFunc(sink.Bytes(), nil)
+	assert.NoError(t, NewNEO3Handler().SyncGenesisHeader(ns))
+	return ns
+}
+
+func syncBlockHeaders(ns *native.NativeService, headers ...*Neo3BlockHeader) error {
+	param := &hscommon.SyncBlockHeaderParam{ChainID: testChainID}
+	for _, header := range headers {
+		sink := common.NewZeroCopySink(nil)
+		header.Serialization(sink)
+		param.Headers = append(param.Headers, sink.Bytes())
+	}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	return NewNEO3Handler().SyncBlockHeader(getNativeFunc(sink.Bytes(), ns.GetCacheDB()))
+}
+
+func TestSyncGenesisHeader(t *testing.T) {
+	kps := newTestKeys(t, 4)
+	ns := syncGenesisHeader(t, newTestHeader(t, 100, kps, kps))
+	neo3Consensus, err := getConsensusValByChainId(ns, testChainID)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(100), neo3Consensus.Height)
+	assert.Equal(t, scriptHashOf(kps), neo3Consensus.NextConsensus)
+}
+
+func TestSyncBlockHeader(t *testing.T) {
+	kps1, kps2, kps3 := newTestKeys(t, 4), newTestKeys(t, 7), newTestKeys(t, 4)
+	ns := syncGenesisHeader(t, newTestHeader(t, 100, kps1, kps1))
+
+	// headers not switching consensus are skipped
+	assert.NoError(t, syncBlockHeaders(ns, newTestHeader(t, 101, kps2, kps1)))
+	// header signed by other consensus
+	assert.Error(t, syncBlockHeaders(ns, newTestHeader(t, 200, kps2, kps2)))
+	// header signed by the other network
+	header := newTestHeader(t, 200, kps1, kps2)
+	header.Witness = signWitness(t, header.GetSignData(testMagic+1), 3, kps1)
+	assert.Error(t, syncBlockHeaders(ns, header))
+
+	// switch twice in one batch
+	assert.NoError(t, syncBlockHeaders(ns, newTestHeader(t, 200, kps1, kps2), newTestHeader(t, 300, kps2, kps3)))
+	neo3Consensus, err := getConsensusValByChainId(ns, testChainID)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(300), neo3Consensus.Height)
+	assert.Equal(t, scriptHashOf(kps3), neo3Consensus.NextConsensus)
+
+	// lower header switching consensus is ignored
+	assert.NoError(t, syncBlockHeaders(ns, newTestHeader(t, 250, kps2, kps1)))
+	neo3Consensus, err = getConsensusValByChainId(ns, testChainID)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(300), neo3Consensus.Height)
+}
+
+func TestVerifyCrossChainMsgSig(t *testing.T) {
+	kps := newTestKeys(t, 4)
+	ns := syncGenesisHeader(t, newTestHeader(t, 100, kps, kps))
+
+	msg := &Neo3CrossChainMsg{Index: 120}
+	msg.Witness = signWitness(t, msg.GetSignData(testMagic), 3, kps)
+	assert.NoError(t, VerifyCrossChainMsgSig(ns, testChainID, msg))
+
+	msg.Witness = signWitness(t, msg.GetSignData(testMagic), 3, []*keys.KeyPair{kps[0], kps[1], kps[2]})
+	assert.Error(t, VerifyCrossChainMsgSig(ns, testChainID, msg))
+}
@@ -0,0 +1,299 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+package neo3
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"github.com/joeqian10/neo-gogogo/crypto"
+	"github.com/joeqian10/neo-gogogo/helper"
+	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
+)
+
+const (
+	// max length of the scripts in a witness of neo N3
+	MAX_SCRIPT_LENGTH = 1024
+)
+
+// ExtraInfo is the json encoded SideChain.ExtraInfo of a chain using neo3 router, e.g.
+// {"Magic":860833102}
+type ExtraInfo struct {
+	Magic uint32 // network magic, which is part of the message signed by consensus nodes
+}
+
+// GetExtraInfo returns the ExtraInfo of side chain chainID
+func GetExtraInfo(native *native.NativeService, chainID uint64) (*ExtraInfo, error) {
+	sideChain, err := side_chain_manager.GetSideChain(native, chainID)
+	if err != nil {
+		return nil, fmt.Errorf("GetExtraInfo, side_chain_manager.GetSideChain error: %v", err)
+	}
+	if sideChain == nil {
+		return nil, fmt.Errorf("GetExtraInfo, side chain %d is not registered", chainID)
+	}
+	extraInfo := new(ExtraInfo)
+	if err := json.Unmarshal(sideChain.ExtraInfo, extraInfo); err != nil {
+		return nil, fmt.Errorf("GetExtraInfo, unmarshal ExtraInfo error: %v", err)
+	}
+	if extraInfo.Magic == 0 {
+		return nil, fmt.Errorf("GetExtraInfo, network magic of chain %d is not set", chainID)
+	}
+	return extraInfo, nil
+}
+
+type Neo3Consensus struct {
+	ChainID       uint64
+	Height        uint32
+	NextConsensus helper.UInt160
+}
+
+func (this *Neo3Consensus) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteUint64(this.ChainID)
+	sink.WriteUint32(this.Height)
+	sink.WriteVarBytes(this.NextConsensus.Bytes())
+}
+
+func (this *Neo3Consensus) Deserialization(source *common.ZeroCopySource) error {
+	var eof bool
+	if this.ChainID, eof = source.NextUint64(); eof {
+		return fmt.Errorf("Neo3Consensus.Deserialization, ChainID NextUint64 error")
+	}
+	if this.Height, eof = source.NextUint32(); eof {
+		return fmt.Errorf("Neo3Consensus.Deserialization, Height NextUint32 error")
+	}
+	nextConsensusBs, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("Neo3Consensus.Deserialization, NextConsensus NextVarBytes error")
+	}
+	var err error
+	if this.NextConsensus, err = helper.UInt160FromBytes(nextConsensusBs); err != nil {
+		return fmt.Errorf("Neo3Consensus.Deserialization, NextConsensus UInt160FromBytes error:%s", err)
+	}
+	return nil
+}
+
+// Witness is the invocation and verification scripts signing a neo N3 header or state root
+type Witness struct {
+	InvocationScript   []byte
+	VerificationScript []byte
+}
+
+func (this *Witness) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarBytes(this.InvocationScript)
+	sink.WriteVarBytes(this.VerificationScript)
+}
+
+func (this *Witness) Deserialization(source *common.ZeroCopySource) error {
+	var eof bool
+	if this.InvocationScript, eof = source.NextVarBytes(); eof {
+		return fmt.Errorf("Witness.Deserialization, InvocationScript NextVarBytes error")
+	}
+	if len(this.InvocationScript) > MAX_SCRIPT_LENGTH {
+		return fmt.Errorf("Witness.Deserialization, InvocationScript is too long")
+	}
+	if this.VerificationScript, eof = source.NextVarBytes(); eof {
+		return fmt.Errorf("Witness.Deserialization, VerificationScript NextVarBytes error")
+	}
+	if len(this.VerificationScript) > MAX_SCRIPT_LENGTH {
+		return fmt.Errorf("Witness.Deserialization, VerificationScript is too long")
+	}
+	return nil
+}
+
+// GetScriptHash returns the hash160 of the verification script
+func (this *Witness) GetScriptHash() helper.UInt160 {
+	hash, _ := helper.UInt160FromBytes(crypto.Hash160(this.VerificationScript))
+	return hash
+}
+
+// deserializeWitnesses reads the witness array of neo N3 which holds at most one witness
+func deserializeWitnesses(source *common.ZeroCopySource) (*Witness, error) {
+	count, eof := source.NextVarUint()
+	if eof {
+		return nil, fmt.Errorf("witnesses count NextVarUint error")
+	}
+	switch count {
+	case 0:
+		return nil, nil
+	case 1:
+		witness := new(Witness)
+		if err := witness.Deserialization(source); err != nil {
+			return nil, err
+		}
+		return witness, nil
+	default:
+		return nil, fmt.Errorf("expect one witness, got %d", count)
+	}
+}
+
+func serializeWitnesses(sink *common.ZeroCopySink, witness *Witness) {
+	if witness == nil {
+		sink.WriteVarUint(0)
+		return
+	}
+	sink.WriteVarUint(1)
+	witness.Serialization(sink)
+}
+
+// getSignData returns the message signed by witness of neo N3, which is the network
+// magic followed by the hash of unsigned data
+func getSignData(magic uint32, unsigned []byte) []byte {
+	hash := sha256.Sum256(unsigned)
+	data := make([]byte, 4, 4+len(hash))
+	binary.LittleEndian.PutUint32(data, magic)
+	return append(data, hash[:]...)
+}
+
+type Neo3BlockHeader struct {
+	Version       uint32
+	PrevHash      helper.UInt256
+	MerkleRoot    helper.UInt256
+	Timestamp     uint64
+	Nonce         uint64
+	Index         uint32
+	PrimaryIndex  byte
+	NextConsensus helper.UInt160
+	Witness       *Witness
+}
+
+func (this *Neo3BlockHeader) serializeUnsigned(sink *common.ZeroCopySink) {
+	sink.WriteUint32(this.Version)
+	sink.WriteBytes(this.PrevHash.Bytes())
+	sink.WriteBytes(this.MerkleRoot.Bytes())
+	sink.WriteUint64(this.Timestamp)
+	sink.WriteUint64(this.Nonce)
+	sink.WriteUint32(this.Index)
+	sink.WriteByte(this.PrimaryIndex)
+	sink.WriteBytes(this.NextConsensus.Bytes())
+}
+
+func (this *Neo3BlockHeader) Serialization(sink *common.ZeroCopySink) {
+	this.serializeUnsigned(sink)
+	serializeWitnesses(sink, this.Witness)
+}
+
+func (this *Neo3BlockHeader) Deserialization(source *common.ZeroCopySource) error {
+	var eof bool
+	if this.Version, eof = source.NextUint32(); eof {
+		return fmt.Errorf("Neo3BlockHeader.Deserialization, Version NextUint32 error")
+	}
+	prevHash, eof := source.NextBytes(32)
+	if eof {
+		return fmt.Errorf("Neo3BlockHeader.Deserialization, PrevHash NextBytes error")
+	}
+	this.PrevHash, _ = helper.UInt256FromBytes(prevHash)
+	merkleRoot, eof := source.NextBytes(32)
+	if eof {
+		return fmt.Errorf("Neo3BlockHeader.Deserialization, MerkleRoot NextBytes error")
+	}
+	this.MerkleRoot, _ = helper.UInt256FromBytes(merkleRoot)
+	if this.Timestamp, eof = source.NextUint64(); eof {
+		return fmt.Errorf("Neo3BlockHeader.Deserialization, Timestamp NextUint64 error")
+	}
+	if this.Nonce, eof = source.NextUint64(); eof {
+		return fmt.Errorf("Neo3BlockHeader.Deserialization, Nonce NextUint64 error")
+	}
+	if this.Index, eof = source.NextUint32(); eof {
+		return fmt.Errorf("Neo3BlockHeader.Deserialization, Index NextUint32 error")
+	}
+	if this.PrimaryIndex, eof = source.NextByte(); eof {
+		return fmt.Errorf("Neo3BlockHeader.Deserialization, PrimaryIndex NextByte error")
+	}
+	nextConsensus, eof := source.NextBytes(20)
+	if eof {
+		return fmt.Errorf("Neo3BlockHeader.Deserialization, NextConsensus NextBytes error")
+	}
+	this.NextConsensus, _ = helper.UInt160FromBytes(nextConsensus)
+	witness, err := deserializeWitnesses(source)
+	if err != nil {
+		return fmt.Errorf("Neo3BlockHeader.Deserialization, %v", err)
+	}
+	if witness == nil {
+		return fmt.Errorf("Neo3BlockHeader.Deserialization, header is not signed")
+	}
+	this.Witness = witness
+	return nil
+}
+
+// Hash returns the sha256 of unsigned header
+func (this *Neo3BlockHeader) Hash() helper.UInt256 {
+	sink := common.NewZeroCopySink(nil)
+	this.serializeUnsigned(sink)
+	hash, _ := helper.UInt256FromBytes(crypto.Sha256(sink.Bytes()))
+	return hash
+}
+
+// GetSignData returns the message signed by consensus nodes of network magic
+func (this *Neo3BlockHeader) GetSignData(magic uint32) []byte {
+	sink := common.NewZeroCopySink(nil)
+	this.serializeUnsigned(sink)
+	return getSignData(magic, sink.Bytes())
+}
+
+// Neo3CrossChainMsg is the state root of neo N3, of which the RootHash is the root of
+// the MPT proof of cross chain transactions
+type Neo3CrossChainMsg struct {
+	Version  byte
+	Index    uint32
+	RootHash helper.UInt256
+	Witness  *Witness
+}
+
+func (this *Neo3CrossChainMsg) serializeUnsigned(sink *common.ZeroCopySink) {
+	sink.WriteByte(this.Version)
+	sink.WriteUint32(this.Index)
+	sink.WriteBytes(this.RootHash.Bytes())
+}
+
+func (this *Neo3CrossChainMsg) Serialization(sink *common.ZeroCopySink) {
+	this.serializeUnsigned(sink)
+	serializeWitnesses(sink, this.Witness)
+}
+
+func (this *Neo3CrossChainMsg) Deserialization(source *common.ZeroCopySource) error {
+	var eof bool
+	if this.Version, eof = source.NextByte(); eof {
+		return fmt.Errorf("Neo3CrossChainMsg.Deserialization, Version NextByte error")
+	}
+	if this.Index, eof = source.NextUint32(); eof {
+		return fmt.Errorf("Neo3CrossChainMsg.Deserialization, Index NextUint32 error")
+	}
+	rootHash, eof := source.NextBytes(32)
+	if eof {
+		return fmt.Errorf("Neo3CrossChainMsg.Deserialization, RootHash NextBytes error")
+	}
+	this.RootHash, _ = helper.UInt256FromBytes(rootHash)
+	witness, err := deserializeWitnesses(source)
+	if err != nil {
+		return fmt.Errorf("Neo3CrossChainMsg.Deserialization, %v", err)
+	}
+	if witness == nil {
+		return fmt.Errorf("Neo3CrossChainMsg.Deserialization, state root is not signed")
+	}
+	this.Witness = witness
+	return nil
+}
+
+// GetSignData returns the message signed by state validators of network magic
+func (this *Neo3CrossChainMsg) GetSignData(magic uint32) []byte {
+	sink := common.NewZeroCopySink(nil)
+	this.serializeUnsigned(sink)
+	return getSignData(magic, sink.Bytes())
+}
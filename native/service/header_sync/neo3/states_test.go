@@ -0,0 +1,159 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+package neo3
+
+import (
+	"github.com/joeqian10/neo-gogogo/helper"
+	"github.com/joeqian10/neo-gogogo/wallet/keys"
+	"github.com/polynetwork/poly/common"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const testMagic = 860833102
+
+func newTestKeys(t *testing.T, n int) []*keys.KeyPair {
+	kps := make([]*keys.KeyPair, n)
+	for i := range kps {
+		kp, err := keys.GenerateKeyPair()
+		assert.NoError(t, err)
+		kps[i] = kp
+	}
+	return kps
+}
+
+// createMultiSigScript builds the verification script of neo N3 for m of kps
+func createMultiSigScript(m int, kps []*keys.KeyPair) []byte {
+	script := []byte{byte(PUSH1 + m - 1)}
+	for _, kp := range kps {
+		script = append(script, PUSHDATA1, 33)
+		script = append(script, kp.PublicKey.EncodeCompression()...)
+	}
+	script = append(script, byte(PUSH1+len(kps)-1), SYSCALL)
+	return append(script, checkMultisigHash...)
+}
+
+// signWitness signs signData with the first m keys
+func signWitness(t *testing.T, signData []byte, m int, kps []*keys.KeyPair) *Witness {
+	witness := &Witness{VerificationScript: createMultiSigScript(m, kps)}
+	for _, kp := range kps[:m] {
+		sig, err := kp.Sign(signData)
+		assert.NoError(t, err)
+		witness.InvocationScript = append(append(witness.InvocationScript, PUSHDATA1, 64), sig...)
+	}
+	return witness
+}
+
+func scriptHashOf(kps []*keys.KeyPair) helper.UInt160 {
+	return (&Witness{VerificationScript: createMultiSigScript(len(kps)-(len(kps)-1)/3, kps)}).GetScriptHash()
+}
+
+func newTestHeader(t *testing.T, index uint32, signers, next []*keys.KeyPair) *Neo3BlockHeader {
+	header := &Neo3BlockHeader{
+		Version:       0,
+		Timestamp:     1627896461306,
+		Nonce:         0x2a,
+		Index:         index,
+		PrimaryIndex:  1,
+		NextConsensus: scriptHashOf(next),
+	}
+	header.PrevHash[0], header.MerkleRoot[0] = 1, 2
+	header.Witness = signWitness(t, header.GetSignData(testMagic), len(signers)-(len(signers)-1)/3, signers)
+	return header
+}
+
+func TestNeo3BlockHeader(t *testing.T) {
+	kps := newTestKeys(t, 4)
+	header := newTestHeader(t, 100, kps, kps)
+	sink := common.NewZeroCopySink(nil)
+	header.Serialization(sink)
+	// 4+32+32+8+8+4+1+20 unsigned bytes, then one witness of 3 signatures and 4 public keys
+	assert.Equal(t, 109+1+(1+3*66)+(1+1+4*35+1+5), len(sink.Bytes()))
+
+	decoded := new(Neo3BlockHeader)
+	assert.NoError(t, decoded.Deserialization(common.NewZeroCopySource(sink.Bytes())))
+	assert.Equal(t, header, decoded)
+	assert.Equal(t, header.Hash(), decoded.Hash())
+
+	signData := header.GetSignData(testMagic)
+	assert.Equal(t, []byte{0x4e, 0x45, 0x4f, 0x33}, signData[:4])
+	assert.Equal(t, header.Hash().Bytes(), signData[4:])
+
+	// header without witness
+	sink = common.NewZeroCopySink(nil)
+	header.serializeUnsigned(sink)
+	sink.WriteVarUint(0)
+	assert.Error(t, decoded.Deserialization(common.NewZeroCopySource(sink.Bytes())))
+}
+
+func TestNeo3CrossChainMsg(t *testing.T) {
+	kps := newTestKeys(t, 4)
+	msg := &Neo3CrossChainMsg{Index: 100}
+	msg.RootHash[0] = 1
+	msg.Witness = signWitness(t, msg.GetSignData(testMagic), 3, kps)
+	sink := common.NewZeroCopySink(nil)
+	msg.Serialization(sink)
+
+	decoded := new(Neo3CrossChainMsg)
+	assert.NoError(t, decoded.Deserialization(common.NewZeroCopySource(sink.Bytes())))
+	assert.Equal(t, msg, decoded)
+	assert.NoError(t, VerifyWitness(decoded.GetSignData(testMagic), decoded.Witness))
+}
+
+func TestVerifyWitness(t *testing.T) {
+	kps := newTestKeys(t, 7)
+	signData := []byte("sign data")
+	assert.NoError(t, VerifyWitness(signData, signWitness(t, signData, 5, kps)))
+
+	// signed by other network
+	witness := signWitness(t, signData, 5, kps)
+	assert.Error(t, VerifyWitness([]byte("other data"), witness))
+
+	// not enough signatures
+	witness.InvocationScript = witness.InvocationScript[:4*66]
+	assert.Error(t, VerifyWitness(signData, witness))
+
+	// signatures out of order of public keys
+	witness = signWitness(t, signData, 5, kps)
+	swapped := append(append([]byte{}, witness.InvocationScript[66:132]...), witness.InvocationScript[:66]...)
+	witness.InvocationScript = append(swapped, witness.InvocationScript[132:]...)
+	assert.Error(t, VerifyWitness(signData, witness))
+
+	// not a multi-signature script
+	witness = signWitness(t, signData, 5, kps)
+	witness.VerificationScript[len(witness.VerificationScript)-1] ^= 1
+	assert.Error(t, VerifyWitness(signData, witness))
+}
+
+func TestParseMultiSigScript(t *testing.T) {
+	kps := newTestKeys(t, 3)
+	m, pubKeys, err := parseMultiSigScript(createMultiSigScript(2, kps))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, m)
+	assert.Equal(t, 3, len(pubKeys))
+	assert.Equal(t, kps[1].PublicKey.EncodeCompression(), pubKeys[1].EncodeCompression())
+
+	// m pushed by PUSHINT8
+	script := append([]byte{PUSHINT8, 2}, createMultiSigScript(2, kps)[1:]...)
+	m, _, err = parseMultiSigScript(script)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, m)
+
+	// m larger than n
+	_, _, err = parseMultiSigScript(createMultiSigScript(4, kps))
+	assert.Error(t, err)
+}
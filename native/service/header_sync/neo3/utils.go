@@ -0,0 +1,188 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+package neo3
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"github.com/joeqian10/neo-gogogo/wallet/keys"
+	"github.com/polynetwork/poly/common"
+	cstates "github.com/polynetwork/poly/core/states"
+	"github.com/polynetwork/poly/native"
+	hscommon "github.com/polynetwork/poly/native/service/header_sync/common"
+	"github.com/polynetwork/poly/native/service/utils"
+)
+
+// opcodes of neo N3 vm used by signature scripts
+const (
+	PUSHINT8  = 0x00
+	PUSHINT16 = 0x01
+	PUSHDATA1 = 0x0C
+	PUSH1     = 0x11
+	PUSH16    = 0x20
+	SYSCALL   = 0x41
+)
+
+// interop hash of System.Crypto.CheckMultisig called by the multi-signature verification script
+var checkMultisigHash = func() []byte {
+	hash := sha256.Sum256([]byte("System.Crypto.CheckMultisig"))
+	return hash[:4]
+}()
+
+// readPushInt reads an integer pushed by PUSH1-PUSH16, PUSHINT8 or PUSHINT16 from script
+func readPushInt(script []byte) (int, []byte, error) {
+	if len(script) == 0 {
+		return 0, nil, fmt.Errorf("unexpected end of script")
+	}
+	switch op := script[0]; {
+	case op >= PUSH1 && op <= PUSH16:
+		return int(op-PUSH1) + 1, script[1:], nil
+	case op == PUSHINT8 && len(script) >= 2:
+		return int(int8(script[1])), script[2:], nil
+	case op == PUSHINT16 && len(script) >= 3:
+		return int(int16(binary.LittleEndian.Uint16(script[1:]))), script[3:], nil
+	default:
+		return 0, nil, fmt.Errorf("expect pushing an integer, got opcode 0x%x", op)
+	}
+}
+
+// parseMultiSigScript returns the threshold and public keys of a multi-signature verification
+// script of neo N3: PUSH m, PUSHDATA1 pubkey * n, PUSH n, SYSCALL System.Crypto.CheckMultisig
+func parseMultiSigScript(script []byte) (int, []*keys.PublicKey, error) {
+	m, rest, err := readPushInt(script)
+	if err != nil {
+		return 0, nil, fmt.Errorf("parseMultiSigScript, read m error: %v", err)
+	}
+	pubKeys := make([]*keys.PublicKey, 0)
+	for len(rest) > 0 && rest[0] == PUSHDATA1 {
+		if len(rest) < 35 || rest[1] != 33 {
+			return 0, nil, fmt.Errorf("parseMultiSigScript, invalid public key of No.%d", len(pubKeys))
+		}
+		pubKey, err := keys.NewPublicKey(rest[2:35])
+		if err != nil {
+			return 0, nil, fmt.Errorf("parseMultiSigScript, decode public key of No.%d error: %v", len(pubKeys), err)
+		}
+		pubKeys = append(pubKeys, pubKey)
+		rest = rest[35:]
+	}
+	n, rest, err := readPushInt(rest)
+	if err != nil {
+		return 0, nil, fmt.Errorf("parseMultiSigScript, read n error: %v", err)
+	}
+	if n != len(pubKeys) || m < 1 || m > n {
+		return 0, nil, fmt.Errorf("parseMultiSigScript, invalid m %d and n %d of %d public keys", m, n, len(pubKeys))
+	}
+	if len(rest) != 5 || rest[0] != SYSCALL || string(rest[1:]) != string(checkMultisigHash) {
+		return 0, nil, fmt.Errorf("parseMultiSigScript, script does not end with CheckMultisig")
+	}
+	return m, pubKeys, nil
+}
+
+// parseInvocationScript returns the signatures pushed by the invocation script
+func parseInvocationScript(script []byte) ([][]byte, error) {
+	sigs := make([][]byte, 0)
+	for len(script) > 0 {
+		if len(script) < 66 || script[0] != PUSHDATA1 || script[1] != 64 {
+			return nil, fmt.Errorf("parseInvocationScript, invalid signature of No.%d", len(sigs))
+		}
+		sigs = append(sigs, script[2:66])
+		script = script[66:]
+	}
+	return sigs, nil
+}
+
+// VerifyWitness checks the witness is a multi-signature of signData from enough signers
+func VerifyWitness(signData []byte, witness *Witness) error {
+	m, pubKeys, err := parseMultiSigScript(witness.VerificationScript)
+	if err != nil {
+		return fmt.Errorf("VerifyWitness, %v", err)
+	}
+	sigs, err := parseInvocationScript(witness.InvocationScript)
+	if err != nil {
+		return fmt.Errorf("VerifyWitness, %v", err)
+	}
+	if len(sigs) != m {
+		return fmt.Errorf("VerifyWitness, expect %d signatures, got %d", m, len(sigs))
+	}
+	if !keys.VerifyMultiSig(signData, sigs, pubKeys) {
+		return fmt.Errorf("VerifyWitness, invalid multi-signature")
+	}
+	return nil
+}
+
+// verifyHeader checks the header is signed by the next consensus of neo3Consensus
+func verifyHeader(neo3Consensus *Neo3Consensus, header *Neo3BlockHeader, magic uint32) error {
+	if scriptHash := header.Witness.GetScriptHash(); !scriptHash.Equals(neo3Consensus.NextConsensus) {
+		return fmt.Errorf("verifyHeader, invalid script hash in header error, expected:%s, got:%s",
+			neo3Consensus.NextConsensus.String(), scriptHash.String())
+	}
+	if err := VerifyWitness(header.GetSignData(magic), header.Witness); err != nil {
+		return fmt.Errorf("verifyHeader, height:%d, %v", header.Index, err)
+	}
+	return nil
+}
+
+// VerifyCrossChainMsgSig checks the state root is signed by the current consensus nodes of chain,
+// which are designated as the state validators of neo N3
+func VerifyCrossChainMsgSig(native *native.NativeService, chainID uint64, crossChainMsg *Neo3CrossChainMsg) error {
+	extraInfo, err := GetExtraInfo(native, chainID)
+	if err != nil {
+		return fmt.Errorf("verifyCrossChainMsg, %v", err)
+	}
+	neo3Consensus, err := getConsensusValByChainId(native, chainID)
+	if err != nil {
+		return fmt.Errorf("verifyCrossChainMsg, get ConsensusPeer error:%v", err)
+	}
+	if scriptHash := crossChainMsg.Witness.GetScriptHash(); !scriptHash.Equals(neo3Consensus.NextConsensus) {
+		return fmt.Errorf("verifyCrossChainMsg, invalid script hash in Neo3CrossChainMsg error, expected:%s, got:%s",
+			neo3Consensus.NextConsensus.String(), scriptHash.String())
+	}
+	if err := VerifyWitness(crossChainMsg.GetSignData(extraInfo.Magic), crossChainMsg.Witness); err != nil {
+		return fmt.Errorf("verifyCrossChainMsg, height:%d, %v", crossChainMsg.Index, err)
+	}
+	return nil
+}
+
+func getConsensusValByChainId(native *native.NativeService, chainID uint64) (*Neo3Consensus, error) {
+	contract := utils.HeaderSyncContractAddress
+	chainIDBytes := utils.GetUint64Bytes(chainID)
+	neo3ConsensusStore, err := native.GetCacheDB().Get(utils.ConcatKey(contract, []byte(hscommon.CONSENSUS_PEER), chainIDBytes))
+	if err != nil {
+		return nil, fmt.Errorf("getConsensusValByChainId, get neo3ConsensusStore error: %v", err)
+	}
+	if neo3ConsensusStore == nil {
+		return nil, fmt.Errorf("getConsensusValByChainId, can not find any record")
+	}
+	neo3ConsensusBytes, err := cstates.GetValueFromRawStorageItem(neo3ConsensusStore)
+	if err != nil {
+		return nil, fmt.Errorf("getConsensusValByChainId, deserialize from raw storage item err:%v", err)
+	}
+	neo3Consensus := new(Neo3Consensus)
+	if err := neo3Consensus.Deserialization(common.NewZeroCopySource(neo3ConsensusBytes)); err != nil {
+		return nil, fmt.Errorf("getConsensusValByChainId, deserialize neo3Consensus error: %v", err)
+	}
+	return neo3Consensus, nil
+}
+
+func putConsensusValByChainId(native *native.NativeService, neo3Consensus *Neo3Consensus) {
+	contract := utils.HeaderSyncContractAddress
+	sink := common.NewZeroCopySink(nil)
+	neo3Consensus.Serialization(sink)
+	chainIDBytes := utils.GetUint64Bytes(neo3Consensus.ChainID)
+	native.GetCacheDB().Put(utils.ConcatKey(contract, []byte(hscommon.CONSENSUS_PEER), chainIDBytes), cstates.GenRawStorageItem(sink.Bytes()))
+}
@@ -33,6 +33,10 @@ import (
 type ONTHandler struct {
 }
 
+func init() {
+	hscommon.RegisterHeaderSyncHandler(utils.ONT_ROUTER, "ont", func() hscommon.HeaderSyncHandler { return NewONTHandler() })
+}
+
 func NewONTHandler() *ONTHandler {
 	return &ONTHandler{}
 }
@@ -146,3 +150,35 @@ func (this *ONTHandler) SyncCrossChainMsg(native *native.NativeService) error {
 	}
 	return nil
 }
+
+// SubmitMisbehaviour verifies two different headers of the same height both signed by the
+// consensus peers
+func (this *ONTHandler) SubmitMisbehaviour(native *native.NativeService) (*hscommon.Misbehaviour, error) {
+	params := new(hscommon.MisbehaviourParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return nil, fmt.Errorf("SubmitMisbehaviour, contract params deserialize error: %v", err)
+	}
+	header1, err := otypes.HeaderFromRawBytes(params.Header1)
+	if err != nil {
+		return nil, fmt.Errorf("SubmitMisbehaviour, deserialize header1 error: %v", err)
+	}
+	header2, err := otypes.HeaderFromRawBytes(params.Header2)
+	if err != nil {
+		return nil, fmt.Errorf("SubmitMisbehaviour, deserialize header2 error: %v", err)
+	}
+	hash1, hash2 := header1.Hash(), header2.Hash()
+	if header1.Height != header2.Height || hash1 == hash2 {
+		return nil, fmt.Errorf("SubmitMisbehaviour, headers are not conflicting ones of the same height")
+	}
+	for i, header := range []*otypes.Header{header1, header2} {
+		if err := verifyCommittedHeader(native, params.ChainID, header); err != nil {
+			return nil, fmt.Errorf("SubmitMisbehaviour, failed to verify header%d: %v", i+1, err)
+		}
+	}
+	return &hscommon.Misbehaviour{
+		ChainID: params.ChainID,
+		Height:  uint64(header1.Height),
+		Hash1:   hash1[:],
+		Hash2:   hash2[:],
+	}, nil
+}
@@ -0,0 +1,128 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+package ont
+
+import (
+	"testing"
+
+	ocommon "github.com/ontio/ontology/common"
+	otypes "github.com/ontio/ontology/core/types"
+	"github.com/polynetwork/poly/account"
+	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/consensus/vbft/config"
+	"github.com/polynetwork/poly/core/signature"
+	"github.com/polynetwork/poly/core/types"
+	scom "github.com/polynetwork/poly/native/service/header_sync/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// newSignedHeader returns a header of height signed by signers
+func newSignedHeader(t *testing.T, signers []*account.Account, height uint32, root byte) []byte {
+	header := &otypes.Header{
+		Height:           height,
+		TransactionsRoot: ocommon.Uint256{root},
+	}
+	hash := header.Hash()
+	for _, signer := range signers {
+		sig, err := signature.Sign(signer, hash[:])
+		assert.NoError(t, err)
+		header.Bookkeepers = append(header.Bookkeepers, signer.PublicKey)
+		header.SigData = append(header.SigData, sig)
+	}
+	sink := ocommon.NewZeroCopySink(nil)
+	header.Serialization(sink)
+	return sink.Bytes()
+}
+
+func TestONTHandler_SubmitMisbehaviour(t *testing.T) {
+	peers := make([]*account.Account, 4)
+	consensusPeers := &ConsensusPeers{
+		ChainID: 3,
+		Height:  100,
+		PeerMap: make(map[string]*Peer),
+	}
+	for i := range peers {
+		peers[i] = account.NewAccount("")
+		id := vconfig.PubkeyID(peers[i].PublicKey)
+		consensusPeers.PeerMap[id] = &Peer{Index: uint32(i), PeerPubkey: id}
+	}
+	ns := NewNative(nil, new(types.Transaction), nil)
+	assert.NoError(t, putConsensusPeers(ns, consensusPeers))
+
+	submit := func(header1, header2 []byte) (*scom.Misbehaviour, error) {
+		param := &scom.MisbehaviourParam{ChainID: 3, Header1: header1, Header2: header2}
+		sink := common.NewZeroCopySink(nil)
+		param.Serialization(sink)
+		return NewONTHandler().SubmitMisbehaviour(NewNative(sink.Bytes(), new(types.Transaction), ns.GetCacheDB()))
+	}
+
+	header1 := newSignedHeader(t, peers[:3], 120, 1)
+	misbehaviour, err := submit(header1, newSignedHeader(t, peers[1:], 120, 2))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), misbehaviour.ChainID)
+	assert.Equal(t, uint64(120), misbehaviour.Height)
+	assert.NotEqual(t, misbehaviour.Hash1, misbehaviour.Hash2)
+
+	// the same header signed by other peers
+	_, err = submit(header1, newSignedHeader(t, peers[1:], 120, 1))
+	assert.Error(t, err)
+	// headers of different heights
+	_, err = submit(header1, newSignedHeader(t, peers[:3], 121, 2))
+	assert.Error(t, err)
+	// header signed by no more than 2/3 of peers, which is enough to sync it
+	_, err = submit(header1, newSignedHeader(t, peers[:2], 120, 2))
+	assert.Error(t, err)
+	// header signed by a peer twice
+	_, err = submit(header1, newSignedHeader(t, []*account.Account{peers[0], peers[1], peers[1]}, 120, 2))
+	assert.Error(t, err)
+	// header signed by one out of peers
+	_, err = submit(header1, newSignedHeader(t, []*account.Account{peers[0], peers[1], account.NewAccount("")}, 120, 2))
+	assert.Error(t, err)
+	// headers not above the key height
+	_, err = submit(newSignedHeader(t, peers[:3], 100, 1), newSignedHeader(t, peers[:3], 100, 2))
+	assert.Error(t, err)
+}
+
+func TestVerifyCommittedHeader(t *testing.T) {
+	peers := make([]*account.Account, 7)
+	consensusPeers := &ConsensusPeers{
+		ChainID: 3,
+		Height:  100,
+		PeerMap: make(map[string]*Peer),
+	}
+	for i := range peers {
+		peers[i] = account.NewAccount("")
+		id := vconfig.PubkeyID(peers[i].PublicKey)
+		consensusPeers.PeerMap[id] = &Peer{Index: uint32(i), PeerPubkey: id}
+	}
+	ns := NewNative(nil, new(types.Transaction), nil)
+	assert.NoError(t, putConsensusPeers(ns, consensusPeers))
+
+	verify := func(signers []*account.Account) error {
+		header, err := otypes.HeaderFromRawBytes(newSignedHeader(t, signers, 120, 1))
+		assert.NoError(t, err)
+		return verifyCommittedHeader(ns, 3, header)
+	}
+	assert.NoError(t, verify(peers[:5]))
+	// 4 of 7 peers are enough to sync the header but not for evidence
+	header, err := otypes.HeaderFromRawBytes(newSignedHeader(t, peers[:4], 120, 1))
+	assert.NoError(t, err)
+	assert.NoError(t, verifyHeader(ns, 3, header))
+	assert.Error(t, verifyCommittedHeader(ns, 3, header))
+	// 5 signatures by 4 distinct peers
+	assert.Error(t, verify(append(peers[:4:4], peers[0])))
+}
@@ -185,17 +185,50 @@ func VerifyCrossChainMsg(native *native.NativeService, chainID uint64, crossChai
 //verify header of any height
 //find key height and get consensus peer first, then check the sign
 func verifyHeader(native *native.NativeService, chainID uint64, header *otypes.Header) error {
-	height := header.Height
-	//search consensus peer
-	keyHeight, err := FindKeyHeight(native, height, chainID)
+	consensusPeer, err := getHeaderConsensusPeers(native, chainID, header)
 	if err != nil {
-		return fmt.Errorf("verifyHeader, findKeyHeight error:%v", err)
+		return fmt.Errorf("verifyHeader, %v", err)
 	}
+	return verifyHeaderByPeers(consensusPeer, header)
+}
 
+// verifyCommittedHeader checks header is signed by more than 2/3 of distinct consensus peers, which is
+// stricter than verifyHeader so that a few peers can not provide evidence of misbehaviour
+func verifyCommittedHeader(native *native.NativeService, chainID uint64, header *otypes.Header) error {
+	consensusPeer, err := getHeaderConsensusPeers(native, chainID, header)
+	if err != nil {
+		return fmt.Errorf("verifyCommittedHeader, %v", err)
+	}
+	if err := verifyHeaderByPeers(consensusPeer, header); err != nil {
+		return err
+	}
+	signers := make(map[string]bool)
+	for _, bookkeeper := range header.Bookkeepers {
+		signers[vconfig.PubkeyID(bookkeeper)] = true
+	}
+	if len(signers)*3 <= len(consensusPeer.PeerMap)*2 {
+		return fmt.Errorf("verifyCommittedHeader, header is signed by %d of %d consensus peers", len(signers),
+			len(consensusPeer.PeerMap))
+	}
+	return nil
+}
+
+// getHeaderConsensusPeers returns the consensus peers at the key height of header
+func getHeaderConsensusPeers(native *native.NativeService, chainID uint64, header *otypes.Header) (*ConsensusPeers, error) {
+	//search consensus peer
+	keyHeight, err := FindKeyHeight(native, header.Height, chainID)
+	if err != nil {
+		return nil, fmt.Errorf("findKeyHeight error:%v", err)
+	}
 	consensusPeer, err := getConsensusPeersByHeight(native, chainID, keyHeight)
 	if err != nil {
-		return fmt.Errorf("verifyHeader, get ConsensusPeer error:%v", err)
+		return nil, fmt.Errorf("get ConsensusPeer error:%v", err)
 	}
+	return consensusPeer, nil
+}
+
+// verifyHeaderByPeers checks the sign of header against the given consensus peers
+func verifyHeaderByPeers(consensusPeer *ConsensusPeers, header *otypes.Header) error {
 	if len(header.Bookkeepers)*3 < len(consensusPeer.PeerMap) {
 		return fmt.Errorf("verifyHeader, header Bookkeepers num %d must more than 2/3 consensus node num %d", len(header.Bookkeepers), len(consensusPeer.PeerMap))
 	}
@@ -207,7 +240,7 @@ func verifyHeader(native *native.NativeService, chainID uint64, header *otypes.H
 		}
 	}
 	hash := header.Hash()
-	err = signature.VerifyMultiSignature(hash[:], header.Bookkeepers, len(header.Bookkeepers), header.SigData)
+	err := signature.VerifyMultiSignature(hash[:], header.Bookkeepers, len(header.Bookkeepers), header.SigData)
 	if err != nil {
 		return fmt.Errorf("verifyHeader, VerifyMultiSignature error:%s, heigh:%d", err, header.Height)
 	}
@@ -30,6 +30,10 @@ import (
 
 type QuorumHandler struct{}
 
+func init() {
+	common.RegisterHeaderSyncHandler(utils.QUORUM_ROUTER, "quorum", func() common.HeaderSyncHandler { return NewQuorumHandler() })
+}
+
 func NewQuorumHandler() *QuorumHandler {
 	return &QuorumHandler{}
 }
@@ -99,6 +103,8 @@ func (h *QuorumHandler) SyncBlockHeader(ns *native.NativeService) error {
 		currh, vs = h, extra.Validators
 	}
 
+	// only the validators of the latest header are kept, so unlike other chains
+	// there are no headers to prune under a retention window
 	putValSet(ns, params.ChainID, currh, vs)
 	return nil
 }
@@ -106,3 +112,45 @@ func (h *QuorumHandler) SyncBlockHeader(ns *native.NativeService) error {
 func (h *QuorumHandler) SyncCrossChainMsg(ns *native.NativeService) error {
 	return nil
 }
+
+// SubmitMisbehaviour verifies two different headers of the same height both committed by the
+// current validators
+func (h *QuorumHandler) SubmitMisbehaviour(ns *native.NativeService) (*common.Misbehaviour, error) {
+	params := new(common.MisbehaviourParam)
+	if err := params.Deserialization(pcom.NewZeroCopySource(ns.GetInput())); err != nil {
+		return nil, fmt.Errorf("QuorumHandler SubmitMisbehaviour, contract params deserialize error: %v", err)
+	}
+	currh, err := GetCurrentValHeight(ns, params.ChainID)
+	if err != nil {
+		return nil, fmt.Errorf("QuorumHandler SubmitMisbehaviour, failed to get current validator height: %v", err)
+	}
+	vs, err := GetValSet(ns, params.ChainID)
+	if err != nil {
+		return nil, fmt.Errorf("QuorumHandler SubmitMisbehaviour, failed to get validators: %v", err)
+	}
+	headers := make([]*types.Header, 2)
+	for i, raw := range [][]byte{params.Header1, params.Header2} {
+		header := &types.Header{}
+		if err := json.Unmarshal(raw, header); err != nil {
+			return nil, fmt.Errorf("QuorumHandler SubmitMisbehaviour, deserialize header%d err: %v", i+1, err)
+		}
+		if header.Number.Uint64() < currh {
+			return nil, fmt.Errorf("QuorumHandler SubmitMisbehaviour, height of header%d %d is less than epoch height %d",
+				i+1, header.Number.Uint64(), currh)
+		}
+		if err := verifyCommittedHeader(vs, header); err != nil {
+			return nil, fmt.Errorf("QuorumHandler SubmitMisbehaviour, failed to verify header%d: %v", i+1, err)
+		}
+		headers[i] = header
+	}
+	hash1, hash2 := GetQuorumHeaderHash(headers[0]), GetQuorumHeaderHash(headers[1])
+	if headers[0].Number.Cmp(headers[1].Number) != 0 || hash1 == hash2 {
+		return nil, errors.New("QuorumHandler SubmitMisbehaviour, headers are not conflicting ones of the same height")
+	}
+	return &common.Misbehaviour{
+		ChainID: params.ChainID,
+		Height:  headers[0].Number.Uint64(),
+		Hash1:   hash1.Bytes(),
+		Hash2:   hash2.Bytes(),
+	}, nil
+}
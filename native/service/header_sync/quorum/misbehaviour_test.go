@@ -0,0 +1,102 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+package quorum
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	pcom "github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/native"
+	common4 "github.com/polynetwork/poly/native/service/header_sync/common"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func setExtra(t *testing.T, hdr *types.Header, extra *IstanbulExtra) {
+	payload, err := rlp.EncodeToBytes(extra)
+	assert.NoError(t, err)
+	hdr.Extra = append(make([]byte, IstanbulExtraVanity), payload...)
+}
+
+// newCommittedHeader returns a header proposed by keys[0] and committed by the first n keys
+func newCommittedHeader(t *testing.T, keys []*ecdsa.PrivateKey, n int, number int64, root byte) []byte {
+	extra := &IstanbulExtra{Seal: []byte{}, CommittedSeal: [][]byte{}}
+	for _, key := range keys {
+		extra.Validators = append(extra.Validators, crypto.PubkeyToAddress(key.PublicKey))
+	}
+	hdr := &types.Header{
+		Number:     big.NewInt(number),
+		Difficulty: big.NewInt(1),
+		MixDigest:  IstanbulDigest,
+		Root:       common.Hash{root},
+	}
+	setExtra(t, hdr, extra)
+	seal, err := crypto.Sign(crypto.Keccak256(sigHash(hdr).Bytes()), keys[0])
+	assert.NoError(t, err)
+	extra.Seal = seal
+	setExtra(t, hdr, extra)
+	committed := crypto.Keccak256(PrepareCommittedSeal(GetQuorumHeaderHash(hdr)))
+	for _, key := range keys[:n] {
+		seal, err := crypto.Sign(committed, key)
+		assert.NoError(t, err)
+		extra.CommittedSeal = append(extra.CommittedSeal, seal)
+	}
+	setExtra(t, hdr, extra)
+	raw, err := json.Marshal(hdr)
+	assert.NoError(t, err)
+	return raw
+}
+
+func TestQuorumHandler_SubmitMisbehaviour(t *testing.T) {
+	keys := make([]*ecdsa.PrivateKey, 4)
+	vals := make([]common.Address, 4)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		vals[i] = crypto.PubkeyToAddress(keys[i].PublicKey)
+	}
+	submit := func(header1, header2 []byte, ns *native.NativeService) (*common4.Misbehaviour, error) {
+		param := &common4.MisbehaviourParam{ChainID: 8, Header1: header1, Header2: header2}
+		sink := pcom.NewZeroCopySink(nil)
+		param.Serialization(sink)
+		return NewQuorumHandler().SubmitMisbehaviour(getNativeFunc(sink.Bytes(), ns.GetCacheDB()))
+	}
+	ns := getNativeFunc(nil, nil)
+	putValSet(ns, 8, 100, vals)
+
+	header1 := newCommittedHeader(t, keys, 3, 120, 1)
+	misbehaviour, err := submit(header1, newCommittedHeader(t, keys, 3, 120, 2), ns)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(120), misbehaviour.Height)
+
+	// the same header committed by other seals
+	_, err = submit(header1, newCommittedHeader(t, keys, 4, 120, 1), ns)
+	assert.Error(t, err)
+	// headers of different heights
+	_, err = submit(header1, newCommittedHeader(t, keys, 3, 121, 2), ns)
+	assert.Error(t, err)
+	// header committed by no more than 2/3 of validators
+	_, err = submit(header1, newCommittedHeader(t, keys, 2, 120, 2), ns)
+	assert.Error(t, err)
+	// headers lower than the epoch
+	_, err = submit(newCommittedHeader(t, keys, 3, 90, 1), newCommittedHeader(t, keys, 3, 90, 2), ns)
+	assert.Error(t, err)
+}
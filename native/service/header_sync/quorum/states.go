@@ -88,6 +88,28 @@ func VerifyQuorumHeader(vs QuorumValSet, hdr *types.Header, isEpoch bool) (*Ista
 	return extra, nil
 }
 
+// verifyCommittedHeader checks hdr is committed by more than 2/3 of distinct validators, which is
+// stricter than VerifyQuorumHeader so that a few validators can not provide evidence of misbehaviour
+func verifyCommittedHeader(vs QuorumValSet, hdr *types.Header) error {
+	extra, err := VerifyQuorumHeader(vs, hdr, false)
+	if err != nil {
+		return err
+	}
+	signers, err := GetSigners(GetQuorumHeaderHash(hdr), extra.CommittedSeal)
+	if err != nil {
+		return fmt.Errorf("failed to get signers of committed seals: %v", err)
+	}
+	committed := make(map[common.Address]bool)
+	for _, v := range signers {
+		committed[v] = true
+	}
+	if len(committed)*3 <= len(vs)*2 {
+		return fmt.Errorf("header %s is committed by %d of %d validators", GetQuorumHeaderHash(hdr).String(),
+			len(committed), len(vs))
+	}
+	return nil
+}
+
 func (vs QuorumValSet) VerifySigner(hdr *types.Header, seal []byte) error {
 	addr, err := GetSignatureAddress(sigHash(hdr).Bytes(), seal)
 	if err != nil {
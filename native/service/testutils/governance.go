@@ -0,0 +1,58 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package testutils provides fixtures shared by tests of native contracts
+package testutils
+
+import (
+	"github.com/polynetwork/poly/account"
+	"github.com/polynetwork/poly/common"
+	vconfig "github.com/polynetwork/poly/consensus/vbft/config"
+	cstates "github.com/polynetwork/poly/core/states"
+	"github.com/polynetwork/poly/native/service/governance/node_manager"
+	"github.com/polynetwork/poly/native/service/utils"
+	"github.com/polynetwork/poly/native/storage"
+)
+
+// PutPeerPoolAndView puts conAccts as the consensus peers of view 0 and makes view 0 the current
+// governance view
+func PutPeerPoolAndView(db *storage.CacheDB, conAccts []*account.Account) {
+	peerPoolMap := new(node_manager.PeerPoolMap)
+	peerPoolMap.PeerPoolMap = make(map[string]*node_manager.PeerPoolItem)
+	for i, conAcct := range conAccts {
+		pkStr := vconfig.PubkeyID(conAcct.PublicKey)
+		peerPoolMap.PeerPoolMap[pkStr] = &node_manager.PeerPoolItem{
+			Index:      uint32(i),
+			PeerPubkey: pkStr,
+			Address:    conAcct.Address,
+			Status:     node_manager.ConsensusStatus,
+		}
+	}
+	viewBytes := utils.GetUint32Bytes(0)
+	sink := common.NewZeroCopySink(nil)
+	peerPoolMap.Serialization(sink)
+	db.Put(utils.ConcatKey(utils.NodeManagerContractAddress, []byte(node_manager.PEER_POOL), viewBytes), cstates.GenRawStorageItem(sink.Bytes()))
+
+	govView := node_manager.GovernanceView{
+		View:   0,
+		Height: 10,
+		TxHash: common.UINT256_EMPTY,
+	}
+	sink = common.NewZeroCopySink(nil)
+	govView.Serialization(sink)
+	db.Put(utils.ConcatKey(utils.NodeManagerContractAddress, []byte(node_manager.GOVERNANCE_VIEW)), cstates.GenRawStorageItem(sink.Bytes()))
+}
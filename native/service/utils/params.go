@@ -46,4 +46,8 @@ var (
 	BSC_ROUTER    = uint64(6)
 	HECO_ROUTER   = uint64(7)
 	QUORUM_ROUTER = uint64(8)
+	EVM_ROUTER    = uint64(9)
+	BCH_ROUTER    = uint64(10)
+	LTC_ROUTER    = uint64(11)
+	NEO3_ROUTER   = uint64(12)
 )